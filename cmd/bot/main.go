@@ -17,10 +17,12 @@ import (
 	"budget-bot/internal/pkg/config"
 	"budget-bot/internal/pkg/db"
 	botlogger "budget-bot/internal/pkg/logger"
+	"budget-bot/internal/stt"
 
 	grpcwire "budget-bot/internal/grpc"
 	"budget-bot/internal/metrics"
 	"budget-bot/internal/repository"
+	"budget-bot/internal/scheduler"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"golang.org/x/net/proxy"
 	"go.uber.org/zap"
@@ -88,9 +90,14 @@ func main() {
 	prefsRepo := repository.NewSQLitePreferencesRepository(dbConn)
 	draftRepo := repository.NewSQLiteDraftRepository(dbConn)
 	opCtxRepo := repository.NewSQLiteOperationContextRepository(dbConn)
+	budgetRepo := repository.NewSQLiteBudgetRepository(dbConn)
+	recurringRepo := repository.NewSQLiteRecurringRepository(dbConn)
+	digestRepo := repository.NewSQLiteDigestRepository(dbConn)
+	groupRepo := repository.NewSQLiteGroupChatRepository(dbConn)
+	findQueryRepo := repository.NewSQLiteFindQueryRepository(dbConn)
 
 	// Wire OAuth clients
-	catClient, reportClient, tenantClient, txClient, oauthClient, authClient := grpcwire.WireClients(log)
+	catClient, reportClient, tenantClient, txClient, oauthClient, authClient, fxClient, importClient := grpcwire.WireClients(log)
 
 	// Create OAuth manager
 	oauthManager := botpkg.NewOAuthManagerWithAuthClient(oauthClient, authClient, sessionRepo, log, cfg.OAuth.WebBaseURL)
@@ -99,10 +106,17 @@ func main() {
 		WithPreferences(prefsRepo).
 		WithDrafts(draftRepo).
 		WithOperationContexts(opCtxRepo).
+		WithBudgets(budgetRepo).
+		WithRecurring(recurringRepo).
+		WithDigests(digestRepo).
+		WithGroupChats(groupRepo).
+		WithFindQueries(findQueryRepo).
 		WithCategoryClient(catClient).
 		WithReportClient(reportClient).
 		WithTransactionClient(txClient).
-		WithTenantClient(tenantClient)
+		WithTenantClient(tenantClient).
+		WithFxClient(fxClient).
+		WithImportClient(importClient)
 	if cfg.OpenRouter.Enable {
 		if cfg.OpenRouter.APIKey == "" || cfg.OpenRouter.Model == "" {
 			log.Warn("openrouter enabled but API key/model is not configured; llm fallback disabled")
@@ -111,6 +125,22 @@ func main() {
 		}
 	}
 
+	if cfg.SpeechToText.Enable {
+		if cfg.SpeechToText.APIKey == "" || cfg.SpeechToText.Model == "" {
+			log.Warn("speech-to-text enabled but API key/model is not configured; voice input disabled")
+		} else {
+			h.WithSpeechToText(stt.NewHTTPClient(cfg.SpeechToText.BaseURL, cfg.SpeechToText.APIKey, cfg.SpeechToText.Model, cfg.SpeechToText.Timeout))
+		}
+	}
+
+	// Background jobs
+	sched := scheduler.New(log)
+	sched.Add("recurring_transactions", time.Minute, h.RunDueRecurring)
+	sched.Add("digests", time.Minute, h.RunDueDigests)
+	sched.Add("transaction_drafts", time.Hour, h.RunDraftCleanup)
+	sched.Add("find_queries", time.Hour, h.RunFindQueryCleanup)
+	sched.Start(ctx)
+
 	// Webhook mode vs long polling
 	if cfg.Telegram.WebhookEnable {
 		// Determine webhook URL
@@ -169,6 +199,7 @@ func main() {
 		}
 
 		log.Info("shutting down")
+		sched.Wait()
 		return
 	}
 
@@ -189,6 +220,7 @@ func main() {
 		select {
 		case <-ctx.Done():
 			log.Info("shutting down")
+			sched.Wait()
 			return
 		case update := <-updates:
 			h.HandleUpdate(ctx, update)
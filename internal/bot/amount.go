@@ -0,0 +1,269 @@
+package bot
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxAmountMinor bounds parsed amounts so that arithmetic cannot overflow int64.
+const maxAmountMinor = int64(1e15)
+
+// amountExpr is an amount found in message text: a number or a small arithmetic expression.
+type amountExpr struct {
+	minor      int64  // absolute value in minor units
+	sign       string // explicit leading "+" or "-", or ""
+	expression string // the expression as typed when the amount was computed ("2x350", "1.5k"), otherwise ""
+	start, end int    // byte range of the expression in the text
+}
+
+// parseAmountExpr finds the first amount in lower-cased text, in minor units with exp fraction digits. Numbers may use space or apostrophe
+// thousand separators ("1 200", "1'200"), dotted or comma groups with the other mark as decimal
+// separator ("1.200,50", "1,200.50"), a decimal comma or dot, and a k/к/тыс suffix ("1.5k", "5 тыс").
+// Numbers joined with + - * x × are evaluated with the usual precedence ("2x350+100").
+func parseAmountExpr(lower string, exp int) (amountExpr, bool) {
+	rs := []rune(lower)
+	i := 0
+	for i < len(rs) && !isDigit(rs[i]) {
+		i++
+	}
+	if i == len(rs) {
+		return amountExpr{}, false
+	}
+	start := i
+	sign := ""
+	if i > 0 && (rs[i-1] == '+' || rs[i-1] == '-') {
+		start = i - 1
+		sign = string(rs[i-1])
+	}
+
+	scale := pow10(exp)
+	value, next, suffixed, ok := scanNumber(rs, i, exp)
+	if !ok {
+		return amountExpr{}, false
+	}
+	computed := suffixed
+	// Sum of products: sum holds the finished terms, term the product being built
+	var sum int64
+	term := value
+	termSign := int64(1)
+	for {
+		op, at := scanOperator(rs, next)
+		if op == 0 {
+			break
+		}
+		operand, after, _, ok := scanNumber(rs, at, exp)
+		if !ok {
+			break
+		}
+		if op == '*' {
+			if operand != 0 && term > (math.MaxInt64-scale)/operand {
+				return amountExpr{}, false
+			}
+			term = (term*operand + scale/2) / scale
+		} else {
+			sum += termSign * term
+			term = operand
+			termSign = 1
+			if op == '-' {
+				termSign = -1
+			}
+		}
+		if term > maxAmountMinor || sum > maxAmountMinor || sum < -maxAmountMinor {
+			return amountExpr{}, false
+		}
+		computed = true
+		next = after
+	}
+	sum += termSign * term
+	if sum < 0 {
+		return amountExpr{}, false
+	}
+
+	res := amountExpr{
+		minor: sum,
+		sign:  sign,
+		start: len(string(rs[:start])),
+		end:   len(string(rs[:next])),
+	}
+	if computed {
+		res.expression = strings.TrimSpace(string(rs[i:next]))
+	}
+	return res, true
+}
+
+// scanNumber reads a number starting at rs[i] and returns its value in units of 10^-exp, the index after it
+// and whether it carried a thousands suffix. Fractions beyond exp digits are truncated.
+func scanNumber(rs []rune, i, exp int) (int64, int, bool, bool) {
+	if i >= len(rs) || !isDigit(rs[i]) {
+		return 0, i, false, false
+	}
+	j := digitsEnd(rs, i)
+	whole := string(rs[i:j])
+	frac := ""
+
+	switch {
+	case j-i <= 3 && isGroupSeparator(rs, j):
+		// 1 200 / 1'200 / 1’200
+		for isGroupSeparator(rs, j) {
+			whole += string(rs[j+1 : j+4])
+			j += 4
+		}
+		if j+1 < len(rs) && (rs[j] == ',' || rs[j] == '.') && isDigit(rs[j+1]) {
+			k := digitsEnd(rs, j+1)
+			frac = string(rs[j+1 : k])
+			j = k
+		}
+	case j-i <= 3 && isMarkGroup(rs, j) && (rs[j] == '.' || rs[j] == ','):
+		// 1.200.000 / 1.200,50 / 1,200.50: repeated or mixed marks mean the first one groups thousands
+		mark := rs[j]
+		other := ','
+		if mark == ',' {
+			other = '.'
+		}
+		k := j
+		groups := ""
+		for isMarkGroup(rs, k) && rs[k] == mark {
+			groups += string(rs[k+1 : k+4])
+			k += 4
+		}
+		hasDecimal := k+1 < len(rs) && rs[k] == other && isDigit(rs[k+1])
+		if len(groups) > 3 || hasDecimal {
+			whole += groups
+			j = k
+			if hasDecimal {
+				k = digitsEnd(rs, j+1)
+				frac = string(rs[j+1 : k])
+				j = k
+			}
+		} else {
+			// A single group is a plain decimal ("12.345" -> 12.34 with two fraction digits)
+			k = digitsEnd(rs, j+1)
+			frac = string(rs[j+1 : k])
+			j = k
+		}
+	case j+1 < len(rs) && (rs[j] == '.' || rs[j] == ',') && isDigit(rs[j+1]):
+		k := digitsEnd(rs, j+1)
+		frac = string(rs[j+1 : k])
+		j = k
+	}
+
+	if len(whole) > 15 {
+		return 0, j, false, false
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, j, false, false
+	}
+	frac = (frac + strings.Repeat("0", exp))[:exp]
+	f, _ := strconv.ParseInt("0"+frac, 10, 64)
+	if w > maxAmountMinor/pow10(exp) {
+		return 0, j, false, false
+	}
+	value := w*pow10(exp) + f
+
+	if end, ok := scanThousandSuffix(rs, j); ok {
+		if value > maxAmountMinor/1000 {
+			return 0, j, false, false
+		}
+		return value * 1000, end, true, true
+	}
+	return value, j, false, true
+}
+
+// scanThousandSuffix recognizes "k"/"к" right after a number and "тыс"/"тыс."/"тысяч" after an optional space.
+func scanThousandSuffix(rs []rune, j int) (int, bool) {
+	if j < len(rs) && (rs[j] == 'k' || rs[j] == 'к') && !isLetterAt(rs, j+1) {
+		return j + 1, true
+	}
+	k := j
+	if k < len(rs) && rs[k] == ' ' {
+		k++
+	}
+	w := k
+	for w < len(rs) && unicode.IsLetter(rs[w]) {
+		w++
+	}
+	switch string(rs[k:w]) {
+	case "тыс":
+		if w < len(rs) && rs[w] == '.' {
+			w++
+		}
+		return w, true
+	case "тысяч", "тысячи", "тысяча":
+		return w, true
+	}
+	return j, false
+}
+
+// scanOperator reads an arithmetic operator after optional spaces and returns it ('+', '-' or '*')
+// with the index of the following operand, or 0 if there is no operator followed by a number.
+func scanOperator(rs []rune, j int) (rune, int) {
+	k := skipSpaces(rs, j)
+	if k >= len(rs) {
+		return 0, j
+	}
+	var op rune
+	switch rs[k] {
+	case '+', '-':
+		op = rs[k]
+	case '*', 'x', '×', 'х':
+		op = '*'
+	default:
+		return 0, j
+	}
+	k = skipSpaces(rs, k+1)
+	if k >= len(rs) || !isDigit(rs[k]) {
+		return 0, j
+	}
+	return op, k
+}
+
+func pow10(exp int) int64 {
+	v := int64(1)
+	for ; exp > 0; exp-- {
+		v *= 10
+	}
+	return v
+}
+
+func isDigit(r rune) bool { return r >= '0' && r <= '9' }
+
+func isLetterAt(rs []rune, i int) bool { return i < len(rs) && unicode.IsLetter(rs[i]) }
+
+func digitsEnd(rs []rune, i int) int {
+	for i < len(rs) && isDigit(rs[i]) {
+		i++
+	}
+	return i
+}
+
+func skipSpaces(rs []rune, i int) int {
+	for i < len(rs) && unicode.IsSpace(rs[i]) {
+		i++
+	}
+	return i
+}
+
+// isGroupSeparator reports whether rs[j] is a (no-break) space or apostrophe followed by exactly three digits.
+func isGroupSeparator(rs []rune, j int) bool {
+	if j >= len(rs) {
+		return false
+	}
+	switch rs[j] {
+	case ' ', '\u00a0', '\u202f', '\'', '’':
+	default:
+		return false
+	}
+	return threeDigitsAt(rs, j+1)
+}
+
+// isMarkGroup reports whether rs[j] is a dot or comma followed by exactly three digits.
+func isMarkGroup(rs []rune, j int) bool {
+	return j < len(rs) && (rs[j] == '.' || rs[j] == ',') && threeDigitsAt(rs, j+1)
+}
+
+func threeDigitsAt(rs []rune, i int) bool {
+	return i+3 <= len(rs) && isDigit(rs[i]) && isDigit(rs[i+1]) && isDigit(rs[i+2]) && (i+3 == len(rs) || !isDigit(rs[i+3]))
+}
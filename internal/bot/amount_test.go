@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestMessageParser_AmountExpressions(t *testing.T) {
+	cases := []struct {
+		in         string
+		minor      int64
+		desc       string
+		expression string
+	}{
+		{"1 200 продукты", 120000, "продукты", ""},
+		{"1 200 300 продукты", 120030000, "продукты", ""},
+		{"1'200 products", 120000, "products", ""},
+		{"1’200,50 products", 120050, "products", ""},
+		{"1 200 продукты", 120000, "продукты", ""},
+		{"1.200,50 продукты", 120050, "продукты", ""},
+		{"1,200.50 groceries", 120050, "groceries", ""},
+		{"1.200.000 квартира", 120000000, "квартира", ""},
+		{"12.345 такси", 1234, "такси", ""},
+		{"1200,5 такси", 120050, "такси", ""},
+		{"1200 300 такси", 120000, "300 такси", ""},
+		{"5k ремонт", 500000, "ремонт", "5k"},
+		{"1.5к ремонт", 150000, "ремонт", "1.5к"},
+		{"5 тыс ремонт", 500000, "ремонт", "5 тыс"},
+		{"5 тыс. ремонт", 500000, "ремонт", "5 тыс."},
+		{"2x350 кофе", 70000, "кофе", "2x350"},
+		{"2 х 350 кофе", 70000, "кофе", "2 х 350"},
+		{"3×99,90 кофе", 29970, "кофе", "3×99,90"},
+		{"120+80 такси", 20000, "такси", "120+80"},
+		{"1000 - 150 скидка", 85000, "скидка", "1000 - 150"},
+		{"2*350+100 обед", 80000, "обед", "2*350+100"},
+		{"100+2x50 обед", 20000, "обед", "100+2x50"},
+		{"1,5k+300 подарки", 180000, "подарки", "1,5k+300"},
+		{"500 к чаю", 50000, "к чаю", ""},
+		{"2 xl футболки", 200, "xl футболки", ""},
+	}
+	p := NewMessageParser()
+	for _, c := range cases {
+		res, err := p.ParseMessage(c.in)
+		if err != nil || res.Amount == nil {
+			t.Errorf("%q: no amount (%v)", c.in, err)
+			continue
+		}
+		if res.Amount.AmountMinor != c.minor || strings.TrimSpace(res.Description) != c.desc || res.Expression != c.expression {
+			t.Errorf("%q: got %d %q %q, want %d %q %q", c.in, res.Amount.AmountMinor, res.Description, res.Expression, c.minor, c.desc, c.expression)
+		}
+	}
+
+	res, _ := p.ParseMessage("+120+80 кэшбэк")
+	if res.Type != "income" || res.Amount.AmountMinor != 20000 {
+		t.Fatalf("leading plus must mark the whole expression as income: %+v", res)
+	}
+	if res, _ := p.ParseMessage("100-200 кофе"); res.IsValid {
+		t.Fatalf("negative result must be rejected: %+v", res)
+	}
+	if v, err := p.ParseAmount("2x350"); err != nil || v != 70000 {
+		t.Fatalf("ParseAmount expression: %d %v", v, err)
+	}
+	if v, err := p.ParseAmount("1 200,50"); err != nil || v != 120050 {
+		t.Fatalf("ParseAmount separators: %d %v", v, err)
+	}
+}
+
+func TestHandler_AmountExpressionShownInReply(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, repository.NewSQLiteCategoryMappingRepository(db), nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db))
+
+	h.HandleUpdate(context.Background(), tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 8620}, From: &tgbotapi.User{ID: 3}, Text: "2x350 кофе"}})
+	texts := rec.Texts()
+	if len(texts) == 0 || !strings.Contains(texts[len(texts)-1], "700.00 RUB (2x350) — кофе") {
+		t.Fatalf("unexpected reply: %q", texts)
+	}
+}
@@ -67,3 +67,19 @@ func (cnm *CategoryNameMapper) GetCategoryByID(ctx context.Context, tenantID, ac
 
 	return nil, nil // Not found
 }
+
+// CategoriesByID loads expense and income categories once and indexes them by ID,
+// so lists of transactions can be labelled without a lookup per row.
+func (cnm *CategoryNameMapper) CategoriesByID(ctx context.Context, tenantID, accessToken, locale string) (map[string]*domain.Category, error) {
+	out := map[string]*domain.Category{}
+	for _, t := range []domain.TransactionType{domain.TransactionExpense, domain.TransactionIncome} {
+		categories, err := cnm.categoryClient.ListCategories(ctx, tenantID, accessToken, t, locale)
+		if err != nil {
+			return nil, err
+		}
+		for _, category := range categories {
+			out[category.ID] = category
+		}
+	}
+	return out, nil
+}
@@ -3,9 +3,12 @@ package bot
 
 import (
 	"context"
+	"math"
+	"strings"
 	"sync"
 	"time"
 
+	"budget-bot/internal/domain"
 	grpcclient "budget-bot/internal/grpc"
 	"go.uber.org/zap"
 )
@@ -24,6 +27,7 @@ type fxCache struct {
 
 type cachedRate struct {
 	rate     float64
+	info     *grpcclient.FxRate // nil when only the bare rate is known
 	storedAt time.Time
 }
 
@@ -44,24 +48,87 @@ func (cc *CurrencyConverter) GetExchangeRate(ctx context.Context, fromCurrency,
 	if r, ok := cc.cacheGet(key); ok {
 		return r, nil
 	}
-	rate, err := cc.fxClient.GetRate(ctx, fromCurrency, toCurrency, date, accessToken)
+	info, err := cc.GetRateInfo(ctx, fromCurrency, toCurrency, date, accessToken)
 	if err != nil {
-		cc.logger.Warn("fx get rate failed", zap.Error(err), zap.String("from", fromCurrency), zap.String("to", toCurrency))
 		return 0, err
 	}
-	cc.cacheSet(key, rate)
-	return rate, nil
+	return info.Rate, nil
+}
+
+// GetRateInfo fetches or caches FX rate together with its effective date and provider.
+func (cc *CurrencyConverter) GetRateInfo(ctx context.Context, fromCurrency, toCurrency string, date time.Time, accessToken string) (*grpcclient.FxRate, error) {
+	if fromCurrency == toCurrency {
+		return &grpcclient.FxRate{FromCurrency: fromCurrency, ToCurrency: toCurrency, Rate: 1.0, AsOf: date}, nil
+	}
+	key := cc.cacheKey(fromCurrency, toCurrency, date)
+	if r, ok := cc.cacheGetInfo(key); ok {
+		return r, nil
+	}
+	info, err := cc.fxClient.GetRateInfo(ctx, fromCurrency, toCurrency, date, accessToken)
+	if err != nil {
+		cc.logger.Warn("fx get rate failed", zap.Error(err), zap.String("from", fromCurrency), zap.String("to", toCurrency))
+		return nil, err
+	}
+	cc.cacheStore(key, info)
+	return info, nil
 }
 
 // ConvertToBaseCurrency converts amount from fromCurrency to toCurrency for a given date.
 func (cc *CurrencyConverter) ConvertToBaseCurrency(ctx context.Context, amountMinor int64, fromCurrency, toCurrency string, date time.Time, accessToken string) (int64, error) {
-	rate, err := cc.GetExchangeRate(ctx, fromCurrency, toCurrency, date, accessToken)
+	converted, _, err := cc.ConvertWithRate(ctx, amountMinor, fromCurrency, toCurrency, date, accessToken)
+	return converted, err
+}
+
+// ConvertWithRate converts amount like ConvertToBaseCurrency and also returns the rate that was applied.
+// Minor units are rescaled between precisions, so 1000 JPY becomes hundredths of the target currency.
+func (cc *CurrencyConverter) ConvertWithRate(ctx context.Context, amountMinor int64, fromCurrency, toCurrency string, date time.Time, accessToken string) (int64, *grpcclient.FxRate, error) {
+	info, err := cc.GetRateInfo(ctx, fromCurrency, toCurrency, date, accessToken)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
+	}
+	// round half away from zero to the nearest minor unit
+	converted := float64(amountMinor) * info.Rate * math.Pow10(domain.CurrencyExponent(toCurrency)-domain.CurrencyExponent(fromCurrency))
+	return int64(math.Round(converted)), info, nil
+}
+
+// UpsertRate stores a manual rate and drops the cached rates it may affect.
+func (cc *CurrencyConverter) UpsertRate(ctx context.Context, rate *grpcclient.FxRate, accessToken string) (*grpcclient.FxRate, error) {
+	stored, err := cc.fxClient.UpsertRate(ctx, rate, accessToken)
+	if err != nil {
+		cc.logger.Warn("fx upsert rate failed", zap.Error(err), zap.String("from", rate.FromCurrency), zap.String("to", rate.ToCurrency))
+		return nil, err
+	}
+	cc.Invalidate(rate.FromCurrency, rate.ToCurrency)
+	return stored, nil
+}
+
+// BatchGetRates fetches rates of several currencies to toCurrency and caches them.
+func (cc *CurrencyConverter) BatchGetRates(ctx context.Context, fromCurrencies []string, toCurrency string, date time.Time, accessToken string) ([]*grpcclient.FxRate, error) {
+	rates, err := cc.fxClient.BatchGetRates(ctx, fromCurrencies, toCurrency, date, accessToken)
+	if err != nil {
+		cc.logger.Warn("fx batch get rates failed", zap.Error(err), zap.String("to", toCurrency))
+		return nil, err
+	}
+	for _, r := range rates {
+		cc.cacheStore(cc.cacheKey(r.FromCurrency, toCurrency, date), r)
+	}
+	return rates, nil
+}
+
+// Invalidate drops cached rates involving any of the currencies, for all dates: the backend may
+// carry a rate forward to later dates or derive cross rates from it.
+func (cc *CurrencyConverter) Invalidate(currencies ...string) {
+	cc.cache.mu.Lock()
+	defer cc.cache.mu.Unlock()
+	for key := range cc.cache.data {
+		parts := strings.SplitN(key, "|", 3)
+		for _, c := range currencies {
+			if parts[0] == c || (len(parts) > 1 && parts[1] == c) {
+				delete(cc.cache.data, key)
+				break
+			}
+		}
 	}
-	// multiply and round to nearest minor unit; use banker's rounding if needed later
-	converted := float64(amountMinor) * rate
-	return int64(converted + 0.5), nil
 }
 
 func (cc *CurrencyConverter) cacheKey(from, to string, date time.Time) string {
@@ -82,10 +149,24 @@ func (cc *CurrencyConverter) cacheGet(key string) (float64, bool) {
 	return val.rate, true
 }
 
+func (cc *CurrencyConverter) cacheGetInfo(key string) (*grpcclient.FxRate, bool) {
+	cc.cache.mu.RLock()
+	defer cc.cache.mu.RUnlock()
+	val, ok := cc.cache.data[key]
+	if !ok || val.info == nil || time.Since(val.storedAt) > 24*time.Hour {
+		return nil, false
+	}
+	return val.info, true
+}
+
 func (cc *CurrencyConverter) cacheSet(key string, rate float64) {
 	cc.cache.mu.Lock()
 	cc.cache.data[key] = cachedRate{rate: rate, storedAt: time.Now()}
 	cc.cache.mu.Unlock()
 }
 
-
+func (cc *CurrencyConverter) cacheStore(key string, info *grpcclient.FxRate) {
+	cc.cache.mu.Lock()
+	cc.cache.data[key] = cachedRate{rate: info.Rate, info: info, storedAt: time.Now()}
+	cc.cache.mu.Unlock()
+}
@@ -2,64 +2,119 @@
 package bot
 
 import (
-    "regexp"
     "strings"
+    "unicode"
+    "unicode/utf8"
+
+    "budget-bot/internal/domain"
 )
 
-// CurrencyParser validates and normalizes currency codes.
+// CurrencyParser finds currencies in message text using the ISO 4217 registry.
 type CurrencyParser struct {
-    symbolToCode map[string]string
-    codeToSymbol map[string]string
+    symbolToCode map[rune]string
+    aliasToCode  map[string]string
 }
 
 // NewCurrencyParser constructs a CurrencyParser.
 func NewCurrencyParser() *CurrencyParser {
-    s2c := map[string]string{
-        "₽": "RUB",
-        "$": "USD",
-        "€": "EUR",
-        "£": "GBP",
-        "¥": "JPY",
-    }
-    c2s := map[string]string{
-        "RUB": "₽",
-        "USD": "$",
-        "EUR": "€",
-        "GBP": "£",
-        "JPY": "¥",
+    s2c := make(map[rune]string)
+    a2c := make(map[string]string)
+    for _, code := range domain.Currencies() {
+        c, _ := domain.LookupCurrency(code)
+        if r, size := utf8.DecodeRuneInString(c.Symbol); size > 0 && size == len(c.Symbol) {
+            s2c[r] = code
+        }
+        for _, a := range c.Aliases {
+            a2c[a] = code
+        }
     }
-    return &CurrencyParser{symbolToCode: s2c, codeToSymbol: c2s}
+    return &CurrencyParser{symbolToCode: s2c, aliasToCode: a2c}
 }
 
-var currencyCodeRe = regexp.MustCompile(`\b(RUB|USD|EUR|GBP|JPY)\b`)
-
 // ParseCurrency returns ISO code and the matched token, and the cleaned text without that token.
+// Symbols (₽, $, ₾…) are found anywhere; ISO codes and words ("usd", "руб", "лари") only right after the
+// amount or, for codes, at the start of the message, so that "cup" or "драма" in a description are not
+// taken for a currency.
 func (cp *CurrencyParser) ParseCurrency(text string) (code string, matched string, cleaned string) {
     t := text
-    // Look for symbols anywhere
-    for sym, c := range cp.symbolToCode {
-        if strings.Contains(t, sym) {
+    for _, r := range t {
+        if c, ok := cp.symbolToCode[r]; ok {
+            sym := string(r)
             cleaned = strings.ReplaceAll(t, sym, "")
             return c, sym, strings.TrimSpace(cleaned)
         }
     }
-    // Look for 3-letter codes (case-insensitive)
-    upper := strings.ToUpper(t)
-    if loc := currencyCodeRe.FindStringIndex(upper); loc != nil {
-        matched = upper[loc[0]:loc[1]]
-        cleaned = strings.TrimSpace(t[:loc[0]] + t[loc[1]:])
-        return matched, matched, cleaned
+    for start := 0; start < len(t); {
+        r, size := utf8.DecodeRuneInString(t[start:])
+        if !unicode.IsLetter(r) {
+            start += size
+            continue
+        }
+        end := start
+        for end < len(t) {
+            r, size := utf8.DecodeRuneInString(t[end:])
+            if !unicode.IsLetter(r) {
+                break
+            }
+            end += size
+        }
+        word := t[start:end]
+        if c, isCode := cp.lookupWord(word); c != "" {
+            after := end
+            if after < len(t) && t[after] == '.' {
+                after++
+            }
+            // "200 руб", "100usd"; an ISO code may also lead the message: "USD 100 food"
+            leading := isCode && strings.TrimSpace(t[:start]) == "" && digitAfter(t[after:])
+            if digitBefore(t[:start]) || leading {
+                cleaned = strings.TrimRight(t[:start], " ") + " " + strings.TrimLeft(t[after:], " ")
+                return c, word, strings.TrimSpace(cleaned)
+            }
+        }
+        start = end
     }
     return "", "", t
 }
 
-// ValidateCurrency checks if code looks like an ISO 4217 currency.
+// lookupWord maps a currency word or an ISO code in any case to the code and reports whether it was a code.
+func (cp *CurrencyParser) lookupWord(word string) (string, bool) {
+    lower := strings.ToLower(word)
+    if c, ok := cp.aliasToCode[lower]; ok {
+        return c, false
+    }
+    if utf8.RuneCountInString(word) == 3 {
+        if c, ok := domain.LookupCurrency(word); ok {
+            return c.Code, true
+        }
+    }
+    return "", false
+}
+
+// digitBefore reports whether s ends with a number, possibly with a thousands suffix ("100 руб", "100usd", "5к руб").
+func digitBefore(s string) bool {
+    s = strings.TrimRightFunc(s, unicode.IsSpace)
+    for _, suffix := range []string{"тыс.", "тыс", "k", "к"} {
+        if trimmed := strings.TrimSuffix(s, suffix); trimmed != s {
+            s = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+            break
+        }
+    }
+    r, _ := utf8.DecodeLastRuneInString(s)
+    return unicode.IsDigit(r)
+}
+
+// digitAfter reports whether s continues with a possibly signed number ("usd 100", "RUB -5").
+func digitAfter(s string) bool {
+    s = strings.TrimLeft(strings.TrimLeftFunc(s, unicode.IsSpace), "+-")
+    r, _ := utf8.DecodeRuneInString(s)
+    return unicode.IsDigit(r)
+}
+
+// ValidateCurrency checks if code is a known ISO 4217 currency.
 func (cp *CurrencyParser) ValidateCurrency(code string) bool {
     if code == "" {
         return false
     }
-    _, ok := cp.codeToSymbol[strings.ToUpper(code)]
+    _, ok := domain.LookupCurrency(code)
     return ok
 }
-
-
@@ -22,3 +22,32 @@ func TestCurrencyParser_Codes(t *testing.T) {
 }
 
 
+
+func TestCurrencyParser_RegistryWords(t *testing.T) {
+    cp := NewCurrencyParser()
+    cases := []struct{ in, code, cleaned string }{
+        {"50 лари хинкали", "GEL", "50 хинкали"},
+        {"3000 тенге такси", "KZT", "3000 такси"},
+        {"такси 200 руб.", "RUB", "такси 200"},
+        {"100р кофе", "RUB", "100 кофе"},
+        {"20 евро музей", "EUR", "20 музей"},
+        {"10 долларов чаевые", "USD", "10 чаевые"},
+        {"₾25 вино", "GEL", "25 вино"},
+        {"350₺ çay", "TRY", "350 çay"},
+        {"gel 40 taxi", "GEL", "40 taxi"},
+        {"1200 thb massage", "THB", "1200 massage"},
+        {"5к руб ремонт", "RUB", "5к ремонт"},
+    }
+    for _, c := range cases {
+        code, _, cleaned := cp.ParseCurrency(c.in)
+        if code != c.code || cleaned != c.cleaned {
+            t.Errorf("%q: got %s %q, want %s %q", c.in, code, cleaned, c.code, c.cleaned)
+        }
+    }
+    // Codes and words away from the amount are part of the description
+    for _, in := range []string{"coffee cup 300", "кино драма 500", "драма 500", "300 на всё"} {
+        if code, _, _ := cp.ParseCurrency(in); code != "" {
+            t.Errorf("%q: unexpected currency %s", in, code)
+        }
+    }
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	grpcclient "budget-bot/internal/grpc"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
@@ -20,6 +21,27 @@ func (m *MockFXClient) GetRate(_ context.Context, fromCurrency, toCurrency strin
 	return 1.0, nil
 }
 
+func (m *MockFXClient) GetRateInfo(ctx context.Context, fromCurrency, toCurrency string, asOf time.Time, token string) (*grpcclient.FxRate, error) {
+	rate, err := m.GetRate(ctx, fromCurrency, toCurrency, asOf, token)
+	if err != nil {
+		return nil, err
+	}
+	return &grpcclient.FxRate{FromCurrency: fromCurrency, ToCurrency: toCurrency, Rate: rate, AsOf: asOf, Provider: "mock"}, nil
+}
+
+func (m *MockFXClient) UpsertRate(_ context.Context, rate *grpcclient.FxRate, _ string) (*grpcclient.FxRate, error) {
+	return rate, nil
+}
+
+func (m *MockFXClient) BatchGetRates(ctx context.Context, fromCurrencies []string, toCurrency string, asOf time.Time, token string) ([]*grpcclient.FxRate, error) {
+	var out []*grpcclient.FxRate
+	for _, from := range fromCurrencies {
+		r, _ := m.GetRateInfo(ctx, from, toCurrency, asOf, token)
+		out = append(out, r)
+	}
+	return out, nil
+}
+
 func TestCurrencyConverter_ConvertToBaseCurrency_SameCurrency(t *testing.T) {
 	ctx := context.Background()
 	fxClient := &MockFXClient{}
@@ -116,7 +138,7 @@ func TestCurrencyConverter_cacheGet_NotExists(t *testing.T) {
 func TestCurrencyParser_ValidateCurrency_ValidCurrencies(t *testing.T) {
 	parser := NewCurrencyParser()
 
-	validCurrencies := []string{"USD", "EUR", "RUB", "GBP", "JPY"}
+	validCurrencies := []string{"USD", "EUR", "RUB", "GBP", "JPY", "CNY", "CAD", "AUD", "CHF", "SEK", "GEL", "usd"}
 
 	for _, currency := range validCurrencies {
 		result := parser.ValidateCurrency(currency)
@@ -127,7 +149,7 @@ func TestCurrencyParser_ValidateCurrency_ValidCurrencies(t *testing.T) {
 func TestCurrencyParser_ValidateCurrency_InvalidCurrencies(t *testing.T) {
 	parser := NewCurrencyParser()
 
-	invalidCurrencies := []string{"", "US", "USDD", "123", "ABC", "USD ", " USD", "CNY", "CAD", "AUD", "CHF", "SEK"}
+	invalidCurrencies := []string{"", "US", "USDD", "123", "ABC", "USD ", " USD", "XXX", "XAU"}
 
 	for _, currency := range invalidCurrencies {
 		result := parser.ValidateCurrency(currency)
@@ -0,0 +1,251 @@
+package bot
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DateExtractor finds a date expression in lower-cased message text.
+type DateExtractor interface {
+	// Extract resolves the first date expression against now (and its location) and returns it
+	// in UTC together with the text without the expression. It returns nil if there is no date.
+	Extract(lower string, now time.Time) (*time.Time, string)
+}
+
+// NaturalDateExtractor understands Russian and English date expressions:
+// сегодня/вчера/позавчера, today/yesterday, "3 дня назад", "3 days ago", weekdays ("в пятницу",
+// "last friday"), month names ("5 марта", "March 5"), ISO 2025-03-05 and DD.MM(.YYYY), optionally
+// followed by a time of day ("вчера в 19:30"). Dates without a time resolve to local midnight.
+type NaturalDateExtractor struct{}
+
+// NewNaturalDateExtractor constructs the default date extractor.
+func NewNaturalDateExtractor() *NaturalDateExtractor { return &NaturalDateExtractor{} }
+
+// Word boundaries are spelled out because \b only knows ASCII letters.
+const (
+	wordStart = `(?:^|[\s,;(])`
+	wordEnd   = `(?:$|[\s,;.!?)])`
+)
+
+var (
+	isoDateRe   = regexp.MustCompile(wordStart + `((\d{4})-(\d{1,2})-(\d{1,2}))` + wordEnd)
+	dateDDMMYY  = regexp.MustCompile(`\b(\d{1,2})[\./](\d{1,2})(?:[\./](\d{2,4}))?\b`)
+	dayMonthRe  = regexp.MustCompile(wordStart + `((\d{1,2})\s+(\p{L}+)\.?(?:\s+((?:19|20)\d{2}))?)` + wordEnd)
+	monthDayRe  = regexp.MustCompile(wordStart + `((\p{L}+)\.?\s+(\d{1,2})(?:st|nd|rd|th)?(?:,?\s+((?:19|20)\d{2}))?)` + wordEnd)
+	agoRe       = regexp.MustCompile(wordStart + `(((?:\d+|a|an|one)\s+)?(день|дня|дней|неделю|недели|недель|days?|weeks?)\s+(назад|ago))` + wordEnd)
+	weekdayRe   = regexp.MustCompile(wordStart + `((?:(?:в|во|on)\s+)?(?:(прошл\p{L}*|last)\s+)?(понедельник|вторник|сред[ауы]|четверг|пятниц[ауы]|суббот[ауы]|воскресенье|monday|tuesday|wednesday|thursday|friday|saturday|sunday))` + wordEnd)
+	relativeRe  = regexp.MustCompile(wordStart + `(позавчера|day before yesterday|вчера|yesterday|сегодня|today)` + wordEnd)
+	timeOfDayRe = regexp.MustCompile(wordStart + `((?:(?:в|at)\s+)?(\d{1,2}):(\d{2}))` + wordEnd)
+)
+
+var monthWords = map[string]time.Month{
+	"янв": time.January, "фев": time.February, "мар": time.March, "апр": time.April,
+	"июн": time.June, "июл": time.July, "авг": time.August, "сен": time.September, "окт": time.October,
+	"ноя": time.November, "дек": time.December,
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April, "may": time.May,
+	"jun": time.June, "jul": time.July, "aug": time.August, "sep": time.September, "oct": time.October,
+	"nov": time.November, "dec": time.December,
+}
+
+var weekdayWords = map[string]time.Weekday{
+	"понедельник": time.Monday, "вторник": time.Tuesday, "сред": time.Wednesday, "четверг": time.Thursday,
+	"пятниц": time.Friday, "суббот": time.Saturday, "воскресенье": time.Sunday,
+	"monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday, "thursday": time.Thursday,
+	"friday": time.Friday, "saturday": time.Saturday, "sunday": time.Sunday,
+}
+
+// monthFromWord recognizes full, genitive and abbreviated month names ("марта", "мар", "March", "sept").
+func monthFromWord(w string) (time.Month, bool) {
+	r := []rune(w)
+	if len(r) < 3 {
+		return 0, false
+	}
+	// "май"/"мая" share only two letters with "март"
+	if w == "май" || w == "мая" {
+		return time.May, true
+	}
+	m, ok := monthWords[string(r[:3])]
+	if !ok || m == time.May && w != "may" {
+		return 0, false
+	}
+	// Reject words that merely start like a month ("марка", "octopus")
+	for _, full := range []string{"январ", "феврал", "март", "апрел", "июн", "июл", "август", "сентябр", "октябр", "ноябр", "декабр",
+		"january", "february", "march", "april", "june", "july", "august", "september", "october", "november", "december"} {
+		if strings.HasPrefix(w, full) || strings.HasPrefix(full, w) {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+func weekdayFromWord(w string) time.Weekday {
+	for stem, wd := range weekdayWords {
+		if strings.HasPrefix(w, stem) {
+			return wd
+		}
+	}
+	return time.Sunday
+}
+
+// span is a recognized expression: its byte range in the text and the resolved calendar date.
+type span struct {
+	start, end int
+	date       time.Time
+}
+
+// Extract implements DateExtractor.
+func (e *NaturalDateExtractor) Extract(lower string, now time.Time) (*time.Time, string) {
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	found, ok := e.findDate(lower, today)
+	text := lower
+	var date time.Time
+	if ok {
+		text = lower[:found.start] + " " + lower[found.end:]
+		date = found.date
+	}
+
+	// Time of day: right after a date, or anywhere with "в"/"at" (then it refers to today)
+	if m := timeOfDayRe.FindStringSubmatchIndex(text); m != nil {
+		hasPrefix := m[4] > m[2]
+		h, _ := strconv.Atoi(text[m[4]:m[5]])
+		mi, _ := strconv.Atoi(text[m[6]:m[7]])
+		if (ok || hasPrefix) && h <= 23 && mi <= 59 {
+			if !ok {
+				date, ok = today, true
+			}
+			date = time.Date(date.Year(), date.Month(), date.Day(), h, mi, 0, 0, loc)
+			text = text[:m[2]] + " " + text[m[3]:]
+		}
+	}
+	if !ok {
+		return nil, lower
+	}
+	utc := date.UTC()
+	return &utc, strings.Join(strings.Fields(text), " ")
+}
+
+// findDate returns the first date expression, trying the most specific forms first.
+func (e *NaturalDateExtractor) findDate(lower string, today time.Time) (span, bool) {
+	loc := today.Location()
+	if m := isoDateRe.FindStringSubmatchIndex(lower); m != nil {
+		y, _ := strconv.Atoi(lower[m[4]:m[5]])
+		mon, _ := strconv.Atoi(lower[m[6]:m[7]])
+		d, _ := strconv.Atoi(lower[m[8]:m[9]])
+		if validDate(y, mon, d) {
+			return span{m[2], m[3], time.Date(y, time.Month(mon), d, 0, 0, 0, 0, loc)}, true
+		}
+	}
+	// "1.5к" is an amount, not the 1st of May
+	if m := dateDDMMYY.FindStringSubmatchIndex(lower); m != nil && !startsWithLetter(lower[m[1]:]) {
+		d, _ := strconv.Atoi(lower[m[2]:m[3]])
+		mon, _ := strconv.Atoi(lower[m[4]:m[5]])
+		y := today.Year()
+		if m[6] >= 0 {
+			y, _ = strconv.Atoi(lower[m[6]:m[7]])
+			if y < 100 { // YY -> 20YY heuristic
+				y += 2000
+			}
+		}
+		if validDate(y, mon, d) {
+			return span{m[0], m[1], time.Date(y, time.Month(mon), d, 0, 0, 0, 0, loc)}, true
+		}
+	}
+	for _, m := range findAllWords(dayMonthRe, lower) {
+		if mon, ok := monthFromWord(lower[m[6]:m[7]]); ok {
+			d, _ := strconv.Atoi(lower[m[4]:m[5]])
+			if s, ok := monthDateSpan(m, d, mon, m[8], m[9], lower, today); ok {
+				return s, true
+			}
+		}
+	}
+	for _, m := range findAllWords(monthDayRe, lower) {
+		if mon, ok := monthFromWord(lower[m[4]:m[5]]); ok {
+			d, _ := strconv.Atoi(lower[m[6]:m[7]])
+			if s, ok := monthDateSpan(m, d, mon, m[8], m[9], lower, today); ok {
+				return s, true
+			}
+		}
+	}
+	if m := agoRe.FindStringSubmatchIndex(lower); m != nil {
+		n := 1
+		if m[4] >= 0 {
+			if v, err := strconv.Atoi(strings.TrimSpace(lower[m[4]:m[5]])); err == nil {
+				n = v
+			}
+		}
+		unit := lower[m[6]:m[7]]
+		if strings.HasPrefix(unit, "нед") || strings.HasPrefix(unit, "week") {
+			n *= 7
+		}
+		return span{m[2], m[3], today.AddDate(0, 0, -n)}, true
+	}
+	if m := weekdayRe.FindStringSubmatchIndex(lower); m != nil {
+		wd := weekdayFromWord(lower[m[6]:m[7]])
+		back := (int(today.Weekday()) - int(wd) + 7) % 7
+		// "last friday" said on a Friday means a week ago, plain "friday" means today
+		if back == 0 && m[4] >= 0 {
+			back = 7
+		}
+		return span{m[2], m[3], today.AddDate(0, 0, -back)}, true
+	}
+	if m := relativeRe.FindStringSubmatchIndex(lower); m != nil {
+		back := 0
+		switch lower[m[2]:m[3]] {
+		case "позавчера", "day before yesterday":
+			back = 2
+		case "вчера", "yesterday":
+			back = 1
+		}
+		return span{m[2], m[3], today.AddDate(0, 0, -back)}, true
+	}
+	return span{}, false
+}
+
+// findAllWords is FindAllStringSubmatchIndex for patterns wrapped in wordStart/wordEnd: it resumes
+// the search inside the previous match so that a shared separator can start the next match.
+func findAllWords(re *regexp.Regexp, s string) [][]int {
+	var out [][]int
+	for pos := 0; pos < len(s); {
+		m := re.FindStringSubmatchIndex(s[pos:])
+		if m == nil {
+			break
+		}
+		for i := range m {
+			if m[i] >= 0 {
+				m[i] += pos
+			}
+		}
+		out = append(out, m)
+		pos = m[3]
+	}
+	return out
+}
+
+// monthDateSpan builds a span for a day and month name with an optional year at [ys, ye).
+func monthDateSpan(m []int, d int, mon time.Month, ys, ye int, lower string, today time.Time) (span, bool) {
+	y := today.Year()
+	if ys >= 0 {
+		y, _ = strconv.Atoi(lower[ys:ye])
+	}
+	if !validDate(y, int(mon), d) {
+		return span{}, false
+	}
+	return span{m[2], m[3], time.Date(y, mon, d, 0, 0, 0, 0, today.Location())}, true
+}
+
+func startsWithLetter(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLetter(r)
+}
+
+func validDate(y, mon, d int) bool {
+	if mon < 1 || mon > 12 || d < 1 {
+		return false
+	}
+	return d <= time.Date(y, time.Month(mon)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNaturalDateExtractor(t *testing.T) {
+	msk := time.FixedZone("UTC+3", 3*3600)
+	// Wednesday
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, msk)
+	day := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 0, 0, 0, 0, msk) }
+	at := func(m time.Month, d, h, mi int) time.Time { return time.Date(2025, m, d, h, mi, 0, 0, msk) }
+
+	cases := []struct {
+		in   string
+		want time.Time
+		rest string
+	}{
+		{"сегодня 100 кофе", day(3, 12), "100 кофе"},
+		{"вчера 100 кофе", day(3, 11), "100 кофе"},
+		{"позавчера 100 кофе", day(3, 10), "100 кофе"},
+		{"100 кофе позавчера", day(3, 10), "100 кофе"},
+		{"yesterday 100 coffee", day(3, 11), "100 coffee"},
+		{"day before yesterday 100 coffee", day(3, 10), "100 coffee"},
+		{"3 дня назад 500 такси", day(3, 9), "500 такси"},
+		{"неделю назад 500 такси", day(3, 5), "500 такси"},
+		{"500 taxi 3 days ago", day(3, 9), "500 taxi"},
+		{"500 taxi a week ago", day(3, 5), "500 taxi"},
+		{"в пятницу 700 кино", day(3, 7), "700 кино"},
+		{"во вторник 700 кино", day(3, 11), "700 кино"},
+		{"в среду 700 кино", day(3, 12), "700 кино"},
+		{"в прошлую среду 700 кино", day(3, 5), "700 кино"},
+		{"700 movies last friday", day(3, 7), "700 movies"},
+		{"700 movies on monday", day(3, 10), "700 movies"},
+		{"5 марта 300 обед", day(3, 5), "300 обед"},
+		{"300 обед 1 мая", day(5, 1), "300 обед"},
+		{"300 обед 15 янв. 2024", time.Date(2024, 1, 15, 0, 0, 0, 0, msk), "300 обед"},
+		{"300 lunch march 5", day(3, 5), "300 lunch"},
+		{"300 lunch 5 march", day(3, 5), "300 lunch"},
+		{"300 lunch feb 28th, 2024", time.Date(2024, 2, 28, 0, 0, 0, 0, msk), "300 lunch"},
+		{"2025-02-20 300 обед", day(2, 20), "300 обед"},
+		{"01.02 300 обед", day(2, 1), "300 обед"},
+		{"01.02.24 300 обед", time.Date(2024, 2, 1, 0, 0, 0, 0, msk), "300 обед"},
+		{"вчера в 19:30 1200 ресторан", at(3, 11, 19, 30), "1200 ресторан"},
+		{"5 марта 8:15 300 завтрак", at(3, 5, 8, 15), "300 завтрак"},
+		{"yesterday at 7:05 300 breakfast", at(3, 11, 7, 5), "300 breakfast"},
+		{"2025-02-20 21:00 300 ужин", at(2, 20, 21, 0), "300 ужин"},
+		{"кофе 300 в 9:15", at(3, 12, 9, 15), "кофе 300"},
+	}
+	e := NewNaturalDateExtractor()
+	for _, c := range cases {
+		got, rest := e.Extract(c.in, now)
+		if got == nil {
+			t.Errorf("%q: no date", c.in)
+			continue
+		}
+		if !got.Equal(c.want) || got.Location() != time.UTC {
+			t.Errorf("%q: got %s, want %s", c.in, got, c.want.UTC())
+		}
+		if rest != c.rest {
+			t.Errorf("%q: rest %q, want %q", c.in, rest, c.rest)
+		}
+	}
+
+	for _, in := range []string{
+		"100 кофе",
+		"300 марки",
+		"300 octopus 5",
+		"31.02 300 обед",
+		"кофе 19:30",
+		"вчерашний хлеб 100",
+		"300 подарок маме",
+	} {
+		if got, rest := e.Extract(in, now); got != nil || rest != in {
+			t.Errorf("%q: unexpected date %v (%q)", in, got, rest)
+		}
+	}
+}
+
+func TestMessageParser_NaturalDates(t *testing.T) {
+	p := NewMessageParser()
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC)
+	res, err := p.ParseMessageAt("позавчера 1500 продукты", now)
+	if err != nil || !res.IsValid || res.OccurredAt == nil {
+		t.Fatalf("unexpected result: %+v %v", res, err)
+	}
+	if res.OccurredAt.Day() != 10 || res.Amount.AmountMinor != 150000 || res.Description != "продукты" {
+		t.Fatalf("позавчера must not be read as вчера: %+v", res)
+	}
+	res, _ = p.ParseMessageAt("вчера в 19:30 1200 ресторан", now)
+	if res.OccurredAt.Hour() != 19 || res.Amount.AmountMinor != 120000 || res.Description != "ресторан" {
+		t.Fatalf("time of day must not be read as the amount: %+v", res)
+	}
+}
+
+type fixedDateExtractor struct{ at time.Time }
+
+func (f fixedDateExtractor) Extract(lower string, _ time.Time) (*time.Time, string) {
+	return &f.at, lower
+}
+
+func TestMessageParser_WithDateExtractor(t *testing.T) {
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	res, _ := NewMessageParser().WithDateExtractor(fixedDateExtractor{at: at}).ParseMessage("100 кофе")
+	if res.OccurredAt == nil || !res.OccurredAt.Equal(at) {
+		t.Fatalf("custom extractor not used: %+v", res)
+	}
+}
@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxDownloadBytes limits the size of files fetched from Telegram.
+const maxDownloadBytes = 20 << 20
+
+// FileDownloader fetches the content of a Telegram file by its file_id.
+type FileDownloader interface {
+	Download(ctx context.Context, fileID string) ([]byte, error)
+}
+
+// TelegramFileDownloader resolves file_id via getFile and downloads it from the Bot API file endpoint.
+// It reuses the bot HTTP client, so proxy settings apply to downloads too.
+type TelegramFileDownloader struct {
+	bot *tgbotapi.BotAPI
+}
+
+// NewTelegramFileDownloader creates a downloader bound to the bot token.
+func NewTelegramFileDownloader(bot *tgbotapi.BotAPI) *TelegramFileDownloader {
+	return &TelegramFileDownloader{bot: bot}
+}
+
+// Download returns the file content.
+func (d *TelegramFileDownloader) Download(ctx context.Context, fileID string) ([]byte, error) {
+	url, err := d.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("get file: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.bot.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download bad status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownloadBytes {
+		return nil, fmt.Errorf("file is too large")
+	}
+	return data, nil
+}
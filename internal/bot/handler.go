@@ -5,7 +5,9 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"budget-bot/internal/bot/ui"
@@ -13,8 +15,8 @@ import (
 	grpcclient "budget-bot/internal/grpc"
 	"budget-bot/internal/llm"
 	"budget-bot/internal/metrics"
-	pb "budget-bot/internal/pb/budget/v1"
 	"budget-bot/internal/repository"
+	"budget-bot/internal/stt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -36,10 +38,24 @@ type Handler struct {
 	report     grpcclient.ReportClient
 	drafts     repository.DraftRepository
 	opCtxs     repository.OperationContextRepository
+	budgets    repository.BudgetRepository
+	recurring  repository.RecurringRepository
+	digests    repository.DigestRepository
+	finds      repository.FindQueryRepository
 	tenants    grpcclient.TenantClient
+	imports    grpcclient.ImportClient
+	fx         *CurrencyConverter
+	groups     repository.GroupChatRepository
 	fmt        *ui.MessageFormatter
 	llm        llm.CategorySuggester
 	llmEnabled bool
+	files      FileDownloader
+	stt        stt.SpeechToText
+
+	// tenantLists maps an access token to its cachedTenants
+	tenantLists sync.Map
+	// typicalAmountsCache maps tenant|type|category|currency to its *typicalAmount
+	typicalAmountsCache sync.Map
 }
 
 // NewHandler constructs a Handler.
@@ -47,7 +63,7 @@ func NewHandler(bot *tgbotapi.BotAPI, states repository.DialogStateRepository, a
 	if categories == nil {
 		categories = &grpcclient.StaticCategoryClient{}
 	}
-	return &Handler{bot: bot, states: states, auth: auth, logger: logger, parser: NewMessageParser(), categories: categories, mappings: mappings, matcher: NewCategoryMatcher(mappings), nameMapper: NewCategoryNameMapper(categories), txClient: &grpcclient.FakeTransactionClient{}, report: &grpcclient.FakeReportClient{}, tenants: &grpcclient.FakeTenantClient{}, fmt: ui.NewMessageFormatter()}
+	return &Handler{bot: bot, states: states, auth: auth, logger: logger, parser: NewMessageParser(), categories: categories, mappings: mappings, matcher: NewCategoryMatcher(mappings), nameMapper: NewCategoryNameMapper(categories), txClient: &grpcclient.FakeTransactionClient{}, report: &grpcclient.FakeReportClient{}, tenants: &grpcclient.FakeTenantClient{}, imports: &grpcclient.FakeImportClient{}, fmt: ui.NewMessageFormatter(), files: NewTelegramFileDownloader(bot)}
 }
 
 // WithPreferences allows injecting a preferences repository after construction.
@@ -68,6 +84,30 @@ func (h *Handler) WithOperationContexts(r repository.OperationContextRepository)
 	return h
 }
 
+// WithBudgets allows injecting a category budget repository.
+func (h *Handler) WithBudgets(r repository.BudgetRepository) *Handler {
+	h.budgets = r
+	return h
+}
+
+// WithRecurring allows injecting a recurring transactions repository.
+func (h *Handler) WithRecurring(r repository.RecurringRepository) *Handler {
+	h.recurring = r
+	return h
+}
+
+// WithDigests allows injecting a digest subscriptions repository.
+func (h *Handler) WithDigests(r repository.DigestRepository) *Handler {
+	h.digests = r
+	return h
+}
+
+// WithFindQueries allows injecting the store of /find queries used by the result page buttons.
+func (h *Handler) WithFindQueries(r repository.FindQueryRepository) *Handler {
+	h.finds = r
+	return h
+}
+
 // WithLLM allows injecting LLM category suggester and feature flag.
 func (h *Handler) WithLLM(s llm.CategorySuggester, enabled bool) *Handler {
 	h.llm = s
@@ -107,18 +147,55 @@ func (h *Handler) WithTenantClient(tc grpcclient.TenantClient) *Handler {
 	return h
 }
 
+// WithImportClient allows injecting a CSV import client.
+func (h *Handler) WithImportClient(ic grpcclient.ImportClient) *Handler {
+	if ic != nil {
+		h.imports = ic
+	}
+	return h
+}
+
+// WithSpeechToText allows injecting a speech recognizer for voice messages.
+func (h *Handler) WithSpeechToText(s stt.SpeechToText) *Handler {
+	h.stt = s
+	return h
+}
+
+// WithFileDownloader allows replacing how photos and other files are fetched from Telegram.
+func (h *Handler) WithFileDownloader(d FileDownloader) *Handler {
+	if d != nil {
+		h.files = d
+	}
+	return h
+}
+
 // HandleUpdate processes a single Telegram update.
 func (h *Handler) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
 	if update.CallbackQuery != nil {
 		h.handleCallback(ctx, update)
 		return
 	}
+	if update.InlineQuery != nil {
+		h.handleInlineQuery(ctx, update.InlineQuery)
+		return
+	}
+	if update.ChosenInlineResult != nil {
+		h.handleChosenInlineResult(ctx, update.ChosenInlineResult)
+		return
+	}
 	if update.Message == nil {
 		return
 	}
 
 	metrics.IncUpdate()
 
+	if isGroupChat(update.Message.Chat) {
+		var ok bool
+		if ctx, ok = h.enterGroup(ctx, update.Message); !ok {
+			return
+		}
+	}
+
 	// Debug logging for command detection
 	if strings.HasPrefix(update.Message.Text, "/") {
 		h.logger.Debug("potential command detected",
@@ -154,7 +231,11 @@ func (h *Handler) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
 		return
 	}
 
+	group := groupFromContext(ctx) != nil
 	rec, _ := h.states.GetState(ctx, update.Message.From.ID)
+	if rec != nil && group && !isEditState(rec.State) {
+		rec = nil
+	}
 	if rec != nil {
 		switch rec.State {
 		case repository.StateWaitingForOAuthEmail:
@@ -163,252 +244,319 @@ func (h *Handler) HandleUpdate(ctx context.Context, update tgbotapi.Update) {
 		case repository.StateWaitingForOAuthCode:
 			h.handleOAuthCode(ctx, update)
 			return
+		case repository.StateWaitingForEditAmount, repository.StateWaitingForEditDate, repository.StateWaitingForEditComment:
+			h.handleEditInput(ctx, update, rec)
+			return
+		case repository.StateWaitingForDraftAmount:
+			h.handleDraftAmountInput(ctx, update, rec)
+			return
+		case repository.StateWaitingForImportFile:
+			if !isCSVDocument(update.Message) {
+				locale := h.userLocale(ctx, update.Message.From.ID)
+				_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Ожидается CSV-файл. Для отмены: /cancel", "A CSV file is expected. To cancel: /cancel")))
+				return
+			}
 		}
 	}
 
+	if isCSVDocument(update.Message) && !group {
+		h.handleImportFile(ctx, update)
+		return
+	}
+
+	if update.Message.Location != nil && !group {
+		h.handleLocation(ctx, update)
+		return
+	}
+
+	// voice notes and photos in a group are usually not addressed to the bot
+	if update.Message.Voice != nil && !group {
+		h.handleVoice(ctx, update)
+		return
+	}
+
+	if fileID, ok := receiptImageFileID(update.Message); ok && !group {
+		h.handleReceipt(ctx, update, fileID)
+		return
+	}
+
+	if lines := batchLines(update.Message.Text); len(lines) > 1 && (!group || h.parsesAsTransaction(ctx, update.Message.From.ID, lines[0])) {
+		h.handleBatch(ctx, update, lines)
+		return
+	}
+
 	// Try parse transaction
-	parsed, _ := h.parser.ParseMessage(update.Message.Text)
+	parsed, _ := h.parser.ParseMessageAt(update.Message.Text, h.userNow(ctx, update.Message.From.ID))
 	if parsed != nil && parsed.IsValid {
-		// Default currency from preferences if missing
-		cur := parsed.Currency
-		if cur == "" && h.prefs != nil {
-			if pref, err := h.prefs.GetPreferences(ctx, update.Message.From.ID); err == nil && pref != nil && pref.DefaultCurrency != "" {
-				cur = pref.DefaultCurrency
-			}
-			if cur == "" {
-				cur = "RUB"
-			}
+		h.saveParsedTransaction(ctx, update, parsed)
+		return
+	}
+
+	if parsed != nil && !parsed.IsValid && !group {
+		// Provide simple validation feedback
+		locale := h.userLocale(ctx, update.Message.From.ID)
+		msgText := tr(locale, "Не удалось распознать сообщение. Убедитесь, что указана сумма (например: 100 кофе)", "Could not parse the message. Make sure amount is provided (e.g. 100 coffee)")
+		if len(parsed.Errors) > 0 {
+			// Show first error in a user-friendly way
+			msgText = tr(locale, "Ошибка: ", "Error: ") + parsed.Errors[0]
 		}
-		amt := float64(parsed.Amount.AmountMinor) / 100.0
-		sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
-		if err == nil && sess != nil {
-			// Проверяем, что сессия действительно валидна (токены не истекли)
-			if time.Now().After(sess.AccessTokenExpiresAt) {
-				h.logger.Warn("Session has expired tokens, user needs to re-authenticate",
-					zap.Int64("telegramID", update.Message.From.ID),
-					zap.Time("accessTokenExpiresAt", sess.AccessTokenExpiresAt),
-					zap.Time("refreshTokenExpiresAt", sess.RefreshTokenExpiresAt))
-				// Удаляем невалидную сессию
-				if err := h.auth.Logout(ctx, update.Message.From.ID); err != nil {
-					h.logger.Error("Failed to logout user with expired tokens",
-						zap.Int64("telegramID", update.Message.From.ID),
-						zap.Error(err))
-				}
-				// Продолжаем как будто сессии нет
-				sess = nil
-			}
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
+		_, sendErr := h.send(ctx, msg)
+		if sendErr != nil {
+			h.logger.Error("failed to send validation error", zap.Error(sendErr), zap.String("text", msgText))
 		}
+		return
+	}
+}
 
-		if sess != nil {
-			h.logger.Debug("Got valid session for user",
+// saveParsedTransaction runs category selection for a parsed transaction and saves it,
+// or echoes the parse result when the user is not logged in. It reports whether the transaction was taken care
+// of: saved, held for confirmation or waiting for its category.
+func (h *Handler) saveParsedTransaction(ctx context.Context, update tgbotapi.Update, parsed *ParsedTransaction) bool {
+	// Default currency from preferences if missing
+	cur := parsed.Currency
+	if cur == "" && (h.prefs != nil || groupFromContext(ctx) != nil) {
+		cur = h.defaultCurrency(ctx, update.Message.From.ID)
+	}
+	parsed.ApplyDefaultCurrency(cur)
+	amt := domain.FormatAmount(parsed.Amount.AmountMinor, cur)
+	sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
+	if err == nil && sess != nil {
+		// Проверяем, что сессия действительно валидна (токены не истекли)
+		if time.Now().After(sess.AccessTokenExpiresAt) {
+			h.logger.Warn("Session has expired tokens, user needs to re-authenticate",
 				zap.Int64("telegramID", update.Message.From.ID),
-				zap.String("accessToken", sess.AccessToken[:int(math.Min(float64(len(sess.AccessToken)), 10))]+"..."),
-				zap.String("refreshToken", sess.RefreshToken[:int(math.Min(float64(len(sess.RefreshToken)), 10))]+"..."),
 				zap.Time("accessTokenExpiresAt", sess.AccessTokenExpiresAt),
-				zap.Time("refreshTokenExpiresAt", sess.RefreshTokenExpiresAt),
-				zap.Time("now", time.Now()),
-				zap.Bool("accessTokenExpired", time.Now().After(sess.AccessTokenExpiresAt)),
-				zap.Bool("refreshTokenExpired", time.Now().After(sess.RefreshTokenExpiresAt)))
-			var catID string
-			source := "manual"
-			if h.matcher != nil {
-				if m, err := h.matcher.FindCategory(ctx, sess.TenantID, parsed.Description); err == nil && m != nil {
-					catID = m.CategoryID
-					source = "mapping"
-				}
-			}
-
-			llmProbability := 0.0
-			if catID == "" {
-				pref, _ := h.prefs.GetPreferences(ctx, update.Message.From.ID)
-				locale := ""
-				if pref != nil && pref.Language != "" {
-					locale = pref.Language
-				}
-
-				h.logger.Debug("Calling ListCategories with access token",
+				zap.Time("refreshTokenExpiresAt", sess.RefreshTokenExpiresAt))
+			// Удаляем невалидную сессию
+			if err := h.auth.Logout(ctx, update.Message.From.ID); err != nil {
+				h.logger.Error("Failed to logout user with expired tokens",
 					zap.Int64("telegramID", update.Message.From.ID),
-					zap.String("accessToken", sess.AccessToken[:int(math.Min(float64(len(sess.AccessToken)), 10))]+"..."),
-					zap.String("transactionType", string(parsed.Type)),
-					zap.String("locale", locale))
-				list, err := h.categories.ListCategories(ctx, sess.TenantID, sess.AccessToken, parsed.Type, locale)
-				if err != nil || len(list) == 0 {
-					h.logger.Error("Failed to get categories",
-						zap.Int64("telegramID", update.Message.From.ID),
-						zap.Error(err))
-					_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить категории", "Failed to load categories")))
-					return
-				}
+					zap.Error(err))
+			}
+			// Продолжаем как будто сессии нет
+			sess = nil
+		}
+	}
 
-				llmFallbackHint := ""
-				if h.llmEnabled && h.llm != nil {
-					_, _ = h.bot.Request(tgbotapi.NewChatAction(update.Message.Chat.ID, tgbotapi.ChatTyping))
-					choices := make([]llm.CategoryOption, 0, len(list))
-					for _, c := range list {
-						choices = append(choices, llm.CategoryOption{ID: c.ID, Name: c.Name})
-					}
-					s, llmErr := h.llm.SuggestCategory(ctx, llm.SuggestCategoryRequest{
-						Description:     parsed.Description,
-						TransactionType: string(parsed.Type),
-						Locale:          locale,
-						Categories:      choices,
-					})
-					if llmErr != nil {
-						h.logger.Warn("llm suggestion failed", zap.Error(llmErr))
-						metrics.IncLLMSuggestion("error")
-						llmFallbackHint = llmFailureHint(locale, llmErr, 0)
-					} else if s.Probability >= 0.5 {
-						catID = s.CategoryID
-						source = "llm"
-						llmProbability = s.Probability
-						metrics.IncLLMSuggestion("applied")
-						h.logger.Info("llm category selected", zap.Float64("probability", s.Probability), zap.String("category_id", s.CategoryID))
-					} else {
-						metrics.IncLLMSuggestion("rejected")
-						llmFallbackHint = llmFailureHint(locale, nil, s.Probability)
-					}
-				}
-				if catID == "" {
-					kb := ui.CreateCategoryKeyboard(list)
-					opID := uuid.NewString()
-					if h.opCtxs != nil {
-						_ = h.opCtxs.Create(ctx, &repository.OperationContext{
-							OpID:                opID,
-							TelegramID:          update.Message.From.ID,
-							TenantID:            sess.TenantID,
-							DescriptionOriginal: strings.TrimSpace(parsed.Description),
-							SelectionSource:     "manual",
-							TxType:              string(parsed.Type),
-							AmountMinor:         parsed.Amount.AmountMinor,
-							Currency:            cur,
-							OccurredAt:          parsed.OccurredAt,
-						})
-					}
-					_ = h.states.SetState(ctx, update.Message.From.ID, repository.StateWaitingForCategory, map[string]any{
-						"type":         string(parsed.Type),
-						"amount_minor": parsed.Amount.AmountMinor,
-						"currency":     cur,
-						"desc":         parsed.Description,
-						"occurred_at":  occurredUnix(parsed.OccurredAt),
-						"op_id":        opID,
-					}, nil)
-					text := tr(locale, "Категорию автоматически определить не получилось. Выберите вручную:", "Could not determine category automatically. Choose manually:")
-					if llmFallbackHint != "" {
-						text += "\n\n" + llmFallbackHint
-					}
-					msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
-					msg.ReplyMarkup = kb
-					sent, _ := h.bot.Send(msg)
-					if h.opCtxs != nil && sent.MessageID != 0 {
-						_ = h.opCtxs.SetCategoryListMessageID(ctx, opID, sent.MessageID)
-					}
-					return
-				}
+	if sess != nil {
+		h.logger.Debug("Got valid session for user",
+			zap.Int64("telegramID", update.Message.From.ID),
+			zap.String("accessToken", sess.AccessToken[:int(math.Min(float64(len(sess.AccessToken)), 10))]+"..."),
+			zap.String("refreshToken", sess.RefreshToken[:int(math.Min(float64(len(sess.RefreshToken)), 10))]+"..."),
+			zap.Time("accessTokenExpiresAt", sess.AccessTokenExpiresAt),
+			zap.Time("refreshTokenExpiresAt", sess.RefreshTokenExpiresAt),
+			zap.Time("now", time.Now()),
+			zap.Bool("accessTokenExpired", time.Now().After(sess.AccessTokenExpiresAt)),
+			zap.Bool("refreshTokenExpired", time.Now().After(sess.RefreshTokenExpiresAt)))
+		catID := parsed.CategoryID
+		source := "manual"
+		if catID == "" && h.matcher != nil {
+			if m, err := h.matcher.FindCategory(ctx, sess.TenantID, parsed.Description); err == nil && m != nil {
+				catID = m.CategoryID
+				source = "mapping"
 			}
+		}
 
-			// Have category -> create transaction immediately
-			var categoryDisplayName string
+		// an unusual amount (a typo like "35000 кофе" instead of "350") is held until the user confirms it
+		if h.holdIfUnusual(ctx, sess, update.Message.Chat.ID, &repository.TransactionDraft{
+			TelegramID:  update.Message.From.ID,
+			Type:        string(parsed.Type),
+			AmountMinor: parsed.Amount.AmountMinor,
+			Currency:    cur,
+			Description: parsed.Description,
+			CategoryID:  catID,
+			OccurredAt:  parsed.OccurredAt,
+			BatchID:     parsed.BatchID,
+		}, parsed.Expression) {
+			return true
+		}
+		var batchID *string
+		if parsed.BatchID != "" {
+			batchID = &parsed.BatchID
+		}
+
+		llmProbability := 0.0
+		if catID == "" {
 			pref, _ := h.prefs.GetPreferences(ctx, update.Message.From.ID)
-			locale := "ru"
+			locale := ""
 			if pref != nil && pref.Language != "" {
 				locale = pref.Language
 			}
-			if h.nameMapper != nil {
-				if name, err := h.nameMapper.GetCategoryNameByID(ctx, sess.TenantID, sess.AccessToken, catID, parsed.Type, locale); err == nil && name != "" {
-					categoryDisplayName = name
-				} else {
-					categoryDisplayName = catID
-				}
-			} else {
-				categoryDisplayName = catID
-			}
 
-			txID, err := h.txClient.CreateTransaction(ctx, &grpcclient.CreateTransactionRequest{
-				TenantID:    sess.TenantID,
-				Type:        string(parsed.Type),
-				AmountMinor: parsed.Amount.AmountMinor,
-				Currency:    cur,
-				Description: parsed.Description,
-				CategoryID:  catID,
-				OccurredAt: func() time.Time {
-					if parsed.OccurredAt != nil {
-						return *parsed.OccurredAt
-					}
-					return time.Now()
-				}(),
-			}, sess.AccessToken)
-
-			if err != nil {
-				h.logger.Error("Failed to create transaction",
+			h.logger.Debug("Calling ListCategories with access token",
+				zap.Int64("telegramID", update.Message.From.ID),
+				zap.String("accessToken", sess.AccessToken[:int(math.Min(float64(len(sess.AccessToken)), 10))]+"..."),
+				zap.String("transactionType", string(parsed.Type)),
+				zap.String("locale", locale))
+			list, err := h.categories.ListCategories(ctx, sess.TenantID, sess.AccessToken, parsed.Type, locale)
+			if err != nil || len(list) == 0 {
+				h.logger.Error("Failed to get categories",
 					zap.Int64("telegramID", update.Message.From.ID),
 					zap.Error(err))
-				_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось сохранить транзакцию", "Failed to save transaction")))
-				return
+				_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить категории", "Failed to load categories")))
+				return false
 			}
 
-			opID := uuid.NewString()
-			if h.opCtxs != nil {
-				_ = h.opCtxs.Create(ctx, &repository.OperationContext{
-					OpID:                 opID,
-					TelegramID:           update.Message.From.ID,
-					TenantID:             sess.TenantID,
-					TransactionID:        &txID,
-					DescriptionOriginal:  strings.TrimSpace(parsed.Description),
-					CategoryIDSelected:   &catID,
-					CategoryNameSelected: &categoryDisplayName,
-					SelectionSource:      source,
-					TxType:               string(parsed.Type),
-					AmountMinor:          parsed.Amount.AmountMinor,
-					Currency:             cur,
-					OccurredAt:           parsed.OccurredAt,
-				})
+			llmFallbackHint := ""
+			if h.llmEnabled && h.llm != nil {
+				_, _ = h.bot.Request(tgbotapi.NewChatAction(update.Message.Chat.ID, tgbotapi.ChatTyping))
+				catID, llmProbability, llmFallbackHint = h.suggestCategoryLLM(ctx, parsed.Description, parsed.Type, locale, list)
+				if catID != "" {
+					source = "llm"
+				}
 			}
-			metrics.IncCategorySelected(source)
-			metrics.IncTransactionsSaved("ok")
-
-			locale = h.userLocale(ctx, update.Message.From.ID)
-			label := tr(locale, "Выбрана категория", "Selected category")
-			if source == "mapping" {
-				label = tr(locale, "Применено сохраненное сопоставление", "Applied saved mapping")
-			} else if source == "llm" {
-				label = fmt.Sprintf(tr(locale, "LLM-подбор категории (уверенность %.0f%%)", "LLM category suggestion (confidence %.0f%%)"), llmProbability*100)
+			if catID == "" {
+				kb := ui.CreateCategoryKeyboard(list)
+				opID := uuid.NewString()
+				if h.opCtxs != nil {
+					_ = h.opCtxs.Create(ctx, &repository.OperationContext{
+						OpID:                opID,
+						TelegramID:          update.Message.From.ID,
+						TenantID:            sess.TenantID,
+						DescriptionOriginal: strings.TrimSpace(parsed.Description),
+						SelectionSource:     "manual",
+						TxType:              string(parsed.Type),
+						AmountMinor:         parsed.Amount.AmountMinor,
+						Currency:            cur,
+						OccurredAt:          parsed.OccurredAt,
+						BatchID:             batchID,
+					})
+				}
+				_ = h.states.SetState(ctx, update.Message.From.ID, repository.StateWaitingForCategory, map[string]any{
+					"type":         string(parsed.Type),
+					"amount_minor": parsed.Amount.AmountMinor,
+					"currency":     cur,
+					"desc":         parsed.Description,
+					"occurred_at":  occurredUnix(parsed.OccurredAt),
+					"op_id":        opID,
+				}, nil)
+				text := tr(locale, "Категорию автоматически определить не получилось. Выберите вручную:", "Could not determine category automatically. Choose manually:")
+				if parsed.Expression != "" {
+					text = fmt.Sprintf("🧮 %s = %s %s\n\n%s", parsed.Expression, amt, cur, text)
+				}
+				if llmFallbackHint != "" {
+					text += "\n\n" + llmFallbackHint
+				}
+				msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+				msg.ReplyMarkup = kb
+				sent, _ := h.send(ctx, msg)
+				if h.opCtxs != nil && sent.MessageID != 0 {
+					_ = h.opCtxs.SetCategoryListMessageID(ctx, opID, sent.MessageID)
+				}
+				return true
 			}
-			msg := tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("%s %s %.2f %s — %s\n%s: %s",
-				tr(locale, "✅ Сохранено:", "✅ Saved:"),
-				txTypeLabel(string(parsed.Type), locale), amt, cur, parsed.Description, label, categoryDisplayName))
-			msg.ReplyMarkup = ui.CreatePostSelectionKeyboard(source, opID, locale)
-			sent, _ := h.bot.Send(msg)
-			if h.opCtxs != nil && sent.MessageID != 0 {
-				_ = h.opCtxs.SetConfirmationMessageID(ctx, opID, sent.MessageID)
+		}
+
+		// Have category -> create transaction immediately
+		var categoryDisplayName string
+		pref, _ := h.prefs.GetPreferences(ctx, update.Message.From.ID)
+		locale := "ru"
+		if pref != nil && pref.Language != "" {
+			locale = pref.Language
+		}
+		if h.nameMapper != nil {
+			if name, err := h.nameMapper.GetCategoryNameByID(ctx, sess.TenantID, sess.AccessToken, catID, parsed.Type, locale); err == nil && name != "" {
+				categoryDisplayName = name
+			} else {
+				categoryDisplayName = catID
 			}
-			return
+		} else {
+			categoryDisplayName = catID
 		}
-		// No session; just echo parse
-		locale := h.userLocale(ctx, update.Message.From.ID)
-		msgText := fmt.Sprintf("%s %s %.2f %s — %s", tr(locale, "Распознано:", "Parsed:"), txTypeLabel(string(parsed.Type), locale), amt, cur, parsed.Description)
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
-		_, sendErr := h.bot.Send(msg)
-		if sendErr != nil {
-			h.logger.Error("failed to send parse result", zap.Error(sendErr), zap.String("text", msgText))
+
+		txID, err := h.txClient.CreateTransaction(ctx, &grpcclient.CreateTransactionRequest{
+			TenantID:    sess.TenantID,
+			Type:        string(parsed.Type),
+			AmountMinor: parsed.Amount.AmountMinor,
+			Currency:    cur,
+			Description: parsed.Description,
+			CategoryID:  catID,
+			OccurredAt: func() time.Time {
+				if parsed.OccurredAt != nil {
+					return *parsed.OccurredAt
+				}
+				return time.Now()
+			}(),
+		}, sess.AccessToken)
+
+		if err != nil {
+			h.logger.Error("Failed to create transaction",
+				zap.Int64("telegramID", update.Message.From.ID),
+				zap.Error(err))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось сохранить транзакцию", "Failed to save transaction")))
+			return false
 		}
-		return
-	}
 
-	if parsed != nil && !parsed.IsValid {
-		// Provide simple validation feedback
-		locale := h.userLocale(ctx, update.Message.From.ID)
-		msgText := tr(locale, "Не удалось распознать сообщение. Убедитесь, что указана сумма (например: 100 кофе)", "Could not parse the message. Make sure amount is provided (e.g. 100 coffee)")
-		if len(parsed.Errors) > 0 {
-			// Show first error in a user-friendly way
-			msgText = tr(locale, "Ошибка: ", "Error: ") + parsed.Errors[0]
+		opID := uuid.NewString()
+		if h.opCtxs != nil {
+			_ = h.opCtxs.Create(ctx, &repository.OperationContext{
+				OpID:                 opID,
+				TelegramID:           update.Message.From.ID,
+				TenantID:             sess.TenantID,
+				TransactionID:        &txID,
+				DescriptionOriginal:  strings.TrimSpace(parsed.Description),
+				CategoryIDSelected:   &catID,
+				CategoryNameSelected: &categoryDisplayName,
+				SelectionSource:      source,
+				TxType:               string(parsed.Type),
+				AmountMinor:          parsed.Amount.AmountMinor,
+				Currency:             cur,
+				OccurredAt:           parsed.OccurredAt,
+				BatchID:              batchID,
+			})
 		}
-		msg := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
-		_, sendErr := h.bot.Send(msg)
-		if sendErr != nil {
-			h.logger.Error("failed to send validation error", zap.Error(sendErr), zap.String("text", msgText))
+		metrics.IncCategorySelected(source)
+		metrics.IncTransactionsSaved("ok")
+
+		locale = h.userLocale(ctx, update.Message.From.ID)
+		label := tr(locale, "Выбрана категория", "Selected category")
+		if source == "mapping" {
+			label = tr(locale, "Применено сохраненное сопоставление", "Applied saved mapping")
+		} else if source == "llm" {
+			label = fmt.Sprintf(tr(locale, "LLM-подбор категории (уверенность %.0f%%)", "LLM category suggestion (confidence %.0f%%)"), llmProbability*100)
 		}
-		return
+		text := fmt.Sprintf("%s %s %s %s%s — %s\n%s: %s",
+			tr(locale, "✅ Сохранено:", "✅ Saved:"),
+			txTypeLabel(string(parsed.Type), locale), amt, cur, expressionSuffix(parsed.Expression), parsed.Description, label, categoryDisplayName)
+		preview, alert := h.savedNotes(ctx, sess, sess.TenantID, update.Message.From.ID, catID, string(parsed.Type), parsed.Amount.AmountMinor, cur, parsed.OccurredAt, locale)
+		if preview != "" {
+			text += "\n" + preview
+		}
+		if alert != "" {
+			text += "\n\n" + alert
+		}
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
+		msg.ReplyMarkup = ui.CreatePostSelectionKeyboard(source, opID, locale)
+		sent, _ := h.send(ctx, msg)
+		if h.opCtxs != nil && sent.MessageID != 0 {
+			_ = h.opCtxs.SetConfirmationMessageID(ctx, opID, sent.MessageID)
+		}
+		return true
 	}
+	// No session; just echo parse
+	locale := h.userLocale(ctx, update.Message.From.ID)
+	if groupFromContext(ctx) != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale,
+			"Чтобы записывать операции в общий бюджет, один раз войдите в личном чате с ботом: /login",
+			"To add transactions to the shared budget, log in once in a private chat with the bot: /login")))
+		return false
+	}
+	msgText := fmt.Sprintf("%s %s %s %s%s — %s", tr(locale, "Распознано:", "Parsed:"), txTypeLabel(string(parsed.Type), locale), amt, cur, expressionSuffix(parsed.Expression), parsed.Description)
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
+	_, sendErr := h.send(ctx, msg)
+	if sendErr != nil {
+		h.logger.Error("failed to send parse result", zap.Error(sendErr), zap.String("text", msgText))
+	}
+	return false
+}
+
+// savedNotes returns what is shown under every new transaction: the amount in the base currency and the budget
+// alert of its category. Both are empty when there is nothing to say.
+func (h *Handler) savedNotes(ctx context.Context, sess *repository.UserSession, tenantID string, telegramID int64, categoryID, txType string, amountMinor int64, currency string, occurredAt *time.Time, locale string) (preview, alert string) {
+	preview = h.fxPreview(ctx, sess, telegramID, amountMinor, currency, occurredAt, locale)
+	alert = h.budgetAlert(ctx, tenantID, sess.AccessToken, categoryID, txType, amountMinor, currency, occurredAt, h.userNow(ctx, telegramID), locale)
+	return preview, alert
 }
 
 func occurredUnix(t *time.Time) int64 {
@@ -419,7 +567,37 @@ func occurredUnix(t *time.Time) int64 {
 	return t.Unix()
 }
 
+// suggestCategoryLLM asks the LLM for a category and returns it only when the model is confident enough.
+// Otherwise the returned hint explains why the user has to choose manually.
+func (h *Handler) suggestCategoryLLM(ctx context.Context, description string, txType domain.TransactionType, locale string, list []*domain.Category) (string, float64, string) {
+	choices := make([]llm.CategoryOption, 0, len(list))
+	for _, c := range list {
+		choices = append(choices, llm.CategoryOption{ID: c.ID, Name: c.Name})
+	}
+	s, err := h.llm.SuggestCategory(ctx, llm.SuggestCategoryRequest{
+		Description:     description,
+		TransactionType: string(txType),
+		Locale:          locale,
+		Categories:      choices,
+	})
+	if err != nil {
+		h.logger.Warn("llm suggestion failed", zap.Error(err))
+		metrics.IncLLMSuggestion("error")
+		return "", 0, llmFailureHint(locale, err, 0)
+	}
+	if s.Probability < 0.5 {
+		metrics.IncLLMSuggestion("rejected")
+		return "", 0, llmFailureHint(locale, nil, s.Probability)
+	}
+	metrics.IncLLMSuggestion("applied")
+	h.logger.Info("llm category selected", zap.Float64("probability", s.Probability), zap.String("category_id", s.CategoryID))
+	return s.CategoryID, s.Probability, ""
+}
+
 func (h *Handler) userLocale(ctx context.Context, telegramID int64) string {
+	if g := groupFromContext(ctx); g != nil && g.binding != nil && g.binding.Language != "" {
+		return g.binding.Language
+	}
 	if h.prefs == nil {
 		return "ru"
 	}
@@ -430,6 +608,14 @@ func (h *Handler) userLocale(ctx context.Context, telegramID int64) string {
 	return "ru"
 }
 
+// expressionSuffix renders the arithmetic expression an amount was computed from, e.g. " (2x350)".
+func expressionSuffix(expr string) string {
+	if expr == "" {
+		return ""
+	}
+	return " (" + expr + ")"
+}
+
 func txTypeLabel(txType, locale string) string {
 	if locale == "en" {
 		if txType == "income" {
@@ -486,6 +672,12 @@ func (h *Handler) handleCallback(ctx context.Context, update tgbotapi.Update) {
 		return
 	}
 	data := cb.Data
+	if cb.Message != nil && isGroupChat(cb.Message.Chat) {
+		var ok bool
+		if ctx, ok = h.enterGroupCallback(ctx, cb); !ok {
+			return
+		}
+	}
 
 	if strings.HasPrefix(data, "v1:remember:") {
 		opID := strings.TrimPrefix(data, "v1:remember:")
@@ -502,6 +694,71 @@ func (h *Handler) handleCallback(ctx context.Context, update tgbotapi.Update) {
 		h.handleChangeCallback(ctx, cb, opID)
 		return
 	}
+	if strings.HasPrefix(data, "v1:edit_amount:") {
+		h.handleEditRequestCallback(ctx, cb, strings.TrimPrefix(data, "v1:edit_amount:"), repository.StateWaitingForEditAmount)
+		return
+	}
+	if strings.HasPrefix(data, "v1:edit_date:") {
+		h.handleEditRequestCallback(ctx, cb, strings.TrimPrefix(data, "v1:edit_date:"), repository.StateWaitingForEditDate)
+		return
+	}
+	if strings.HasPrefix(data, "v1:edit_comment:") {
+		h.handleEditRequestCallback(ctx, cb, strings.TrimPrefix(data, "v1:edit_comment:"), repository.StateWaitingForEditComment)
+		return
+	}
+	if strings.HasPrefix(data, "v1:delete:") {
+		h.handleDeleteCallback(ctx, cb, strings.TrimPrefix(data, "v1:delete:"))
+		return
+	}
+	if strings.HasPrefix(data, "v1:delete_yes:") {
+		h.handleDeleteConfirmCallback(ctx, cb, strings.TrimPrefix(data, "v1:delete_yes:"))
+		return
+	}
+	if strings.HasPrefix(data, "v1:delete_no:") {
+		h.handleDeleteCancelCallback(ctx, cb, strings.TrimPrefix(data, "v1:delete_no:"))
+		return
+	}
+	if data == "v1:member_yes" || data == "v1:member_no" {
+		h.handleMemberConfirmCallback(ctx, cb, data == "v1:member_yes")
+		return
+	}
+	if strings.HasPrefix(data, "v1:batch_undo:") {
+		h.handleBatchUndoCallback(ctx, cb, strings.TrimPrefix(data, "v1:batch_undo:"))
+		return
+	}
+	if strings.HasPrefix(data, "v1:rec_cat:") {
+		h.handleRecurringCategoryCallback(ctx, cb, strings.TrimPrefix(data, "v1:rec_cat:"))
+		return
+	}
+	if strings.HasPrefix(data, "v1:rec_ok:") {
+		h.handleRecurringDecisionCallback(ctx, cb, strings.TrimPrefix(data, "v1:rec_ok:"), true)
+		return
+	}
+	if strings.HasPrefix(data, "v1:rec_skip:") {
+		h.handleRecurringDecisionCallback(ctx, cb, strings.TrimPrefix(data, "v1:rec_skip:"), false)
+		return
+	}
+	if strings.HasPrefix(data, "v1:imp_") {
+		h.handleImportCallback(ctx, cb, strings.TrimPrefix(data, "v1:imp_"))
+		return
+	}
+	if strings.HasPrefix(data, "v1:recent:") {
+		h.handleRecentPageCallback(ctx, cb, strings.TrimPrefix(data, "v1:recent:"))
+		return
+	}
+	if strings.HasPrefix(data, "v1:recent_tx:") {
+		h.handleRecentOpenCallback(ctx, cb, strings.TrimPrefix(data, "v1:recent_tx:"))
+		return
+	}
+	if strings.HasPrefix(data, "v1:find:") {
+		h.handleFindPageCallback(ctx, cb, strings.TrimPrefix(data, "v1:find:"))
+		return
+	}
+	if strings.HasPrefix(data, "v1:draft_") {
+		action, draftID, _ := strings.Cut(strings.TrimPrefix(data, "v1:draft_"), ":")
+		h.handleDraftCallback(ctx, cb, action, draftID)
+		return
+	}
 	if strings.HasPrefix(data, "v1:cat_select:") {
 		h.handleCategorySelectV1(ctx, cb, strings.TrimPrefix(data, "v1:cat_select:"))
 		return
@@ -569,7 +826,7 @@ func (h *Handler) handleCallback(ctx context.Context, update tgbotapi.Update) {
 				zap.Int64("telegramID", cb.From.ID),
 				zap.Error(err))
 			_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Ошибка", "Error")))
-			_, _ = h.bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, tr(locale, "Не удалось сохранить транзакцию", "Failed to save transaction")))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(cb.Message.Chat.ID, tr(locale, "Не удалось сохранить транзакцию", "Failed to save transaction")))
 			_ = h.states.ClearState(ctx, cb.From.ID)
 			return
 		}
@@ -584,10 +841,10 @@ func (h *Handler) handleCallback(ctx context.Context, update tgbotapi.Update) {
 		// Clear state and send success message
 		_ = h.states.ClearState(ctx, cb.From.ID)
 		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Сохранено", "Saved")))
-		_, _ = h.bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, fmt.Sprintf("%s %s %.2f %s — %s (%s: %s)",
+		_, _ = h.send(ctx, tgbotapi.NewMessage(cb.Message.Chat.ID, fmt.Sprintf("%s %s %s %s — %s (%s: %s)",
 			tr(locale, "✅ Сохранено:", "✅ Saved:"),
 			txTypeLabel(typeStr, locale),
-			float64(amountMinor)/100.0,
+			domain.FormatAmount(amountMinor, currency),
 			currency,
 			desc,
 			tr(locale, "категория", "category"),
@@ -599,30 +856,30 @@ func (h *Handler) handleCallback(ctx context.Context, update tgbotapi.Update) {
 	if strings.HasPrefix(data, "lang:") {
 		lang := strings.TrimPrefix(data, "lang:")
 		if h.prefs != nil {
-			// preserve currency
-			var cur string
+			// preserve currency and timezone
+			var cur, tz string
 			if pref, err := h.prefs.GetPreferences(ctx, cb.From.ID); err == nil && pref != nil {
-				cur = pref.DefaultCurrency
+				cur, tz = pref.DefaultCurrency, pref.Timezone
 			}
-			_ = h.prefs.SavePreferences(ctx, &repository.UserPreferences{TelegramID: cb.From.ID, Language: lang, DefaultCurrency: cur})
+			_ = h.prefs.SavePreferences(ctx, &repository.UserPreferences{TelegramID: cb.From.ID, Language: lang, DefaultCurrency: cur, Timezone: tz})
 		}
 		locale := h.userLocale(ctx, cb.From.ID)
 		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Язык: ", "Language: ")+lang))
-		_, _ = h.bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, tr(locale, "Язык обновлён", "Language updated")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(cb.Message.Chat.ID, tr(locale, "Язык обновлён", "Language updated")))
 		return
 	}
 	if strings.HasPrefix(data, "cur:") {
 		cur := strings.TrimPrefix(data, "cur:")
 		if h.prefs != nil {
-			var lang string
+			var lang, tz string
 			if pref, err := h.prefs.GetPreferences(ctx, cb.From.ID); err == nil && pref != nil {
-				lang = pref.Language
+				lang, tz = pref.Language, pref.Timezone
 			}
-			_ = h.prefs.SavePreferences(ctx, &repository.UserPreferences{TelegramID: cb.From.ID, Language: lang, DefaultCurrency: cur})
+			_ = h.prefs.SavePreferences(ctx, &repository.UserPreferences{TelegramID: cb.From.ID, Language: lang, DefaultCurrency: cur, Timezone: tz})
 		}
 		locale := h.userLocale(ctx, cb.From.ID)
 		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Валюта: ", "Currency: ")+cur))
-		_, _ = h.bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, tr(locale, "Валюта по умолчанию обновлена", "Default currency updated")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(cb.Message.Chat.ID, tr(locale, "Валюта по умолчанию обновлена", "Default currency updated")))
 		return
 	}
 	if strings.HasPrefix(data, "tenant:") {
@@ -630,10 +887,10 @@ func (h *Handler) handleCallback(ctx context.Context, update tgbotapi.Update) {
 		tenantID := strings.TrimPrefix(data, "tenant:")
 		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Организация выбрана", "Tenant selected")))
 		if err := h.auth.sessionRepo.UpdateTenantID(ctx, cb.From.ID, tenantID); err == nil {
-			_, _ = h.bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, tr(locale, "Организация переключена", "Tenant switched")))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(cb.Message.Chat.ID, tr(locale, "Организация переключена", "Tenant switched")))
 			return
 		}
-		_, _ = h.bot.Send(tgbotapi.NewMessage(cb.Message.Chat.ID, tr(locale, "Не удалось переключить организацию", "Failed to switch tenant")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(cb.Message.Chat.ID, tr(locale, "Не удалось переключить организацию", "Failed to switch tenant")))
 		return
 	}
 	if strings.HasPrefix(data, "help:") {
@@ -681,14 +938,40 @@ func (h *Handler) handleCommand(ctx context.Context, update tgbotapi.Update) {
 		h.handleLanguage(ctx, update)
 	case "currency":
 		h.handleCurrency(ctx, update)
+	case "rate":
+		h.handleRate(ctx, update)
+	case "rates":
+		h.handleRates(ctx, update)
 	case "stats":
 		h.handleStats(ctx, update)
 	case "top_categories":
 		h.handleTopCategories(ctx, update)
+	case "trend":
+		h.handleTrend(ctx, update)
+	case "compare":
+		h.handleCompare(ctx, update)
+	case "forecast":
+		h.handleForecast(ctx, update)
 	case "recent":
 		h.handleRecent(ctx, update)
+	case "find":
+		h.handleFind(ctx, update)
 	case "export":
 		h.handleExport(ctx, update)
+	case "import":
+		h.handleImport(ctx, update)
+	case "budget":
+		h.handleBudget(ctx, update)
+	case "budgets":
+		h.handleBudgets(ctx, update)
+	case "recurring":
+		h.handleRecurring(ctx, update)
+	case "digest":
+		h.handleDigest(ctx, update)
+	case "timezone":
+		h.handleTimezone(ctx, update)
+	case "limit":
+		h.handleLimit(ctx, update)
 	case "create_category":
 		h.handleCreateCategory(ctx, update)
 	case "rename_category":
@@ -697,6 +980,24 @@ func (h *Handler) handleCommand(ctx context.Context, update tgbotapi.Update) {
 		h.handleDeleteCategory(ctx, update)
 	case "switch_tenant":
 		h.handleSwitchTenant(ctx, update)
+	case "members":
+		h.handleMembers(ctx, update)
+	case "invite":
+		h.handleInvite(ctx, update)
+	case "role":
+		h.handleRole(ctx, update)
+	case "kick":
+		h.handleKick(ctx, update)
+	case "new_tenant":
+		h.handleNewTenant(ctx, update)
+	case "tenant_settings":
+		h.handleTenantSettings(ctx, update)
+	case "bind_tenant":
+		h.handleBindTenant(ctx, update)
+	case "unbind_tenant":
+		h.handleUnbindTenant(ctx, update)
+	case "chat_settings":
+		h.handleChatSettings(ctx, update)
 	case "profile":
 		h.handleProfile(ctx, update)
 	case "help":
@@ -706,7 +1007,7 @@ func (h *Handler) handleCommand(ctx context.Context, update tgbotapi.Update) {
 	default:
 		locale := h.userLocale(ctx, update.Message.From.ID)
 		msg := tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Неизвестная команда. Используйте /help для получения справки.", "Unknown command. Use /help for details."))
-		_, err := h.bot.Send(msg)
+		_, err := h.send(ctx, msg)
 		if err != nil {
 			h.logger.Error("failed to send unknown command message", zap.Error(err))
 		}
@@ -748,7 +1049,7 @@ func (h *Handler) handleRememberCallback(ctx context.Context, cb *tgbotapi.Callb
 		}
 		confirm := tgbotapi.NewMessage(cb.Message.Chat.ID, fmt.Sprintf(tr(locale, "Запомнил сопоставление: \"%s\" -> \"%s\".", "Saved mapping: \"%s\" -> \"%s\"."), strings.TrimSpace(op.DescriptionOriginal), categoryName))
 		confirm.ReplyMarkup = ui.CreatePostSelectionKeyboard("mapping", opID, locale)
-		_, _ = h.bot.Send(confirm)
+		_, _ = h.send(ctx, confirm)
 	}
 }
 
@@ -780,7 +1081,7 @@ func (h *Handler) handleForgetCallback(ctx context.Context, cb *tgbotapi.Callbac
 		}
 		confirm := tgbotapi.NewMessage(cb.Message.Chat.ID, fmt.Sprintf(tr(locale, "Удалил сопоставление: \"%s\" -> \"%s\".", "Removed mapping: \"%s\" -> \"%s\"."), strings.TrimSpace(op.DescriptionOriginal), categoryName))
 		confirm.ReplyMarkup = ui.CreatePostSelectionKeyboard("manual", opID, locale)
-		_, _ = h.bot.Send(confirm)
+		_, _ = h.send(ctx, confirm)
 	}
 }
 
@@ -811,7 +1112,7 @@ func (h *Handler) handleChangeCallback(ctx context.Context, cb *tgbotapi.Callbac
 	}
 	msg := tgbotapi.NewMessage(cb.Message.Chat.ID, tr(locale, "Выберите новую категорию:", "Choose a new category:"))
 	msg.ReplyMarkup = ui.CreateChangeCategoryKeyboard(list, opID)
-	sent, _ := h.bot.Send(msg)
+	sent, _ := h.send(ctx, msg)
 	if sent.MessageID != 0 {
 		_ = h.opCtxs.SetCategoryListMessageID(ctx, opID, sent.MessageID)
 	}
@@ -892,31 +1193,50 @@ func (h *Handler) handleCategorySelectV1(ctx context.Context, cb *tgbotapi.Callb
 		}
 	}
 
-	_ = h.opCtxs.UpdateSelection(ctx, opID, categoryID, categoryName, "manual")
+	source := "manual"
+	if op.SelectionSource == recentSource {
+		source = recentSource
+	}
+	_ = h.opCtxs.UpdateSelection(ctx, opID, categoryID, categoryName, source)
 	if op.CategoryListMessageID != nil && cb.Message != nil {
 		del := tgbotapi.NewDeleteMessage(cb.Message.Chat.ID, *op.CategoryListMessageID)
 		if _, err := h.bot.Request(del); err != nil {
 			h.logger.Warn("failed to delete category list message", zap.Error(err))
 		}
 	}
+	if source == recentSource && cb.Message != nil {
+		// the opened /recent card is updated in place instead of posting a new confirmation
+		op.CategoryIDSelected, op.CategoryNameSelected = &categoryID, &categoryName
+		h.refreshConfirmation(ctx, cb.Message.Chat.ID, op, locale, tr(locale, "🏷 Категория обновлена", "🏷 Category updated"))
+		_ = h.states.ClearState(ctx, cb.From.ID)
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Готово", "Done")))
+		return
+	}
 
 	var txt string
 	if op.TransactionID == nil || *op.TransactionID == "" {
 		txt = fmt.Sprintf(
-			"%s %s %.2f %s — %s\n%s: %s",
+			"%s %s %s %s — %s\n%s: %s",
 			tr(locale, "✅ Сохранено:", "✅ Saved:"),
 			txTypeLabel(op.TxType, locale),
-			float64(op.AmountMinor)/100.0,
+			domain.FormatAmount(op.AmountMinor, op.Currency),
 			op.Currency,
 			op.DescriptionOriginal,
 			tr(locale, "Выбрана категория", "Selected category"),
 			categoryName,
 		)
+		preview, alert := h.savedNotes(ctx, sess, op.TenantID, cb.From.ID, categoryID, op.TxType, op.AmountMinor, op.Currency, op.OccurredAt, locale)
+		if preview != "" {
+			txt += "\n" + preview
+		}
+		if alert != "" {
+			txt += "\n\n" + alert
+		}
 	} else {
 		txt = fmt.Sprintf(
-			"%s %.2f %s — %s\n%s: %s",
+			"%s %s %s — %s\n%s: %s",
 			txTypeLabel(op.TxType, locale),
-			float64(op.AmountMinor)/100.0,
+			domain.FormatAmount(op.AmountMinor, op.Currency),
 			op.Currency,
 			op.DescriptionOriginal,
 			tr(locale, "Категория обновлена", "Category updated"),
@@ -925,11 +1245,14 @@ func (h *Handler) handleCategorySelectV1(ctx context.Context, cb *tgbotapi.Callb
 	}
 	msg := tgbotapi.NewMessage(cb.Message.Chat.ID, txt)
 	msg.ReplyMarkup = ui.CreatePostSelectionKeyboard("manual", opID, locale)
-	sent, _ := h.bot.Send(msg)
+	sent, _ := h.send(ctx, msg)
 	if sent.MessageID != 0 {
 		_ = h.opCtxs.SetConfirmationMessageID(ctx, opID, sent.MessageID)
 	}
-	_ = h.states.ClearState(ctx, cb.From.ID)
+	rec, _ := h.states.GetState(ctx, cb.From.ID)
+	if pending := pendingFromState(rec); len(pending) == 0 || !h.promptPendingCategory(ctx, cb.From.ID, cb.Message.Chat.ID, locale, pending) {
+		_ = h.states.ClearState(ctx, cb.From.ID)
+	}
 	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Готово", "Done")))
 }
 
@@ -937,27 +1260,32 @@ func (h *Handler) handleSwitchTenant(ctx context.Context, update tgbotapi.Update
 	locale := h.userLocale(ctx, update.Message.From.ID)
 	sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
 	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
 		return
 	}
-	list, err := h.tenants.ListTenants(ctx, sess.AccessToken)
+	list, err := h.listTenants(ctx, sess)
 	if err != nil || len(list) == 0 {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить организации", "Failed to load tenants")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить организации", "Failed to load tenants")))
 		return
 	}
 	kb := ui.CreateTenantKeyboard(list)
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Выберите организацию", "Choose a tenant"))
 	msg.ReplyMarkup = kb
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
 
 func (h *Handler) handleCancel(ctx context.Context, update tgbotapi.Update) {
 	locale := h.userLocale(ctx, update.Message.From.ID)
 	_ = h.states.ClearState(ctx, update.Message.From.ID)
-	_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Текущая операция отменена", "Current operation canceled")))
+	_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Текущая операция отменена", "Current operation canceled")))
 }
 
 func (h *Handler) handleStart(ctx context.Context, update tgbotapi.Update) {
+	// "/start login" comes from the inline mode button for users without a session
+	if update.Message.CommandArguments() == "login" {
+		h.startLogin(ctx, update)
+		return
+	}
 	// Greet and show basic commands
 	locale := h.userLocale(ctx, update.Message.From.ID)
 	text := "Привет! Я бот учёта бюджета.\n\n" +
@@ -981,7 +1309,7 @@ func (h *Handler) handleStart(ctx context.Context, update tgbotapi.Update) {
 	menu := ui.CreateMainMenuKeyboard()
 	msg.ReplyMarkup = menu
 
-	_, err := h.bot.Send(msg)
+	_, err := h.send(ctx, msg)
 	if err != nil {
 		h.logger.Error("failed to send start message", zap.Error(err))
 	}
@@ -991,7 +1319,7 @@ func (h *Handler) startLogin(ctx context.Context, update tgbotapi.Update) {
 	_ = h.states.SetState(ctx, update.Message.From.ID, repository.StateWaitingForOAuthEmail, nil, nil)
 	locale := h.userLocale(ctx, update.Message.From.ID)
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Введите email для авторизации через OAuth:", "Enter email for OAuth login:"))
-	_, err := h.bot.Send(msg)
+	_, err := h.send(ctx, msg)
 	if err != nil {
 		h.logger.Error("failed to send OAuth login email prompt", zap.Error(err))
 	}
@@ -1004,7 +1332,7 @@ func (h *Handler) handleOAuthEmail(ctx context.Context, update tgbotapi.Update)
 	// Простая валидация email на стороне клиента
 	if !isValidEmail(email) {
 		msg := tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Неверный формат email. Пожалуйста, введите корректный email адрес.\n\nПример: user@example.com", "Invalid email format. Please enter a valid email.\n\nExample: user@example.com"))
-		_, _ = h.bot.Send(msg)
+		_, _ = h.send(ctx, msg)
 		return
 	}
 
@@ -1021,7 +1349,7 @@ func (h *Handler) handleOAuthEmail(ctx context.Context, update tgbotapi.Update)
 			errorMsg += tr(locale, "\n\nПопробуйте снова /login", "\n\nTry again with /login")
 		}
 		msg := tgbotapi.NewMessage(update.Message.Chat.ID, errorMsg)
-		_, _ = h.bot.Send(msg)
+		_, _ = h.send(ctx, msg)
 		return
 	}
 
@@ -1036,7 +1364,7 @@ func (h *Handler) handleOAuthEmail(ctx context.Context, update tgbotapi.Update)
 	// Send auth link to user
 	authMessage := fmt.Sprintf(tr(locale, "Для авторизации перейдите по ссылке:\n%s\n\nПосле авторизации введите код подтверждения, который появится на странице.", "Open this link to authorize:\n%s\n\nAfter that enter the verification code from the page."), authURL)
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, authMessage)
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
 
 func (h *Handler) handleOAuthCode(ctx context.Context, update tgbotapi.Update) {
@@ -1044,7 +1372,7 @@ func (h *Handler) handleOAuthCode(ctx context.Context, update tgbotapi.Update) {
 	rec, _ := h.states.GetState(ctx, update.Message.From.ID)
 	if rec == nil || rec.Context == nil {
 		msg := tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Начните с /login", "Start with /login"))
-		_, _ = h.bot.Send(msg)
+		_, _ = h.send(ctx, msg)
 		return
 	}
 
@@ -1064,20 +1392,20 @@ func (h *Handler) handleOAuthCode(ctx context.Context, update tgbotapi.Update) {
 			errorMsg += tr(locale, "\n\nПопробуйте снова /login", "\n\nTry again with /login")
 		}
 		msg := tgbotapi.NewMessage(update.Message.Chat.ID, errorMsg)
-		_, _ = h.bot.Send(msg)
+		_, _ = h.send(ctx, msg)
 		return
 	}
 
 	_ = h.states.ClearState(ctx, update.Message.From.ID)
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Вы успешно авторизованы через OAuth!", "OAuth login successful!"))
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
 
 func (h *Handler) handleLogout(ctx context.Context, update tgbotapi.Update) {
 	locale := h.userLocale(ctx, update.Message.From.ID)
 	_ = h.auth.Logout(ctx, update.Message.From.ID)
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Вы вышли из системы", "You are logged out"))
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
 
 // getSessionWithErrorHandling получает сессию пользователя с понятной обработкой ошибок
@@ -1091,7 +1419,7 @@ func (h *Handler) getSessionWithErrorHandling(ctx context.Context, chatID int64,
 		}
 		errorMsg += tr(locale, "\n\nВыполните вход: /login", "\n\nPlease login: /login")
 		msg := tgbotapi.NewMessage(chatID, errorMsg)
-		_, _ = h.bot.Send(msg)
+		_, _ = h.send(ctx, msg)
 		return nil, false
 	}
 	return session, true
@@ -1101,7 +1429,7 @@ func (h *Handler) getSessionWithErrorHandling(ctx context.Context, chatID int64,
 func (h *Handler) startRegister(ctx context.Context, update tgbotapi.Update) {
 	locale := h.userLocale(ctx, update.Message.From.ID)
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Регистрация через бота не поддерживается. Пожалуйста, зарегистрируйтесь через веб-интерфейс.", "Registration in bot is not supported. Please register in the web interface."))
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
 
 func (h *Handler) handleMap(ctx context.Context, update tgbotapi.Update) {
@@ -1111,16 +1439,16 @@ func (h *Handler) handleMap(ctx context.Context, update tgbotapi.Update) {
 	if args == "--all" {
 		sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
 		if err != nil {
-			_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
 			return
 		}
 		items, err := h.mappings.ListMappings(ctx, sess.TenantID)
 		if err != nil {
-			_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить сопоставления", "Failed to load mappings")))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить сопоставления", "Failed to load mappings")))
 			return
 		}
 		if len(items) == 0 {
-			_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сопоставлений нет", "No mappings")))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сопоставлений нет", "No mappings")))
 			return
 		}
 
@@ -1135,24 +1463,24 @@ func (h *Handler) handleMap(ctx context.Context, update tgbotapi.Update) {
 				b.WriteString(fmt.Sprintf("%s = %s\n", m.Keyword, categoryName))
 			}
 		}
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, b.String()))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, b.String()))
 		return
 	}
 	if len(parts) == 1 {
 		// show mapping for keyword
 		keyword := strings.TrimSpace(parts[0])
 		if keyword == "" {
-			_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /map слово = название_категории", "Format: /map keyword = category_name")))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /map слово = название_категории", "Format: /map keyword = category_name")))
 			return
 		}
 		sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
 		if err != nil {
-			_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
 			return
 		}
 		m, err := h.mappings.FindMapping(ctx, sess.TenantID, keyword)
 		if err != nil || m == nil {
-			_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сопоставление не найдено", "Mapping not found")))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сопоставление не найдено", "Mapping not found")))
 			return
 		}
 
@@ -1160,9 +1488,9 @@ func (h *Handler) handleMap(ctx context.Context, update tgbotapi.Update) {
 		categoryName, err := h.nameMapper.GetCategoryNameByID(ctx, sess.TenantID, sess.AccessToken, m.CategoryID, domain.TransactionExpense, locale)
 		if err != nil || categoryName == "" {
 			// Fallback to ID if name not found
-			_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("%s = %s", m.Keyword, m.CategoryID)))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("%s = %s", m.Keyword, m.CategoryID)))
 		} else {
-			_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("%s = %s", m.Keyword, categoryName)))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf("%s = %s", m.Keyword, categoryName)))
 		}
 		return
 	}
@@ -1170,69 +1498,69 @@ func (h *Handler) handleMap(ctx context.Context, update tgbotapi.Update) {
 		keyword := strings.TrimSpace(parts[0])
 		categoryName := strings.TrimSpace(parts[1])
 		if keyword == "" || categoryName == "" {
-			_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /map слово = название_категории", "Format: /map keyword = category_name")))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /map слово = название_категории", "Format: /map keyword = category_name")))
 			return
 		}
 		sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
 		if err != nil {
-			_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
 			return
 		}
 
 		// Map category name to ID
 		categoryID, err := h.nameMapper.GetCategoryIDByName(ctx, sess.TenantID, sess.AccessToken, categoryName, domain.TransactionExpense, locale)
 		if err != nil || categoryID == "" {
-			_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Категория не найдена", "Category not found")))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Категория не найдена", "Category not found")))
 			return
 		}
 
 		id := uuid.NewString()
 		if err := h.mappings.AddMapping(ctx, &repository.CategoryMapping{ID: id, TenantID: sess.TenantID, Keyword: keyword, CategoryID: categoryID, Priority: 0}); err != nil {
-			_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось сохранить сопоставление", "Failed to save mapping")))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось сохранить сопоставление", "Failed to save mapping")))
 			return
 		}
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сопоставление сохранено", "Mapping saved")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сопоставление сохранено", "Mapping saved")))
 		return
 	}
-	_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /map слово = название_категории", "Format: /map keyword = category_name")))
+	_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /map слово = название_категории", "Format: /map keyword = category_name")))
 }
 
 func (h *Handler) handleUnmap(ctx context.Context, update tgbotapi.Update) {
 	locale := h.userLocale(ctx, update.Message.From.ID)
 	keyword := strings.TrimSpace(update.Message.CommandArguments())
 	if keyword == "" {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /unmap слово", "Format: /unmap keyword")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /unmap слово", "Format: /unmap keyword")))
 		return
 	}
 	sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
 	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
 		return
 	}
 	if err := h.mappings.RemoveMapping(ctx, sess.TenantID, keyword); err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось удалить сопоставление", "Failed to remove mapping")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось удалить сопоставление", "Failed to remove mapping")))
 		return
 	}
-	_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сопоставление удалено", "Mapping removed")))
+	_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сопоставление удалено", "Mapping removed")))
 }
 
 func (h *Handler) handleCategories(ctx context.Context, update tgbotapi.Update) {
 	locale := h.userLocale(ctx, update.Message.From.ID)
 	sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
 	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
 		return
 	}
 	// Default to expense categories for /categories command
 	list, err := h.categories.ListCategories(ctx, sess.TenantID, sess.AccessToken, domain.TransactionExpense, locale)
 	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить категории", "Failed to load categories")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить категории", "Failed to load categories")))
 		return
 	}
 	kb := ui.CreateCategoryKeyboard(list)
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Выберите категорию", "Choose category"))
 	msg.ReplyMarkup = kb
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
 
 func (h *Handler) handleLanguage(ctx context.Context, update tgbotapi.Update) {
@@ -1240,15 +1568,32 @@ func (h *Handler) handleLanguage(ctx context.Context, update tgbotapi.Update) {
 	kb := ui.CreateLanguageKeyboard()
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Выберите язык интерфейса", "Choose interface language"))
 	msg.ReplyMarkup = kb
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
 
 func (h *Handler) handleCurrency(ctx context.Context, update tgbotapi.Update) {
 	locale := h.userLocale(ctx, update.Message.From.ID)
+	// "/currency GEL" sets any ISO 4217 currency that is not on the keyboard
+	if arg := strings.TrimSpace(update.Message.CommandArguments()); arg != "" {
+		c, ok := domain.LookupCurrency(arg)
+		if !ok {
+			_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf(tr(locale, "Неизвестная валюта %q. Укажите код ISO 4217, например GEL", "Unknown currency %q. Use an ISO 4217 code such as GEL"), arg)))
+			return
+		}
+		if h.prefs != nil {
+			var lang, tz string
+			if pref, err := h.prefs.GetPreferences(ctx, update.Message.From.ID); err == nil && pref != nil {
+				lang, tz = pref.Language, pref.Timezone
+			}
+			_ = h.prefs.SavePreferences(ctx, &repository.UserPreferences{TelegramID: update.Message.From.ID, Language: lang, DefaultCurrency: c.Code, Timezone: tz})
+		}
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Валюта по умолчанию: ", "Default currency: ")+c.Code))
+		return
+	}
 	kb := ui.CreateCurrencyKeyboard()
-	msg := tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Выберите валюту по умолчанию", "Choose default currency"))
+	msg := tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Выберите валюту по умолчанию или отправьте /currency <код>, например /currency GEL", "Choose default currency or send /currency <code>, e.g. /currency GEL"))
 	msg.ReplyMarkup = kb
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
 
 func (h *Handler) handleStats(ctx context.Context, update tgbotapi.Update) {
@@ -1262,7 +1607,7 @@ func (h *Handler) handleStats(ctx context.Context, update tgbotapi.Update) {
 		h.logger.Warn("handleStats: no session found",
 			zap.Int64("userID", update.Message.From.ID),
 			zap.Error(err))
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
 		return
 	}
 
@@ -1270,41 +1615,42 @@ func (h *Handler) handleStats(ctx context.Context, update tgbotapi.Update) {
 		zap.String("tenantID", sess.TenantID),
 		zap.String("accessToken", sess.AccessToken[:10]+"..."))
 
-	// Current month (overridden by optional arg)
-	now := time.Now()
-	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	to := from.AddDate(0, 1, -1)
-	if arg := strings.TrimSpace(update.Message.CommandArguments()); arg != "" {
-		if arg == "week" {
-			wd := int(now.Weekday())
-			if wd == 0 {
-				wd = 7
-			}
-			from = time.Date(now.Year(), now.Month(), now.Day()-(wd-1), 0, 0, 0, 0, now.Location())
-			to = from.AddDate(0, 0, 6)
-		} else if len(arg) == 7 {
-			var y, m int
-			if _, e := fmt.Sscanf(arg, "%d-%d", &y, &m); e == nil && m >= 1 && m <= 12 {
-				from = time.Date(y, time.Month(m), 1, 0, 0, 0, 0, now.Location())
-				to = from.AddDate(0, 1, -1)
+	// Optional args in any order: a period (current month by default), a currency to express totals in and "chart"
+	now := h.userNow(ctx, update.Message.From.ID)
+	args, withChart := chartRequested(strings.Fields(update.Message.CommandArguments()))
+	period, rest, err := h.parseReportPeriod(args, now, locale)
+	target := ""
+	for _, arg := range rest {
+		c, ok := domain.LookupCurrency(arg)
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf(tr(locale, "непонятный аргумент %q", "unknown argument %q"), arg)
 			}
+			continue
 		}
+		target = c.Code
 	}
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf(tr(locale, "Не удалось разобрать период: %v\n%s", "Failed to parse the period: %v\n%s"), err, statsUsage(locale, "/stats"))))
+		return
+	}
+	from, to := period.From, period.To
 
-	h.logger.Debug("handleStats: calling GetStats",
+	h.logger.Debug("handleStats: loading stats",
 		zap.Time("from", from),
 		zap.Time("to", to))
 
-	st, err := h.report.GetStats(ctx, sess.TenantID, from, to, sess.AccessToken)
+	st, err := h.periodStats(ctx, sess, from, to)
 	if err != nil {
 		h.logger.Error("handleStats: GetStats failed",
 			zap.String("tenantID", sess.TenantID),
 			zap.Time("from", from),
 			zap.Time("to", to),
 			zap.Error(err))
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить статистику", "Failed to load statistics")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить статистику", "Failed to load statistics")))
 		return
 	}
+	st.Period = period.Label
 
 	h.logger.Debug("handleStats: GetStats successful",
 		zap.String("period", st.Period),
@@ -1312,169 +1658,71 @@ func (h *Handler) handleStats(ctx context.Context, update tgbotapi.Update) {
 		zap.Int64("totalExpense", st.TotalExpense),
 		zap.String("currency", st.Currency))
 
-	text := h.fmt.FormatStats(st)
-	_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, text))
-}
-
-func (h *Handler) handleTopCategories(ctx context.Context, update tgbotapi.Update) {
-	locale := h.userLocale(ctx, update.Message.From.ID)
-	sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
-	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
-		return
-	}
-	now := time.Now()
-	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	to := from.AddDate(0, 1, -1)
-	limit := 5
-	if arg := strings.TrimSpace(update.Message.CommandArguments()); arg != "" {
-		parts := strings.Fields(arg)
-		for _, p := range parts {
-			if p == "week" {
-				wd := int(now.Weekday())
-				if wd == 0 {
-					wd = 7
-				}
-				from = time.Date(now.Year(), now.Month(), now.Day()-(wd-1), 0, 0, 0, 0, now.Location())
-				to = from.AddDate(0, 0, 6)
-				continue
-			}
-			if len(p) == 7 {
-				var y, m int
-				if _, e := fmt.Sscanf(p, "%d-%d", &y, &m); e == nil && m >= 1 && m <= 12 {
-					from = time.Date(y, time.Month(m), 1, 0, 0, 0, 0, now.Location())
-					to = from.AddDate(0, 1, -1)
-					continue
-				}
-			}
-			if v, e := fmt.Sscanf(p, "%d", &limit); e == nil && v >= 0 {
-				// limit parsed via Sscanf above; ensure sensible bounds
-				if limit <= 0 {
-					limit = 5
-				}
-				if limit > 50 {
-					limit = 50
-				}
-			}
-		}
+	note := ""
+	base := st.Currency
+	if target != "" && target != st.Currency {
+		note = h.convertStats(ctx, st, target, from, to, now, sess, update.Message.From.ID, locale)
 	}
-	items, err := h.report.TopCategories(ctx, sess.TenantID, from, to, limit, sess.AccessToken)
-	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить топ категорий", "Failed to load top categories")))
-		return
+	text := h.fmt.FormatStats(st)
+	if note != "" {
+		text += "\n\n" + note
 	}
-	if len(items) == 0 {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Нет данных", "No data")))
+	if withChart {
+		h.sendSpendingChart(ctx, update.Message.Chat.ID, sess, period, base, text, locale)
 		return
 	}
-	var b strings.Builder
-	b.WriteString(tr(locale, "Топ категорий:\n", "Top categories:\n"))
-	for i, it := range items {
-		b.WriteString(fmt.Sprintf("%d) %s — %.2f %s\n", i+1, it.Name, float64(it.SumMinor)/100.0, it.Currency))
-	}
-	_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, b.String()))
+	_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, text))
 }
 
-func (h *Handler) handleRecent(ctx context.Context, update tgbotapi.Update) {
+func (h *Handler) handleTopCategories(ctx context.Context, update tgbotapi.Update) {
 	locale := h.userLocale(ctx, update.Message.From.ID)
 	sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
 	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
 		return
 	}
-	limit := 10
-	if arg := strings.TrimSpace(update.Message.CommandArguments()); arg != "" {
-		var parsed int
-		if _, e := fmt.Sscanf(arg, "%d", &parsed); e == nil {
-			if parsed > 0 && parsed <= 100 {
-				limit = parsed
+	now := h.userNow(ctx, update.Message.From.ID)
+	args, withChart := chartRequested(strings.Fields(update.Message.CommandArguments()))
+	period, rest, err := h.parseReportPeriod(args, now, locale)
+	limit := 5
+	for _, p := range rest {
+		v, e := strconv.Atoi(p)
+		if e != nil {
+			if err == nil {
+				err = fmt.Errorf(tr(locale, "непонятный аргумент %q", "unknown argument %q"), p)
 			}
+			continue
+		}
+		// keep the limit within sensible bounds
+		limit = 5
+		if v > 0 {
+			limit = min(v, 50)
 		}
 	}
-	txs, err := h.txClient.ListRecent(ctx, sess.TenantID, limit, sess.AccessToken)
 	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить последние транзакции", "Failed to load recent transactions")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf(tr(locale, "Не удалось разобрать период: %v\n%s", "Failed to parse the period: %v\n%s"), err, statsUsage(locale, "/top_categories"))))
 		return
 	}
-	if len(txs) == 0 {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Нет данных", "No data")))
+	if withChart {
+		h.sendCategoryChart(ctx, update.Message.Chat.ID, sess, period, locale)
 		return
 	}
-	var b strings.Builder
-	b.WriteString(tr(locale, "Последние транзакции:\n", "Recent transactions:\n"))
-	for _, t := range txs {
-		sign := "-"
-		if t.GetType() == pb.TransactionType_TRANSACTION_TYPE_INCOME {
-			sign = "+"
-		}
-		amt := float64(t.GetAmount().GetMinorUnits()) / 100.0
-		curr := t.GetAmount().GetCurrencyCode()
-		b.WriteString(fmt.Sprintf("- %s%.2f %s %s\n", sign, amt, curr, t.GetComment()))
-	}
-	_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, b.String()))
-}
-
-func (h *Handler) handleExport(ctx context.Context, update tgbotapi.Update) {
-	locale := h.userLocale(ctx, update.Message.From.ID)
-	sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
+	items, err := h.periodTopCategories(ctx, sess, period.From, period.To, limit, locale)
 	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		h.logger.Error("handleTopCategories: failed to load top categories", zap.String("tenantID", sess.TenantID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить топ категорий", "Failed to load top categories")))
 		return
 	}
-	// Export current month by default; supports args: YYYY-MM|week [limit]
-	now := time.Now()
-	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	to := from.AddDate(0, 1, -1)
-	limit := 100
-	if arg := strings.TrimSpace(update.Message.CommandArguments()); arg != "" {
-		parts := strings.Fields(arg)
-		for _, p := range parts {
-			if p == "week" {
-				wd := int(now.Weekday())
-				if wd == 0 {
-					wd = 7
-				}
-				from = time.Date(now.Year(), now.Month(), now.Day()-(wd-1), 0, 0, 0, 0, now.Location())
-				to = from.AddDate(0, 0, 6)
-				continue
-			}
-			if len(p) == 7 {
-				var y, m int
-				if _, e := fmt.Sscanf(p, "%d-%d", &y, &m); e == nil && m >= 1 && m <= 12 {
-					from = time.Date(y, time.Month(m), 1, 0, 0, 0, 0, now.Location())
-					to = from.AddDate(0, 1, -1)
-					continue
-				}
-			}
-			var v int
-			if _, e := fmt.Sscanf(p, "%d", &v); e == nil {
-				if v > 0 && v <= 5000 {
-					limit = v
-				}
-			}
-		}
-	}
-	txs, err := h.txClient.ListForExport(ctx, sess.TenantID, from, to, limit, sess.AccessToken)
-	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось выгрузить транзакции", "Failed to export transactions")))
+	if len(items) == 0 {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Нет данных", "No data")))
 		return
 	}
 	var b strings.Builder
-	b.WriteString("date,type,amount,currency,category_id,comment\n")
-	for _, t := range txs {
-		dt := t.GetOccurredAt().AsTime().Format("2006-01-02")
-		typ := "expense"
-		if t.GetType() == pb.TransactionType_TRANSACTION_TYPE_INCOME {
-			typ = "income"
-		}
-		amt := float64(t.GetAmount().GetMinorUnits()) / 100.0
-		curr := t.GetAmount().GetCurrencyCode()
-		b.WriteString(fmt.Sprintf("%s,%s,%.2f,%s,%s,%s\n", dt, typ, amt, curr, t.GetCategoryId(), strings.ReplaceAll(t.GetComment(), ",", " ")))
-	}
-	file := tgbotapi.FileBytes{Name: "export.csv", Bytes: []byte(b.String())}
-	msg := tgbotapi.NewDocument(update.Message.Chat.ID, file)
-	msg.Caption = tr(locale, "Экспорт за текущий месяц", "Export for current month")
-	_, _ = h.bot.Send(msg)
+	fmt.Fprintf(&b, tr(locale, "Топ категорий за %s:\n", "Top categories for %s:\n"), period.Label)
+	for i, it := range items {
+		b.WriteString(fmt.Sprintf("%d) %s — %s %s\n", i+1, it.Name, domain.FormatAmount(it.SumMinor, it.Currency), it.Currency))
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, b.String()))
 }
 
 func (h *Handler) handleProfile(ctx context.Context, update tgbotapi.Update) {
@@ -1490,85 +1738,86 @@ func (h *Handler) handleProfile(ctx context.Context, update tgbotapi.Update) {
 	}
 	if pref != nil {
 		b.WriteString(fmt.Sprintf("%s: %s\n%s: %s\n", tr(locale, "Язык", "Language"), pref.Language, tr(locale, "Валюта по умолчанию", "Default currency"), pref.DefaultCurrency))
+		b.WriteString(fmt.Sprintf("%s: %s\n", tr(locale, "Часовой пояс", "Timezone"), h.userLocation(ctx, update.Message.From.ID).String()))
 	}
-	_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, b.String()))
+	_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, b.String()))
 }
 
 func (h *Handler) handleCreateCategory(ctx context.Context, update tgbotapi.Update) {
 	locale := h.userLocale(ctx, update.Message.From.ID)
 	sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
 	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
 		return
 	}
 	args := strings.TrimSpace(update.Message.CommandArguments())
 	if args == "" {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /create_category code название", "Format: /create_category code name")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /create_category code название", "Format: /create_category code name")))
 		return
 	}
 	parts := strings.Fields(args)
 	if len(parts) < 2 {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /create_category code название", "Format: /create_category code name")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /create_category code название", "Format: /create_category code name")))
 		return
 	}
 	code := parts[0]
 	name := strings.TrimSpace(strings.TrimPrefix(args, code))
 	if name == "" {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Укажите название категории", "Provide category name")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Укажите название категории", "Provide category name")))
 		return
 	}
 	cat, err := h.categories.CreateCategory(ctx, sess.AccessToken, code, name, locale)
 	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось создать категорию (доступно в сборке withgrpc)", "Failed to create category (available in withgrpc build)")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось создать категорию (доступно в сборке withgrpc)", "Failed to create category (available in withgrpc build)")))
 		return
 	}
-	_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf(tr(locale, "Категория создана: %s (%s)", "Category created: %s (%s)"), cat.Name, cat.ID)))
+	_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf(tr(locale, "Категория создана: %s (%s)", "Category created: %s (%s)"), cat.Name, cat.ID)))
 }
 
 func (h *Handler) handleRenameCategory(ctx context.Context, update tgbotapi.Update) {
 	locale := h.userLocale(ctx, update.Message.From.ID)
 	sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
 	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
 		return
 	}
 	args := strings.TrimSpace(update.Message.CommandArguments())
 	parts := strings.Fields(args)
 	if len(parts) < 2 {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /rename_category category_id новое_название", "Format: /rename_category category_id new_name")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /rename_category category_id новое_название", "Format: /rename_category category_id new_name")))
 		return
 	}
 	id := parts[0]
 	name := strings.TrimSpace(strings.TrimPrefix(args, id))
 	if name == "" {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Укажите новое название", "Provide new name")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Укажите новое название", "Provide new name")))
 		return
 	}
 	cat, err := h.categories.UpdateCategoryName(ctx, sess.AccessToken, id, name, locale)
 	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось обновить категорию (доступно в сборке withgrpc)", "Failed to rename category (available in withgrpc build)")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось обновить категорию (доступно в сборке withgrpc)", "Failed to rename category (available in withgrpc build)")))
 		return
 	}
-	_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf(tr(locale, "Категория обновлена: %s (%s)", "Category updated: %s (%s)"), cat.Name, cat.ID)))
+	_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, fmt.Sprintf(tr(locale, "Категория обновлена: %s (%s)", "Category updated: %s (%s)"), cat.Name, cat.ID)))
 }
 
 func (h *Handler) handleDeleteCategory(ctx context.Context, update tgbotapi.Update) {
 	locale := h.userLocale(ctx, update.Message.From.ID)
 	sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
 	if err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
 		return
 	}
 	id := strings.TrimSpace(update.Message.CommandArguments())
 	if id == "" {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /delete_category category_id", "Format: /delete_category category_id")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /delete_category category_id", "Format: /delete_category category_id")))
 		return
 	}
 	if err := h.categories.DeleteCategory(ctx, sess.AccessToken, id); err != nil {
-		_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось удалить категорию (доступно в сборке withgrpc)", "Failed to delete category (available in withgrpc build)")))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось удалить категорию (доступно в сборке withgrpc)", "Failed to delete category (available in withgrpc build)")))
 		return
 	}
-	_, _ = h.bot.Send(tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Категория удалена", "Category deleted")))
+	_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Категория удалена", "Category deleted")))
 }
 
 func (h *Handler) handleHelp(ctx context.Context, update tgbotapi.Update) {
@@ -1642,7 +1891,7 @@ func (h *Handler) showMainHelp(ctx context.Context, update tgbotapi.Update) {
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = kb
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
 
 func (h *Handler) showAuthHelp(ctx context.Context, update tgbotapi.Update) {
@@ -1707,7 +1956,7 @@ Choose organization
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = kb
 
-	_, err := h.bot.Send(msg)
+	_, err := h.send(ctx, msg)
 	if err != nil {
 		h.logger.Error("failed to send auth help message",
 			zap.Int64("chatID", update.Message.Chat.ID),
@@ -1731,6 +1980,7 @@ func (h *Handler) showTransactionsHelp(ctx context.Context, update tgbotapi.Upda
 • ` + "`+50000 зарплата`" + ` - Доход 50000
 • ` + "`01.12 5000 подарок`" + ` - Расход с датой
 • ` + "`вчера 100 кофе`" + ` - Расход за вчера
+• ` + "`2x350 кофе`" + `, ` + "`120+80 такси`" + `, ` + "`5к ремонт`" + `, ` + "`1 200,50 продукты`" + ` - Выражения и разделители
 • ` + "`1000₽ продукты`" + ` - Расход в рублях
 • ` + "`+50000$ зарплата`" + ` - Доход в долларах
 
@@ -1738,10 +1988,23 @@ func (h *Handler) showTransactionsHelp(ctx context.Context, update tgbotapi.Upda
 • ` + "`сегодня`" + `, ` + "`вчера`" + `, ` + "`позавчера`" + `
 • ` + "`DD.MM.YYYY`" + ` (например, ` + "`15.12.2023`" + `)
 • ` + "`DD.MM`" + ` (например, ` + "`15.12`" + ` - текущий год)
+• ` + "`5 марта`" + `, ` + "`в пятницу`" + `, ` + "`в прошлую среду`" + `, ` + "`3 дня назад`" + `
+• Время: ` + "`вчера в 19:30 1200 ресторан`" + `
 
 *Поддерживаемые валюты:*
-• Символы: ₽, $, €, £, ¥
-• Коды: RUB, USD, EUR, GBP, JPY
+• Коды ISO 4217: RUB, USD, EUR, GEL, TRY, KZT, JPY…
+• Символы: ₽, $, €, £, ¥, ₾, ₺, ₸
+• Слова после суммы: ` + "`50 лари`" + `, ` + "`3000 тенге`" + `, ` + "`20 евро`" + `, ` + "`200 руб`" + `
+
+*Фото чека:* отправьте фото QR-кода кассового чека, подпись к фото станет описанием.
+
+*Голосом:* отправьте голосовое сообщение, например «450 шаурма».
+
+*Несколько транзакций сразу:* каждая строка сообщения сохраняется отдельно, в ответ приходит сводка с кнопкой «↩️ Отменить все».
+
+*Повторяющиеся:* ` + "`/recurring add monthly 5 10:00; 50000 аренда`" + `, список и управление - /recurring
+
+*Из любого чата:* наберите ` + "`@бот 350 кофе`" + ` и выберите категорию в списке - транзакция сохранится. ` + "`@бот stats`" + ` отправит в чат карточку с итогами месяца
 
 *Процесс добавления:*
 1. Отправьте транзакцию в нужном формате
@@ -1759,6 +2022,22 @@ Bot parses transaction messages automatically.
 • ` + "`+50000 salary`" + ` - Income
 • ` + "`01.12 5000 gift`" + ` - Expense with date
 • ` + "`yesterday 100 coffee`" + ` - Expense for yesterday
+• ` + "`2x350 coffee`" + `, ` + "`120+80 taxi`" + `, ` + "`5k repairs`" + `, ` + "`1,200.50 groceries`" + ` - Expressions and separators
+• ` + "`last friday at 19:30 1200 dinner`" + ` - Date and time
+
+*Currencies:* any ISO 4217 code, symbols ₽ $ € £ ¥ ₾ ₺ ₸ and words such as ` + "`50 lari`" + `, ` + "`20 euro`" + `
+
+*Dates:* today, yesterday, "3 days ago", weekdays ("monday", "last friday"), "March 5", ` + "`2023-12-15`" + `, ` + "`15.12.2023`" + `
+
+*Receipt photo:* send a photo of a fiscal receipt QR code, the caption becomes the description.
+
+*By voice:* send a voice message such as "450 shawarma".
+
+*Several transactions at once:* each line of a message is saved separately, the reply summarizes them with an "↩️ Undo all" button.
+
+*Recurring:* ` + "`/recurring add monthly 5 10:00; 50000 rent`" + `, list and manage with /recurring
+
+*From any chat:* type ` + "`@bot 350 coffee`" + ` and pick a category to save it; ` + "`@bot stats`" + ` shares a month summary card
 
 *Flow:*
 1. Send transaction text
@@ -1770,7 +2049,7 @@ Bot parses transaction messages automatically.
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = kb
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
 
 func (h *Handler) showCategoriesHelp(ctx context.Context, update tgbotapi.Update) {
@@ -1809,7 +2088,7 @@ func (h *Handler) showCategoriesHelp(ctx context.Context, update tgbotapi.Update
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = kb
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
 
 func (h *Handler) showStatsHelp(ctx context.Context, update tgbotapi.Update) {
@@ -1819,35 +2098,73 @@ func (h *Handler) showStatsHelp(ctx context.Context, update tgbotapi.Update) {
 		zap.Int64("userID", update.Message.From.ID))
 
 	text := "📊 *Статистика и отчеты*\n\n" +
-		"`/stats [период]` - Общая статистика\n" +
+		"`/stats [период] [валюта]` - Общая статистика\n" +
 		"Показывает доходы и расходы за период\n\n" +
 		"*Варианты периода:*\n" +
 		"• /stats - Текущий месяц\n" +
 		"• `/stats 2023\\-12` - Конкретный месяц \\(YYYY\\-MM\\)\n" +
-		"• `/stats week` - Текущая неделя\n\n" +
+		"• `/stats week` - Текущая неделя\n" +
+		"• `/stats last 30d` - Последние 30 дней \\(также `2w`, `3m`\\)\n" +
+		"• `/stats q1`, `/stats 2025` - Квартал или год\n" +
+		"• `/stats 2025\\-01\\-01..2025\\-03\\-31` - Произвольный диапазон дат\n" +
+		"• `/stats week USD` - Итоги в другой валюте по курсу с указанием источника\n" +
+		"• `/stats chart` - Итоги с графиком накопленных расходов по дням\n\n" +
 		"`/top\\_categories [период] [лимит]` - Топ категорий\n" +
 		"Показывает категории с наибольшими расходами\n\n" +
 		"*Примеры:*\n" +
 		"• /top\\_categories - Топ\\-5 за текущий месяц\n" +
 		"• `/top\\_categories 2023\\-12` - Топ\\-5 за декабрь 2023\n" +
-		"• `/top\\_categories week 10` - Топ\\-10 за неделю\n\n" +
-		"`/recent [лимит]` - Последние транзакции\n" +
-		"Показывает последние транзакции\n\n" +
+		"• `/top\\_categories week 10` - Топ\\-10 за неделю\n" +
+		"• `/top\\_categories last 30d` - Топ\\-5 за последние 30 дней\n" +
+		"• `/top\\_categories chart` - Кольцевая диаграмма долей всех категорий\n\n" +
+		"`/trend [12m]` - Доходы и расходы по месяцам\n" +
+		"Столбчатая диаграмма за последние 2–24 месяца, по умолчанию за 12\n\n" +
+		"`/compare [период] [период]` - Сравнение расходов по категориям\n" +
+		"Показывает изменение по каждой категории в деньгах и процентах и выделяет самые заметные изменения\n\n" +
 		"*Примеры:*\n" +
-		"• /recent - Последние 10 транзакций\n" +
-		"• `/recent 20` - Последние 20 транзакций\n\n" +
-		"`/export [период] [лимит]` - Экспорт данных\n" +
-		"Экспортирует транзакции в CSV формат\n\n" +
+		"• /compare - Текущий месяц против прошлого\n" +
+		"• `/compare q2` - Второй квартал против первого\n" +
+		"• `/compare 2025\\-10 2024\\-10` - Октябрь против октября прошлого года\n\n" +
+		"/forecast - Прогноз расходов на конец месяца\n" +
+		"По дневному темпу трат и ожидаемым повторяющимся платежам, по категориям и в сравнении со средним за 3 прошлых месяца\n\n" +
+		"`/recent [на странице]` - Последние транзакции\n" +
+		"Показывает транзакции по страницам; нажмите номер, чтобы изменить категорию, сумму, дату, комментарий или удалить операцию\n\n" +
 		"*Примеры:*\n" +
-		"• /export - Экспорт за текущий месяц\n" +
-		"• `/export 2023\\-12` - Экспорт за декабрь 2023\n" +
-		"• `/export week 100` - Экспорт 100 транзакций за неделю"
+		"• /recent - По 10 транзакций на странице\n" +
+		"• `/recent 20` - По 20 транзакций на странице\n\n" +
+		"`/find запрос` - Поиск транзакций\n" +
+		"Текст ищется в комментариях; фильтры: `>сумма`, `<сумма`, `cat:категория`, `from:дата`, `to:дата`, `type:expense|income`, `cur:USD`\n\n" +
+		"*Пример:*\n" +
+		"• `/find кофе >500 cat:Питание from:2025\\-01\\-01`\n\n" +
+		"`/export [период] [лимит] [format=формат]` - Экспорт данных\n" +
+		"Форматы: csv, json, xlsx, ofx, qif, hledger, beancount; период - месяц, неделя или диапазон `с..по`\n\n" +
+		"*Примеры:*\n" +
+		"• /export - CSV за текущий месяц\n" +
+		"• `/export 2023\\-12 format=xlsx` - Таблица за декабрь 2023\n" +
+		"• `/export 2025\\-01\\-01..2025\\-03\\-31 format=ofx` - Выписка за квартал\n" +
+		"• `/export week 100` - Экспорт 100 транзакций за неделю\n\n" +
+		"/import - Импорт CSV\\-выписки\n" +
+		"Отправьте файл, проверьте сопоставление колонок, посмотрите предпросмотр и импортируйте \\(есть пробный режим\\)\n\n" +
+		"`/budget категория сумма [month|week]` - Бюджет категории\n" +
+		"/budgets - Расход относительно бюджетов\n" +
+		"При достижении 80% и 100% бюджета бот предупредит в сообщении о сохранении\n\n" +
+		"`/digest weekly mon 09:00 [часовой пояс]` - Дайджест по расписанию\n" +
+		"Также daily и monthly; `/digest off` - отписаться"
 	if locale == "en" {
 		text = "📊 *Statistics and reports*\n\n" +
-			"`/stats [period]` - Summary stats\n\n" +
-			"`/top_categories [period] [limit]` - Top categories\n\n" +
-			"`/recent [limit]` - Recent transactions\n\n" +
-			"`/export [period] [limit]` - CSV export"
+			"`/stats [period] [currency]` - Summary stats, optionally converted to another currency (e.g. `/stats week USD`); the period is `week`, `month`, `2025-03`, `2025`, `q1`, `last 30d` or `2025-01-01..2025-03-31`\n\n" +
+			"`/top_categories [period] [limit]` - Top categories for the same periods\n\n" +
+			"Add `chart` to `/stats` for a cumulative spending line or to `/top_categories` for a category donut chart\n\n" +
+			"`/trend [12m]` - Income vs expenses bar chart for the last 2 to 24 months\n\n" +
+			"`/forecast` - Month-end spending forecast by category from the daily run-rate and upcoming recurring payments, compared with the average of the last 3 months\n\n" +
+			"`/compare [period] [period]` - Spending by category in two periods with absolute and percentage changes; one period is compared with the one before it, none compares this month with the last (e.g. `/compare 2025-10 2024-10`)\n\n" +
+			"`/recent [page size]` - Recent transactions page by page; tap a number to change or delete one\n\n" +
+			"`/find query` - Search transactions by comment with filters `>amount`, `<amount`, `cat:category`, `from:date`, `to:date`, `type:expense|income`, `cur:USD` (e.g. `/find coffee >500 from:2025-01-01`)\n\n" +
+			"`/export [period] [limit] [format=fmt]` - Export to csv, json, xlsx, ofx, qif, hledger or beancount; the period is a month, `week` or a `from..to` range (e.g. `/export 2025-01-01..2025-03-31 format=xlsx`)\n\n" +
+			"`/import` - Import a CSV bank statement with column mapping, preview and dry run\n\n" +
+			"`/budget category amount [month|week]` - Category budget\n\n" +
+			"`/budgets` - Spending vs budgets, with 80% and 100% alerts on save\n\n" +
+			"`/digest weekly mon 09:00 [timezone]` - Scheduled digest (also daily and monthly, `/digest off` to stop)"
 	}
 
 	kb := ui.CreateBackToHelpKeyboard(locale)
@@ -1855,7 +2172,7 @@ func (h *Handler) showStatsHelp(ctx context.Context, update tgbotapi.Update) {
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = kb
 
-	_, err := h.bot.Send(msg)
+	_, err := h.send(ctx, msg)
 	if err != nil {
 		h.logger.Error("failed to send stats help message",
 			zap.Int64("chatID", update.Message.Chat.ID),
@@ -1882,12 +2199,31 @@ func (h *Handler) showSettingsHelp(ctx context.Context, update tgbotapi.Update)
 • € EUR
 • £ GBP
 • ¥ JPY
+` + "`/currency GEL`" + ` - любая валюта ISO 4217
+
+/rates - Курсы к базовой валюте организации
+` + "`/rates 05.03.2025`" + ` - на дату
+` + "`/rate USD RUB 92.5 [дата]`" + ` - свой курс, например из обменника (только владельцы и администраторы)
+
+/members - Участники организации
+` + "`/invite email [роль]`" + `, ` + "`/role email роль`" + `, ` + "`/kick email`" + ` - управление участниками (владельцы и администраторы)
+` + "`/new_tenant название [валюта]`" + ` - новая организация
+/tenant\_settings - название и базовая валюта организации
+
+👥 В групповом чате: /bind\_tenant привязывает чат к организации, /chat\_settings - язык и валюта чата. Сообщения участников сохраняются в организацию чата от их имени
+
+/timezone - Часовой пояс
+` + "`/timezone Europe/Moscow`" + `, ` + "`/timezone UTC+3`" + ` или отправьте местоположение. Используется для дат «сегодня»/«вчера», границ месяца и недели в отчётах и времени в сообщениях
+
+/limit - Лимит суммы
+` + "`/limit 20000 [валюта]`" + ` - расходы больше суммы сохраняются только после подтверждения, ` + "`/limit off`" + ` - отключить. Суммы, во много раз больше обычных для категории, тоже требуют подтверждения
 
 /profile - Профиль пользователя
 Показывает информацию о пользователе:
 • UserID и TenantID
 • Язык интерфейса
 • Валюта по умолчанию
+• Часовой пояс
 • Статус авторизации
 
 /settings - Общие настройки
@@ -1901,7 +2237,15 @@ func (h *Handler) showSettingsHelp(ctx context.Context, update tgbotapi.Update)
 		text = `⚙️ *Settings*
 
 /language - Choose interface language
-/currency - Choose default currency
+/currency - Choose default currency (any ISO 4217 code: ` + "`/currency GEL`" + `)
+/rates [date] - Exchange rates to the tenant's base currency
+` + "`/rate USD RUB 92.5 [date]`" + ` - Set your own rate (owners and admins only)
+/members - Tenant members; ` + "`/invite email [role]`" + `, ` + "`/role email role`" + `, ` + "`/kick email`" + ` for owners and admins
+` + "`/new_tenant name [currency]`" + ` - Create a tenant
+/tenant\_settings - Tenant name and base currency
+/bind\_tenant, /chat\_settings - Shared budget in a group chat: members' messages are saved to the chat's tenant on their behalf
+/timezone - Set timezone (` + "`/timezone Europe/Moscow`" + `, ` + "`/timezone UTC+3`" + ` or share location)
+/limit - Confirm expenses above an amount before saving (` + "`/limit 20000 [currency]`" + `, ` + "`/limit off`" + `); amounts far above the usual ones of a category are confirmed too
 /profile - Show user profile`
 	}
 
@@ -1909,7 +2253,7 @@ func (h *Handler) showSettingsHelp(ctx context.Context, update tgbotapi.Update)
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = kb
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
 
 func (h *Handler) showAdminHelp(ctx context.Context, update tgbotapi.Update) {
@@ -1944,5 +2288,5 @@ func (h *Handler) showAdminHelp(ctx context.Context, update tgbotapi.Update) {
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID, text)
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = kb
-	_, _ = h.bot.Send(msg)
+	_, _ = h.send(ctx, msg)
 }
@@ -0,0 +1,341 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"budget-bot/internal/bot/ui"
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Amount guard settings: the history the typical amounts are taken from (days and transactions), how long the
+// typical amounts are reused, the number of past amounts needed to judge, how many times the median an amount must
+// exceed to count as an outlier and how long an unanswered draft is kept.
+const (
+	guardHistoryDays   = 90
+	guardHistorySize   = 200
+	guardHistoryTTL    = 10 * time.Minute
+	guardMinSamples    = 5
+	guardOutlierFactor = 10
+	guardDraftTTL      = 24 * time.Hour
+)
+
+type amountConfirmedKey struct{}
+
+// withAmountConfirmed marks the transaction being saved as confirmed by the user, so the amount guard lets it pass.
+func withAmountConfirmed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, amountConfirmedKey{}, true)
+}
+
+func amountConfirmed(ctx context.Context) bool {
+	ok, _ := ctx.Value(amountConfirmedKey{}).(bool)
+	return ok
+}
+
+// typicalAmount summarizes the recent amounts of one kind of transactions.
+type typicalAmount struct {
+	median, max int64
+	samples     int
+	storedAt    time.Time
+}
+
+// typicalAmounts looks at the recent transactions of the tenant with the same type, currency and, when known,
+// category. The result is reused for guardHistoryTTL, so saving does not list the history every time.
+func (h *Handler) typicalAmounts(ctx context.Context, sess *repository.UserSession, txType, categoryID, currency string, now time.Time) (*typicalAmount, error) {
+	key := strings.Join([]string{sess.TenantID, txType, categoryID, currency}, "|")
+	if v, ok := h.typicalAmountsCache.Load(key); ok {
+		if t := v.(*typicalAmount); time.Since(t.storedAt) < guardHistoryTTL {
+			return t, nil
+		}
+	}
+	t, err := h.listTypicalAmounts(ctx, sess, txType, categoryID, currency, now)
+	if err != nil {
+		return nil, err
+	}
+	t.storedAt = time.Now()
+	// drop the amounts of kinds that are no longer saved
+	h.typicalAmountsCache.Range(func(k, v any) bool {
+		if t.storedAt.Sub(v.(*typicalAmount).storedAt) >= guardHistoryTTL {
+			h.typicalAmountsCache.Delete(k)
+		}
+		return true
+	})
+	h.typicalAmountsCache.Store(key, t)
+	return t, nil
+}
+
+func (h *Handler) listTypicalAmounts(ctx context.Context, sess *repository.UserSession, txType, categoryID, currency string, now time.Time) (*typicalAmount, error) {
+	filter := &grpcclient.TransactionFilter{From: now.AddDate(0, 0, -guardHistoryDays), To: now, Type: txType, Currency: currency}
+	if categoryID != "" {
+		filter.CategoryIDs = []string{categoryID}
+	}
+	res, err := h.txClient.ListTransactions(ctx, filter, 1, guardHistorySize, sess.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	var amounts []int64
+	for _, t := range res.Transactions {
+		if t.GetAmount().GetCurrencyCode() == currency {
+			amounts = append(amounts, absMinor(t.GetAmount().GetMinorUnits()))
+		}
+	}
+	if len(amounts) == 0 {
+		return &typicalAmount{}, nil
+	}
+	sort.Slice(amounts, func(i, j int) bool { return amounts[i] < amounts[j] })
+	return &typicalAmount{median: amounts[len(amounts)/2], max: amounts[len(amounts)-1], samples: len(amounts)}, nil
+}
+
+// amountCeiling returns the user's expense ceiling, or 0 when none is set.
+func (h *Handler) amountCeiling(ctx context.Context, telegramID int64) (int64, string) {
+	if h.prefs == nil {
+		return 0, ""
+	}
+	pref, _ := h.prefs.GetPreferences(ctx, telegramID)
+	if pref == nil {
+		return 0, ""
+	}
+	return pref.AmountCeilingMinor, pref.AmountCeilingCurrency
+}
+
+// unusualAmount explains why the amount of a transaction should be confirmed before saving: an expense above
+// the user's ceiling or an amount far above the recent ones of the category (of the tenant when the category is
+// not known yet). It returns "" for usual amounts.
+func (h *Handler) unusualAmount(ctx context.Context, sess *repository.UserSession, telegramID int64, txType string, amount int64, currency, categoryID, locale string) string {
+	now := h.userNow(ctx, telegramID)
+	if ceiling, ceilingCur := h.amountCeiling(ctx, telegramID); ceiling > 0 && txType == string(domain.TransactionExpense) {
+		converted, err := h.convertAmount(ctx, amount, currency, ceilingCur, now, sess.AccessToken)
+		if err == nil && converted > ceiling {
+			return fmt.Sprintf(tr(locale, "сумма больше вашего лимита %s (/limit)", "the amount is above your limit of %s (/limit)"), money(ceiling, ceilingCur))
+		}
+	}
+	typical, err := h.typicalAmounts(ctx, sess, txType, categoryID, currency, now)
+	if err != nil {
+		h.logger.Warn("amount guard: failed to list recent transactions", zap.String("tenantID", sess.TenantID), zap.Error(err))
+		return ""
+	}
+	if typical.samples < guardMinSamples || typical.median == 0 || amount <= typical.median*guardOutlierFactor || amount <= typical.max {
+		return ""
+	}
+	scope := tr(locale, "обычно", "usually")
+	if categoryID != "" {
+		scope = tr(locale, "в этой категории обычно", "usually in this category")
+	}
+	return fmt.Sprintf(tr(locale, "сумма в %d раз больше обычной: %s около %s, максимум за %d дней — %s", "the amount is %d times the usual one: %s about %s, over %d days at most %s"),
+		amount/typical.median, scope, money(typical.median, currency), guardHistoryDays, money(typical.max, currency))
+}
+
+// holdIfUnusual is called by every path that saves a new transaction or a new amount. When the amount looks like
+// a typo, the transaction is kept as a draft and the user is asked in chatID to save it, fix the amount or cancel;
+// it reports true then and the caller must not save. Amounts confirmed with the draft buttons are not checked again.
+func (h *Handler) holdIfUnusual(ctx context.Context, sess *repository.UserSession, chatID int64, draft *repository.TransactionDraft, expression string) bool {
+	if h.drafts == nil || amountConfirmed(ctx) {
+		return false
+	}
+	userID := draft.TelegramID
+	locale := h.userLocale(ctx, userID)
+	reason := h.unusualAmount(ctx, sess, userID, draft.Type, draft.AmountMinor, draft.Currency, draft.CategoryID, locale)
+	if reason == "" {
+		return false
+	}
+	draft.ID = uuid.NewString()
+	if err := h.drafts.Create(ctx, draft); err != nil {
+		h.logger.Error("failed to save draft", zap.Int64("telegramID", userID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось сохранить транзакцию", "Failed to save transaction")))
+		return true
+	}
+	held := tr(locale, "Транзакция не сохранена, пока вы не подтвердите её.", "The transaction is not saved until you confirm it.")
+	if draft.OpID != "" {
+		held = tr(locale, "Сумма не изменена, пока вы не подтвердите её.", "The amount is not changed until you confirm it.")
+	}
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale,
+		"⚠️ Проверьте сумму: %s %s — %s\nПохоже на опечатку: %s.\n%s",
+		"⚠️ Check the amount: %s %s — %s\nThis looks like a typo: %s.\n%s"),
+		txTypeLabel(draft.Type, locale), money(draft.AmountMinor, draft.Currency)+expressionSuffix(expression), draft.Description, reason, held))
+	msg.ReplyMarkup = ui.CreateAmountCheckKeyboard(draft.ID, locale)
+	_, _ = h.send(ctx, msg)
+	return true
+}
+
+// draftTransaction restores the parsed transaction held in a draft.
+func draftTransaction(d *repository.TransactionDraft) *ParsedTransaction {
+	return &ParsedTransaction{
+		Type:        domain.TransactionType(d.Type),
+		Amount:      &domain.Money{AmountMinor: d.AmountMinor, CurrencyCode: d.Currency},
+		Currency:    d.Currency,
+		Description: d.Description,
+		OccurredAt:  d.OccurredAt,
+		CategoryID:  d.CategoryID,
+		BatchID:     d.BatchID,
+		IsValid:     true,
+	}
+}
+
+// saveDraft saves a held transaction with the given amount in the chat of update: a new transaction through
+// saveParsedTransaction, a changed amount through the edit of its operation. The draft is removed only once the
+// transaction is taken care of, so after a failed save the user can press its buttons again; it reports that.
+func (h *Handler) saveDraft(ctx context.Context, update tgbotapi.Update, d *repository.TransactionDraft, amount int64) bool {
+	var ok bool
+	if d.OpID == "" {
+		parsed := draftTransaction(d)
+		parsed.Amount.AmountMinor = amount
+		ok = h.saveParsedTransaction(ctx, update, parsed)
+	} else {
+		ok = h.saveDraftAmount(ctx, update, d, amount)
+	}
+	if ok {
+		_ = h.drafts.Delete(ctx, d.ID)
+	}
+	return ok
+}
+
+// saveDraftAmount changes the amount of the saved transaction a draft was held for.
+func (h *Handler) saveDraftAmount(ctx context.Context, update tgbotapi.Update, d *repository.TransactionDraft, amount int64) bool {
+	userID := update.Message.From.ID
+	locale := h.userLocale(ctx, userID)
+	op, ok := h.getOwnedOperation(ctx, userID, d.OpID)
+	if !ok {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Транзакция для изменения не найдена", "Transaction to edit not found")))
+		return false
+	}
+	sess, err := h.auth.GetSession(ctx, userID)
+	if err != nil || sess == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		return false
+	}
+	return h.editOperationAmount(ctx, update.Message.Chat.ID, sess, op, amount, locale)
+}
+
+// handleDraftCallback handles the buttons of a held transaction: save as is, fix the amount or cancel.
+func (h *Handler) handleDraftCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, action, draftID string) {
+	locale := h.userLocale(ctx, cb.From.ID)
+	var d *repository.TransactionDraft
+	if h.drafts != nil {
+		d, _ = h.drafts.Get(ctx, draftID)
+	}
+	// in a group only the author can decide about the transaction
+	if d == nil || d.TelegramID != cb.From.ID || cb.Message == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Транзакция не найдена", "Transaction not found")))
+		return
+	}
+	closeWith := func(note string) {
+		_, _ = h.bot.Request(tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, cb.Message.Text+"\n\n"+note))
+	}
+	update := tgbotapi.Update{Message: &tgbotapi.Message{Chat: cb.Message.Chat, From: cb.From}}
+	switch action {
+	case "save":
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Сохраняю", "Saving")))
+		// the buttons stay when saving fails, so the user can try again
+		if h.saveDraft(withAmountConfirmed(ctx), update, d, d.AmountMinor) {
+			closeWith(tr(locale, "✅ Сумма подтверждена", "✅ Amount confirmed"))
+		}
+	case "fix":
+		_ = h.states.SetState(ctx, cb.From.ID, repository.StateWaitingForDraftAmount, map[string]any{"draft_id": d.ID}, nil)
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, ""))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(cb.Message.Chat.ID, fmt.Sprintf(tr(locale,
+			"Введите правильную сумму вместо %s. Для отмены: /cancel", "Enter the correct amount instead of %s. To cancel: /cancel"), money(d.AmountMinor, d.Currency))))
+	default:
+		_ = h.drafts.Delete(ctx, d.ID)
+		closeWith(tr(locale, "✖️ Отменено, транзакция не сохранена", "✖️ Canceled, the transaction is not saved"))
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Отменено", "Canceled")))
+	}
+}
+
+// handleDraftAmountInput saves a held transaction with the corrected amount; the new amount is checked again.
+func (h *Handler) handleDraftAmountInput(ctx context.Context, update tgbotapi.Update, rec *repository.DialogStateRecord) {
+	userID := update.Message.From.ID
+	locale := h.userLocale(ctx, userID)
+	draftID, _ := rec.Context["draft_id"].(string)
+	var d *repository.TransactionDraft
+	if h.drafts != nil {
+		d, _ = h.drafts.Get(ctx, draftID)
+	}
+	if d == nil {
+		_ = h.states.ClearState(ctx, userID)
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Транзакция не найдена", "Transaction not found")))
+		return
+	}
+	amount, err := h.parser.ParseAmountIn(strings.TrimSpace(update.Message.Text), d.Currency)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось распознать сумму, попробуйте ещё раз", "Could not parse the amount, please try again")))
+		return
+	}
+	_ = h.states.ClearState(ctx, userID)
+	h.saveDraft(ctx, update, d, amount)
+}
+
+// RunDraftCleanup removes held transactions the user has not answered within a day; their buttons then report
+// that the transaction is not found.
+func (h *Handler) RunDraftCleanup(ctx context.Context, now time.Time) error {
+	if h.drafts == nil {
+		return nil
+	}
+	n, err := h.drafts.DeleteCreatedBefore(ctx, now.Add(-guardDraftTTL))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		h.logger.Info("removed unanswered drafts", zap.Int64("count", n))
+	}
+	return nil
+}
+
+// handleLimit shows or sets the expense amount above which saving asks for confirmation: /limit [amount [currency]|off].
+func (h *Handler) handleLimit(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	if h.prefs == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Настройки недоступны", "Settings are unavailable")))
+		return
+	}
+	args := strings.Fields(update.Message.CommandArguments())
+	usage := tr(locale,
+		"Формат: /limit 20000 [валюта] — спрашивать подтверждение для расходов больше суммы, /limit off — отключить",
+		"Usage: /limit 20000 [currency] — ask to confirm expenses above the amount, /limit off — turn off")
+	if len(args) == 0 {
+		text := tr(locale, "Лимит суммы не задан", "No amount limit is set")
+		if ceiling, cur := h.amountCeiling(ctx, userID); ceiling > 0 {
+			text = fmt.Sprintf(tr(locale, "Расходы больше %s сохраняются только после подтверждения", "Expenses above %s are saved only after confirmation"), money(ceiling, cur))
+		}
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, text+"\n\n"+usage))
+		return
+	}
+	var amount int64
+	currency := ""
+	if len(args) == 1 && (strings.EqualFold(args[0], "off") || strings.EqualFold(args[0], "выкл")) {
+		args = nil
+	} else {
+		currency = h.defaultCurrency(ctx, userID)
+		if len(args) == 2 {
+			if _, ok := domain.LookupCurrency(strings.ToUpper(args[1])); !ok {
+				_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, usage))
+				return
+			}
+			currency = strings.ToUpper(args[1])
+		}
+		var err error
+		if amount, err = h.parser.ParseAmountIn(args[0], currency); err != nil || len(args) > 2 {
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, usage))
+			return
+		}
+	}
+	if err := h.prefs.UpdateAmountCeiling(ctx, userID, amount, currency); err != nil {
+		h.logger.Error("failed to save amount ceiling", zap.Int64("telegramID", userID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось сохранить лимит", "Failed to save the limit")))
+		return
+	}
+	text := tr(locale, "Лимит суммы отключён", "Amount limit is off")
+	if amount > 0 {
+		text = fmt.Sprintf(tr(locale, "🛡 Расходы больше %s будут сохраняться только после подтверждения", "🛡 Expenses above %s will be saved only after confirmation"), money(amount, currency))
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, text))
+}
@@ -0,0 +1,218 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// guardTxClient has a history of twelve 600.00 RUB coffees, records created and updated transactions and counts
+// how often the history is listed. With fail set, creating fails.
+type guardTxClient struct {
+	pagingTxClient
+	created []*grpcclient.CreateTransactionRequest
+	updated []*grpcclient.UpdateTransactionRequest
+	lists   int
+	fail    bool
+}
+
+func (c *guardTxClient) ListTransactions(ctx context.Context, filter *grpcclient.TransactionFilter, page, pageSize int, token string) (*grpcclient.TransactionPage, error) {
+	c.lists++
+	return c.pagingTxClient.ListTransactions(ctx, filter, page, pageSize, token)
+}
+
+func (c *guardTxClient) UpdateTransaction(_ context.Context, _ string, req *grpcclient.UpdateTransactionRequest, _ string) error {
+	c.updated = append(c.updated, req)
+	return nil
+}
+
+func (c *guardTxClient) CreateTransaction(ctx context.Context, req *grpcclient.CreateTransactionRequest, token string) (string, error) {
+	if c.fail {
+		return "", errors.New("unavailable")
+	}
+	c.created = append(c.created, req)
+	return c.FakeTransactionClient.CreateTransaction(ctx, req, token)
+}
+
+func TestHandler_AmountGuard(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	drafts := repository.NewSQLiteDraftRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &guardTxClient{}
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, mappings, nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithOperationContexts(repository.NewSQLiteOperationContextRepository(db)).
+		WithDrafts(drafts).
+		WithTransactionClient(tx)
+
+	ctx := context.Background()
+	chatID, userID := int64(9700), int64(97)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token1234567", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	if err := mappings.AddMapping(ctx, &repository.CategoryMapping{ID: "m1", TenantID: "t", Keyword: "кофе", CategoryID: "cat-food"}); err != nil {
+		t.Fatalf("add mapping: %v", err)
+	}
+
+	message := func(text string) {
+		msg := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text}
+		if strings.HasPrefix(text, "/") {
+			msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}
+		}
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: msg})
+	}
+	last := func() string {
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+	draftRe := regexp.MustCompile(`v1:draft_save:([0-9a-f-]+)`)
+	// held sends a transaction that must be held and returns its draft id
+	held := func(text, reason string) string {
+		created := len(tx.created)
+		message(text)
+		if len(tx.created) != created {
+			t.Fatalf("%q saved without confirmation", text)
+		}
+		if got := last(); !strings.HasPrefix(got, "⚠️ Проверьте сумму") || !strings.Contains(got, reason) {
+			t.Fatalf("unexpected warning for %q: %q", text, got)
+		}
+		sends := rec.Calls("sendMessage")
+		m := draftRe.FindStringSubmatch(sends[len(sends)-1].Params.Get("reply_markup"))
+		if m == nil {
+			t.Fatalf("confirmation keyboard missing: %s", sends[len(sends)-1].Params.Get("reply_markup"))
+		}
+		return m[1]
+	}
+	press := func(from int64, data string) {
+		h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: &tgbotapi.User{ID: from}, Data: data, Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: chatID}, Text: "⚠️"}}})
+	}
+	saved := func(n int, amount int64) {
+		if len(tx.created) != n || tx.created[n-1].AmountMinor != amount || tx.created[n-1].CategoryID != "cat-food" {
+			t.Fatalf("expected transaction %d of %d, got %+v", n, amount, tx.created)
+		}
+	}
+
+	message("700 кофе")
+	saved(1, 70000)
+
+	id := held("60000 кофе", "сумма в 100 раз больше обычной: в этой категории обычно около 600.00 RUB")
+	press(userID+1, "v1:draft_save:"+id)
+	if len(tx.created) != 1 {
+		t.Fatal("another user confirmed the transaction")
+	}
+	press(userID, "v1:draft_fix:"+id)
+	if got := last(); !strings.HasPrefix(got, "Введите правильную сумму вместо 60000.00 RUB") {
+		t.Fatalf("unexpected prompt: %q", got)
+	}
+	message("600")
+	saved(2, 60000)
+	if d, _ := drafts.Get(ctx, id); d != nil {
+		t.Fatal("draft kept after fixing the amount")
+	}
+
+	id = held("60000 кофе", "в 100 раз")
+	press(userID, "v1:draft_save:"+id)
+	saved(3, 6000000)
+	edits := rec.Calls("editMessageText")
+	if got := edits[len(edits)-1].Params.Get("text"); !strings.Contains(got, "✅ Сумма подтверждена") {
+		t.Fatalf("unexpected edit: %q", got)
+	}
+
+	id = held("60000 кофе", "в 100 раз")
+	press(userID, "v1:draft_cancel:"+id)
+	press(userID, "v1:draft_save:"+id)
+	if len(tx.created) != 3 {
+		t.Fatalf("canceled transaction saved: %+v", tx.created)
+	}
+
+	message("/limit 1000")
+	if got := last(); got != "🛡 Расходы больше 1000.00 RUB будут сохраняться только после подтверждения" {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+	held("1500 кофе", "сумма больше вашего лимита 1000.00 RUB")
+	message("/limit off")
+	message("1500 кофе")
+	saved(4, 150000)
+
+	// a new amount of a saved transaction is held the same way
+	sends := rec.Calls("sendMessage")
+	opID := regexp.MustCompile(`v1:edit_amount:([0-9a-f-]+)`).FindStringSubmatch(sends[len(sends)-1].Params.Get("reply_markup"))
+	if opID == nil {
+		t.Fatalf("edit keyboard missing: %s", sends[len(sends)-1].Params.Get("reply_markup"))
+	}
+	press(userID, "v1:edit_amount:"+opID[1])
+	message("90000")
+	if len(tx.updated) != 0 || !strings.Contains(last(), "Сумма не изменена, пока вы не подтвердите её") {
+		t.Fatalf("unusual new amount saved without confirmation: %+v %q", tx.updated, last())
+	}
+	id = draftRe.FindStringSubmatch(rec.Calls("sendMessage")[len(rec.Calls("sendMessage"))-1].Params.Get("reply_markup"))[1]
+	press(userID, "v1:draft_fix:"+id)
+	message("900")
+	if len(tx.updated) != 1 || *tx.updated[0].AmountMinor != 90000 || len(tx.created) != 4 {
+		t.Fatalf("fixed amount must update the transaction: %+v %+v", tx.updated, tx.created)
+	}
+
+	// every line of a batch is checked on its own
+	message("700 кофе\n60000 кофе")
+	if got := last(); len(tx.created) != 5 || !strings.Contains(got, "1. ✅") || !strings.Contains(got, "2. ⏸ расход 60000.00 RUB — кофе — ждёт подтверждения суммы") {
+		t.Fatalf("unusual batch line must be held: %+v %q", tx.created, got)
+	}
+	if texts := rec.Texts(); !strings.HasPrefix(texts[len(texts)-2], "⚠️ Проверьте сумму") {
+		t.Fatalf("batch line warning missing: %q", texts[len(texts)-2])
+	}
+	// a confirmed batch line is undone together with the rest of the batch
+	sends = rec.Calls("sendMessage")
+	batchID := regexp.MustCompile(`v1:batch_undo:([0-9a-f-]+)`).FindStringSubmatch(sends[len(sends)-1].Params.Get("reply_markup"))
+	if batchID == nil {
+		t.Fatalf("undo keyboard missing: %s", sends[len(sends)-1].Params.Get("reply_markup"))
+	}
+	press(userID, "v1:draft_save:"+draftRe.FindStringSubmatch(sends[len(sends)-2].Params.Get("reply_markup"))[1])
+	if ops, _ := repository.NewSQLiteOperationContextRepository(db).ListByBatch(ctx, batchID[1]); len(tx.created) != 6 || len(ops) != 2 {
+		t.Fatalf("confirmed batch line must join the batch: %+v %+v", tx.created, ops)
+	}
+
+	// unanswered drafts expire
+	id = held("60000 кофе", "в 100 раз")
+	if err := h.RunDraftCleanup(ctx, time.Now().Add(guardDraftTTL+time.Minute)); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if d, _ := drafts.Get(ctx, id); d != nil {
+		t.Fatal("expired draft kept")
+	}
+
+	// a failed save keeps the draft and its buttons, so the user can press save again
+	id = held("60000 кофе", "в 100 раз")
+	created, edited := len(tx.created), len(rec.Calls("editMessageText"))
+	tx.fail = true
+	press(userID, "v1:draft_save:"+id)
+	if d, _ := drafts.Get(ctx, id); d == nil || len(rec.Calls("editMessageText")) != edited || last() != "Не удалось сохранить транзакцию" {
+		t.Fatalf("draft must survive a failed save: %v %q", d, last())
+	}
+	tx.fail = false
+	press(userID, "v1:draft_save:"+id)
+	saved(created+1, 6000000)
+	if d, _ := drafts.Get(ctx, id); d != nil {
+		t.Fatal("draft kept after saving")
+	}
+
+	// the typical amounts are not listed again for every save
+	lists := tx.lists
+	message("700 кофе")
+	message("800 кофе")
+	if tx.lists != lists {
+		t.Fatalf("history listed %d times for two saves", tx.lists-lists)
+	}
+}
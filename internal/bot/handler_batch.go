@@ -0,0 +1,334 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"budget-bot/internal/bot/ui"
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/metrics"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxBatchLines limits how many transactions a single message may create.
+const maxBatchLines = 30
+
+// batchLines splits a message into non-empty trimmed lines.
+func batchLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// defaultCurrency returns the user's preferred currency, falling back to RUB.
+func (h *Handler) defaultCurrency(ctx context.Context, telegramID int64) string {
+	if g := groupFromContext(ctx); g != nil && g.binding != nil && g.binding.DefaultCurrency != "" {
+		return g.binding.DefaultCurrency
+	}
+	if h.prefs != nil {
+		if pref, err := h.prefs.GetPreferences(ctx, telegramID); err == nil && pref != nil && pref.DefaultCurrency != "" {
+			return pref.DefaultCurrency
+		}
+	}
+	return "RUB"
+}
+
+// handleBatch parses every line as a separate transaction and replies with one summary.
+func (h *Handler) handleBatch(ctx context.Context, update tgbotapi.Update, lines []string) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	sess, err := h.auth.GetSession(ctx, userID)
+	if err != nil || sess == nil || time.Now().After(sess.AccessTokenExpiresAt) {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		return
+	}
+	if h.opCtxs == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Пакетный ввод недоступен", "Batch entry is unavailable")))
+		return
+	}
+
+	var skipped int
+	if len(lines) > maxBatchLines {
+		skipped = len(lines) - maxBatchLines
+		lines = lines[:maxBatchLines]
+	}
+	if h.llmEnabled && h.llm != nil {
+		_, _ = h.bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+	}
+
+	batchID := uuid.NewString()
+	cur := h.defaultCurrency(ctx, userID)
+	now := h.userNow(ctx, userID)
+	categories := map[domain.TransactionType][]*domain.Category{}
+	var (
+		report  []string
+		pending []string
+		saved   int
+		held    int
+	)
+	for i, line := range lines {
+		n := i + 1
+		parsed, _ := h.parser.ParseMessageAt(line, now)
+		if parsed == nil || !parsed.IsValid {
+			reason := tr(locale, "не удалось распознать", "could not parse")
+			if parsed != nil && len(parsed.Errors) > 0 {
+				reason = parsed.Errors[0]
+			}
+			report = append(report, fmt.Sprintf("%d. ⚠️ «%s» — %s", n, line, reason))
+			continue
+		}
+		parsed.ApplyDefaultCurrency(cur)
+		currency := parsed.Currency
+		amount := fmt.Sprintf("%s %s %s%s — %s", txTypeLabel(string(parsed.Type), locale), domain.FormatAmount(parsed.Amount.AmountMinor, currency), currency, expressionSuffix(parsed.Expression), parsed.Description)
+
+		catID, source := "", "manual"
+		if h.matcher != nil {
+			if m, err := h.matcher.FindCategory(ctx, sess.TenantID, parsed.Description); err == nil && m != nil {
+				catID, source = m.CategoryID, "mapping"
+			}
+		}
+		if catID == "" {
+			list, ok := categories[parsed.Type]
+			if !ok {
+				list, err = h.categories.ListCategories(ctx, sess.TenantID, sess.AccessToken, parsed.Type, locale)
+				if err != nil {
+					h.logger.Error("Failed to get categories", zap.Int64("telegramID", userID), zap.Error(err))
+				}
+				categories[parsed.Type] = list
+			}
+			if h.llmEnabled && h.llm != nil && len(list) > 0 {
+				if catID, _, _ = h.suggestCategoryLLM(ctx, parsed.Description, parsed.Type, locale, list); catID != "" {
+					source = "llm"
+				}
+			}
+		}
+
+		// an unusual amount is held like a single transaction and confirmed in its own message
+		if h.holdIfUnusual(ctx, sess, chatID, &repository.TransactionDraft{
+			TelegramID:  userID,
+			Type:        string(parsed.Type),
+			AmountMinor: parsed.Amount.AmountMinor,
+			Currency:    currency,
+			Description: parsed.Description,
+			CategoryID:  catID,
+			OccurredAt:  parsed.OccurredAt,
+			BatchID:     batchID,
+		}, parsed.Expression) {
+			held++
+			report = append(report, fmt.Sprintf("%d. ⏸ %s — %s", n, amount, tr(locale, "ждёт подтверждения суммы", "waiting for the amount to be confirmed")))
+			continue
+		}
+
+		op := &repository.OperationContext{
+			OpID:                uuid.NewString(),
+			TelegramID:          userID,
+			TenantID:            sess.TenantID,
+			DescriptionOriginal: strings.TrimSpace(parsed.Description),
+			SelectionSource:     source,
+			TxType:              string(parsed.Type),
+			AmountMinor:         parsed.Amount.AmountMinor,
+			Currency:            currency,
+			OccurredAt:          parsed.OccurredAt,
+			BatchID:             &batchID,
+		}
+		if catID == "" {
+			if len(categories[parsed.Type]) == 0 {
+				report = append(report, fmt.Sprintf("%d. ⚠️ %s — %s", n, amount, tr(locale, "не удалось получить категории", "failed to load categories")))
+				continue
+			}
+			op.SelectionSource = "manual"
+			_ = h.opCtxs.Create(ctx, op)
+			pending = append(pending, op.OpID)
+			report = append(report, fmt.Sprintf("%d. ❓ %s — %s", n, amount, tr(locale, "выберите категорию ниже", "choose a category below")))
+			continue
+		}
+
+		categoryName := catID
+		if h.nameMapper != nil {
+			if name, err := h.nameMapper.GetCategoryNameByID(ctx, sess.TenantID, sess.AccessToken, catID, parsed.Type, locale); err == nil && name != "" {
+				categoryName = name
+			}
+		}
+		occurredAt := time.Now()
+		if parsed.OccurredAt != nil {
+			occurredAt = *parsed.OccurredAt
+		}
+		txID, err := h.txClient.CreateTransaction(ctx, &grpcclient.CreateTransactionRequest{
+			TenantID:    sess.TenantID,
+			Type:        string(parsed.Type),
+			AmountMinor: parsed.Amount.AmountMinor,
+			Currency:    currency,
+			Description: parsed.Description,
+			CategoryID:  catID,
+			OccurredAt:  occurredAt,
+		}, sess.AccessToken)
+		if err != nil {
+			h.logger.Error("Failed to create transaction",
+				zap.Int64("telegramID", userID),
+				zap.Int("line", n),
+				zap.Error(err))
+			metrics.IncTransactionsSaved("error")
+			report = append(report, fmt.Sprintf("%d. ❌ %s — %s", n, amount, tr(locale, "не удалось сохранить", "failed to save")))
+			continue
+		}
+		op.TransactionID = &txID
+		op.CategoryIDSelected = &catID
+		op.CategoryNameSelected = &categoryName
+		_ = h.opCtxs.Create(ctx, op)
+		metrics.IncCategorySelected(source)
+		metrics.IncTransactionsSaved("ok")
+		saved++
+		line := fmt.Sprintf("%d. ✅ %s (%s)", n, amount, categoryName)
+		preview, alert := h.savedNotes(ctx, sess, sess.TenantID, userID, catID, string(parsed.Type), parsed.Amount.AmountMinor, currency, parsed.OccurredAt, locale)
+		for _, note := range []string{preview, alert} {
+			if note != "" {
+				line += "\n    " + note
+			}
+		}
+		report = append(report, line)
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(tr(locale, "📦 Сохранено %d из %d", "📦 Saved %d of %d"), saved, len(lines)))
+	b.WriteString("\n\n")
+	b.WriteString(strings.Join(report, "\n"))
+	if skipped > 0 {
+		b.WriteString("\n\n")
+		b.WriteString(fmt.Sprintf(tr(locale, "Пропущено строк сверх лимита (%d): %d", "Lines over the limit (%d) skipped: %d"), maxBatchLines, skipped))
+	}
+	msg := tgbotapi.NewMessage(chatID, b.String())
+	if saved > 0 || held > 0 || len(pending) > 0 {
+		msg.ReplyMarkup = ui.CreateBatchUndoKeyboard(batchID, locale)
+	}
+	_, _ = h.send(ctx, msg)
+
+	if len(pending) > 0 {
+		h.promptPendingCategory(ctx, userID, chatID, locale, pending)
+	}
+}
+
+// promptPendingCategory asks for the category of the first pending batch line and keeps the rest in dialog state.
+func (h *Handler) promptPendingCategory(ctx context.Context, userID, chatID int64, locale string, pending []string) bool {
+	for len(pending) > 0 {
+		opID := pending[0]
+		pending = pending[1:]
+		op, err := h.opCtxs.Get(ctx, opID)
+		if err != nil || (op.TransactionID != nil && *op.TransactionID != "") {
+			continue
+		}
+		sess, err := h.auth.GetSession(ctx, userID)
+		if err != nil || sess == nil {
+			return false
+		}
+		txType := domain.TransactionExpense
+		if op.TxType == "income" {
+			txType = domain.TransactionIncome
+		}
+		list, err := h.categories.ListCategories(ctx, op.TenantID, sess.AccessToken, txType, locale)
+		if err != nil || len(list) == 0 {
+			continue
+		}
+		_ = h.states.SetState(ctx, userID, repository.StateWaitingForCategory, map[string]any{
+			"op_id":          opID,
+			"pending_op_ids": pending,
+		}, nil)
+		text := fmt.Sprintf("%s\n%s %s %s — %s",
+			tr(locale, "Выберите категорию:", "Choose a category:"),
+			txTypeLabel(op.TxType, locale),
+			domain.FormatAmount(op.AmountMinor, op.Currency),
+			op.Currency,
+			op.DescriptionOriginal,
+		)
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ReplyMarkup = ui.CreateChangeCategoryKeyboard(list, opID)
+		sent, _ := h.send(ctx, msg)
+		if sent.MessageID != 0 {
+			_ = h.opCtxs.SetCategoryListMessageID(ctx, opID, sent.MessageID)
+		}
+		return true
+	}
+	return false
+}
+
+// pendingFromState extracts the queue of batch lines still waiting for a category.
+func pendingFromState(rec *repository.DialogStateRecord) []string {
+	if rec == nil || rec.Context == nil {
+		return nil
+	}
+	var out []string
+	switch v := rec.Context["pending_op_ids"].(type) {
+	case []string:
+		out = append(out, v...)
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// handleBatchUndoCallback deletes every transaction saved from one multi-line message.
+func (h *Handler) handleBatchUndoCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, batchID string) {
+	locale := h.userLocale(ctx, cb.From.ID)
+	if h.opCtxs == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Недоступно", "Unavailable")))
+		return
+	}
+	ops, err := h.opCtxs.ListByBatch(ctx, batchID)
+	if err != nil || len(ops) == 0 || ops[0].TelegramID != cb.From.ID {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Контекст не найден", "Context not found")))
+		return
+	}
+	sess, err := h.auth.GetSession(ctx, cb.From.ID)
+	if err != nil || sess == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Нет сессии", "No session")))
+		return
+	}
+
+	var deleted, failed int
+	for _, op := range ops {
+		if op.TransactionID != nil && *op.TransactionID != "" {
+			if err := h.txClient.DeleteTransaction(ctx, *op.TransactionID, sess.AccessToken); err != nil {
+				h.logger.Error("Failed to delete transaction",
+					zap.Int64("telegramID", cb.From.ID),
+					zap.String("transactionID", *op.TransactionID),
+					zap.Error(err))
+				failed++
+				continue
+			}
+			deleted++
+		}
+		_ = h.opCtxs.Delete(ctx, op.OpID)
+	}
+	if rec, _ := h.states.GetState(ctx, cb.From.ID); rec != nil && rec.State == repository.StateWaitingForCategory {
+		_ = h.states.ClearState(ctx, cb.From.ID)
+	}
+	metrics.IncTransactionMutation("batch_undo")
+
+	text := fmt.Sprintf(tr(locale, "↩️ Отменено транзакций: %d", "↩️ Transactions undone: %d"), deleted)
+	if failed > 0 {
+		text += "\n" + fmt.Sprintf(tr(locale, "Не удалось удалить: %d", "Failed to delete: %d"), failed)
+	}
+	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Отменено", "Undone")))
+	if cb.Message == nil {
+		return
+	}
+	if failed > 0 {
+		_, _ = h.bot.Request(tgbotapi.NewEditMessageTextAndMarkup(cb.Message.Chat.ID, cb.Message.MessageID, text, ui.CreateBatchUndoKeyboard(batchID, locale)))
+		return
+	}
+	_, _ = h.bot.Request(tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, text))
+}
@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestHandler_BatchEntryAndUndo(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	states := repository.NewSQLiteDialogStateRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	prefs := repository.NewSQLitePreferencesRepository(db)
+	opCtxs := repository.NewSQLiteOperationContextRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &recordingTxClient{}
+
+	h := NewHandler(bot, states, auth, mappings, nil, log).
+		WithPreferences(prefs).
+		WithOperationContexts(opCtxs).
+		WithTransactionClient(tx)
+
+	ctx := context.Background()
+	chatID := int64(8200)
+	userID := int64(82)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	for keyword, cat := range map[string]string{"кофе": "cat-food", "такси": "cat-transport"} {
+		if err := mappings.AddMapping(ctx, &repository.CategoryMapping{ID: keyword, TenantID: "t", Keyword: keyword, CategoryID: cat}); err != nil {
+			t.Fatalf("add mapping: %v", err)
+		}
+	}
+
+	h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: "350 кофе\n1200 такси\n\nпросто текст\n+5000 кэшбэк"}})
+
+	summary := rec.Calls("sendMessage")
+	if len(summary) < 2 {
+		t.Fatalf("expected summary and category prompt, got %d messages", len(summary))
+	}
+	text := summary[0].Params.Get("text")
+	for _, want := range []string{"Сохранено 2 из 4", "1. ✅", "2. ✅", "3. ⚠️", "4. ❓"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("summary %q does not contain %q", text, want)
+		}
+	}
+	var batchID string
+	if markup := summary[0].Params.Get("reply_markup"); strings.Contains(markup, "v1:batch_undo:") {
+		batchID = markup[strings.Index(markup, "v1:batch_undo:")+len("v1:batch_undo:"):]
+		batchID = batchID[:strings.Index(batchID, `"`)]
+	}
+	if batchID == "" {
+		t.Fatalf("undo button missing: %s", summary[0].Params.Get("reply_markup"))
+	}
+
+	// The income line without a mapping waits for a manual category
+	st, _ := states.GetState(ctx, userID)
+	if st == nil || st.State != repository.StateWaitingForCategory {
+		t.Fatalf("expected category selection state, got %+v", st)
+	}
+	h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: &tgbotapi.User{ID: userID}, Message: &tgbotapi.Message{MessageID: 100, Chat: &tgbotapi.Chat{ID: chatID}}, Data: "v1:cat_select:cat-other-income"}})
+	if st, _ := states.GetState(ctx, userID); st != nil {
+		t.Fatalf("state should be cleared after the last pending line, got %+v", st)
+	}
+
+	ops, err := opCtxs.ListByBatch(ctx, batchID)
+	if err != nil || len(ops) != 3 {
+		t.Fatalf("expected 3 batch operations, got %d (%v)", len(ops), err)
+	}
+
+	// Another user cannot undo the batch
+	h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: &tgbotapi.User{ID: 999}, Data: "v1:batch_undo:" + batchID}})
+	if len(tx.deleted) != 0 {
+		t.Fatalf("foreign user must not undo the batch")
+	}
+
+	h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: &tgbotapi.User{ID: userID}, Message: &tgbotapi.Message{MessageID: 100, Chat: &tgbotapi.Chat{ID: chatID}}, Data: "v1:batch_undo:" + batchID}})
+	if len(tx.deleted) != 3 {
+		t.Fatalf("expected 3 deletions, got %v", tx.deleted)
+	}
+	if ops, _ := opCtxs.ListByBatch(ctx, batchID); len(ops) != 0 {
+		t.Fatalf("batch operation contexts should be removed, got %d", len(ops))
+	}
+}
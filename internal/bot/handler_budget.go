@@ -0,0 +1,243 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Budget alert thresholds in percent of the limit.
+const (
+	budgetWarnPercent = 80
+	budgetOverPercent = 100
+)
+
+// parseBudgetPeriod recognizes an optional trailing period argument.
+func parseBudgetPeriod(s string) (string, bool) {
+	switch strings.ToLower(s) {
+	case "month", "месяц", "мес":
+		return repository.BudgetPeriodMonth, true
+	case "week", "неделя", "нед":
+		return repository.BudgetPeriodWeek, true
+	}
+	return "", false
+}
+
+// budgetPeriodRange returns the current month or ISO week containing now.
+func budgetPeriodRange(period string, now time.Time) (time.Time, time.Time) {
+	if period == repository.BudgetPeriodWeek {
+		wd := int(now.Weekday())
+		if wd == 0 {
+			wd = 7
+		}
+		from := time.Date(now.Year(), now.Month(), now.Day()-(wd-1), 0, 0, 0, 0, now.Location())
+		return from, from.AddDate(0, 0, 7).Add(-time.Nanosecond)
+	}
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return from, from.AddDate(0, 1, 0).Add(-time.Nanosecond)
+}
+
+func budgetPeriodLabel(period, locale string) string {
+	if period == repository.BudgetPeriodWeek {
+		return tr(locale, "неделю", "week")
+	}
+	return tr(locale, "месяц", "month")
+}
+
+// findExpenseCategory resolves a category by id or case-insensitive name.
+func findExpenseCategory(list []*domain.Category, query string) *domain.Category {
+	q := strings.ToLower(strings.TrimSpace(query))
+	for _, c := range list {
+		if c.ID == query || strings.ToLower(c.Name) == q {
+			return c
+		}
+	}
+	return nil
+}
+
+// budgetSpent sums expenses of the budget category within its current period. The totals come in the tenant base
+// currency and are converted to the currency of the budget.
+func (h *Handler) budgetSpent(ctx context.Context, b *repository.Budget, accessToken string, now time.Time) (int64, error) {
+	from, to := budgetPeriodRange(b.Period, now)
+	totals, err := h.txClient.GetTransactionsTotals(ctx, &grpcclient.TransactionFilter{
+		From:        from,
+		To:          to,
+		CategoryIDs: []string{b.CategoryID},
+		Type:        string(domain.TransactionExpense),
+	}, accessToken)
+	if err != nil {
+		return 0, err
+	}
+	return h.convertAmount(ctx, absMinor(totals.ExpenseMinor), totals.Currency, b.Currency, now, accessToken)
+}
+
+func (h *Handler) handleBudget(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	if h.budgets == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Бюджеты недоступны", "Budgets are unavailable")))
+		return
+	}
+	sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+	usage := tr(locale,
+		"Использование: /budget <категория> <сумма> [month|week]\nПример: /budget Питание 30000\nУдалить: /budget Питание 0",
+		"Usage: /budget <category> <amount> [month|week]\nExample: /budget Food 30000\nRemove: /budget Food 0")
+	args := strings.Fields(update.Message.CommandArguments())
+	period := repository.BudgetPeriodMonth
+	if len(args) > 0 {
+		if p, ok := parseBudgetPeriod(args[len(args)-1]); ok {
+			period = p
+			args = args[:len(args)-1]
+		}
+	}
+	if len(args) < 2 {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, usage))
+		return
+	}
+	currency := h.defaultCurrency(ctx, userID)
+	amountMinor, err := h.parser.ParseAmountIn(args[len(args)-1], currency)
+	if err != nil && args[len(args)-1] != "0" {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, usage))
+		return
+	}
+	list, err := h.categories.ListCategories(ctx, sess.TenantID, sess.AccessToken, domain.TransactionExpense, locale)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить категории", "Failed to load categories")))
+		return
+	}
+	cat := findExpenseCategory(list, strings.Join(args[:len(args)-1], " "))
+	if cat == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Категория расходов не найдена. Список: /categories", "Expense category not found. See /categories")))
+		return
+	}
+
+	if amountMinor <= 0 {
+		if err := h.budgets.Delete(ctx, sess.TenantID, cat.ID); err != nil {
+			h.logger.Error("failed to delete budget", zap.String("categoryID", cat.ID), zap.Error(err))
+		}
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "Бюджет «%s» удалён", "Budget for %q removed"), cat.Name)))
+		return
+	}
+	b := &repository.Budget{
+		TenantID:     sess.TenantID,
+		CategoryID:   cat.ID,
+		CategoryName: cat.Name,
+		AmountMinor:  amountMinor,
+		Currency:     currency,
+		Period:       period,
+		CreatedBy:    userID,
+	}
+	if err := h.budgets.Upsert(ctx, b); err != nil {
+		h.logger.Error("failed to save budget", zap.String("categoryID", cat.ID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось сохранить бюджет", "Failed to save the budget")))
+		return
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf("%s «%s»: %s / %s",
+		tr(locale, "🎯 Бюджет", "🎯 Budget"),
+		cat.Name,
+		h.fmt.FormatMoney(amountMinor, b.Currency),
+		budgetPeriodLabel(period, locale))))
+}
+
+func (h *Handler) handleBudgets(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	if h.budgets == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Бюджеты недоступны", "Budgets are unavailable")))
+		return
+	}
+	sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+	list, err := h.budgets.ListByTenant(ctx, sess.TenantID)
+	if err != nil {
+		h.logger.Error("failed to list budgets", zap.String("tenantID", sess.TenantID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить бюджеты", "Failed to load budgets")))
+		return
+	}
+	if len(list) == 0 {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Бюджетов нет. Добавьте: /budget <категория> <сумма> [month|week]", "No budgets yet. Add one: /budget <category> <amount> [month|week]")))
+		return
+	}
+	now := h.userNow(ctx, userID)
+	var b strings.Builder
+	b.WriteString(tr(locale, "🎯 Бюджеты:\n", "🎯 Budgets:\n"))
+	for _, bg := range list {
+		spent, err := h.budgetSpent(ctx, bg, sess.AccessToken, now)
+		if err != nil {
+			h.logger.Warn("failed to load budget spending", zap.String("categoryID", bg.CategoryID), zap.Error(err))
+			fmt.Fprintf(&b, "\n%s: %s / %s — %s\n", bg.CategoryName, h.fmt.FormatMoney(bg.AmountMinor, bg.Currency), budgetPeriodLabel(bg.Period, locale), tr(locale, "нет данных", "no data"))
+			continue
+		}
+		pct := float64(spent) / float64(bg.AmountMinor) * 100
+		marker := ""
+		if pct >= budgetOverPercent {
+			marker = " ⛔"
+		} else if pct >= budgetWarnPercent {
+			marker = " ⚠️"
+		}
+		fmt.Fprintf(&b, "\n%s (%s)%s\n%s %.0f%%\n%s / %s\n",
+			bg.CategoryName, budgetPeriodLabel(bg.Period, locale), marker,
+			h.fmt.FormatProgressBar(pct), pct,
+			h.fmt.FormatMoney(spent, bg.Currency), h.fmt.FormatMoney(bg.AmountMinor, bg.Currency))
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, strings.TrimRight(b.String(), "\n")))
+}
+
+// budgetAlert returns a warning when a just-saved expense pushes its category past 80% or 100% of the budget.
+// Period boundaries are taken in the location of now, i.e. the user's timezone. The expense is converted from its
+// currency to the currency of the budget.
+func (h *Handler) budgetAlert(ctx context.Context, tenantID, accessToken, categoryID, txType string, amountMinor int64, currency string, occurredAt *time.Time, now time.Time, locale string) string {
+	if h.budgets == nil || txType != string(domain.TransactionExpense) || categoryID == "" {
+		return ""
+	}
+	b, err := h.budgets.Get(ctx, tenantID, categoryID)
+	if err != nil || b == nil || b.AmountMinor <= 0 {
+		return ""
+	}
+	if from, to := budgetPeriodRange(b.Period, now); occurredAt != nil && (occurredAt.Before(from) || occurredAt.After(to)) {
+		return ""
+	}
+	after, err := h.budgetSpent(ctx, b, accessToken, now)
+	if err != nil {
+		h.logger.Warn("failed to check budget", zap.String("categoryID", categoryID), zap.Error(err))
+		return ""
+	}
+	at := now
+	if occurredAt != nil {
+		at = *occurredAt
+	}
+	amount, err := h.convertAmount(ctx, amountMinor, currency, b.Currency, at, accessToken)
+	if err != nil {
+		h.logger.Warn("failed to convert expense to budget currency", zap.String("from", currency), zap.String("to", b.Currency), zap.Error(err))
+		return ""
+	}
+	before := after - amount
+	crossed := func(percent int64) bool {
+		limit := b.AmountMinor * percent / 100
+		return before < limit && after >= limit
+	}
+	pct := float64(after) / float64(b.AmountMinor) * 100
+	switch {
+	case crossed(budgetOverPercent):
+		return fmt.Sprintf(tr(locale, "⛔ Бюджет «%s» превышен: %s из %s (%.0f%%)", "⛔ Budget for %q exceeded: %s of %s (%.0f%%)"),
+			b.CategoryName, h.fmt.FormatMoney(after, b.Currency), h.fmt.FormatMoney(b.AmountMinor, b.Currency), pct)
+	case crossed(budgetWarnPercent):
+		return fmt.Sprintf(tr(locale, "⚠️ Бюджет «%s» использован на %.0f%%: %s из %s", "⚠️ Budget for %q is %.0f%% used: %s of %s"),
+			b.CategoryName, pct, h.fmt.FormatMoney(after, b.Currency), h.fmt.FormatMoney(b.AmountMinor, b.Currency))
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// spendingTxClient accumulates created expenses per category and reports them as totals in RUB; amounts in other
+// currencies are multiplied by their rate in toRUB.
+type spendingTxClient struct {
+	grpcclient.FakeTransactionClient
+	spent   map[string]int64
+	toRUB   map[string]int64
+	filters []*grpcclient.TransactionFilter
+}
+
+func (c *spendingTxClient) CreateTransaction(ctx context.Context, req *grpcclient.CreateTransactionRequest, token string) (string, error) {
+	if req.Type == "expense" {
+		amount := req.AmountMinor
+		if rate, ok := c.toRUB[req.Currency]; ok {
+			amount *= rate
+		}
+		c.spent[req.CategoryID] += amount
+	}
+	return c.FakeTransactionClient.CreateTransaction(ctx, req, token)
+}
+
+func (c *spendingTxClient) GetTransactionsTotals(_ context.Context, f *grpcclient.TransactionFilter, _ string) (*grpcclient.TransactionTotals, error) {
+	c.filters = append(c.filters, f)
+	var sum int64
+	for _, id := range f.CategoryIDs {
+		sum += c.spent[id]
+	}
+	return &grpcclient.TransactionTotals{ExpenseMinor: sum, Currency: "RUB"}, nil
+}
+
+func TestHandler_BudgetsAndAlerts(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	states := repository.NewSQLiteDialogStateRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	prefs := repository.NewSQLitePreferencesRepository(db)
+	budgets := repository.NewSQLiteBudgetRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &spendingTxClient{spent: map[string]int64{}}
+
+	h := NewHandler(bot, states, auth, mappings, nil, log).
+		WithPreferences(prefs).
+		WithOperationContexts(repository.NewSQLiteOperationContextRepository(db)).
+		WithTransactionClient(tx).
+		WithBudgets(budgets)
+
+	ctx := context.Background()
+	chatID := int64(8600)
+	userID := int64(86)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	if err := mappings.AddMapping(ctx, &repository.CategoryMapping{ID: "m1", TenantID: "t", Keyword: "кофе", CategoryID: "cat-food"}); err != nil {
+		t.Fatalf("add mapping: %v", err)
+	}
+	send := func(text string) string {
+		msg := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text}
+		if strings.HasPrefix(text, "/") {
+			msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}
+		}
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: msg})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+
+	if got := send("/budget Такси 1000"); !strings.Contains(got, "не найдена") {
+		t.Fatalf("unknown category must be rejected, got %q", got)
+	}
+	if got := send("/budget питание 1000 week"); !strings.Contains(got, "1000.00 RUB / неделю") {
+		t.Fatalf("unexpected budget confirmation: %q", got)
+	}
+	if b, err := budgets.Get(ctx, "t", "cat-food"); err != nil || b.AmountMinor != 100000 || b.Period != repository.BudgetPeriodWeek {
+		t.Fatalf("budget not stored: %+v %v", b, err)
+	}
+
+	if got := send("700 кофе"); strings.Contains(got, "Бюджет") {
+		t.Fatalf("no alert expected below 80%%: %q", got)
+	}
+	if got := send("150 кофе"); !strings.Contains(got, "⚠️ Бюджет «Питание» использован на 85%") {
+		t.Fatalf("expected 80%% warning: %q", got)
+	}
+	if got := send("10 кофе"); strings.Contains(got, "Бюджет") {
+		t.Fatalf("warning must fire only when crossing the threshold: %q", got)
+	}
+	// lines of a batch get the alert of the line that crosses the budget
+	if got := send("100 кофе\n100 кофе"); !strings.Contains(got, "(Питание)\n2. ✅ расход 100.00 RUB — кофе (Питание)\n    ⛔ Бюджет «Питание» превышен") {
+		t.Fatalf("expected over-budget alert on the second line: %q", got)
+	}
+	last := tx.filters[len(tx.filters)-1]
+	if last.Type != "expense" || last.From.Weekday() != time.Monday || len(last.CategoryIDs) != 1 {
+		t.Fatalf("unexpected totals filter: %+v", last)
+	}
+
+	overview := send("/budgets")
+	for _, want := range []string{"Питание (неделю) ⛔", "▓▓▓▓▓▓▓▓▓▓ 106%", "1060.00 RUB / 1000.00 RUB"} {
+		if !strings.Contains(overview, want) {
+			t.Fatalf("overview %q does not contain %q", overview, want)
+		}
+	}
+
+	send("/budget Питание 0")
+	if got := send("/budgets"); !strings.Contains(got, "Бюджетов нет") {
+		t.Fatalf("budget should be removed: %q", got)
+	}
+}
+
+func TestHandler_BudgetInOtherCurrency(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	prefs := repository.NewSQLitePreferencesRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	// 70 000 RUB are already spent; the tenant reports totals in RUB while the user counts in USD
+	tx := &spendingTxClient{spent: map[string]int64{"cat-food": 7000000}, toRUB: map[string]int64{"USD": 100}}
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, mappings, nil, log).
+		WithPreferences(prefs).
+		WithOperationContexts(repository.NewSQLiteOperationContextRepository(db)).
+		WithTransactionClient(tx).
+		WithFxClient(&stubFxClient{rates: map[string]float64{"RUB|USD": 0.01, "USD|RUB": 100}}).
+		WithBudgets(repository.NewSQLiteBudgetRepository(db))
+
+	ctx := context.Background()
+	chatID, userID := int64(8610), int64(861)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	if err := prefs.SavePreferences(ctx, &repository.UserPreferences{TelegramID: userID, Language: "ru", DefaultCurrency: "USD"}); err != nil {
+		t.Fatalf("save preferences: %v", err)
+	}
+	if err := mappings.AddMapping(ctx, &repository.CategoryMapping{ID: "m1", TenantID: "t", Keyword: "кофе", CategoryID: "cat-food"}); err != nil {
+		t.Fatalf("add mapping: %v", err)
+	}
+	send := func(text string) string {
+		msg := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text}
+		if strings.HasPrefix(text, "/") {
+			msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}
+		}
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: msg})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+
+	send("/budget питание 1000 week")
+	if got := send("150 кофе"); !strings.Contains(got, "⚠️ Бюджет «Питание» использован на 85%: 850.00 USD из 1000.00 USD") {
+		t.Fatalf("expected 80%% warning in USD: %q", got)
+	}
+	if got := send("/budgets"); !strings.Contains(got, "850.00 USD / 1000.00 USD") {
+		t.Fatalf("overview must be in the budget currency: %q", got)
+	}
+}
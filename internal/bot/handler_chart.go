@@ -0,0 +1,220 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"budget-bot/internal/chart"
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Chart sizes in pixels and the /trend range in months.
+const (
+	chartWidth       = 800
+	chartHeight      = 420
+	donutSize        = 480
+	trendMonths      = 12
+	maxTrendMonths   = 24
+	photoCaptionSize = 1024
+)
+
+// chartRequested removes the "chart" argument of /stats and /top_categories and tells whether it was given.
+func chartRequested(args []string) ([]string, bool) {
+	out := make([]string, 0, len(args))
+	found := false
+	for _, a := range args {
+		if strings.EqualFold(a, "chart") || strings.EqualFold(a, "график") {
+			found = true
+			continue
+		}
+		out = append(out, a)
+	}
+	return out, found
+}
+
+// majorUnits converts minor units to a chart value.
+func majorUnits(minor int64, currency string) float64 {
+	return float64(minor) / math.Pow10(domain.CurrencyExponent(currency))
+}
+
+// captionLines joins caption lines, leaving out the ones that do not fit into a photo caption.
+func captionLines(lines []string) string {
+	out := ""
+	for _, l := range lines {
+		next := l
+		if out != "" {
+			next = out + "\n" + l
+		}
+		if len([]rune(next)) > photoCaptionSize {
+			break
+		}
+		out = next
+	}
+	return out
+}
+
+// sendChart sends a rendered chart as a photo, or a text reply when there is nothing to draw or rendering fails.
+func (h *Handler) sendChart(ctx context.Context, chatID int64, png []byte, err error, caption, locale string) {
+	if err != nil {
+		if err != chart.ErrNoData {
+			h.logger.Error("failed to render chart", zap.Error(err))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось построить график", "Failed to draw the chart")))
+			return
+		}
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, caption+tr(locale, "\n\nНет данных для графика", "\n\nNo data to chart")))
+		return
+	}
+	msg := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "chart.png", Bytes: png})
+	msg.Caption = caption
+	_, _ = h.send(ctx, msg)
+}
+
+// dailyExpenses returns the expenses of every day of the period in the base currency. Transactions in other
+// currencies are converted at their date; when rates are unavailable they are left out and skipped is set.
+func (h *Handler) dailyExpenses(ctx context.Context, sess *repository.UserSession, period reportPeriod, base string) (days []int64, skipped bool, err error) {
+	loc := period.From.Location()
+	// rounded, as days around DST changes are 23 or 25 hours long
+	n := int(math.Round(period.To.Add(time.Nanosecond).Sub(period.From).Hours() / 24))
+	days = make([]int64, n)
+	filter := &grpcclient.TransactionFilter{From: period.From, To: period.To, Type: string(domain.TransactionExpense)}
+	for page := 1; ; page++ {
+		res, err := h.txClient.ListTransactions(ctx, filter, page, statsPageSize, sess.AccessToken)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, t := range res.Transactions {
+			at := t.GetOccurredAt().AsTime().In(loc)
+			day := int(math.Round(time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, loc).Sub(period.From).Hours() / 24))
+			if day < 0 || day >= n {
+				continue
+			}
+			amount := absMinor(t.GetAmount().GetMinorUnits())
+			if cur := t.GetAmount().GetCurrencyCode(); cur != base {
+				if h.fx == nil {
+					skipped = true
+					continue
+				}
+				if amount, err = h.fx.ConvertToBaseCurrency(ctx, amount, cur, base, at, sess.AccessToken); err != nil {
+					h.logger.Warn("chart: conversion failed", zap.String("from", cur), zap.String("to", base), zap.Error(err))
+					skipped = true
+					continue
+				}
+			}
+			days[day] += amount
+		}
+		if len(res.Transactions) == 0 || page >= res.TotalPages {
+			break
+		}
+	}
+	return days, skipped, nil
+}
+
+// sendSpendingChart sends the cumulative spend of the period as a line chart captioned with the stats text.
+func (h *Handler) sendSpendingChart(ctx context.Context, chatID int64, sess *repository.UserSession, period reportPeriod, base, text, locale string) {
+	days, skipped, err := h.dailyExpenses(ctx, sess, period, base)
+	if err != nil {
+		h.logger.Error("chart: failed to list expenses", zap.String("tenantID", sess.TenantID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, text))
+		return
+	}
+	oneMonth := period.From.Year() == period.To.Year() && period.From.Month() == period.To.Month()
+	values := make([]float64, len(days))
+	labels := make([]string, len(days))
+	var total int64
+	for i, d := range days {
+		total += d
+		values[i] = majorUnits(total, base)
+		day := period.From.AddDate(0, 0, i)
+		labels[i] = day.Format("02.01")
+		if oneMonth {
+			labels[i] = day.Format("02")
+		}
+	}
+	lines := []string{text, "", fmt.Sprintf(tr(locale, "%s Накопленные расходы по дням, %s", "%s Cumulative spending by day, %s"), chart.Expense.Marker, base)}
+	if skipped {
+		lines = append(lines, tr(locale, "⚠️ Операции в других валютах не учтены: курсы недоступны", "⚠️ Transactions in other currencies are left out: rates are unavailable"))
+	}
+	png, err := chart.Line(values, labels, chart.Expense, chartWidth, chartHeight)
+	h.sendChart(ctx, chatID, png, err, captionLines(lines), locale)
+}
+
+// sendCategoryChart sends the expense shares of all categories of the period as a donut chart.
+func (h *Handler) sendCategoryChart(ctx context.Context, chatID int64, sess *repository.UserSession, period reportPeriod, locale string) {
+	items, err := h.periodTopCategories(ctx, sess, period.From, period.To, 0, locale)
+	if err != nil {
+		h.logger.Error("chart: failed to load categories", zap.String("tenantID", sess.TenantID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить топ категорий", "Failed to load top categories")))
+		return
+	}
+	currency := h.defaultCurrency(ctx, sess.TelegramID)
+	slices := make([]chart.Slice, 0, len(items))
+	var total int64
+	for _, it := range items {
+		currency = it.Currency
+		total += it.SumMinor
+		slices = append(slices, chart.Slice{Label: it.Name, Value: float64(it.SumMinor)})
+	}
+	slices = chart.Shares(slices, tr(locale, "Остальное", "Other"))
+	lines := []string{fmt.Sprintf(tr(locale, "Расходы по категориям за %s: %s %s", "Spending by category for %s: %s %s"), period.Label, domain.FormatAmount(total, currency), currency), ""}
+	for _, s := range slices {
+		lines = append(lines, fmt.Sprintf("%s %s — %.0f%% · %s %s", s.Color.Marker, s.Label, s.Value/float64(total)*100, domain.FormatAmount(int64(s.Value), currency), currency))
+	}
+	png, err := chart.Donut(slices, chart.Compact(majorUnits(total, currency)), donutSize)
+	h.sendChart(ctx, chatID, png, err, captionLines(lines), locale)
+}
+
+// handleTrend draws income and expense of the last months as bars: /trend [12m].
+func (h *Handler) handleTrend(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+	months := trendMonths
+	if arg := strings.TrimSpace(update.Message.CommandArguments()); arg != "" {
+		n, err := strconv.Atoi(strings.TrimRight(strings.ToLower(arg), "mм"))
+		if err != nil || n < 2 || n > maxTrendMonths {
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "Формат: /trend [число месяцев от 2 до %d], например /trend 12m", "Usage: /trend [2 to %d months], e.g. /trend 12m"), maxTrendMonths)))
+			return
+		}
+		months = n
+	}
+	now := h.userNow(ctx, userID)
+	first := monthPeriod(now).From.AddDate(0, -(months - 1), 0)
+	groups := make([]chart.BarGroup, 0, months)
+	var income, expense int64
+	currency := ""
+	for i := 0; i < months; i++ {
+		p := monthPeriod(first.AddDate(0, i, 0))
+		st, err := h.periodStats(ctx, sess, p.From, p.To)
+		if err != nil {
+			h.logger.Error("trend: failed to load stats", zap.String("tenantID", sess.TenantID), zap.String("month", p.Label), zap.Error(err))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить статистику", "Failed to load statistics")))
+			return
+		}
+		if currency == "" {
+			currency = st.Currency
+		}
+		income += st.TotalIncome
+		expense += st.TotalExpense
+		groups = append(groups, chart.BarGroup{Label: p.From.Format("01"), Values: []float64{majorUnits(st.TotalIncome, st.Currency), majorUnits(st.TotalExpense, st.Currency)}})
+	}
+	last := first.AddDate(0, months-1, 0)
+	lines := []string{
+		fmt.Sprintf(tr(locale, "📊 Доходы и расходы за %d мес. (%s–%s)", "📊 Income and expenses for %d months (%s–%s)"), months, first.Format("01.2006"), last.Format("01.2006")),
+		fmt.Sprintf("%s %s: %s · %s %s: %s", chart.Income.Marker, tr(locale, "Доходы", "Income"), h.fmt.FormatMoney(income, currency), chart.Expense.Marker, tr(locale, "Расходы", "Expenses"), h.fmt.FormatMoney(expense, currency)),
+		fmt.Sprintf(tr(locale, "В среднем за месяц: доходы %s, расходы %s", "Monthly average: income %s, expenses %s"), h.fmt.FormatMoney(income/int64(months), currency), h.fmt.FormatMoney(expense/int64(months), currency)),
+	}
+	png, err := chart.Bars(groups, []chart.Color{chart.Income, chart.Expense}, chartWidth, chartHeight)
+	h.sendChart(ctx, chatID, png, err, captionLines(lines), locale)
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestHandler_Charts(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &pagingTxClient{}
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, repository.NewSQLiteCategoryMappingRepository(db), nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithTransactionClient(tx)
+
+	ctx := context.Background()
+	chatID, userID := int64(9400), int64(94)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token1234567", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	// photo sends the command and returns the caption of the chart it replied with
+	photo := func(text string) string {
+		t.Helper()
+		before := len(rec.Calls("sendPhoto"))
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text, Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}}})
+		photos := rec.Calls("sendPhoto")
+		if len(photos) != before+1 {
+			t.Fatalf("%s: a chart expected, got %q", text, rec.Texts())
+		}
+		if _, err := png.Decode(bytes.NewReader(photos[before].Files["photo"])); err != nil {
+			t.Fatalf("%s: the chart must be a png: %v", text, err)
+		}
+		return photos[before].Params.Get("caption")
+	}
+
+	if got := photo("/stats chart 2025-02"); !strings.HasPrefix(got, "Статистика 2025-02") || !strings.Contains(got, "🟥 Накопленные расходы по дням, RUB") {
+		t.Fatalf("unexpected stats chart caption: %q", got)
+	}
+	if tx.filter.Type != "expense" || !tx.filter.From.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, tx.filter.From.Location())) {
+		t.Fatalf("the chart must list the expenses of the period: %+v", tx.filter)
+	}
+	if got := photo("/top_categories 2025-02-01..2025-02-20 график"); !strings.HasPrefix(got, "Расходы по категориям за 01.02.2025–20.02.2025: 7200.00 RUB") || !strings.Contains(got, "🟦 Питание — 100% · 7200.00 RUB") {
+		t.Fatalf("unexpected category chart caption: %q", got)
+	}
+	if got := photo("/trend 3m"); !strings.HasPrefix(got, "📊 Доходы и расходы за 3 мес.") || !strings.Contains(got, "🟩 Доходы") {
+		t.Fatalf("unexpected trend caption: %q", got)
+	}
+
+	h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: "/trend 40m", Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 6}}}})
+	if texts := rec.Texts(); !strings.Contains(texts[len(texts)-1], "Формат: /trend") {
+		t.Fatalf("usage expected: %q", texts[len(texts)-1])
+	}
+}
+
+func TestCaptionLines(t *testing.T) {
+	lines := []string{strings.Repeat("a", 600), strings.Repeat("b", 400), strings.Repeat("c", 100)}
+	if got := captionLines(lines); len(got) != 1001 || strings.Contains(got, "c") {
+		t.Fatalf("lines past the caption limit must be left out: %d", len(got))
+	}
+}
@@ -0,0 +1,206 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"budget-bot/internal/domain"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Limits of the /compare report: the movers named in the summary and the category lines listed.
+const (
+	compareMovers   = 3
+	compareMaxLines = 20
+)
+
+// categoryDelta is the spending of one category in the compared period and in the base period.
+type categoryDelta struct {
+	name          string
+	cur, base     int64
+	inCur, inBase bool
+}
+
+func (d categoryDelta) change() int64 { return d.cur - d.base }
+
+// parseComparePeriods reads "/compare [periodA] [periodB]". Without periods the current month is compared with
+// the previous one; with one period, that period is compared with the one before it.
+func (h *Handler) parseComparePeriods(args []string, now time.Time, locale string) (cur, base reportPeriod, err error) {
+	groups := splitPeriodArgs(args)
+	if len(groups) > 2 {
+		return cur, base, errors.New(tr(locale, "укажите не больше двух периодов", "specify at most two periods"))
+	}
+	periods := make([]reportPeriod, 0, 2)
+	for _, g := range groups {
+		p, rest, err := h.parseReportPeriod(g, now, locale)
+		if err != nil {
+			return cur, base, err
+		}
+		if len(rest) > 0 {
+			return cur, base, fmt.Errorf(tr(locale, "непонятный период %q", "unknown period %q"), strings.Join(rest, " "))
+		}
+		periods = append(periods, p)
+	}
+	switch len(periods) {
+	case 0:
+		cur = monthPeriod(now)
+		return cur, previousReportPeriod(cur), nil
+	case 1:
+		return periods[0], previousReportPeriod(periods[0]), nil
+	}
+	return periods[0], periods[1], nil
+}
+
+// compareCategories matches the categories of both periods by id (by name when the id is missing)
+// and orders them by the size of the change.
+func compareCategories(cur, base []*domain.CategoryTotal) []categoryDelta {
+	index := map[string]int{}
+	var out []categoryDelta
+	get := func(c *domain.CategoryTotal) *categoryDelta {
+		key := c.CategoryID
+		if key == "" {
+			key = "name:" + c.Name
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(out)
+			index[key] = i
+			out = append(out, categoryDelta{name: c.Name})
+		}
+		return &out[i]
+	}
+	for _, c := range cur {
+		d := get(c)
+		d.cur += c.SumMinor
+		d.inCur = d.inCur || c.SumMinor != 0
+	}
+	for _, c := range base {
+		d := get(c)
+		d.base += c.SumMinor
+		d.inBase = d.inBase || c.SumMinor != 0
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := absMinor(out[i].change()), absMinor(out[j].change())
+		if a != b {
+			return a > b
+		}
+		return out[i].name < out[j].name
+	})
+	return out
+}
+
+// money formats an amount with its currency code.
+func money(minor int64, currency string) string {
+	return domain.FormatAmount(minor, currency) + " " + currency
+}
+
+// signedMoney formats a change with an explicit sign.
+func signedMoney(minor int64, currency string) string {
+	if minor > 0 {
+		return "+" + money(minor, currency)
+	}
+	return money(minor, currency)
+}
+
+// percentChange formats the change relative to base, or nothing without a base.
+func percentChange(cur, base int64) string {
+	if base == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%+.0f%%)", float64(cur-base)/float64(base)*100)
+}
+
+// formatComparison renders the spending of cur against base by category.
+func formatComparison(cur, base reportPeriod, deltas []categoryDelta, currency, locale string) string {
+	var b strings.Builder
+	var curTotal, baseTotal int64
+	for _, d := range deltas {
+		curTotal += d.cur
+		baseTotal += d.base
+	}
+	fmt.Fprintf(&b, tr(locale, "⚖️ Расходы: %s против %s\n", "⚖️ Spending: %s vs %s\n"), cur.Label, base.Label)
+	fmt.Fprintf(&b, tr(locale, "Итого: %s против %s · %s%s\n", "Total: %s vs %s · %s%s\n"),
+		money(curTotal, currency), money(baseTotal, currency), signedMoney(curTotal-baseTotal, currency), percentChange(curTotal, baseTotal))
+	if len(deltas) == 0 {
+		b.WriteString(tr(locale, "\nНет расходов ни в одном из периодов", "\nNo spending in either period"))
+		return b.String()
+	}
+	var movers []string
+	for _, d := range deltas {
+		if len(movers) == compareMovers || d.change() == 0 {
+			break
+		}
+		movers = append(movers, d.name+" "+signedMoney(d.change(), currency))
+	}
+	if len(movers) > 0 {
+		fmt.Fprintf(&b, tr(locale, "\nСильнее всего изменились: %s\n", "\nBiggest movers: %s\n"), strings.Join(movers, ", "))
+	}
+	b.WriteString("\n")
+	for i, d := range deltas {
+		if i == compareMaxLines {
+			fmt.Fprintf(&b, tr(locale, "… и ещё категорий: %d\n", "… and %d more categories\n"), len(deltas)-i)
+			break
+		}
+		switch {
+		case !d.inBase:
+			fmt.Fprintf(&b, tr(locale, "🆕 %s: %s · только в %s\n", "🆕 %s: %s · only in %s\n"), d.name, money(d.cur, currency), cur.Label)
+		case !d.inCur:
+			fmt.Fprintf(&b, tr(locale, "✖️ %s: нет расходов, было %s · только в %s\n", "✖️ %s: no spending, was %s · only in %s\n"), d.name, money(d.base, currency), base.Label)
+		default:
+			marker := "▫️"
+			if d.change() > 0 {
+				marker = "🔺"
+			} else if d.change() < 0 {
+				marker = "🔻"
+			}
+			fmt.Fprintf(&b, "%s %s: %s ← %s · %s%s\n", marker, d.name, money(d.cur, currency), money(d.base, currency), signedMoney(d.change(), currency), percentChange(d.cur, d.base))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// handleCompare compares the spending by category of two periods: /compare [periodA] [periodB].
+func (h *Handler) handleCompare(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+	cur, base, err := h.parseComparePeriods(strings.Fields(update.Message.CommandArguments()), h.userNow(ctx, userID), locale)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale,
+			"Не удалось разобрать периоды: %v\nФормат: /compare [период] [период для сравнения], например /compare 2025-10 2025-09\n%s",
+			"Failed to parse the periods: %v\nUsage: /compare [period] [period to compare with], e.g. /compare 2025-10 2025-09\n%s"), err, statsUsage(locale, "/compare"))))
+		return
+	}
+	curItems, err := h.periodTopCategories(ctx, sess, cur.From, cur.To, 0, locale)
+	if err == nil {
+		var baseItems []*domain.CategoryTotal
+		if baseItems, err = h.periodTopCategories(ctx, sess, base.From, base.To, 0, locale); err == nil {
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, formatComparison(cur, base, compareCategories(curItems, baseItems), h.compareCurrency(ctx, sess, curItems, baseItems), locale)))
+			return
+		}
+	}
+	h.logger.Error("compare: failed to load categories", zap.String("tenantID", sess.TenantID), zap.Error(err))
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить статистику", "Failed to load statistics")))
+}
+
+// compareCurrency is the currency of the category totals, the user's default one when there are none.
+func (h *Handler) compareCurrency(ctx context.Context, sess *repository.UserSession, lists ...[]*domain.CategoryTotal) string {
+	for _, l := range lists {
+		for _, c := range l {
+			if c.Currency != "" {
+				return c.Currency
+			}
+		}
+	}
+	return h.defaultCurrency(ctx, sess.TelegramID)
+}
@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// monthlyTopReportClient returns the category totals of the month the range starts in.
+type monthlyTopReportClient struct {
+	grpcclient.FakeReportClient
+	months map[string][]*domain.CategoryTotal
+}
+
+func (c *monthlyTopReportClient) TopCategories(_ context.Context, _ string, from, _ time.Time, _ int, _ string) ([]*domain.CategoryTotal, error) {
+	return c.months[from.Format("2006-01")], nil
+}
+
+func TestPreviousReportPeriod(t *testing.T) {
+	now := time.Date(2025, 5, 14, 12, 0, 0, 0, time.UTC)
+	h := &Handler{parser: NewMessageParser()}
+	cases := map[string]string{
+		"2025-03":                "2025-02",
+		"2025":                   "2024",
+		"2025-q1":                "Q4 2024 (01.10.2024–31.12.2024)",
+		"2025-02-10..2025-02-16": "03.02.2025–09.02.2025",
+	}
+	for in, want := range cases {
+		p, _, err := h.parseReportPeriod(strings.Fields(in), now, "ru")
+		if err != nil {
+			t.Fatalf("%s: %v", in, err)
+		}
+		if got := previousReportPeriod(p); got.Label != want || !got.To.Add(time.Nanosecond).Equal(p.From) {
+			t.Errorf("previousReportPeriod(%s) = %q %v..%v", in, got.Label, got.From, got.To)
+		}
+	}
+	if got := splitPeriodArgs([]string{"last", "30d", "2025-01"}); len(got) != 2 || len(got[0]) != 2 {
+		t.Fatalf("unexpected groups: %q", got)
+	}
+}
+
+func TestHandler_Compare(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	report := &monthlyTopReportClient{months: map[string][]*domain.CategoryTotal{
+		"2025-10": {
+			{CategoryID: "cat-food", Name: "Питание", SumMinor: 1500000, Currency: "RUB"},
+			{CategoryID: "cat-travel", Name: "Путешествия", SumMinor: 3000000, Currency: "RUB"},
+			{CategoryID: "cat-home", Name: "Дом", SumMinor: 500000, Currency: "RUB"},
+		},
+		"2025-09": {
+			{CategoryID: "cat-food", Name: "Питание", SumMinor: 1000000, Currency: "RUB"},
+			{CategoryID: "cat-home", Name: "Дом", SumMinor: 500000, Currency: "RUB"},
+			{CategoryID: "cat-cafe", Name: "Кафе", SumMinor: 800000, Currency: "RUB"},
+		},
+	}}
+	prefs := repository.NewSQLitePreferencesRepository(db)
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, repository.NewSQLiteCategoryMappingRepository(db), nil, log).
+		WithPreferences(prefs).
+		WithReportClient(report)
+
+	ctx := context.Background()
+	chatID, userID := int64(9500), int64(95)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token1234567", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	command := func(text string) string {
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text, Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}}})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+
+	got := command("/compare 2025-10")
+	for _, want := range []string{
+		"⚖️ Расходы: 2025-10 против 2025-09",
+		"Итого: 50000.00 RUB против 23000.00 RUB · +27000.00 RUB (+117%)",
+		"Сильнее всего изменились: Путешествия +30000.00 RUB, Кафе -8000.00 RUB, Питание +5000.00 RUB",
+		"🆕 Путешествия: 30000.00 RUB · только в 2025-10",
+		"✖️ Кафе: нет расходов, было 8000.00 RUB · только в 2025-09",
+		"🔺 Питание: 15000.00 RUB ← 10000.00 RUB · +5000.00 RUB (+50%)",
+		"▫️ Дом: 5000.00 RUB ← 5000.00 RUB · 0.00 RUB (+0%)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "Путешествия: ") > strings.Index(got, "Кафе: ") || strings.Index(got, "Кафе: ") > strings.Index(got, "Питание: ") {
+		t.Fatalf("categories must be ordered by the change:\n%s", got)
+	}
+	if got := command("/compare 2025-09 2025-10"); !strings.Contains(got, "🔻 Питание: 10000.00 RUB ← 15000.00 RUB · -5000.00 RUB (-33%)") {
+		t.Fatalf("unexpected reverse comparison:\n%s", got)
+	}
+	if got := command("/compare 2025-10 2025-09 2025-08"); !strings.Contains(got, "не больше двух периодов") {
+		t.Fatalf("usage expected: %q", got)
+	}
+
+	if err := prefs.SavePreferences(ctx, &repository.UserPreferences{TelegramID: userID, Language: "en", DefaultCurrency: "RUB"}); err != nil {
+		t.Fatalf("set language: %v", err)
+	}
+	if got := command("/compare 2025-10 2025-09"); !strings.Contains(got, "Biggest movers: Путешествия") || !strings.Contains(got, "🆕 Путешествия: 30000.00 RUB · only in 2025-10") {
+		t.Fatalf("unexpected english comparison:\n%s", got)
+	}
+}
@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestMessageParser_CurrencyPrecision(t *testing.T) {
+	p := NewMessageParser()
+	cases := []struct {
+		in       string
+		currency string
+		minor    int64
+	}{
+		{"1500 JPY рамен", "JPY", 1500},
+		{"1500.7 йен рамен", "JPY", 1500},
+		{"50 лари хинкали", "GEL", 5000},
+		{"1.250 KWD", "KWD", 1250},
+		{"2x3.5 евро кофе", "EUR", 700},
+	}
+	for _, c := range cases {
+		res, _ := p.ParseMessage(c.in)
+		if !res.IsValid || res.Currency != c.currency || res.Amount.AmountMinor != c.minor {
+			t.Errorf("%q: got %+v %+v", c.in, res, res.Amount)
+		}
+	}
+
+	res, _ := p.ParseMessage("1500,50 рамен")
+	res.ApplyDefaultCurrency("JPY")
+	if res.Currency != "JPY" || res.Amount.AmountMinor != 1500 || res.Amount.CurrencyCode != "JPY" {
+		t.Fatalf("default currency must rescale the amount: %+v", res.Amount)
+	}
+	res.ApplyDefaultCurrency("USD")
+	if res.Currency != "JPY" {
+		t.Fatalf("explicit currency must not be replaced")
+	}
+}
+
+func TestHandler_DefaultCurrencyPrecision(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	prefs := repository.NewSQLitePreferencesRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &createRecordingTxClient{}
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, mappings, nil, log).
+		WithPreferences(prefs).
+		WithOperationContexts(repository.NewSQLiteOperationContextRepository(db)).
+		WithTransactionClient(tx)
+
+	ctx := context.Background()
+	chatID := int64(8630)
+	userID := int64(86)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	if err := mappings.AddMapping(ctx, &repository.CategoryMapping{ID: "m1", TenantID: "t", Keyword: "рамен", CategoryID: "cat-food"}); err != nil {
+		t.Fatalf("add mapping: %v", err)
+	}
+	send := func(text string) string {
+		msg := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text}
+		if strings.HasPrefix(text, "/") {
+			msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}
+		}
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: msg})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+
+	if got := send("/currency XYZ"); !strings.Contains(got, "Неизвестная валюта") {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+	if got := send("/currency jpy"); !strings.Contains(got, "JPY") {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+	if p, _ := prefs.GetPreferences(ctx, userID); p.DefaultCurrency != "JPY" {
+		t.Fatalf("default currency not saved: %+v", p)
+	}
+
+	got := send("1500 рамен")
+	if len(tx.created) != 1 || tx.created[0].AmountMinor != 1500 || tx.created[0].Currency != "JPY" {
+		t.Fatalf("unexpected transaction: %+v", tx.created)
+	}
+	if !strings.Contains(got, "1500 JPY — рамен") {
+		t.Fatalf("JPY must be shown without fraction digits: %q", got)
+	}
+
+	send("40 лари рамен")
+	if last := tx.created[len(tx.created)-1]; last.AmountMinor != 4000 || last.Currency != "GEL" {
+		t.Fatalf("unexpected transaction: %+v", last)
+	}
+}
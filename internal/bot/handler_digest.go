@@ -0,0 +1,292 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"budget-bot/internal/domain"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/scheduler"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// digestBatchSize limits how many due digests a single worker pass sends.
+const digestBatchSize = 50
+
+// digestTopLimit is the number of top expense categories shown in a digest.
+const digestTopLimit = 3
+
+// digestRetryWindow is how long a digest that failed to send is retried before its occurrence is skipped.
+const digestRetryWindow = 12 * time.Hour
+
+func digestUsage(locale string) string {
+	return tr(locale,
+		"Дайджесты:\n"+
+			"/digest - список подписок\n"+
+			"/digest daily [HH:MM] [часовой пояс]\n"+
+			"/digest weekly <день> [HH:MM] [часовой пояс]\n"+
+			"/digest monthly <число> [HH:MM] [часовой пояс]\n"+
+			"/digest off [daily|weekly|monthly] - отписаться\n\n"+
+			"Пример: /digest weekly mon 09:00 Europe/Moscow\n"+
+			"Дайджест приходит за прошедший день, 7 дней или месяц до дня отправки",
+		"Digests:\n"+
+			"/digest - list subscriptions\n"+
+			"/digest daily [HH:MM] [timezone]\n"+
+			"/digest weekly <day> [HH:MM] [timezone]\n"+
+			"/digest monthly <day> [HH:MM] [timezone]\n"+
+			"/digest off [daily|weekly|monthly] - unsubscribe\n\n"+
+			"Example: /digest weekly mon 09:00 Europe/Moscow\n"+
+			"A digest covers the day, 7 days or month before the sending day")
+}
+
+func digestFrequencyLabel(frequency, locale string) string {
+	switch frequency {
+	case repository.DigestDaily:
+		return tr(locale, "ежедневный", "daily")
+	case repository.DigestMonthly:
+		return tr(locale, "ежемесячный", "monthly")
+	default:
+		return tr(locale, "еженедельный", "weekly")
+	}
+}
+
+// digestPeriod returns the period a digest sent at due reports on: the whole days before the sending day.
+// Both bounds are inclusive, like budgetPeriodRange.
+func digestPeriod(frequency string, due time.Time) (time.Time, time.Time) {
+	end := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, due.Location())
+	return previousPeriod(frequency, end), end.Add(-time.Nanosecond)
+}
+
+// previousPeriod returns the start of the period that ends right before end.
+func previousPeriod(frequency string, end time.Time) time.Time {
+	switch frequency {
+	case repository.DigestDaily:
+		return end.AddDate(0, 0, -1)
+	case repository.DigestMonthly:
+		return end.AddDate(0, -1, 0)
+	default:
+		return end.AddDate(0, 0, -7)
+	}
+}
+
+func (h *Handler) handleDigest(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	if h.digests == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Дайджесты недоступны", "Digests are unavailable")))
+		return
+	}
+	if _, ok := h.getSessionWithErrorHandling(ctx, chatID, userID); !ok {
+		return
+	}
+	args := strings.Fields(strings.ToLower(update.Message.CommandArguments()))
+	if len(args) == 0 || args[0] == "list" {
+		h.listDigests(ctx, chatID, userID, locale)
+		return
+	}
+	if args[0] == "off" {
+		frequencies := []string{repository.DigestDaily, repository.DigestWeekly, repository.DigestMonthly}
+		if len(args) > 1 {
+			frequencies = args[1:2]
+		}
+		for _, f := range frequencies {
+			if err := h.digests.Delete(ctx, userID, f); err != nil {
+				h.logger.Error("failed to delete digest", zap.Int64("telegramID", userID), zap.String("frequency", f), zap.Error(err))
+			}
+		}
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "🔕 Подписка на дайджест отменена", "🔕 Digest subscription cancelled")))
+		return
+	}
+	switch args[0] {
+	case repository.DigestDaily, repository.DigestWeekly, repository.DigestMonthly:
+	default:
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, digestUsage(locale)))
+		return
+	}
+
+	// Defaults to the user's timezone; the original spelling matters for IANA names such as Europe/Moscow
+	loc := h.userLocation(ctx, userID)
+	if raw := strings.Fields(update.Message.CommandArguments()); len(raw) > 1 {
+		if last := raw[len(raw)-1]; strings.Contains(last, "/") || strings.HasPrefix(strings.ToUpper(last), "UTC") {
+			name, err := parseTimezone(last)
+			if err != nil {
+				_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "Неизвестный часовой пояс %q", "Unknown timezone %q"), last)))
+				return
+			}
+			loc, _ = loadLocation(name)
+			args = args[:len(args)-1]
+		}
+	}
+	rule, err := scheduler.ParseRule(strings.Join(args, " "))
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось разобрать расписание.\n\n", "Could not parse the schedule.\n\n")+digestUsage(locale)))
+		return
+	}
+	sub := &repository.DigestSubscription{
+		TelegramID: userID,
+		ChatID:     chatID,
+		Frequency:  args[0],
+		Schedule:   rule.String(),
+		Timezone:   loc.String(),
+		NextRunAt:  rule.Next(time.Now().In(loc)),
+	}
+	if err := h.digests.Upsert(ctx, sub); err != nil {
+		h.logger.Error("failed to save digest", zap.Int64("telegramID", userID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось сохранить подписку", "Failed to save the subscription")))
+		return
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "📬 Подписка оформлена: %s (%s), ближайший дайджест %s", "📬 Subscribed: %s (%s), next digest %s"),
+		sub.Schedule, sub.Timezone, sub.NextRunAt.In(loc).Format("02.01.2006 15:04"))))
+}
+
+func (h *Handler) listDigests(ctx context.Context, chatID, userID int64, locale string) {
+	subs, err := h.digests.ListByUser(ctx, userID)
+	if err != nil {
+		h.logger.Error("failed to list digests", zap.Int64("telegramID", userID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить подписки", "Failed to load subscriptions")))
+		return
+	}
+	if len(subs) == 0 {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Подписок нет.\n\n", "No subscriptions yet.\n\n")+digestUsage(locale)))
+		return
+	}
+	lines := []string{tr(locale, "📬 Дайджесты:", "📬 Digests:")}
+	for _, s := range subs {
+		loc, err := loadLocation(s.Timezone)
+		if err != nil {
+			loc = time.Local
+		}
+		lines = append(lines, fmt.Sprintf("• %s (%s) — %s %s", s.Schedule, s.Timezone, tr(locale, "следующий", "next"), s.NextRunAt.In(loc).Format("02.01.2006 15:04")))
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, strings.Join(lines, "\n")))
+}
+
+// RunDueDigests sends digests whose next run is due. The subscription is advanced only after the digest
+// is sent, so a failed send is retried on the next pass for up to digestRetryWindow. After downtime only
+// the latest missed occurrence is sent; older ones are skipped rather than flooding the chat.
+func (h *Handler) RunDueDigests(ctx context.Context, now time.Time) error {
+	if h.digests == nil {
+		return nil
+	}
+	subs, err := h.digests.ListDue(ctx, now, digestBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, s := range subs {
+		h.processDigest(ctx, s, now)
+	}
+	return nil
+}
+
+func (h *Handler) processDigest(ctx context.Context, s *repository.DigestSubscription, now time.Time) {
+	rule, err := scheduler.ParseRule(s.Schedule)
+	if err != nil {
+		h.logger.Error("invalid digest schedule, removing subscription", zap.Int64("telegramID", s.TelegramID), zap.String("schedule", s.Schedule), zap.Error(err))
+		_ = h.digests.Delete(ctx, s.TelegramID, s.Frequency)
+		return
+	}
+	loc, err := loadLocation(s.Timezone)
+	if err != nil {
+		loc = time.Local
+	}
+	due := s.NextRunAt.In(loc)
+	next := rule.Next(due)
+	for !next.IsZero() && !next.After(now) {
+		due, next = next, rule.Next(next)
+	}
+	if next.IsZero() {
+		return
+	}
+	sendErr := h.sendDigest(ctx, s, due)
+	if sendErr != nil {
+		if now.Sub(due) < digestRetryWindow {
+			h.logger.Warn("failed to send digest, will retry", zap.Int64("telegramID", s.TelegramID), zap.String("frequency", s.Frequency), zap.Error(sendErr))
+			return
+		}
+		h.logger.Error("failed to send digest, skipping it", zap.Int64("telegramID", s.TelegramID), zap.String("frequency", s.Frequency), zap.Time("due", due), zap.Error(sendErr))
+	}
+	if _, err := h.digests.AdvanceNextRun(ctx, s.TelegramID, s.Frequency, s.NextRunAt, next); err != nil {
+		h.logger.Error("failed to advance digest", zap.Int64("telegramID", s.TelegramID), zap.Error(err))
+	}
+	if sendErr != nil {
+		return
+	}
+	if err := h.digests.MarkSent(ctx, s.TelegramID, s.Frequency, now); err != nil {
+		h.logger.Error("failed to mark digest as sent", zap.Int64("telegramID", s.TelegramID), zap.Error(err))
+	}
+}
+
+func (h *Handler) sendDigest(ctx context.Context, s *repository.DigestSubscription, due time.Time) error {
+	sess, err := h.auth.GetSession(ctx, s.TelegramID)
+	if err != nil {
+		return err
+	}
+	locale := h.userLocale(ctx, s.TelegramID)
+	from, to := digestPeriod(s.Frequency, due)
+	cur, err := h.periodStats(ctx, sess, from, to)
+	if err != nil {
+		return err
+	}
+	prevFrom := previousPeriod(s.Frequency, from)
+	prev, err := h.periodStats(ctx, sess, prevFrom, from.Add(-time.Nanosecond))
+	if err != nil {
+		h.logger.Warn("failed to load previous digest period", zap.Int64("telegramID", s.TelegramID), zap.Error(err))
+		prev = nil
+	}
+	top, err := h.periodTopCategories(ctx, sess, from, to, digestTopLimit, locale)
+	if err != nil {
+		h.logger.Warn("failed to load digest top categories", zap.Int64("telegramID", s.TelegramID), zap.Error(err))
+	}
+	_, err = h.send(ctx, tgbotapi.NewMessage(s.ChatID, h.formatDigest(s.Frequency, from, to, cur, prev, top, locale)))
+	return err
+}
+
+func absMinor(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// formatChange renders the relative change against the previous period, or nothing if there is no base.
+func formatChange(cur, prev int64, locale string) string {
+	if prev == 0 {
+		return ""
+	}
+	return fmt.Sprintf(tr(locale, " (%+.0f%% к прошлому периоду)", " (%+.0f%% vs previous period)"), float64(cur-prev)/float64(prev)*100)
+}
+
+func (h *Handler) formatDigest(frequency string, from, to time.Time, cur, prev *domain.Stats, top []*domain.CategoryTotal, locale string) string {
+	var b strings.Builder
+	period := from.Format("02.01.2006")
+	if frequency != repository.DigestDaily {
+		period += "–" + to.Format("02.01.2006")
+	}
+	fmt.Fprintf(&b, tr(locale, "📬 %s дайджест за %s\n\n", "📬 %s digest for %s\n\n"), capitalize(digestFrequencyLabel(frequency, locale)), period)
+	var prevIncome, prevExpense int64
+	if prev != nil && prev.Currency == cur.Currency {
+		prevIncome, prevExpense = prev.TotalIncome, prev.TotalExpense
+	}
+	fmt.Fprintf(&b, "%s: %s%s\n", tr(locale, "Доходы", "Income"), h.fmt.FormatMoney(cur.TotalIncome, cur.Currency), formatChange(cur.TotalIncome, prevIncome, locale))
+	fmt.Fprintf(&b, "%s: %s%s\n", tr(locale, "Расходы", "Expenses"), h.fmt.FormatMoney(cur.TotalExpense, cur.Currency), formatChange(cur.TotalExpense, prevExpense, locale))
+	fmt.Fprintf(&b, "%s: %s\n", tr(locale, "Баланс", "Balance"), h.fmt.FormatMoney(cur.TotalIncome-cur.TotalExpense, cur.Currency))
+	if len(top) > 0 {
+		b.WriteString(tr(locale, "\nТоп расходов:\n", "\nTop expenses:\n"))
+		for i, it := range top {
+			fmt.Fprintf(&b, "%d) %s — %s\n", i+1, it.Name, h.fmt.FormatMoney(it.SumMinor, it.Currency))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func capitalize(s string) string {
+	r := []rune(s)
+	if len(r) == 0 {
+		return s
+	}
+	return strings.ToUpper(string(r[:1])) + string(r[1:])
+}
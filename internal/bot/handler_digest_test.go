@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	grpcclient "budget-bot/internal/grpc"
+	pb "budget-bot/internal/pb/budget/v1"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// periodTotalsTxClient returns totals keyed by the first day of the requested range and the category filter,
+// and lists the transactions keyed by the first day.
+type periodTotalsTxClient struct {
+	grpcclient.FakeTransactionClient
+	totals map[string]*grpcclient.TransactionTotals
+	lists  map[string][]*pb.Transaction
+}
+
+func (c *periodTotalsTxClient) ListTransactions(_ context.Context, f *grpcclient.TransactionFilter, page, _ int, _ string) (*grpcclient.TransactionPage, error) {
+	if page > 1 {
+		return &grpcclient.TransactionPage{Page: page, TotalPages: 1}, nil
+	}
+	return &grpcclient.TransactionPage{Transactions: c.lists[f.From.Format("2006-01-02")], Page: 1, TotalPages: 1}, nil
+}
+
+func (c *periodTotalsTxClient) GetTransactionsTotals(_ context.Context, f *grpcclient.TransactionFilter, _ string) (*grpcclient.TransactionTotals, error) {
+	if t, ok := c.totals[f.From.Format("2006-01-02")+"/"+strings.Join(f.CategoryIDs, ",")]; ok {
+		return t, nil
+	}
+	return &grpcclient.TransactionTotals{Currency: "RUB"}, nil
+}
+
+func TestHandler_Digests(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	digests := repository.NewSQLiteDigestRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &periodTotalsTxClient{totals: map[string]*grpcclient.TransactionTotals{
+		"2025-03-03/":         {IncomeMinor: 1000000, ExpenseMinor: -500000, Currency: "RUB"},
+		"2025-02-24/":         {IncomeMinor: 800000, ExpenseMinor: -1000000, Currency: "RUB"},
+		"2025-03-03/cat-food": {ExpenseMinor: -200000, Currency: "RUB"},
+		"2025-03-03/cat-home": {ExpenseMinor: -300000, Currency: "RUB"},
+		"2025-03-17/":         {IncomeMinor: 0, ExpenseMinor: -70000, Currency: "RUB"},
+	}, lists: map[string][]*pb.Transaction{
+		// food is summed from the list, home is spent in USD and is converted by the totals
+		"2025-03-03": {
+			{CategoryId: "cat-food", Amount: &pb.Money{CurrencyCode: "RUB", MinorUnits: 150000}},
+			{CategoryId: "cat-home", Amount: &pb.Money{CurrencyCode: "USD", MinorUnits: 3000}},
+			{CategoryId: "cat-food", Amount: &pb.Money{CurrencyCode: "RUB", MinorUnits: 50000}},
+		},
+	}}
+
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, repository.NewSQLiteCategoryMappingRepository(db), nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithTransactionClient(tx).
+		WithDigests(digests)
+
+	ctx := context.Background()
+	chatID := int64(8800)
+	userID := int64(88)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	command := func(text string) string {
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text, Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/digest")}}}})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+
+	if got := command("/digest weekly mon 09:00 Mars/Olympus"); !strings.Contains(got, "Неизвестный часовой пояс") {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+	if got := command("/digest hourly"); !strings.Contains(got, "/digest weekly") {
+		t.Fatalf("usage expected: %q", got)
+	}
+	if got := command("/digest weekly mon 09:00 Europe/Moscow"); !strings.Contains(got, "weekly mon 09:00 (Europe/Moscow)") {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+	subs, _ := digests.ListByUser(ctx, userID)
+	if len(subs) != 1 {
+		t.Fatalf("expected one subscription, got %d", len(subs))
+	}
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	if next := subs[0].NextRunAt.In(moscow); next.Weekday() != time.Monday || next.Hour() != 9 || !next.After(time.Now()) {
+		t.Fatalf("unexpected next run: %s", next)
+	}
+
+	// Weekly digest for 03.03-09.03 compared with the week before
+	due := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	sub := &repository.DigestSubscription{TelegramID: userID, ChatID: chatID, Frequency: repository.DigestWeekly, Schedule: "weekly mon 09:00", Timezone: "UTC", NextRunAt: due}
+	if err := digests.Upsert(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	sent := len(rec.Texts())
+	if err := h.RunDueDigests(ctx, due.Add(30*time.Second)); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	texts := rec.Texts()
+	if len(texts) != sent+1 {
+		t.Fatalf("expected one digest, got %d", len(texts)-sent)
+	}
+	got := texts[len(texts)-1]
+	for _, want := range []string{"Еженедельный дайджест за 03.03.2025–09.03.2025", "Доходы: 10000.00 RUB (+25% к прошлому периоду)", "Расходы: 5000.00 RUB (-50% к прошлому периоду)", "Баланс: 5000.00 RUB", "1) Дом — 3000.00 RUB", "2) Питание — 2000.00 RUB"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("digest misses %q:\n%s", want, got)
+		}
+	}
+	_ = h.RunDueDigests(ctx, due.Add(time.Minute))
+	if len(rec.Texts()) != sent+1 {
+		t.Fatalf("digest must be sent once")
+	}
+
+	// After downtime only the latest missed digest is delivered
+	if err := digests.Upsert(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 3, 26, 12, 0, 0, 0, time.UTC)
+	_ = h.RunDueDigests(ctx, now)
+	texts = rec.Texts()
+	if len(texts) != sent+2 || !strings.Contains(texts[len(texts)-1], "17.03.2025–23.03.2025") {
+		t.Fatalf("expected a single catch-up digest, got %v", texts[sent+1:])
+	}
+	subs, _ = digests.ListByUser(ctx, userID)
+	for _, s := range subs {
+		if s.Frequency == repository.DigestWeekly && (!s.NextRunAt.Equal(time.Date(2025, 3, 31, 9, 0, 0, 0, time.UTC)) || s.LastSentAt == nil) {
+			t.Fatalf("unexpected subscription after catch-up: %+v", s)
+		}
+	}
+
+	// Whole months are summarized by the report service
+	if err := digests.Upsert(ctx, &repository.DigestSubscription{TelegramID: userID, ChatID: chatID, Frequency: repository.DigestMonthly, Schedule: "monthly 1 09:00", Timezone: "UTC", NextRunAt: time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+	_ = h.RunDueDigests(ctx, time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC))
+	if got := rec.Texts()[len(rec.Texts())-1]; !strings.Contains(got, "Ежемесячный дайджест за 01.03.2025–31.03.2025") || !strings.Contains(got, "Доходы: 25000.00 RUB") || !strings.Contains(got, "1) Питание") {
+		t.Fatalf("unexpected monthly digest:\n%s", got)
+	}
+
+	// A digest that failed to send is retried and skipped only after the retry window
+	weekly := func() time.Time {
+		subs, _ := digests.ListByUser(ctx, userID)
+		for _, s := range subs {
+			if s.Frequency == repository.DigestWeekly {
+				return s.NextRunAt
+			}
+		}
+		return time.Time{}
+	}
+	sub.NextRunAt = time.Date(2025, 4, 7, 9, 0, 0, 0, time.UTC)
+	if err := digests.Upsert(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	session, _ := sessions.GetSession(ctx, userID)
+	_ = sessions.DeleteSession(ctx, userID)
+	sent = len(rec.Texts())
+	_ = h.RunDueDigests(ctx, time.Date(2025, 4, 7, 9, 0, 30, 0, time.UTC))
+	if next := weekly(); len(rec.Texts()) != sent || !next.Equal(sub.NextRunAt) {
+		t.Fatalf("failed digest must stay due, next run %s", next)
+	}
+	if err := sessions.SaveSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	_ = h.RunDueDigests(ctx, time.Date(2025, 4, 7, 9, 1, 30, 0, time.UTC))
+	if next := weekly(); len(rec.Texts()) != sent+1 || !next.Equal(time.Date(2025, 4, 14, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("digest must be sent on retry, next run %s", next)
+	}
+	_ = sessions.DeleteSession(ctx, userID)
+	_ = h.RunDueDigests(ctx, time.Date(2025, 4, 14, 9, 0, 0, 0, time.UTC).Add(digestRetryWindow))
+	if next := weekly(); len(rec.Texts()) != sent+1 || !next.Equal(time.Date(2025, 4, 21, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("digest must be skipped after the retry window, next run %s", next)
+	}
+	if err := sessions.SaveSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+
+	command("/digest off")
+	if subs, _ := digests.ListByUser(ctx, userID); len(subs) != 0 {
+		t.Fatalf("subscriptions should be removed, got %d", len(subs))
+	}
+}
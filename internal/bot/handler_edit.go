@@ -0,0 +1,262 @@
+// Package bot contains the core Telegram bot business logic.
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"budget-bot/internal/bot/ui"
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/metrics"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// getOwnedOperation loads an operation context that belongs to the user and is bound to a saved transaction.
+func (h *Handler) getOwnedOperation(ctx context.Context, telegramID int64, opID string) (*repository.OperationContext, bool) {
+	if h.opCtxs == nil {
+		return nil, false
+	}
+	op, err := h.opCtxs.Get(ctx, opID)
+	if err != nil || op.TelegramID != telegramID || op.TransactionID == nil || *op.TransactionID == "" {
+		return nil, false
+	}
+	return op, true
+}
+
+// formatOperationSummary renders the confirmation text for a saved transaction, with the date shown in loc.
+func formatOperationSummary(op *repository.OperationContext, locale string, loc *time.Location) string {
+	categoryName := ""
+	if op.CategoryNameSelected != nil {
+		categoryName = *op.CategoryNameSelected
+	} else if op.CategoryIDSelected != nil {
+		categoryName = *op.CategoryIDSelected
+	}
+	occurredAt := op.CreatedAt
+	if op.OccurredAt != nil {
+		occurredAt = *op.OccurredAt
+	}
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+	return fmt.Sprintf("%s %s %s %s — %s\n%s: %s\n%s: %s",
+		tr(locale, "✅ Сохранено:", "✅ Saved:"),
+		txTypeLabel(op.TxType, locale),
+		domain.FormatAmount(op.AmountMinor, op.Currency),
+		op.Currency,
+		op.DescriptionOriginal,
+		tr(locale, "Категория", "Category"),
+		categoryName,
+		tr(locale, "Дата", "Date"),
+		occurredAt.In(loc).Format("02.01.2006"),
+	)
+}
+
+// operationKeyboard returns the actions shown under a saved transaction; transactions opened from /recent
+// keep their way back to the list.
+func operationKeyboard(op *repository.OperationContext, locale string) tgbotapi.InlineKeyboardMarkup {
+	if op.SelectionSource == recentSource {
+		return ui.CreateRecentDetailKeyboard(op.OpID, 1, recentPageSize, locale)
+	}
+	return ui.CreatePostSelectionKeyboard(op.SelectionSource, op.OpID, locale)
+}
+
+// refreshConfirmation edits the stored confirmation message in place, or sends a new one if it is unknown.
+func (h *Handler) refreshConfirmation(ctx context.Context, chatID int64, op *repository.OperationContext, locale, note string) {
+	text := formatOperationSummary(op, locale, h.userLocation(ctx, op.TelegramID))
+	if op.SelectionSource == recentSource {
+		text = formatRecentDetails(op, locale, h.userLocation(ctx, op.TelegramID))
+	}
+	if note != "" {
+		text += "\n\n" + note
+	}
+	kb := operationKeyboard(op, locale)
+	if op.ConfirmationMessageID != nil && *op.ConfirmationMessageID != 0 {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, *op.ConfirmationMessageID, text, kb)
+		_, err := h.bot.Request(edit)
+		if err == nil {
+			return
+		}
+		h.logger.Warn("failed to edit confirmation message", zap.Error(err))
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = kb
+	sent, _ := h.send(ctx, msg)
+	if sent.MessageID != 0 {
+		_ = h.opCtxs.SetConfirmationMessageID(ctx, op.OpID, sent.MessageID)
+	}
+}
+
+func (h *Handler) handleEditRequestCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, opID string, state repository.DialogState) {
+	locale := h.userLocale(ctx, cb.From.ID)
+	if _, ok := h.getOwnedOperation(ctx, cb.From.ID, opID); !ok {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Контекст не найден", "Context not found")))
+		return
+	}
+	var prompt string
+	switch state {
+	case repository.StateWaitingForEditAmount:
+		prompt = tr(locale, "Введите новую сумму (например: 350 или 1200,50):", "Enter a new amount (e.g. 350 or 1200.50):")
+	case repository.StateWaitingForEditDate:
+		prompt = tr(locale, "Введите новую дату (сегодня, вчера, позавчера или ДД.ММ[.ГГГГ]):", "Enter a new date (сегодня, вчера, позавчера or DD.MM[.YYYY]):")
+	default:
+		prompt = tr(locale, "Введите новый комментарий:", "Enter a new comment:")
+	}
+	_ = h.states.SetState(ctx, cb.From.ID, state, map[string]any{"op_id": opID}, nil)
+	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, ""))
+	if cb.Message != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(cb.Message.Chat.ID, prompt+"\n\n"+tr(locale, "Для отмены: /cancel", "To cancel: /cancel")))
+	}
+}
+
+func (h *Handler) handleEditInput(ctx context.Context, update tgbotapi.Update, rec *repository.DialogStateRecord) {
+	locale := h.userLocale(ctx, update.Message.From.ID)
+	chatID := update.Message.Chat.ID
+	opID, _ := rec.Context["op_id"].(string)
+	op, ok := h.getOwnedOperation(ctx, update.Message.From.ID, opID)
+	if !ok {
+		_ = h.states.ClearState(ctx, update.Message.From.ID)
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Транзакция для изменения не найдена", "Transaction to edit not found")))
+		return
+	}
+	sess, err := h.auth.GetSession(ctx, update.Message.From.ID)
+	if err != nil || sess == nil {
+		_ = h.states.ClearState(ctx, update.Message.From.ID)
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		return
+	}
+
+	text := strings.TrimSpace(update.Message.Text)
+	req := &grpcclient.UpdateTransactionRequest{Currency: op.Currency}
+	var note, action string
+	switch rec.State {
+	case repository.StateWaitingForEditAmount:
+		amountMinor, err := h.parser.ParseAmountIn(text, op.Currency)
+		if err != nil {
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось распознать сумму, попробуйте ещё раз", "Could not parse the amount, please try again")))
+			return
+		}
+		_ = h.states.ClearState(ctx, update.Message.From.ID)
+		h.editOperationAmount(ctx, chatID, sess, op, amountMinor, locale)
+		return
+	case repository.StateWaitingForEditDate:
+		occurredAt, err := h.parser.ParseDateAt(text, h.userNow(ctx, op.TelegramID))
+		if err != nil {
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось распознать дату, попробуйте ещё раз", "Could not parse the date, please try again")))
+			return
+		}
+		req.OccurredAt = occurredAt
+		op.OccurredAt = occurredAt
+		note, action = tr(locale, "📅 Дата обновлена", "📅 Date updated"), "edit_date"
+	default:
+		if text == "" {
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Комментарий не может быть пустым", "Comment cannot be empty")))
+			return
+		}
+		req.Description = &text
+		op.DescriptionOriginal = text
+		note, action = tr(locale, "💬 Комментарий обновлён", "💬 Comment updated"), "edit_comment"
+	}
+	_ = h.states.ClearState(ctx, update.Message.From.ID)
+	h.applyOperationUpdate(ctx, chatID, sess, op, req, locale, note, action)
+}
+
+// editOperationAmount changes the amount of a saved transaction; an unusual amount is held for confirmation
+// like the amount of a new transaction. It reports whether the amount was changed or held.
+func (h *Handler) editOperationAmount(ctx context.Context, chatID int64, sess *repository.UserSession, op *repository.OperationContext, amountMinor int64, locale string) bool {
+	draft := &repository.TransactionDraft{
+		TelegramID:  op.TelegramID,
+		OpID:        op.OpID,
+		Type:        op.TxType,
+		AmountMinor: amountMinor,
+		Currency:    op.Currency,
+		Description: op.DescriptionOriginal,
+		CategoryID:  derefString(op.CategoryIDSelected),
+		OccurredAt:  op.OccurredAt,
+	}
+	if h.holdIfUnusual(ctx, sess, chatID, draft, "") {
+		return true
+	}
+	op.AmountMinor = amountMinor
+	req := &grpcclient.UpdateTransactionRequest{Currency: op.Currency, AmountMinor: &amountMinor}
+	return h.applyOperationUpdate(ctx, chatID, sess, op, req, locale, tr(locale, "✏️ Сумма обновлена", "✏️ Amount updated"), "edit_amount")
+}
+
+// applyOperationUpdate saves the changes of a transaction edit and refreshes its confirmation message.
+// It reports whether the transaction was updated.
+func (h *Handler) applyOperationUpdate(ctx context.Context, chatID int64, sess *repository.UserSession, op *repository.OperationContext, req *grpcclient.UpdateTransactionRequest, locale, note, action string) bool {
+	if err := h.txClient.UpdateTransaction(ctx, *op.TransactionID, req, sess.AccessToken); err != nil {
+		h.logger.Error("Failed to update transaction",
+			zap.Int64("telegramID", op.TelegramID),
+			zap.String("transactionID", *op.TransactionID),
+			zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось обновить транзакцию", "Failed to update transaction")))
+		return false
+	}
+	_ = h.opCtxs.UpdateDetails(ctx, op.OpID, op.AmountMinor, op.DescriptionOriginal, op.OccurredAt)
+	metrics.IncTransactionMutation(action)
+	h.refreshConfirmation(ctx, chatID, op, locale, note)
+	return true
+}
+
+func (h *Handler) handleDeleteCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, opID string) {
+	locale := h.userLocale(ctx, cb.From.ID)
+	if _, ok := h.getOwnedOperation(ctx, cb.From.ID, opID); !ok || cb.Message == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Контекст не найден", "Context not found")))
+		return
+	}
+	edit := tgbotapi.NewEditMessageReplyMarkup(cb.Message.Chat.ID, cb.Message.MessageID, ui.CreateDeleteConfirmKeyboard(opID, locale))
+	_, _ = h.bot.Request(edit)
+	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Подтвердите удаление", "Confirm deletion")))
+}
+
+func (h *Handler) handleDeleteCancelCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, opID string) {
+	locale := h.userLocale(ctx, cb.From.ID)
+	op, ok := h.getOwnedOperation(ctx, cb.From.ID, opID)
+	if !ok || cb.Message == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Контекст не найден", "Context not found")))
+		return
+	}
+	edit := tgbotapi.NewEditMessageReplyMarkup(cb.Message.Chat.ID, cb.Message.MessageID, operationKeyboard(op, locale))
+	_, _ = h.bot.Request(edit)
+	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Отменено", "Canceled")))
+}
+
+func (h *Handler) handleDeleteConfirmCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, opID string) {
+	locale := h.userLocale(ctx, cb.From.ID)
+	op, ok := h.getOwnedOperation(ctx, cb.From.ID, opID)
+	if !ok {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Контекст не найден", "Context not found")))
+		return
+	}
+	sess, err := h.auth.GetSession(ctx, cb.From.ID)
+	if err != nil || sess == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Нет сессии", "No session")))
+		return
+	}
+	if err := h.txClient.DeleteTransaction(ctx, *op.TransactionID, sess.AccessToken); err != nil {
+		h.logger.Error("Failed to delete transaction",
+			zap.Int64("telegramID", cb.From.ID),
+			zap.String("transactionID", *op.TransactionID),
+			zap.Error(err))
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Ошибка", "Error")))
+		return
+	}
+	_ = h.opCtxs.Delete(ctx, opID)
+	metrics.IncTransactionMutation("delete")
+	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Удалено", "Deleted")))
+	if cb.Message != nil {
+		text := fmt.Sprintf("%s %s %s %s — %s",
+			tr(locale, "🗑 Удалено:", "🗑 Deleted:"),
+			txTypeLabel(op.TxType, locale),
+			domain.FormatAmount(op.AmountMinor, op.Currency),
+			op.Currency,
+			op.DescriptionOriginal,
+		)
+		_, _ = h.bot.Request(tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, text))
+	}
+}
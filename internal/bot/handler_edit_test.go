@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+type recordingTxClient struct {
+	grpcclient.FakeTransactionClient
+	updates []*grpcclient.UpdateTransactionRequest
+	deleted []string
+}
+
+func (c *recordingTxClient) UpdateTransaction(_ context.Context, _ string, req *grpcclient.UpdateTransactionRequest, _ string) error {
+	c.updates = append(c.updates, req)
+	return nil
+}
+
+func (c *recordingTxClient) DeleteTransaction(_ context.Context, txID, _ string) error {
+	c.deleted = append(c.deleted, txID)
+	return nil
+}
+
+func TestHandler_EditAndDeleteSavedTransaction(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	states := repository.NewSQLiteDialogStateRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	prefs := repository.NewSQLitePreferencesRepository(db)
+	opCtxs := repository.NewSQLiteOperationContextRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &recordingTxClient{}
+
+	h := NewHandler(bot, states, auth, mappings, nil, log).
+		WithPreferences(prefs).
+		WithOperationContexts(opCtxs).
+		WithTransactionClient(tx)
+
+	ctx := context.Background()
+	chatID := int64(8100)
+	userID := int64(81)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	txID := "tx-1"
+	catName := "Питание"
+	confirmationID := 77
+	if err := opCtxs.Create(ctx, &repository.OperationContext{OpID: "op-1", TelegramID: userID, TenantID: "t", TransactionID: &txID, DescriptionOriginal: "кофе", CategoryNameSelected: &catName, SelectionSource: "manual", TxType: "expense", AmountMinor: 350000, Currency: "RUB", ConfirmationMessageID: &confirmationID}); err != nil {
+		t.Fatalf("create op: %v", err)
+	}
+
+	callback := func(data string) {
+		h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: &tgbotapi.User{ID: userID}, Message: &tgbotapi.Message{MessageID: confirmationID, Chat: &tgbotapi.Chat{ID: chatID}}, Data: data}})
+	}
+	message := func(text string) {
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text}})
+	}
+
+	// Another user cannot edit someone else's transaction
+	h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: &tgbotapi.User{ID: 999}, Data: "v1:edit_amount:op-1"}})
+	if st, _ := states.GetState(ctx, 999); st != nil {
+		t.Fatalf("foreign user must not enter edit state")
+	}
+
+	callback("v1:edit_amount:op-1")
+	st, _ := states.GetState(ctx, userID)
+	if st == nil || st.State != repository.StateWaitingForEditAmount {
+		t.Fatalf("expected edit amount state, got %+v", st)
+	}
+	message("abc")
+	if len(tx.updates) != 0 {
+		t.Fatalf("invalid amount must not be sent")
+	}
+	message("350")
+	if len(tx.updates) != 1 || tx.updates[0].AmountMinor == nil || *tx.updates[0].AmountMinor != 35000 {
+		t.Fatalf("unexpected update: %+v", tx.updates)
+	}
+	op, _ := opCtxs.Get(ctx, "op-1")
+	if op.AmountMinor != 35000 {
+		t.Fatalf("operation context not updated: %d", op.AmountMinor)
+	}
+	edits := rec.Calls("editMessageText")
+	if len(edits) != 1 || edits[0].Params.Get("message_id") != "77" || !strings.Contains(edits[0].Params.Get("text"), "350.00 RUB") {
+		t.Fatalf("confirmation was not edited in place: %+v", edits)
+	}
+
+	callback("v1:edit_comment:op-1")
+	message("капучино")
+	if len(tx.updates) != 2 || tx.updates[1].Description == nil || *tx.updates[1].Description != "капучино" {
+		t.Fatalf("unexpected comment update: %+v", tx.updates)
+	}
+
+	callback("v1:edit_date:op-1")
+	message("01.02.2025")
+	if len(tx.updates) != 3 || tx.updates[2].OccurredAt == nil {
+		t.Fatalf("unexpected date update: %+v", tx.updates)
+	}
+
+	callback("v1:delete:op-1")
+	if len(tx.deleted) != 0 {
+		t.Fatalf("delete must ask for confirmation first")
+	}
+	callback("v1:delete_yes:op-1")
+	if len(tx.deleted) != 1 || tx.deleted[0] != txID {
+		t.Fatalf("unexpected deletions: %v", tx.deleted)
+	}
+	if _, err := opCtxs.Get(ctx, "op-1"); err == nil {
+		t.Fatalf("operation context should be removed after delete")
+	}
+
+	// Without a session the edit is dropped instead of trapping later messages
+	if err := opCtxs.Create(ctx, &repository.OperationContext{OpID: "op-2", TelegramID: userID, TenantID: "t", TransactionID: &txID, DescriptionOriginal: "чай", SelectionSource: "manual", TxType: "expense", AmountMinor: 10000, Currency: "RUB"}); err != nil {
+		t.Fatalf("create op: %v", err)
+	}
+	callback("v1:edit_comment:op-2")
+	if err := sessions.DeleteSession(ctx, userID); err != nil {
+		t.Fatalf("delete session: %v", err)
+	}
+	message("зелёный чай")
+	if st, _ := states.GetState(ctx, userID); st != nil {
+		t.Fatalf("edit state must be cleared without a session, got %+v", st)
+	}
+	if len(tx.updates) != 3 {
+		t.Fatalf("nothing must be updated without a session: %+v", tx.updates)
+	}
+}
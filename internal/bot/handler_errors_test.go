@@ -23,6 +23,7 @@ func (f *failReport) TopCategories(_ context.Context, _ string, _ , _ time.Time,
 
 type failTx struct{ grpcclient.TransactionClient }
 func (f *failTx) ListRecent(_ context.Context, _ string, _ int, _ string) ([]*pb.Transaction, error) { return nil, errors.New("boom") }
+func (f *failTx) ListTransactions(_ context.Context, _ *grpcclient.TransactionFilter, _, _ int, _ string) (*grpcclient.TransactionPage, error) { return nil, errors.New("boom") }
 func (f *failTx) ListForExport(_ context.Context, _ string, _ , _ time.Time, _ int, _ string) ([]*pb.Transaction, error) { return nil, errors.New("boom") }
 
 func TestHandler_ErrorBranches_NoPanic(t *testing.T) {
@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"budget-bot/internal/domain"
+	"budget-bot/internal/exporter"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Export limits: transactions are fetched exportPageSize at a time, at most maxExportRows in total.
+const (
+	exportPageSize = 200
+	maxExportRows  = 10000
+)
+
+// exportArgs are the parsed /export arguments.
+type exportArgs struct {
+	from, to time.Time
+	limit    int
+	format   exporter.Exporter
+}
+
+// parseExportArgs reads "[YYYY-MM|week|from..to] [limit] [format=csv]"; the period defaults to the current month.
+// The range ends are dates in any form the parser understands ("2025-01-01..2025-03-31", "01.03..вчера").
+func (h *Handler) parseExportArgs(args string, now time.Time) (*exportArgs, error) {
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	out := &exportArgs{from: from, to: from.AddDate(0, 1, -1), limit: maxExportRows}
+	out.format, _ = exporter.Lookup("csv")
+	for _, p := range strings.Fields(args) {
+		if key, value, ok := strings.Cut(p, "="); ok && (strings.EqualFold(key, "format") || strings.EqualFold(key, "формат")) {
+			e, err := exporter.Lookup(value)
+			if err != nil {
+				return nil, err
+			}
+			out.format = e
+			continue
+		}
+		if start, end, ok := strings.Cut(p, ".."); ok {
+			f, err := h.parser.ParseDateAt(start, now)
+			if err != nil {
+				return nil, fmt.Errorf("bad date %q", start)
+			}
+			t, err := h.parser.ParseDateAt(end, now)
+			if err != nil {
+				return nil, fmt.Errorf("bad date %q", end)
+			}
+			if t.Before(*f) {
+				return nil, fmt.Errorf("range %q ends before it starts", p)
+			}
+			// the parser returns UTC instants of local midnight
+			out.from, out.to = f.In(now.Location()), t.In(now.Location())
+			continue
+		}
+		if p == "week" {
+			wd := int(now.Weekday())
+			if wd == 0 {
+				wd = 7
+			}
+			out.from = time.Date(now.Year(), now.Month(), now.Day()-(wd-1), 0, 0, 0, 0, now.Location())
+			out.to = out.from.AddDate(0, 0, 6)
+			continue
+		}
+		if m, err := time.ParseInLocation("2006-01", p, now.Location()); err == nil {
+			out.from, out.to = m, m.AddDate(0, 1, -1)
+			continue
+		}
+		if v, err := strconv.Atoi(p); err == nil && v > 0 {
+			out.limit = min(v, maxExportRows)
+			continue
+		}
+		return nil, fmt.Errorf("unknown argument %q", p)
+	}
+	return out, nil
+}
+
+// collectExportRows pages through ListTransactions until the period or the limit is exhausted and returns the rows
+// oldest first, with category names resolved in one lookup. truncated tells that the limit left transactions out.
+func (h *Handler) collectExportRows(ctx context.Context, sess *repository.UserSession, args *exportArgs, locale string) (rows []exporter.Row, truncated bool, err error) {
+	filter := &grpcclient.TransactionFilter{From: args.from, To: args.to.AddDate(0, 0, 1).Add(-time.Nanosecond)}
+	categories, err := h.nameMapper.CategoriesByID(ctx, sess.TenantID, sess.AccessToken, locale)
+	if err != nil {
+		h.logger.Warn("export: failed to load categories", zap.String("tenantID", sess.TenantID), zap.Error(err))
+		categories = map[string]*domain.Category{}
+	}
+	for page := 1; ; page++ {
+		res, err := h.txClient.ListTransactions(ctx, filter, page, exportPageSize, sess.AccessToken)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, t := range res.Transactions {
+			if len(rows) == args.limit {
+				truncated = true
+				break
+			}
+			name := t.GetCategoryId()
+			if c := categories[name]; c != nil {
+				name = c.Name
+			}
+			rows = append(rows, exporter.Row{
+				ID:          t.GetId(),
+				Date:        t.GetOccurredAt().AsTime(),
+				Type:        domain.TransactionType(txTypeOf(t)),
+				AmountMinor: t.GetAmount().GetMinorUnits(),
+				Currency:    t.GetAmount().GetCurrencyCode(),
+				CategoryID:  t.GetCategoryId(),
+				Category:    name,
+				Comment:     t.GetComment(),
+			})
+		}
+		if truncated || len(res.Transactions) == 0 || page >= res.TotalPages {
+			break
+		}
+	}
+	// pages are newest first
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+	return rows, truncated, nil
+}
+
+func (h *Handler) handleExport(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	sess, err := h.auth.GetSession(ctx, userID)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		return
+	}
+	now := h.userNow(ctx, userID)
+	args, err := h.parseExportArgs(update.Message.CommandArguments(), now)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale,
+			"Не удалось разобрать аргументы: %v\nФормат: /export [YYYY-MM|week|с..по] [лимит] [format=%s]",
+			"Failed to parse the arguments: %v\nFormat: /export [YYYY-MM|week|from..to] [limit] [format=%s]"), err, strings.Join(exporter.Formats(), "|"))))
+		return
+	}
+	rows, truncated, err := h.collectExportRows(ctx, sess, args, locale)
+	if err != nil {
+		h.logger.Error("export: failed to list transactions", zap.Int64("telegramID", userID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось выгрузить транзакции", "Failed to export transactions")))
+		return
+	}
+	period := args.from.Format("02.01.2006") + "–" + args.to.Format("02.01.2006")
+	if len(rows) == 0 {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "Нет транзакций за %s", "No transactions for %s"), period)))
+		return
+	}
+	var buf bytes.Buffer
+	data := &exporter.Data{From: args.from, To: args.to, Location: now.Location(), Rows: rows}
+	if err := args.format.Write(&buf, data); err != nil {
+		h.logger.Error("export: failed to render file", zap.String("format", args.format.Format()), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось выгрузить транзакции", "Failed to export transactions")))
+		return
+	}
+	name := fmt.Sprintf("export_%s_%s.%s", args.from.Format("2006-01-02"), args.to.Format("2006-01-02"), args.format.Extension())
+	msg := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: name, Bytes: buf.Bytes()})
+	msg.Caption = fmt.Sprintf(tr(locale, "Экспорт за %s (%s), операций: %d", "Export for %s (%s), transactions: %d"), period, args.format.Format(), len(rows))
+	if truncated {
+		msg.Caption += tr(locale, "\nДостигнут лимит, часть операций не выгружена", "\nThe limit was reached, some transactions were left out")
+	}
+	_, _ = h.send(ctx, msg)
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestHandler_ExportFormats(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &pagingTxClient{}
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, repository.NewSQLiteCategoryMappingRepository(db), nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithTransactionClient(tx)
+
+	ctx := context.Background()
+	chatID, userID := int64(9200), int64(92)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "tenant-1", AccessToken: "access-token-92", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	export := func(args string) {
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{
+			Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: "/export " + args,
+			Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 7}},
+		}})
+	}
+
+	export("2025-02-01..2025-02-28 format=json")
+	docs := rec.Calls("sendDocument")
+	if len(docs) != 1 {
+		t.Fatalf("one document expected, got %d", len(docs))
+	}
+	if caption := docs[0].Params.Get("caption"); caption != "Экспорт за 01.02.2025–28.02.2025 (json), операций: 12" {
+		t.Fatalf("unexpected caption: %q", caption)
+	}
+	if !tx.filter.From.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) || tx.filter.To.Before(time.Date(2025, 2, 28, 23, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected period: %v..%v", tx.filter.From, tx.filter.To)
+	}
+	var doc struct {
+		Transactions []struct {
+			ID, Date, Amount, Category string
+		}
+	}
+	if err := json.Unmarshal(docs[0].Files["document"], &doc); err != nil {
+		t.Fatalf("document must be json: %v", err)
+	}
+	// all pages are collected and reversed, since the API lists newest first; category names are resolved
+	if n := len(doc.Transactions); n != 12 || doc.Transactions[0].ID != "tx-11" || doc.Transactions[11].ID != "tx-0" ||
+		doc.Transactions[0].Amount != "600.00" || doc.Transactions[0].Category != "Питание" {
+		t.Fatalf("unexpected transactions: %+v", doc.Transactions)
+	}
+
+	export("2025-02 5 format=qif")
+	docs = rec.Calls("sendDocument")
+	if caption := docs[1].Params.Get("caption"); !strings.Contains(caption, "(qif), операций: 5") || !strings.Contains(caption, "Достигнут лимит") {
+		t.Fatalf("truncation must be reported: %q", caption)
+	}
+	if got := string(docs[1].Files["document"]); strings.Count(got, "^\n") != 6 || !strings.HasPrefix(got, "!Account\nNBudget RUB") {
+		t.Fatalf("unexpected qif: %q", got)
+	}
+
+	before := len(rec.Texts())
+	export("format=pdf")
+	if texts := rec.Texts()[before:]; len(texts) != 1 || !strings.Contains(texts[0], "beancount|csv|hledger|json|ofx|qif|xlsx") {
+		t.Fatalf("supported formats must be listed: %q", texts)
+	}
+	if len(rec.Calls("sendDocument")) != 2 {
+		t.Fatal("no document expected for a bad format")
+	}
+}
@@ -0,0 +1,326 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"budget-bot/internal/bot/ui"
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	pb "budget-bot/internal/pb/budget/v1"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// findPageSize is the number of transactions shown per /find page.
+const findPageSize = 10
+
+// findHeader starts every /find result.
+const findHeader = "🔎 "
+
+// findQueryTTL is how long the page buttons of a /find result keep working.
+const findQueryTTL = 7 * 24 * time.Hour
+
+// findQuery is a parsed /find query. Category names are resolved to IDs by findFilter.
+type findQuery struct {
+	Search     []string
+	Categories []string
+	Type       string
+	Currency   string
+	MinAmount  string
+	MaxAmount  string
+	From       *time.Time
+	To         *time.Time
+}
+
+// splitFindQuery splits a query into tokens; double quotes keep spaces inside a token (cat:"Кафе и бары").
+func splitFindQuery(s string) []string {
+	var out []string
+	var b strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == '«' || r == '»':
+			quoted = r == '«'
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if b.Len() > 0 {
+				out = append(out, b.String())
+				b.Reset()
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 {
+		out = append(out, b.String())
+	}
+	return out
+}
+
+// parseFindQuery parses "кофе >500 cat:Еда from:2025-01-01 to:вчера type:expense cur:USD".
+// Amount bounds are kept as text because their minor units depend on the currency.
+func (h *Handler) parseFindQuery(text string, now time.Time) (*findQuery, error) {
+	q := &findQuery{}
+	for _, tok := range splitFindQuery(text) {
+		key, value, hasKey := strings.Cut(tok, ":")
+		if hasKey && value != "" {
+			switch strings.ToLower(key) {
+			case "cat", "категория", "кат":
+				q.Categories = append(q.Categories, value)
+				continue
+			case "from", "с", "от":
+				d, err := h.parser.ParseDateAt(value, now)
+				if err != nil {
+					return nil, fmt.Errorf("bad date %q", value)
+				}
+				q.From = d
+				continue
+			case "to", "по", "до":
+				d, err := h.parser.ParseDateAt(value, now)
+				if err != nil {
+					return nil, fmt.Errorf("bad date %q", value)
+				}
+				// the end of that day in the user's zone, 23 or 25 hours long on DST switches
+				end := d.In(now.Location()).AddDate(0, 0, 1).Add(-time.Nanosecond)
+				q.To = &end
+				continue
+			case "type", "тип":
+				switch strings.ToLower(value) {
+				case "expense", "расход", "расходы":
+					q.Type = string(domain.TransactionExpense)
+				case "income", "доход", "доходы":
+					q.Type = string(domain.TransactionIncome)
+				default:
+					return nil, fmt.Errorf("bad type %q", value)
+				}
+				continue
+			case "cur", "валюта":
+				code := strings.ToUpper(value)
+				if _, ok := domain.LookupCurrency(code); !ok {
+					return nil, fmt.Errorf("bad currency %q", value)
+				}
+				q.Currency = code
+				continue
+			}
+		}
+		switch {
+		case strings.HasPrefix(tok, ">") && len(tok) > 1:
+			q.MinAmount = strings.TrimLeft(tok, ">=")
+		case strings.HasPrefix(tok, "<") && len(tok) > 1:
+			q.MaxAmount = strings.TrimLeft(tok, "<=")
+		default:
+			q.Search = append(q.Search, tok)
+		}
+	}
+	return q, nil
+}
+
+// findFilter turns a parsed query into a transaction filter. Amounts are read in the query currency,
+// falling back to currency (the user's default).
+func (h *Handler) findFilter(q *findQuery, categories map[string]*domain.Category, currency string) (*grpcclient.TransactionFilter, error) {
+	f := &grpcclient.TransactionFilter{Type: q.Type, Currency: q.Currency, Search: strings.Join(q.Search, " ")}
+	if q.From != nil {
+		f.From = *q.From
+	}
+	if q.To != nil {
+		f.To = *q.To
+	}
+	if q.Currency != "" {
+		currency = q.Currency
+	}
+	var err error
+	if q.MinAmount != "" {
+		if f.MinMinor, err = h.parser.ParseAmountIn(q.MinAmount, currency); err != nil {
+			return nil, fmt.Errorf("bad amount %q", q.MinAmount)
+		}
+	}
+	if q.MaxAmount != "" {
+		if f.MaxMinor, err = h.parser.ParseAmountIn(q.MaxAmount, currency); err != nil {
+			return nil, fmt.Errorf("bad amount %q", q.MaxAmount)
+		}
+	}
+	for _, name := range q.Categories {
+		ids := findCategoryIDs(categories, name)
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("unknown category %q", name)
+		}
+		f.CategoryIDs = append(f.CategoryIDs, ids...)
+	}
+	return f, nil
+}
+
+// findCategoryIDs returns the categories named exactly like name, or starting with it when none is.
+// Expense and income categories may share a name, so there can be several.
+func findCategoryIDs(categories map[string]*domain.Category, name string) []string {
+	n := strings.ToLower(strings.TrimSpace(name))
+	var exact, prefix []string
+	for id, c := range categories {
+		cn := strings.ToLower(c.Name)
+		switch {
+		case id == name || cn == n:
+			exact = append(exact, id)
+		case strings.HasPrefix(cn, n):
+			prefix = append(prefix, id)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return prefix
+}
+
+// handleFind searches transactions: /find кофе >500 cat:Еда from:2025-01-01 type:expense cur:USD.
+func (h *Handler) handleFind(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	query := strings.TrimSpace(update.Message.CommandArguments())
+	if query == "" {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale,
+			"Использование: /find [текст] [>сумма] [<сумма] [cat:категория] [from:дата] [to:дата] [type:expense|income] [cur:USD]\nПример: /find кофе >500 cat:Питание from:2025-01-01",
+			"Usage: /find [text] [>amount] [<amount] [cat:category] [from:date] [to:date] [type:expense|income] [cur:USD]\nExample: /find coffee >500 cat:Food from:2025-01-01")))
+		return
+	}
+	sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+	// the page buttons refer to the stored query, a result without it has the first page only
+	var queryID string
+	if h.finds != nil {
+		stored := &repository.FindQuery{ID: uuid.NewString(), TelegramID: userID, Query: query}
+		if err := h.finds.Create(ctx, stored); err != nil {
+			h.logger.Warn("find: failed to store the query", zap.Int64("telegramID", userID), zap.Error(err))
+		} else {
+			queryID = stored.ID
+		}
+	}
+	text, keyboard, err := h.findPage(ctx, sess, userID, query, queryID, 1, locale)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, err.Error()))
+		return
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	if keyboard != nil {
+		msg.ReplyMarkup = *keyboard
+	}
+	_, _ = h.send(ctx, msg)
+}
+
+// handleFindPageCallback turns the page of a /find result, "v1:find:<query id>:<page>".
+func (h *Handler) handleFindPageCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, arg string) {
+	locale := h.userLocale(ctx, cb.From.ID)
+	queryID, pageRaw, _ := strings.Cut(arg, ":")
+	page, err := strconv.Atoi(pageRaw)
+	if err != nil || cb.Message == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Некорректные данные", "Invalid data")))
+		return
+	}
+	var stored *repository.FindQuery
+	if h.finds != nil {
+		stored, _ = h.finds.Get(ctx, queryID)
+	}
+	if stored == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Поиск устарел, повторите /find", "The search has expired, repeat /find")))
+		return
+	}
+	sess, err := h.auth.GetSession(ctx, cb.From.ID)
+	if err != nil || sess == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Нет сессии", "No session")))
+		return
+	}
+	text, keyboard, err := h.findPage(ctx, sess, cb.From.ID, stored.Query, stored.ID, page, locale)
+	if err != nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, err.Error()))
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, text)
+	edit.ReplyMarkup = keyboard
+	_, _ = h.bot.Request(edit)
+	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, ""))
+}
+
+// findPage renders one page of /find results with a totals footer and navigation buttons for the stored
+// query queryID. Errors are user-facing messages.
+func (h *Handler) findPage(ctx context.Context, sess *repository.UserSession, userID int64, query, queryID string, page int, locale string) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	q, err := h.parseFindQuery(query, h.userNow(ctx, userID))
+	if err != nil {
+		return "", nil, fmt.Errorf(tr(locale, "Не удалось разобрать запрос: %v", "Failed to parse the query: %v"), err)
+	}
+	categories, err := h.nameMapper.CategoriesByID(ctx, sess.TenantID, sess.AccessToken, locale)
+	if err != nil {
+		h.logger.Warn("find: failed to load categories", zap.Int64("telegramID", userID), zap.Error(err))
+		categories = map[string]*domain.Category{}
+	}
+	filter, err := h.findFilter(q, categories, h.defaultCurrency(ctx, userID))
+	if err != nil {
+		return "", nil, fmt.Errorf(tr(locale, "Не удалось разобрать запрос: %v", "Failed to parse the query: %v"), err)
+	}
+	res, err := h.txClient.ListTransactions(ctx, filter, page, findPageSize, sess.AccessToken)
+	if err != nil {
+		h.logger.Error("find: failed to list transactions", zap.Int64("telegramID", userID), zap.Error(err))
+		return "", nil, errors.New(tr(locale, "Не удалось выполнить поиск", "Search failed"))
+	}
+
+	var b strings.Builder
+	b.WriteString(findHeader + query + "\n")
+	if len(res.Transactions) == 0 {
+		b.WriteString(tr(locale, "\nНичего не найдено", "\nNothing found"))
+		return b.String(), nil, nil
+	}
+	loc := h.userLocation(ctx, userID)
+	b.WriteString("\n")
+	for _, t := range res.Transactions {
+		sign := "-"
+		if t.GetType() == pb.TransactionType_TRANSACTION_TYPE_INCOME {
+			sign = "+"
+		}
+		curr := t.GetAmount().GetCurrencyCode()
+		line := fmt.Sprintf("%s · %s%s %s", t.GetOccurredAt().AsTime().In(loc).Format("02.01.2006"), sign, domain.FormatAmount(t.GetAmount().GetMinorUnits(), curr), curr)
+		if c := categories[t.GetCategoryId()]; c != nil {
+			line += " · " + strings.TrimSpace(c.Emoji+" "+c.Name)
+		}
+		if t.GetComment() != "" {
+			line += " — " + t.GetComment()
+		}
+		b.WriteString(line + "\n")
+	}
+	if totals, err := h.txClient.GetTransactionsTotals(ctx, filter, sess.AccessToken); err == nil && totals != nil {
+		fmt.Fprintf(&b, "\n%s: %s · %s: %s\n",
+			tr(locale, "Доходы", "Income"), h.fmt.FormatMoney(totals.IncomeMinor, totals.Currency),
+			tr(locale, "Расходы", "Expenses"), h.fmt.FormatMoney(totals.ExpenseMinor, totals.Currency))
+	} else if err != nil {
+		h.logger.Warn("find: failed to load totals", zap.Int64("telegramID", userID), zap.Error(err))
+	}
+	pages := res.TotalPages
+	if pages < res.Page {
+		pages = res.Page
+	}
+	fmt.Fprintf(&b, tr(locale, "Найдено: %d · страница %d/%d", "Found: %d · page %d/%d"), res.TotalItems, res.Page, pages)
+
+	if queryID == "" {
+		return b.String(), nil, nil
+	}
+	keyboard := ui.CreateFindPageKeyboard(queryID, res.Page, pages, locale)
+	if len(keyboard.InlineKeyboard) == 0 {
+		return b.String(), nil, nil
+	}
+	return b.String(), &keyboard, nil
+}
+
+// RunFindQueryCleanup removes /find queries older than findQueryTTL; their page buttons then ask to repeat the search.
+func (h *Handler) RunFindQueryCleanup(ctx context.Context, now time.Time) error {
+	if h.finds == nil {
+		return nil
+	}
+	_, err := h.finds.DeleteCreatedBefore(ctx, now.Add(-findQueryTTL))
+	return err
+}
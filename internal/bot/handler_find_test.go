@@ -0,0 +1,182 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	grpcclient "budget-bot/internal/grpc"
+	pb "budget-bot/internal/pb/budget/v1"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// pagingTxClient serves 12 matching transactions in pages and records the last filter.
+type pagingTxClient struct {
+	grpcclient.FakeTransactionClient
+	filter *grpcclient.TransactionFilter
+}
+
+func (c *pagingTxClient) ListTransactions(_ context.Context, filter *grpcclient.TransactionFilter, page, pageSize int, _ string) (*grpcclient.TransactionPage, error) {
+	c.filter = filter
+	if filter.Search == "nothing" {
+		return &grpcclient.TransactionPage{Page: page}, nil
+	}
+	const total = 12
+	out := &grpcclient.TransactionPage{Page: page, TotalPages: (total + pageSize - 1) / pageSize, TotalItems: total}
+	for i := (page - 1) * pageSize; i < total && i < page*pageSize; i++ {
+		out.Transactions = append(out.Transactions, &pb.Transaction{
+			Id:         "tx-" + strconv.Itoa(i),
+			CategoryId: "cat-food",
+			Type:       pb.TransactionType_TRANSACTION_TYPE_EXPENSE,
+			Amount:     &pb.Money{CurrencyCode: "RUB", MinorUnits: 60000},
+			Comment:    "кофе",
+			OccurredAt: timestamppb.New(time.Date(2025, 2, 1+i, 10, 0, 0, 0, time.UTC)),
+		})
+	}
+	return out, nil
+}
+
+func (c *pagingTxClient) GetTransaction(ctx context.Context, txID, token string) (*pb.Transaction, error) {
+	res, _ := c.ListTransactions(ctx, &grpcclient.TransactionFilter{}, 1, 100, token)
+	for _, t := range res.Transactions {
+		if t.GetId() == txID {
+			return t, nil
+		}
+	}
+	return c.FakeTransactionClient.GetTransaction(ctx, txID, token)
+}
+
+func (c *pagingTxClient) GetTransactionsTotals(_ context.Context, _ *grpcclient.TransactionFilter, _ string) (*grpcclient.TransactionTotals, error) {
+	return &grpcclient.TransactionTotals{ExpenseMinor: 720000, Currency: "RUB"}, nil
+}
+
+func TestHandler_Find(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &pagingTxClient{}
+	finds := repository.NewSQLiteFindQueryRepository(db)
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, repository.NewSQLiteCategoryMappingRepository(db), nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithTransactionClient(tx).
+		WithFindQueries(finds)
+
+	ctx := context.Background()
+	chatID, userID := int64(9100), int64(91)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "tenant-1", AccessToken: "access-token-91", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	find := func(query string) string {
+		before := len(rec.Texts())
+		text := strings.TrimSpace("/find " + query)
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{
+			Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text,
+			Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 5}},
+		}})
+		texts := rec.Texts()[before:]
+		if len(texts) != 1 {
+			t.Fatalf("one reply expected: %q", texts)
+		}
+		return texts[0]
+	}
+
+	got := find(`кофе >500 cat:пит from:2025-01-01 to:2025-03-31 type:расход`)
+	f := tx.filter
+	if f.Search != "кофе" || f.MinMinor != 50000 || f.Type != "expense" || len(f.CategoryIDs) != 1 || f.CategoryIDs[0] != "cat-food" {
+		t.Fatalf("unexpected filter: %+v", f)
+	}
+	if !f.From.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) || f.To.Before(time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected period: %v..%v", f.From, f.To)
+	}
+	if !strings.HasPrefix(got, "🔎 кофе >500") || !strings.Contains(got, "01.02.2025 · -600.00 RUB · 🍽️ Питание — кофе") ||
+		!strings.Contains(got, "Расходы: 7200.00 RUB") || !strings.Contains(got, "Найдено: 12 · страница 1/2") {
+		t.Fatalf("unexpected first page: %q", got)
+	}
+	sends := rec.Calls("sendMessage")
+	markup := sends[len(sends)-1].Params.Get("reply_markup")
+	next := regexp.MustCompile(`v1:find:([0-9a-f-]+):2`).FindStringSubmatch(markup)
+	if next == nil || strings.Contains(markup, ":0\"") {
+		t.Fatalf("next button expected: %s", markup)
+	}
+	queryID := next[1]
+
+	// the query is read from the store, not from the message text
+	h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID: "cb", From: &tgbotapi.User{ID: userID}, Data: "v1:find:" + queryID + ":2",
+		Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: chatID}, Text: "🔎 что-то другое"},
+	}})
+	edits := rec.Calls("editMessageText")
+	if len(edits) != 1 {
+		t.Fatalf("page must be edited in place: %d", len(edits))
+	}
+	page2 := edits[0].Params
+	if !strings.Contains(page2.Get("text"), "страница 2/2") || strings.Count(page2.Get("text"), "— кофе") != 2 || !strings.Contains(page2.Get("reply_markup"), "v1:find:"+queryID+":1") {
+		t.Fatalf("unexpected second page: %v", page2)
+	}
+	if tx.filter.MinMinor != 50000 || len(tx.filter.CategoryIDs) != 1 {
+		t.Fatalf("query must be read from the store: %+v", tx.filter)
+	}
+	if err := h.RunFindQueryCleanup(ctx, time.Now().Add(findQueryTTL+time.Minute)); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID: "cb", From: &tgbotapi.User{ID: userID}, Data: "v1:find:" + queryID + ":1",
+		Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: chatID}, Text: got},
+	}})
+	if answers := rec.Calls("answerCallbackQuery"); len(rec.Calls("editMessageText")) != 1 || !strings.Contains(answers[len(answers)-1].Params.Get("text"), "Поиск устарел") {
+		t.Fatalf("an expired query must ask to repeat the search")
+	}
+
+	if got := find(`"nothing"`); !strings.Contains(got, "Ничего не найдено") {
+		t.Fatalf("unexpected empty result: %q", got)
+	}
+	if got := find("cur:USD >10"); tx.filter.MinMinor != 1000 || tx.filter.Currency != "USD" || !strings.Contains(got, "Найдено") {
+		t.Fatalf("amount must be read in the query currency: %+v", tx.filter)
+	}
+	if got := find("cat:Нет такой"); !strings.Contains(got, "Не удалось разобрать запрос") {
+		t.Fatalf("unknown category must be reported: %q", got)
+	}
+	if got := find(""); !strings.Contains(got, "Использование: /find") {
+		t.Fatalf("usage expected: %q", got)
+	}
+}
+
+func TestSplitFindQuery(t *testing.T) {
+	got := splitFindQuery(`кофе  cat:"Кафе и бары" «с собой» >500`)
+	want := []string{"кофе", "cat:Кафе и бары", "с собой", ">500"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseFindQuery_DayEndOnDSTSwitch(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata")
+	}
+	h := &Handler{parser: NewMessageParser()}
+	// 30.03.2025 is 23 hours long in Berlin, 26.10.2025 is 25 hours long
+	for _, day := range []int{30, 26} {
+		month := time.March
+		if day == 26 {
+			month = time.October
+		}
+		q, err := h.parseFindQuery(fmt.Sprintf("to:%02d.%02d.2025", day, int(month)), time.Date(2025, 11, 5, 12, 0, 0, 0, berlin))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := time.Date(2025, month, day+1, 0, 0, 0, 0, berlin).Add(-time.Nanosecond); !q.To.Equal(want) {
+			t.Fatalf("to: got %v, want %v", q.To, want)
+		}
+	}
+}
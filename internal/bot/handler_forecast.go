@@ -0,0 +1,297 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"budget-bot/internal/domain"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/scheduler"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Forecast settings: the past months averaged for comparison, the category lines listed, the occurrences of one
+// recurring rule counted in a month, the deviation from the average (in percent) still considered on track and
+// the first days of a month whose run-rate is too short to rely on.
+const (
+	forecastAverageMonths  = 3
+	forecastMaxLines       = 15
+	forecastMaxOccurrences = 62
+	forecastTolerance      = 10
+	forecastEarlyDays      = 3
+)
+
+// recurringPlan is the recurring spending of a month by category: created occurrences and the ones still due.
+type recurringPlan struct {
+	past, upcoming map[string]int64
+	names          map[string]string
+	count          int
+	skipped        bool
+}
+
+// categoryForecast is the month-to-date and projected spending of one category.
+type categoryForecast struct {
+	name                                string
+	spent, upcoming, projected, average int64
+}
+
+// monthForecast is the projected spending of the current month.
+type monthForecast struct {
+	month                                 reportPeriod
+	day, days                             int
+	currency                              string
+	spent, recurring, upcoming, projected int64
+	daily                                 int64
+	upcomingCount                         int
+	average                               int64
+	averageMonths                         int
+	skipped                               bool
+	categories                            []categoryForecast
+}
+
+// recurringForecast collects the recurring expenses of the user in the month. Occurrences already created are
+// reported as past so that they are not extrapolated by the daily run-rate; occurrences awaiting confirmation
+// and the ones due until the end of the month are upcoming.
+func (h *Handler) recurringForecast(ctx context.Context, sess *repository.UserSession, month reportPeriod, now time.Time, base string) (*recurringPlan, error) {
+	plan := &recurringPlan{past: map[string]int64{}, upcoming: map[string]int64{}, names: map[string]string{}}
+	if h.recurring == nil {
+		return plan, nil
+	}
+	rules, err := h.recurring.ListByUser(ctx, sess.TelegramID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		// rules without a category are not run until one is chosen
+		if r.TenantID != sess.TenantID || r.TxType != string(domain.TransactionExpense) || r.CategoryID == nil {
+			continue
+		}
+		amount := r.AmountMinor
+		if r.Currency != base {
+			if h.fx == nil {
+				plan.skipped = true
+				continue
+			}
+			if amount, err = h.fx.ConvertToBaseCurrency(ctx, amount, r.Currency, base, now, sess.AccessToken); err != nil {
+				h.logger.Warn("forecast: conversion failed", zap.String("from", r.Currency), zap.String("to", base), zap.Error(err))
+				plan.skipped = true
+				continue
+			}
+		}
+		id := *r.CategoryID
+		plan.names[id] = derefString(r.CategoryName)
+		runs, err := h.recurring.ListRuns(ctx, r.ID, month.From, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, run := range runs {
+			switch run.Status {
+			case repository.RecurringRunCreated:
+				plan.past[id] += amount
+			case repository.RecurringRunAwaiting:
+				plan.upcoming[id] += amount
+				plan.count++
+			}
+		}
+		if r.Paused {
+			continue
+		}
+		sched, err := scheduler.ParseRule(r.Schedule)
+		if err != nil {
+			continue
+		}
+		// an overdue occurrence of an earlier month is saved with its own date, so only this month counts
+		for t, n := r.NextRunAt.In(now.Location()), 0; !t.IsZero() && !t.After(month.To) && n < forecastMaxOccurrences; t, n = sched.Next(t), n+1 {
+			if !t.Before(month.From) {
+				plan.upcoming[id] += amount
+				plan.count++
+			}
+		}
+	}
+	return plan, nil
+}
+
+// buildForecast projects the spending of the month of now: what is spent so far without recurring payments is
+// extrapolated by the daily run-rate, and the recurring payments still due are added. The projection is compared
+// with the average of the previous months.
+func (h *Handler) buildForecast(ctx context.Context, sess *repository.UserSession, now time.Time, locale string) (*monthForecast, error) {
+	month := monthPeriod(now)
+	soFar := dayRange(month.From, now, "")
+	f := &monthForecast{month: month, day: now.Day(), days: month.To.Day()}
+	st, err := h.periodStats(ctx, sess, soFar.From, soFar.To)
+	if err != nil {
+		return nil, err
+	}
+	f.spent, f.currency = st.TotalExpense, st.Currency
+	if f.currency == "" {
+		f.currency = h.defaultCurrency(ctx, sess.TelegramID)
+	}
+	items, err := h.periodTopCategories(ctx, sess, soFar.From, soFar.To, 0, locale)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := h.recurringForecast(ctx, sess, month, now, f.currency)
+	if err != nil {
+		return nil, err
+	}
+	f.upcomingCount, f.skipped = plan.count, plan.skipped
+
+	index := map[string]int{}
+	var ids []string
+	get := func(id, name string) *categoryForecast {
+		i, ok := index[id]
+		if !ok {
+			i = len(f.categories)
+			index[id] = i
+			ids = append(ids, id)
+			f.categories = append(f.categories, categoryForecast{name: name})
+		}
+		return &f.categories[i]
+	}
+	for _, it := range items {
+		get(it.CategoryID, it.Name).spent += it.SumMinor
+	}
+	for id, sum := range plan.upcoming {
+		get(id, plan.names[id]).upcoming += sum
+		f.upcoming += sum
+	}
+	for _, sum := range plan.past {
+		f.recurring += sum
+	}
+
+	averages := map[string]int64{}
+	for i := 1; i <= forecastAverageMonths; i++ {
+		p := monthPeriod(month.From.AddDate(0, -i, 0))
+		st, err := h.periodStats(ctx, sess, p.From, p.To)
+		if err != nil {
+			return nil, err
+		}
+		if st.TotalExpense == 0 {
+			continue
+		}
+		f.averageMonths++
+		f.average += st.TotalExpense
+		items, err := h.periodTopCategories(ctx, sess, p.From, p.To, 0, locale)
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range items {
+			averages[it.CategoryID] += it.SumMinor
+		}
+	}
+	if f.averageMonths > 0 {
+		f.average /= int64(f.averageMonths)
+	}
+
+	remaining := int64(f.days - f.day)
+	project := func(spent, past, upcoming int64) int64 {
+		variable := max(spent-past, 0)
+		return spent + variable*remaining/int64(f.day) + upcoming
+	}
+	f.daily = max(f.spent-f.recurring, 0) / int64(f.day)
+	f.projected = project(f.spent, f.recurring, f.upcoming)
+	for i, id := range ids {
+		c := &f.categories[i]
+		c.projected = project(c.spent, plan.past[id], c.upcoming)
+		if f.averageMonths > 0 {
+			c.average = averages[id] / int64(f.averageMonths)
+		}
+	}
+	sort.SliceStable(f.categories, func(i, j int) bool {
+		if f.categories[i].projected != f.categories[j].projected {
+			return f.categories[i].projected > f.categories[j].projected
+		}
+		return f.categories[i].name < f.categories[j].name
+	})
+	return f, nil
+}
+
+// aboveAverage tells whether the projection exceeds the average by more than the tolerance.
+func aboveAverage(projected, average int64) bool {
+	return average > 0 && projected*100 > average*(100+forecastTolerance)
+}
+
+func formatForecast(f *monthForecast, locale string) string {
+	var b strings.Builder
+	cur := f.currency
+	fmt.Fprintf(&b, tr(locale, "🔮 Прогноз расходов на %s (день %d из %d)\n", "🔮 Spending forecast for %s (day %d of %d)\n"), f.month.Label, f.day, f.days)
+	fmt.Fprintf(&b, tr(locale, "Потрачено: %s", "Spent: %s"), money(f.spent, cur))
+	if f.recurring > 0 {
+		fmt.Fprintf(&b, tr(locale, ", из них регулярные платежи: %s", ", recurring payments: %s"), money(f.recurring, cur))
+	}
+	fmt.Fprintf(&b, tr(locale, "\nВ среднем в день: %s\n", "\nDaily run-rate: %s\n"), money(f.daily, cur))
+	if f.upcomingCount > 0 {
+		fmt.Fprintf(&b, tr(locale, "Ожидаются регулярные платежи: %s (%d)\n", "Upcoming recurring payments: %s (%d)\n"), money(f.upcoming, cur), f.upcomingCount)
+	}
+	fmt.Fprintf(&b, tr(locale, "Прогноз на конец месяца: %s\n", "Projected by month end: %s\n"), money(f.projected, cur))
+	if f.averageMonths == 0 {
+		b.WriteString(tr(locale, "Нет данных за прошлые месяцы для сравнения\n", "No data for previous months to compare with\n"))
+	} else {
+		fmt.Fprintf(&b, tr(locale, "Среднее за %d мес.: %s — ", "Average of %d months: %s — "), f.averageMonths, money(f.average, cur))
+		pct := (f.projected - f.average) * 100 / f.average
+		switch {
+		case aboveAverage(f.projected, f.average):
+			fmt.Fprintf(&b, tr(locale, "⚠️ прогноз выше среднего на %d%%\n", "⚠️ the forecast is %d%% above average\n"), pct)
+		case pct < -forecastTolerance:
+			fmt.Fprintf(&b, tr(locale, "✅ прогноз ниже среднего на %d%%\n", "✅ the forecast is %d%% below average\n"), -pct)
+		default:
+			b.WriteString(tr(locale, "✅ в пределах среднего\n", "✅ on track with the average\n"))
+		}
+	}
+	if f.day < forecastEarlyDays {
+		b.WriteString(tr(locale, "ℹ️ В первые дни месяца прогноз по дневному темпу неточен\n", "ℹ️ The run-rate of the first days of a month is a rough guide\n"))
+	}
+	if f.skipped {
+		b.WriteString(tr(locale, "⚠️ Регулярные платежи в других валютах не учтены: курсы недоступны\n", "⚠️ Recurring payments in other currencies are left out: rates are unavailable\n"))
+	}
+	if len(f.categories) > 0 {
+		b.WriteString(tr(locale, "\nПо категориям (потрачено → прогноз):\n", "\nBy category (spent → projected):\n"))
+	}
+	for i, c := range f.categories {
+		if i == forecastMaxLines {
+			fmt.Fprintf(&b, tr(locale, "… и ещё категорий: %d\n", "… and %d more categories\n"), len(f.categories)-i)
+			break
+		}
+		marker := "•"
+		if aboveAverage(c.projected, c.average) {
+			marker = "⚠️"
+		}
+		fmt.Fprintf(&b, "%s %s: %s → %s", marker, c.name, domain.FormatAmount(c.spent, cur), money(c.projected, cur))
+		if c.upcoming > 0 {
+			fmt.Fprintf(&b, tr(locale, " · регулярные %s", " · recurring %s"), signedMoney(c.upcoming, cur))
+		}
+		if c.average > 0 {
+			fmt.Fprintf(&b, tr(locale, " · в среднем %s%s", " · average %s%s"), money(c.average, cur), percentChange(c.projected, c.average))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// handleForecast projects the spending of the current month by category: /forecast.
+func (h *Handler) handleForecast(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(update.Message.CommandArguments()) != "" {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale,
+			"Формат: /forecast — прогноз расходов на конец текущего месяца",
+			"Usage: /forecast — spending forecast for the end of the current month")))
+		return
+	}
+	f, err := h.buildForecast(ctx, sess, h.userNow(ctx, userID), locale)
+	if err != nil {
+		h.logger.Error("forecast: failed to load spending", zap.String("tenantID", sess.TenantID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось построить прогноз", "Failed to build the forecast")))
+		return
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, formatForecast(f, locale)))
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	pb "budget-bot/internal/pb/budget/v1"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestHandler_Forecast(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	recurring := repository.NewSQLiteRecurringRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	// 15 000 spent by October 15th, 5 000 of them the rent created by a recurring rule
+	tx := &periodTotalsTxClient{totals: map[string]*grpcclient.TransactionTotals{
+		"2025-10-01/": {ExpenseMinor: -1500000, Currency: "RUB"},
+	}, lists: map[string][]*pb.Transaction{
+		"2025-10-01": {
+			{CategoryId: "cat-food", Amount: &pb.Money{CurrencyCode: "RUB", MinorUnits: 1000000}},
+			{CategoryId: "cat-home", Amount: &pb.Money{CurrencyCode: "RUB", MinorUnits: 500000}},
+		},
+	}}
+	food := []*domain.CategoryTotal{{CategoryID: "cat-food", Name: "Питание", SumMinor: 1200000, Currency: "RUB"}}
+	report := &monthlyTopReportClient{months: map[string][]*domain.CategoryTotal{"2025-09": food, "2025-08": food, "2025-07": food}}
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, repository.NewSQLiteCategoryMappingRepository(db), nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithTransactionClient(tx).
+		WithReportClient(report).
+		WithRecurring(recurring)
+
+	ctx := context.Background()
+	chatID, userID := int64(9600), int64(96)
+	sess := &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token1234567", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}
+	if err := sessions.SaveSession(ctx, sess); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	now := time.Date(2025, 10, 15, 12, 0, 0, 0, time.UTC)
+	str := func(s string) *string { return &s }
+	rule := func(r *repository.RecurringRule) int64 {
+		r.TelegramID, r.ChatID, r.TenantID, r.Currency = userID, chatID, "t", "RUB"
+		if r.TxType == "" {
+			r.TxType = "expense"
+		}
+		id, err := recurring.Create(ctx, r)
+		if err != nil {
+			t.Fatalf("create rule: %v", err)
+		}
+		return id
+	}
+	rent := rule(&repository.RecurringRule{Schedule: "monthly 5 09:00", AmountMinor: 500000, Description: "аренда", CategoryID: str("cat-home"), CategoryName: str("Дом"), NextRunAt: time.Date(2025, 11, 5, 9, 0, 0, 0, time.UTC)})
+	rentDue := time.Date(2025, 10, 5, 9, 0, 0, 0, time.UTC)
+	if _, err := recurring.ClaimRun(ctx, rent, rentDue); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	_, _ = recurring.UpdateRunStatus(ctx, rent, rentDue, repository.RecurringRunPending, repository.RecurringRunCreated, str("tx-rent"))
+	rule(&repository.RecurringRule{Schedule: "weekly mon 10:00", AmountMinor: 10000, Description: "кино", CategoryID: str("cat-fun"), CategoryName: str("Развлечения"), NextRunAt: time.Date(2025, 10, 20, 10, 0, 0, 0, time.UTC)})
+	rule(&repository.RecurringRule{Schedule: "monthly 20", AmountMinor: 100000, Description: "спортзал", CategoryID: str("cat-fun"), CategoryName: str("Развлечения"), Paused: true, NextRunAt: time.Date(2025, 10, 20, 9, 0, 0, 0, time.UTC)})
+	rule(&repository.RecurringRule{Schedule: "monthly 25", TxType: "income", AmountMinor: 10000000, Description: "зарплата", CategoryID: str("cat-salary"), NextRunAt: time.Date(2025, 10, 25, 9, 0, 0, 0, time.UTC)})
+
+	f, err := h.buildForecast(ctx, sess, now, "ru")
+	if err != nil {
+		t.Fatalf("forecast: %v", err)
+	}
+	// 10 000 of variable spending in 15 days continues for 16 more days, and two film nights (20th, 27th) are due
+	if f.spent != 1500000 || f.recurring != 500000 || f.upcoming != 20000 || f.upcomingCount != 2 || f.projected != 1500000+1066666+20000 || f.average != 1750000 {
+		t.Fatalf("unexpected forecast: %+v", f)
+	}
+	got := formatForecast(f, "ru")
+	for _, want := range []string{
+		"🔮 Прогноз расходов на 2025-10 (день 15 из 31)",
+		"Потрачено: 15000.00 RUB, из них регулярные платежи: 5000.00 RUB",
+		"В среднем в день: 666.66 RUB",
+		"Ожидаются регулярные платежи: 200.00 RUB (2)",
+		"Прогноз на конец месяца: 25866.66 RUB",
+		"Среднее за 3 мес.: 17500.00 RUB — ⚠️ прогноз выше среднего на 47%",
+		"⚠️ Питание: 10000.00 → 20666.66 RUB · в среднем 12000.00 RUB (+72%)",
+		"• Дом: 5000.00 → 5000.00 RUB",
+		"• Развлечения: 0.00 → 200.00 RUB · регулярные +200.00 RUB",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q in:\n%s", want, got)
+		}
+	}
+	if en := formatForecast(f, "en"); !strings.Contains(en, "Projected by month end: 25866.66 RUB") || !strings.Contains(en, "47% above average") {
+		t.Fatalf("unexpected english forecast:\n%s", en)
+	}
+
+	command := func(text string) string {
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text, Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}}})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+	if got := command("/forecast"); !strings.HasPrefix(got, "🔮 Прогноз расходов на "+time.Now().Format("2006-01")) {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+	if got := command("/forecast 2025-10"); !strings.HasPrefix(got, "Формат: /forecast") {
+		t.Fatalf("usage expected: %q", got)
+	}
+}
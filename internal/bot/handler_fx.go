@@ -0,0 +1,215 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// WithFxClient enables currency conversion previews and /stats in other currencies.
+func (h *Handler) WithFxClient(fx grpcclient.FxClient) *Handler {
+	if fx != nil {
+		h.fx = NewCurrencyConverter(fx, h.logger)
+	}
+	return h
+}
+
+// baseCurrency returns the base currency of the session's tenant, falling back to the user's default currency.
+func (h *Handler) baseCurrency(ctx context.Context, sess *repository.UserSession, telegramID int64) string {
+	if t := h.cachedTenant(ctx, sess); t != nil && t.DefaultCurrency != "" {
+		return t.DefaultCurrency
+	}
+	return h.defaultCurrency(ctx, telegramID)
+}
+
+// convertAmount converts an amount between currencies at the rate of the date; equal currencies pass through.
+func (h *Handler) convertAmount(ctx context.Context, amountMinor int64, from, to string, at time.Time, accessToken string) (int64, error) {
+	if from == to || from == "" || to == "" {
+		return amountMinor, nil
+	}
+	if h.fx == nil {
+		return 0, fmt.Errorf("no exchange rates to convert %s to %s", from, to)
+	}
+	return h.fx.ConvertToBaseCurrency(ctx, amountMinor, from, to, at, accessToken)
+}
+
+// fxPreview renders the amount converted into the tenant's base currency, e.g.
+// "💱 ≈ 2000.00 RUB (курс 1 EUR = 100 RUB, cbr, 17.10.2026)". Empty when no conversion is needed or possible.
+func (h *Handler) fxPreview(ctx context.Context, sess *repository.UserSession, telegramID int64, amountMinor int64, currency string, occurredAt *time.Time, locale string) string {
+	if h.fx == nil || sess == nil {
+		return ""
+	}
+	base := h.baseCurrency(ctx, sess, telegramID)
+	if base == "" || base == currency {
+		return ""
+	}
+	date := time.Now()
+	if occurredAt != nil {
+		date = *occurredAt
+	}
+	converted, rate, err := h.fx.ConvertWithRate(ctx, amountMinor, currency, base, date, sess.AccessToken)
+	if err != nil {
+		h.logger.Warn("fx preview failed", zap.Error(err), zap.String("from", currency), zap.String("to", base))
+		return ""
+	}
+	return fmt.Sprintf("💱 ≈ %s %s (%s)", domain.FormatAmount(converted, base), base, rateSource(rate, h.userLocation(ctx, telegramID), locale))
+}
+
+// rateSource describes a rate with its provider and date: "курс 1 EUR = 100 RUB, cbr, 17.10.2026".
+func rateSource(r *grpcclient.FxRate, loc *time.Location, locale string) string {
+	provider := r.Provider
+	if provider == "" {
+		provider = tr(locale, "источник не указан", "unknown source")
+	}
+	return fmt.Sprintf("%s 1 %s = %s %s, %s, %s",
+		tr(locale, "курс", "rate"), r.FromCurrency, formatRate(r.Rate), r.ToCurrency, provider, r.AsOf.In(loc).Format("02.01.2006"))
+}
+
+// formatRate prints a rate with up to six decimals and without trailing zeros: "92.5", "0.010526".
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(math.Round(rate*1e6)/1e6, 'f', -1, 64)
+}
+
+// convertStats expresses the totals in target currency at the rate for the end of the period
+// (or today for the current one) and returns a line describing the rate used.
+func (h *Handler) convertStats(ctx context.Context, st *domain.Stats, target string, from, to, now time.Time, sess *repository.UserSession, telegramID int64, locale string) string {
+	if h.fx == nil {
+		return fmt.Sprintf(tr(locale, "⚠️ Курсы валют недоступны, суммы показаны в %s", "⚠️ Exchange rates are unavailable, totals are shown in %s"), st.Currency)
+	}
+	asOf := to
+	if now.Before(asOf) {
+		asOf = now
+	}
+	if asOf.Before(from) {
+		asOf = from
+	}
+	income, rate, err := h.fx.ConvertWithRate(ctx, st.TotalIncome, st.Currency, target, asOf, sess.AccessToken)
+	if err == nil {
+		var expense int64
+		if expense, _, err = h.fx.ConvertWithRate(ctx, st.TotalExpense, st.Currency, target, asOf, sess.AccessToken); err == nil {
+			st.TotalIncome, st.TotalExpense, st.Currency = income, expense, target
+			return "💱 " + rateSource(rate, h.userLocation(ctx, telegramID), locale)
+		}
+	}
+	h.logger.Warn("stats conversion failed", zap.Error(err), zap.String("from", st.Currency), zap.String("to", target))
+	return fmt.Sprintf(tr(locale, "⚠️ Не удалось получить курс %s → %s, суммы показаны в %s", "⚠️ Failed to get the %s → %s rate, totals are shown in %s"), st.Currency, target, st.Currency)
+}
+
+// ratesCurrencies are listed by /rates besides the user's default currency.
+var ratesCurrencies = []string{"USD", "EUR", "GBP", "CNY", "JPY"}
+
+// handleRate stores a manual rate: /rate USD RUB 92.5 [date]. Only tenant owners and admins may do it.
+func (h *Handler) handleRate(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	usage := tr(locale, "Формат: /rate USD RUB 92.5 [дата]", "Usage: /rate USD RUB 92.5 [date]")
+	args := strings.Fields(update.Message.CommandArguments())
+	if len(args) < 3 {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, usage))
+		return
+	}
+	from, okFrom := domain.LookupCurrency(args[0])
+	to, okTo := domain.LookupCurrency(args[1])
+	if !okFrom || !okTo || from.Code == to.Code {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Укажите две разные валюты ISO 4217. ", "Specify two different ISO 4217 currencies. ")+usage))
+		return
+	}
+	rate, err := strconv.ParseFloat(strings.ReplaceAll(args[2], ",", "."), 64)
+	if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Курс должен быть положительным числом. ", "The rate must be a positive number. ")+usage))
+		return
+	}
+	asOf, ok := h.rateDate(ctx, userID, args[3:])
+	if !ok {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось распознать дату. ", "Could not parse the date. ")+usage))
+		return
+	}
+	sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+	if !h.isTenantAdmin(ctx, sess) {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Менять курсы могут только владельцы и администраторы организации", "Only tenant owners and admins can change rates")))
+		return
+	}
+	if h.fx == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Курсы валют недоступны", "Exchange rates are unavailable")))
+		return
+	}
+	stored, err := h.fx.UpsertRate(ctx, &grpcclient.FxRate{FromCurrency: from.Code, ToCurrency: to.Code, Rate: rate, AsOf: asOf, Provider: grpcclient.ManualRateProvider}, sess.AccessToken)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось сохранить курс", "Failed to save the rate")))
+		return
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf("%s %s", tr(locale, "✅ Курс сохранён:", "✅ Rate saved:"), rateSource(stored, h.userLocation(ctx, userID), locale))))
+}
+
+// handleRates lists rates of common currencies to the tenant's base currency: /rates [date].
+func (h *Handler) handleRates(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	asOf, ok := h.rateDate(ctx, userID, strings.Fields(update.Message.CommandArguments()))
+	if !ok {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось распознать дату. Формат: /rates [дата]", "Could not parse the date. Usage: /rates [date]")))
+		return
+	}
+	sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+	if h.fx == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Курсы валют недоступны", "Exchange rates are unavailable")))
+		return
+	}
+	base := h.baseCurrency(ctx, sess, userID)
+	var from []string
+	for _, c := range append([]string{h.defaultCurrency(ctx, userID)}, ratesCurrencies...) {
+		if c != base && !slices.Contains(from, c) {
+			from = append(from, c)
+		}
+	}
+	rates, err := h.fx.BatchGetRates(ctx, from, base, asOf, sess.AccessToken)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить курсы", "Failed to load rates")))
+		return
+	}
+	loc := h.userLocation(ctx, userID)
+	lines := []string{fmt.Sprintf(tr(locale, "💱 Курсы к %s на %s:", "💱 Rates to %s as of %s:"), base, asOf.In(loc).Format("02.01.2006"))}
+	for _, r := range rates {
+		provider := r.Provider
+		if provider == "" {
+			provider = tr(locale, "источник не указан", "unknown source")
+		}
+		lines = append(lines, fmt.Sprintf("1 %s = %s %s (%s)", r.FromCurrency, formatRate(r.Rate), base, provider))
+	}
+	if len(rates) == 0 {
+		lines = append(lines, tr(locale, "Курсов нет", "No rates"))
+	}
+	lines = append(lines, "", tr(locale, "Задать свой курс: /rate USD RUB 92.5 [дата]", "Set your own rate: /rate USD RUB 92.5 [date]"))
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, strings.Join(lines, "\n")))
+}
+
+// rateDate resolves an optional date argument ("вчера", "05.03.2025") to the start of that day, today if empty.
+func (h *Handler) rateDate(ctx context.Context, telegramID int64, args []string) (time.Time, bool) {
+	now := h.userNow(ctx, telegramID)
+	if len(args) == 0 {
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).UTC(), true
+	}
+	d, err := h.parser.ParseDateAt(strings.Join(args, " "), now)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return *d, true
+}
@@ -0,0 +1,198 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// stubFxClient serves fixed rates keyed by "FROM|TO", records requested dates and applies upserts to all dates.
+type stubFxClient struct {
+	rates    map[string]float64
+	asOf     []time.Time
+	upserted []*grpcclient.FxRate
+}
+
+func (s *stubFxClient) GetRate(ctx context.Context, from, to string, asOf time.Time, token string) (float64, error) {
+	r, err := s.GetRateInfo(ctx, from, to, asOf, token)
+	if err != nil {
+		return 0, err
+	}
+	return r.Rate, nil
+}
+
+func (s *stubFxClient) GetRateInfo(_ context.Context, from, to string, asOf time.Time, _ string) (*grpcclient.FxRate, error) {
+	s.asOf = append(s.asOf, asOf)
+	provider := "cbr"
+	for _, u := range s.upserted {
+		if u.FromCurrency == from && u.ToCurrency == to {
+			provider = u.Provider
+		}
+	}
+	return &grpcclient.FxRate{FromCurrency: from, ToCurrency: to, Rate: s.rates[from+"|"+to], AsOf: asOf, Provider: provider}, nil
+}
+
+func (s *stubFxClient) UpsertRate(_ context.Context, rate *grpcclient.FxRate, _ string) (*grpcclient.FxRate, error) {
+	s.upserted = append(s.upserted, rate)
+	s.rates[rate.FromCurrency+"|"+rate.ToCurrency] = rate.Rate
+	return rate, nil
+}
+
+func (s *stubFxClient) BatchGetRates(ctx context.Context, from []string, to string, asOf time.Time, token string) ([]*grpcclient.FxRate, error) {
+	var out []*grpcclient.FxRate
+	for _, f := range from {
+		if _, ok := s.rates[f+"|"+to]; ok {
+			r, _ := s.GetRateInfo(ctx, f, to, asOf, token)
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func TestHandler_FxPreviewAndStatsCurrency(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	fx := &stubFxClient{rates: map[string]float64{"EUR|RUB": 100, "JPY|RUB": 0.6, "RUB|USD": 0.0125}}
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, mappings, nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithOperationContexts(repository.NewSQLiteOperationContextRepository(db)).
+		WithTransactionClient(&createRecordingTxClient{}).
+		WithFxClient(fx)
+
+	ctx := context.Background()
+	chatID := int64(8640)
+	userID := int64(87)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "access-token-87", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	if err := mappings.AddMapping(ctx, &repository.CategoryMapping{ID: "m1", TenantID: "t", Keyword: "ужин", CategoryID: "cat-food"}); err != nil {
+		t.Fatalf("add mapping: %v", err)
+	}
+	send := func(text string) string {
+		msg := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text}
+		if strings.HasPrefix(text, "/") {
+			msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}
+		}
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: msg})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+
+	got := send("20 EUR ужин 05.03.2025")
+	if !strings.Contains(got, "💱 ≈ 2000.00 RUB (курс 1 EUR = 100 RUB, cbr, 05.03.2025)") {
+		t.Fatalf("missing conversion preview: %q", got)
+	}
+	if d := fx.asOf[len(fx.asOf)-1]; d.Year() != 2025 || d.Month() != time.March {
+		t.Fatalf("rate must be requested for the transaction date, got %v", d)
+	}
+	if got := send("1500 JPY ужин"); !strings.Contains(got, "💱 ≈ 900.00 RUB") {
+		t.Fatalf("JPY must be rescaled to kopecks: %q", got)
+	}
+	if got := send("300 ужин"); strings.Contains(got, "💱") {
+		t.Fatalf("no preview expected for the base currency: %q", got)
+	}
+
+	got = send("/stats 2025-08 usd")
+	if !strings.Contains(got, "312.50 USD") || !strings.Contains(got, "218.75 USD") || !strings.Contains(got, "курс 1 RUB = 0.0125 USD, cbr, 31.08.2025") {
+		t.Fatalf("unexpected stats in USD: %q", got)
+	}
+	if got := send("/stats 2025-08"); strings.Contains(got, "USD") {
+		t.Fatalf("stats must stay in the report currency by default: %q", got)
+	}
+}
+
+// staticTenantClient overrides the fake's tenant list and counts how often it is listed.
+type staticTenantClient struct {
+	grpcclient.FakeTenantClient
+	tenants []*grpcclient.Tenant
+	lists   int
+}
+
+func (s *staticTenantClient) ListTenants(context.Context, string) ([]*grpcclient.Tenant, error) {
+	s.lists++
+	return s.tenants, nil
+}
+
+func TestHandler_RateOverride(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	fx := &stubFxClient{rates: map[string]float64{"USD|RUB": 80, "EUR|RUB": 100}}
+	tenants := &staticTenantClient{tenants: []*grpcclient.Tenant{{ID: "t", Name: "Путешествия", DefaultCurrency: "RUB", Role: grpcclient.TenantRoleMember}}}
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, mappings, nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithOperationContexts(repository.NewSQLiteOperationContextRepository(db)).
+		WithTransactionClient(&createRecordingTxClient{}).
+		WithTenantClient(tenants).
+		WithFxClient(fx)
+
+	ctx := context.Background()
+	userID := int64(88)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "access-token-88", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	if err := mappings.AddMapping(ctx, &repository.CategoryMapping{ID: "m1", TenantID: "t", Keyword: "ужин", CategoryID: "cat-food"}); err != nil {
+		t.Fatalf("add mapping: %v", err)
+	}
+	send := func(text string) string {
+		msg := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 8650}, From: &tgbotapi.User{ID: userID}, Text: text}
+		if strings.HasPrefix(text, "/") {
+			msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}
+		}
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: msg})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+
+	// warm the converter cache with the provider rate
+	if got := send("10 USD ужин"); !strings.Contains(got, "800.00 RUB") {
+		t.Fatalf("unexpected preview: %q", got)
+	}
+	if got := send("/rate USD RUB 92.5"); !strings.Contains(got, "владельцы и администраторы") || len(fx.upserted) != 0 {
+		t.Fatalf("members must not change rates: %q", got)
+	}
+
+	tenants.tenants[0].Role = grpcclient.TenantRoleAdmin
+	for _, bad := range []string{"/rate USD", "/rate USD XYZ 92.5", "/rate USD RUB -1", "/rate USD RUB 92.5 когда-нибудь"} {
+		if got := send(bad); !strings.Contains(got, "/rate USD RUB 92.5") {
+			t.Fatalf("%q: expected usage hint, got %q", bad, got)
+		}
+	}
+	got := send("/rate usd rub 92,5 05.03.2025")
+	if len(fx.upserted) != 1 || fx.upserted[0].Rate != 92.5 || fx.upserted[0].Provider != grpcclient.ManualRateProvider || fx.upserted[0].AsOf.Day() != 5 {
+		t.Fatalf("unexpected upsert: %+v", fx.upserted)
+	}
+	if !strings.Contains(got, "1 USD = 92.5 RUB, manual, 05.03.2025") {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+	if got := send("10 USD ужин"); !strings.Contains(got, "925.00 RUB") {
+		t.Fatalf("cached rate must be invalidated after the override: %q", got)
+	}
+
+	got = send("/rates")
+	if !strings.Contains(got, "Курсы к RUB") || !strings.Contains(got, "1 USD = 92.5 RUB (manual)") || !strings.Contains(got, "1 EUR = 100 RUB (cbr)") {
+		t.Fatalf("unexpected rates: %q", got)
+	}
+
+	// ordinary saves reuse the tenant list for the base currency
+	lists := tenants.lists
+	send("10 USD ужин")
+	send("20 USD ужин")
+	if tenants.lists != lists {
+		t.Fatalf("tenants listed %d times for two saves", tenants.lists-lists)
+	}
+}
@@ -0,0 +1,411 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// groupScope describes the group chat an update came from.
+type groupScope struct {
+	chatID int64
+	// binding is nil while the chat is not bound to a tenant
+	binding *repository.GroupChat
+	// replyTo is the message replies are threaded to
+	replyTo int
+}
+
+type groupScopeKey struct{}
+
+func withGroupScope(ctx context.Context, g *groupScope) context.Context {
+	return context.WithValue(ctx, groupScopeKey{}, g)
+}
+
+// groupFromContext returns the group chat of the update being handled, nil in private chats.
+func groupFromContext(ctx context.Context) *groupScope {
+	g, _ := ctx.Value(groupScopeKey{}).(*groupScope)
+	return g
+}
+
+func isGroupChat(chat *tgbotapi.Chat) bool {
+	return chat != nil && (chat.IsGroup() || chat.IsSuperGroup())
+}
+
+// scopedSession points the member's own session at the tenant bound to the group chat.
+// The stored session is not changed.
+func scopedSession(ctx context.Context, s *repository.UserSession) *repository.UserSession {
+	g := groupFromContext(ctx)
+	if s == nil || g == nil || g.binding == nil {
+		return s
+	}
+	c := *s
+	c.TenantID = g.binding.TenantID
+	return &c
+}
+
+// WithGroupChats allows injecting a group chat bindings repository; without it group chats stay unbound.
+func (h *Handler) WithGroupChats(r repository.GroupChatRepository) *Handler {
+	h.groups = r
+	return h
+}
+
+// groupCommands are the commands available in group chats; the value tells whether the chat must be bound.
+// Login, personal settings and other private flows are only available in a private chat with the bot.
+var groupCommands = map[string]bool{
+	"help":           false,
+	"cancel":         false,
+	"bind_tenant":    false,
+	"unbind_tenant":  true,
+	"chat_settings":  true,
+	"stats":          true,
+	"top_categories": true,
+	"trend":          true,
+	"compare":        true,
+	"forecast":       true,
+	"recent":         true,
+	"find":           true,
+	"budgets":        true,
+	"categories":     true,
+	"rates":          true,
+	"members":        true,
+}
+
+// commandTarget splits "/stats@budget_bot args" into the command and the bot it is addressed to.
+func commandTarget(text string) (string, string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", ""
+	}
+	cmd, bot, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+	return strings.ToLower(cmd), bot
+}
+
+// enterGroup prepares a group chat message for handling and reports whether the bot should react to it.
+// The returned context carries the chat binding, so sessions resolve to the bound tenant and replies
+// are threaded to the author's message.
+func (h *Handler) enterGroup(ctx context.Context, msg *tgbotapi.Message) (context.Context, bool) {
+	if msg.From == nil || msg.From.IsBot {
+		return ctx, false
+	}
+	g := &groupScope{chatID: msg.Chat.ID, replyTo: msg.MessageID}
+	if h.groups != nil {
+		if b, err := h.groups.Get(ctx, msg.Chat.ID); err == nil {
+			g.binding = b
+		}
+	}
+	ctx = withGroupScope(ctx, g)
+	locale := h.userLocale(ctx, msg.From.ID)
+
+	cmd, target := commandTarget(msg.Text)
+	if cmd == "" {
+		// "@budget_bot 100 кофе" is a message to the bot
+		if mention := "@" + h.bot.Self.UserName; h.bot.Self.UserName != "" && strings.HasPrefix(strings.ToLower(msg.Text), strings.ToLower(mention)) {
+			msg.Text = strings.TrimSpace(msg.Text[len(mention):])
+		}
+		if g.binding == nil {
+			return ctx, false
+		}
+		if !h.isGroupMember(ctx, msg.From.ID) {
+			// stay quiet on chatter, answer only what looks like a transaction
+			if h.parsesAsTransaction(ctx, msg.From.ID, msg.Text) {
+				h.sendNotGroupMember(ctx, msg)
+			}
+			return ctx, false
+		}
+		return ctx, true
+	}
+	if target != "" && !strings.EqualFold(target, h.bot.Self.UserName) {
+		return ctx, false
+	}
+	needsBinding, allowed := groupCommands[cmd]
+	if !allowed {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(msg.Chat.ID, tr(locale,
+			"Эта команда доступна только в личном чате с ботом",
+			"This command is only available in a private chat with the bot")))
+		return ctx, false
+	}
+	if needsBinding && g.binding == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(msg.Chat.ID, tr(locale,
+			"Чат не привязан к организации. Владелец или администратор может привязать его: /bind_tenant",
+			"This chat is not bound to a tenant. An owner or admin can bind it: /bind_tenant")))
+		return ctx, false
+	}
+	if needsBinding && !h.isGroupMember(ctx, msg.From.ID) {
+		h.sendNotGroupMember(ctx, msg)
+		return ctx, false
+	}
+	return ctx, true
+}
+
+// isGroupMember checks that a logged-in author belongs to the chat's tenant. Users without a session pass:
+// handlers explain how to log in. It runs on every group message, so the tenant list is taken from the cache.
+func (h *Handler) isGroupMember(ctx context.Context, userID int64) bool {
+	sess, err := h.auth.GetSession(ctx, userID)
+	if err != nil || sess == nil {
+		return true
+	}
+	return h.cachedTenant(ctx, sess) != nil
+}
+
+// parsesAsTransaction tells transactions from ordinary chatter in a group.
+func (h *Handler) parsesAsTransaction(ctx context.Context, userID int64, text string) bool {
+	parsed, _ := h.parser.ParseMessageAt(text, h.userNow(ctx, userID))
+	return parsed != nil && parsed.IsValid
+}
+
+func (h *Handler) sendNotGroupMember(ctx context.Context, msg *tgbotapi.Message) {
+	locale := h.userLocale(ctx, msg.From.ID)
+	_, _ = h.send(ctx, tgbotapi.NewMessage(msg.Chat.ID, tr(locale,
+		"Вы не участник организации, к которой привязан этот чат. Попросите администратора пригласить вас: /invite",
+		"You are not a member of the tenant this chat is bound to. Ask an admin to invite you: /invite")))
+}
+
+// enterGroupCallback scopes a keyboard tap in a group chat and reports whether the tapping user may use it:
+// keyboards only react to the author of the message they were sent for.
+func (h *Handler) enterGroupCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) (context.Context, bool) {
+	g := &groupScope{chatID: cb.Message.Chat.ID}
+	if reply := cb.Message.ReplyToMessage; reply != nil {
+		g.replyTo = reply.MessageID
+	}
+	if h.groups != nil {
+		if b, err := h.groups.Get(ctx, cb.Message.Chat.ID); err == nil {
+			g.binding = b
+		}
+	}
+	ctx = withGroupScope(ctx, g)
+	if author := h.callbackAuthor(ctx, cb); author != 0 && author != cb.From.ID {
+		locale := h.userLocale(ctx, cb.From.ID)
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Эти кнопки для автора сообщения", "These buttons are for the message author")))
+		return ctx, false
+	}
+	return ctx, true
+}
+
+// opCallbackPrefixes are keyboards bound to an operation context; the operation records its author.
+var opCallbackPrefixes = []string{
+	"v1:remember:", "v1:forget:", "v1:change:", "v1:edit_amount:", "v1:edit_date:", "v1:edit_comment:",
+	"v1:delete:", "v1:delete_yes:", "v1:delete_no:",
+}
+
+// callbackAuthor returns who a keyboard was shown to, 0 if unknown.
+func (h *Handler) callbackAuthor(ctx context.Context, cb *tgbotapi.CallbackQuery) int64 {
+	opID := ""
+	for _, p := range opCallbackPrefixes {
+		if strings.HasPrefix(cb.Data, p) {
+			opID = strings.TrimPrefix(cb.Data, p)
+		}
+	}
+	if payload, ok := strings.CutPrefix(cb.Data, "v1:cat_select:"); ok {
+		if _, id, found := strings.Cut(payload, ":"); found {
+			opID = id
+		}
+	}
+	if opID != "" && h.opCtxs != nil {
+		if op, err := h.opCtxs.Get(ctx, opID); err == nil && op.TelegramID != 0 {
+			return op.TelegramID
+		}
+	}
+	if reply := cb.Message.ReplyToMessage; reply != nil && reply.From != nil && !reply.From.IsBot {
+		return reply.From.ID
+	}
+	return 0
+}
+
+// send delivers a message; in group chats replies are threaded to the message that triggered them.
+func (h *Handler) send(ctx context.Context, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if g := groupFromContext(ctx); g != nil && g.replyTo != 0 {
+		switch m := c.(type) {
+		case tgbotapi.MessageConfig:
+			if m.ChatID == g.chatID && m.ReplyToMessageID == 0 {
+				m.ReplyToMessageID, m.AllowSendingWithoutReply = g.replyTo, true
+				c = m
+			}
+		case tgbotapi.DocumentConfig:
+			if m.ChatID == g.chatID && m.ReplyToMessageID == 0 {
+				m.ReplyToMessageID, m.AllowSendingWithoutReply = g.replyTo, true
+				c = m
+			}
+		case tgbotapi.PhotoConfig:
+			if m.ChatID == g.chatID && m.ReplyToMessageID == 0 {
+				m.ReplyToMessageID, m.AllowSendingWithoutReply = g.replyTo, true
+				c = m
+			}
+		}
+	}
+	return h.bot.Send(c)
+}
+
+// handleBindTenant binds the group chat to a tenant: /bind_tenant [name or id], the current tenant by default.
+// Only owners and admins of the tenant may bind a chat, and of the previously bound tenant when rebinding.
+func (h *Handler) handleBindTenant(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	g := groupFromContext(ctx)
+	if g == nil || h.groups == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Команда работает в групповом чате: добавьте бота в группу и отправьте /bind_tenant там", "Use this command in a group chat: add the bot to a group and send /bind_tenant there")))
+		return
+	}
+	sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+	list, err := h.tenants.ListTenants(ctx, sess.AccessToken)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить организации", "Failed to load tenants")))
+		return
+	}
+	query := strings.TrimSpace(update.Message.CommandArguments())
+	var target *grpcclient.Tenant
+	for _, t := range list {
+		if (query == "" && t.ID == sess.TenantID) || (query != "" && (t.ID == query || strings.EqualFold(t.Name, query))) {
+			target = t
+			break
+		}
+	}
+	if target == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Организация не найдена среди ваших. Формат: /bind_tenant [название]", "Tenant not found among yours. Usage: /bind_tenant [name]")))
+		return
+	}
+	if !isAdminRole(target.Role) || (g.binding != nil && g.binding.TenantID != target.ID && !h.isAdminOf(list, g.binding.TenantID)) {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Привязать чат могут только владельцы и администраторы организации", "Only tenant owners and admins can bind the chat")))
+		return
+	}
+	if err := h.groups.Bind(ctx, &repository.GroupChat{ChatID: chatID, TenantID: target.ID, BoundBy: userID}); err != nil {
+		h.logger.Error("bind group chat failed", zap.Int64("chatID", chatID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось привязать чат", "Failed to bind the chat")))
+		return
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale,
+		"✅ Чат привязан к организации «%s». Сообщения вида «100 кофе» сохраняются от имени их авторов — каждому участнику нужно один раз войти в личном чате с ботом: /login",
+		"✅ The chat is bound to “%s”. Messages like “100 coffee” are saved on behalf of their authors — each member needs to log in once in a private chat with the bot: /login"), target.Name)))
+}
+
+// isAdminOf reports whether the tenant is among the user's tenants with an owner or admin role.
+func (h *Handler) isAdminOf(list []*grpcclient.Tenant, tenantID string) bool {
+	for _, t := range list {
+		if t.ID == tenantID {
+			return isAdminRole(t.Role)
+		}
+	}
+	return false
+}
+
+// groupAdmin loads the tenant bound to the chat and checks that the user may manage the binding.
+func (h *Handler) groupAdmin(ctx context.Context, update tgbotapi.Update) (*grpcclient.Tenant, bool) {
+	locale := h.userLocale(ctx, update.Message.From.ID)
+	_, t, ok := h.tenantContext(ctx, update)
+	if !ok {
+		return nil, false
+	}
+	if !isAdminRole(t.Role) {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Это могут делать только владельцы и администраторы организации", "Only tenant owners and admins can do this")))
+		return nil, false
+	}
+	return t, true
+}
+
+// handleUnbindTenant removes the chat binding.
+func (h *Handler) handleUnbindTenant(ctx context.Context, update tgbotapi.Update) {
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, update.Message.From.ID)
+	if _, ok := h.groupAdmin(ctx, update); !ok {
+		return
+	}
+	if err := h.groups.Unbind(ctx, chatID); err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось отвязать чат", "Failed to unbind the chat")))
+		return
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Чат отвязан от организации", "The chat is unbound from the tenant")))
+}
+
+// handleChatSettings shows or changes per-chat settings:
+// /chat_settings language ru|en|off, /chat_settings currency <code>|off.
+func (h *Handler) handleChatSettings(ctx context.Context, update tgbotapi.Update) {
+	chatID := update.Message.Chat.ID
+	userID := update.Message.From.ID
+	locale := h.userLocale(ctx, userID)
+	g := groupFromContext(ctx)
+	args := strings.Fields(update.Message.CommandArguments())
+	if len(args) == 0 {
+		sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+		if !ok {
+			return
+		}
+		name := g.binding.TenantID
+		if t := h.currentTenant(ctx, sess); t != nil {
+			name = t.Name
+		}
+		unset := tr(locale, "личные настройки участника", "member's own setting")
+		lang, cur := g.binding.Language, g.binding.DefaultCurrency
+		if lang == "" {
+			lang = unset
+		}
+		if cur == "" {
+			cur = unset
+		}
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, strings.Join([]string{
+			fmt.Sprintf(tr(locale, "👥 Организация чата: %s", "👥 Chat tenant: %s"), name),
+			fmt.Sprintf(tr(locale, "Язык: %s", "Language: %s"), lang),
+			fmt.Sprintf(tr(locale, "Валюта по умолчанию: %s", "Default currency: %s"), cur),
+			"",
+			tr(locale,
+				"Изменить: /chat_settings language ru|en|off или /chat_settings currency <код>|off",
+				"Change: /chat_settings language ru|en|off or /chat_settings currency <code>|off"),
+		}, "\n")))
+		return
+	}
+	usage := tr(locale, "Формат: /chat_settings language ru|en|off или /chat_settings currency <код>|off", "Usage: /chat_settings language ru|en|off or /chat_settings currency <code>|off")
+	if len(args) != 2 {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, usage))
+		return
+	}
+	lang, cur := g.binding.Language, g.binding.DefaultCurrency
+	value := strings.ToLower(args[1])
+	switch strings.ToLower(args[0]) {
+	case "language", "язык":
+		switch value {
+		case "ru", "en":
+			lang = value
+		case "off":
+			lang = ""
+		default:
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, usage))
+			return
+		}
+	case "currency", "валюта":
+		if value == "off" {
+			cur = ""
+		} else if c, ok := domain.LookupCurrency(args[1]); ok {
+			cur = c.Code
+		} else {
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "Неизвестная валюта %q. Укажите код ISO 4217, например GEL", "Unknown currency %q. Use an ISO 4217 code such as GEL"), args[1])))
+			return
+		}
+	default:
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, usage))
+		return
+	}
+	if _, ok := h.groupAdmin(ctx, update); !ok {
+		return
+	}
+	if err := h.groups.UpdateSettings(ctx, chatID, lang, cur); err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось сохранить настройки", "Failed to save settings")))
+		return
+	}
+	g.binding.Language, g.binding.DefaultCurrency = lang, cur
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(h.userLocale(ctx, userID), "✅ Настройки чата сохранены", "✅ Chat settings saved")))
+}
+
+// isEditState reports whether a dialog may continue in a group chat: edits and amount fixes are started from
+// confirmations there, while login and import dialogs belong to the private chat.
+func isEditState(s repository.DialogState) bool {
+	return s == repository.StateWaitingForEditAmount || s == repository.StateWaitingForEditDate || s == repository.StateWaitingForEditComment ||
+		s == repository.StateWaitingForDraftAmount
+}
@@ -0,0 +1,159 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// tokenTenantClient returns a different tenant list for every access token and counts the lists.
+type tokenTenantClient struct {
+	grpcclient.FakeTenantClient
+	byToken map[string][]*grpcclient.Tenant
+	lists   int
+}
+
+func (c *tokenTenantClient) ListTenants(_ context.Context, token string) ([]*grpcclient.Tenant, error) {
+	c.lists++
+	return c.byToken[token], nil
+}
+
+func TestHandler_GroupChat(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	states := repository.NewSQLiteDialogStateRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	opCtxs := repository.NewSQLiteOperationContextRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &createRecordingTxClient{}
+	family := func(role string) *grpcclient.Tenant {
+		return &grpcclient.Tenant{ID: "family", Name: "Семья", DefaultCurrency: "RUB", Role: role}
+	}
+	tenants := &tokenTenantClient{byToken: map[string][]*grpcclient.Tenant{
+		"token-alice": {{ID: "alice", Name: "Личное", Role: grpcclient.TenantRoleOwner}, family(grpcclient.TenantRoleOwner)},
+		"token-bob-1": {{ID: "bob", Name: "Личное", Role: grpcclient.TenantRoleOwner}, family(grpcclient.TenantRoleMember)},
+		"token-carol": {{ID: "carol", Name: "Личное", Role: grpcclient.TenantRoleOwner}},
+	}}
+	h := NewHandler(bot, states, auth, mappings, nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithOperationContexts(opCtxs).
+		WithTransactionClient(tx).
+		WithTenantClient(tenants).
+		WithGroupChats(repository.NewSQLiteGroupChatRepository(db))
+
+	ctx := context.Background()
+	const alice, bob, carol = int64(1), int64(2), int64(3)
+	for id, s := range map[int64][2]string{alice: {"alice", "token-alice"}, bob: {"bob", "token-bob-1"}, carol: {"carol", "token-carol"}} {
+		if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: id, UserID: s[0], TenantID: s[0], AccessToken: s[1], RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatalf("save session: %v", err)
+		}
+	}
+	if err := mappings.AddMapping(ctx, &repository.CategoryMapping{ID: "m1", TenantID: "family", Keyword: "такси", CategoryID: "cat-transport"}); err != nil {
+		t.Fatalf("add mapping: %v", err)
+	}
+
+	chat := &tgbotapi.Chat{ID: -1001, Type: "supergroup"}
+	messageID := 0
+	send := func(from int64, text string) (int, []string) {
+		messageID++
+		before := len(rec.Texts())
+		msg := &tgbotapi.Message{MessageID: messageID, Chat: chat, From: &tgbotapi.User{ID: from}, Text: text}
+		if strings.HasPrefix(text, "/") {
+			msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}
+		}
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: msg})
+		return messageID, rec.Texts()[before:]
+	}
+
+	if _, got := send(alice, "100 кофе"); len(got) != 0 || len(tx.created) != 0 {
+		t.Fatalf("unbound chat must be ignored: %q", got)
+	}
+	if _, got := send(alice, "/stats"); len(got) != 1 || !strings.Contains(got[0], "не привязан") {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+	if _, got := send(alice, "/login"); len(got) != 1 || !strings.Contains(got[0], "личном чате") {
+		t.Fatalf("login must be private: %q", got)
+	}
+	if _, got := send(bob, "/bind_tenant@testbot Семья"); len(got) != 1 || !strings.Contains(got[0], "только владельцы") {
+		t.Fatalf("members must not bind: %q", got)
+	}
+	if _, got := send(alice, "/bind_tenant Семья"); len(got) != 1 || !strings.Contains(got[0], "Семья") {
+		t.Fatalf("unexpected bind reply: %q", got)
+	}
+	if _, got := send(alice, "/stats@otherbot"); len(got) != 0 {
+		t.Fatalf("commands for other bots must be ignored: %q", got)
+	}
+	if _, got := send(bob, "привет всем"); len(got) != 0 {
+		t.Fatalf("chatter must be ignored: %q", got)
+	}
+
+	bobMsg, got := send(bob, "250 такси")
+	if len(tx.created) != 1 || tx.created[0].TenantID != "family" || tx.created[0].AmountMinor != 25000 {
+		t.Fatalf("transaction must go to the bound tenant: %+v", tx.created)
+	}
+	calls := rec.Calls("sendMessage")
+	if len(got) != 1 || calls[len(calls)-1].Params.Get("reply_to_message_id") != strconv.Itoa(bobMsg) {
+		t.Fatalf("reply must be threaded to the author: %q %v", got, calls[len(calls)-1].Params)
+	}
+	if sess, _ := sessions.GetSession(ctx, bob); sess.TenantID != "bob" {
+		t.Fatalf("personal tenant must not change: %+v", sess)
+	}
+	// the membership of the author is not looked up again on every message
+	lists := tenants.lists
+	send(bob, "привет всем")
+	send(bob, "ещё привет")
+	if tenants.lists != lists {
+		t.Fatalf("tenants listed %d times for two group messages", tenants.lists-lists)
+	}
+
+	if _, got := send(carol, "100 кофе"); len(got) != 1 || !strings.Contains(got[0], "не участник") || len(tx.created) != 1 {
+		t.Fatalf("non-members must not save: %q", got)
+	}
+
+	// category keyboards react only to the author's taps
+	aliceMsg, _ := send(alice, "300 обед")
+	st, _ := states.GetState(ctx, alice)
+	opID, _ := st.Context["op_id"].(string)
+	if opID == "" {
+		t.Fatalf("category prompt expected: %+v", st)
+	}
+	tap := func(from int64) {
+		h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:   "cb",
+			From: &tgbotapi.User{ID: from},
+			Data: "v1:cat_select:cat-food:" + opID,
+			Message: &tgbotapi.Message{MessageID: 500, Chat: chat, From: &tgbotapi.User{ID: 99, IsBot: true},
+				ReplyToMessage: &tgbotapi.Message{MessageID: aliceMsg, From: &tgbotapi.User{ID: alice}}},
+		}})
+	}
+	tap(bob)
+	answers := rec.Calls("answerCallbackQuery")
+	if len(tx.created) != 1 || !strings.Contains(answers[len(answers)-1].Params.Get("text"), "автора") {
+		t.Fatalf("other members must not pick a category: %+v", tx.created)
+	}
+	tap(alice)
+	if len(tx.created) != 2 || tx.created[1].TenantID != "family" || tx.created[1].CategoryID != "cat-food" {
+		t.Fatalf("author's tap must save: %+v", tx.created)
+	}
+
+	if _, got := send(bob, "/chat_settings currency EUR"); len(got) != 1 || !strings.Contains(got[0], "только владельцы") {
+		t.Fatalf("members must not change chat settings: %q", got)
+	}
+	if _, got := send(alice, "/chat_settings currency EUR"); len(got) != 1 || !strings.Contains(got[0], "сохранены") {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+	send(bob, "50 такси")
+	if last := tx.created[len(tx.created)-1]; last.Currency != "EUR" {
+		t.Fatalf("chat currency must apply: %+v", last)
+	}
+}
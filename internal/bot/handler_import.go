@@ -0,0 +1,345 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"budget-bot/internal/bot/ui"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	// maxImportBytes limits the size of an uploaded CSV file.
+	maxImportBytes = 5 << 20
+	// importChunkBytes is the size of a single UploadCsvChunk request.
+	importChunkBytes = 64 << 10
+	// importPreviewLimit is how many rows the server validates for a preview.
+	importPreviewLimit = 1000
+)
+
+// importFieldKeys lists mappable transaction fields in display order.
+var importFieldKeys = []string{"date", "amount", "currency", "type", "category", "comment"}
+
+// importHeaderHints maps lowercase header fragments to transaction fields.
+var importHeaderHints = map[string][]string{
+	"date":     {"дата", "date"},
+	"amount":   {"сумма", "amount", "sum"},
+	"currency": {"валюта", "currency"},
+	"type":     {"тип", "type"},
+	"category": {"категория", "category"},
+	"comment":  {"описание", "комментарий", "назначение", "description", "comment", "memo", "payee"},
+}
+
+func importFieldLabel(field, locale string) string {
+	switch field {
+	case "date":
+		return tr(locale, "Дата", "Date")
+	case "amount":
+		return tr(locale, "Сумма", "Amount")
+	case "currency":
+		return tr(locale, "Валюта", "Currency")
+	case "type":
+		return tr(locale, "Тип", "Type")
+	case "category":
+		return tr(locale, "Категория", "Category")
+	default:
+		return tr(locale, "Комментарий", "Comment")
+	}
+}
+
+// isCSVDocument reports whether the message carries a CSV file.
+func isCSVDocument(m *tgbotapi.Message) bool {
+	if m == nil || m.Document == nil {
+		return false
+	}
+	return strings.EqualFold(path.Ext(m.Document.FileName), ".csv") || m.Document.MimeType == "text/csv"
+}
+
+// readCSVHeader strips a UTF-8 BOM, detects encoding and delimiter, and returns the header columns.
+func readCSVHeader(data []byte) (headers []string, delimiter, encoding string, body []byte) {
+	body = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	encoding = "utf-8"
+	firstLine := body
+	if i := bytes.IndexByte(body, '\n'); i >= 0 {
+		firstLine = body[:i]
+	}
+	if !utf8.Valid(firstLine) {
+		if decoded, err := charmap.Windows1251.NewDecoder().Bytes(firstLine); err == nil {
+			firstLine = decoded
+			encoding = "windows-1251"
+		}
+	}
+	line := strings.TrimRight(string(firstLine), "\r")
+	delimiter = ","
+	best := strings.Count(line, delimiter)
+	for _, d := range []string{";", "\t"} {
+		if n := strings.Count(line, d); n > best {
+			delimiter, best = d, n
+		}
+	}
+	r := csv.NewReader(strings.NewReader(line))
+	r.Comma = []rune(delimiter)[0]
+	r.LazyQuotes = true
+	headers, _ = r.Read()
+	for i := range headers {
+		headers[i] = strings.TrimSpace(headers[i])
+	}
+	return headers, delimiter, encoding, body
+}
+
+// guessImportMapping binds fields to the first header containing one of their hints.
+func guessImportMapping(headers []string) map[string]string {
+	mapping := map[string]string{}
+	used := map[string]bool{}
+	for _, field := range importFieldKeys {
+		for _, name := range headers {
+			if used[name] {
+				continue
+			}
+			lower := strings.ToLower(name)
+			for _, hint := range importHeaderHints[field] {
+				if strings.Contains(lower, hint) {
+					mapping[field] = name
+					used[name] = true
+					break
+				}
+			}
+			if mapping[field] != "" {
+				break
+			}
+		}
+	}
+	return mapping
+}
+
+// importStateFrom restores the import id, headers and mapping stored in the dialog state.
+func importStateFrom(rec *repository.DialogStateRecord) (string, []string, map[string]string) {
+	if rec == nil || rec.State != repository.StateConfiguringImport {
+		return "", nil, nil
+	}
+	importID, _ := rec.Context["import_id"].(string)
+	var headers []string
+	if raw, ok := rec.Context["headers"].([]any); ok {
+		for _, v := range raw {
+			s, _ := v.(string)
+			headers = append(headers, s)
+		}
+	}
+	mapping := map[string]string{}
+	if raw, ok := rec.Context["mapping"].(map[string]any); ok {
+		for k, v := range raw {
+			if s, _ := v.(string); s != "" {
+				mapping[k] = s
+			}
+		}
+	}
+	return importID, headers, mapping
+}
+
+func importMappingFields(mapping map[string]string, locale string) []ui.ImportField {
+	fields := make([]ui.ImportField, 0, len(importFieldKeys))
+	for _, key := range importFieldKeys {
+		fields = append(fields, ui.ImportField{Key: key, Label: importFieldLabel(key, locale), Column: mapping[key]})
+	}
+	return fields
+}
+
+func toCsvColumnMapping(m map[string]string) grpcclient.CsvColumnMapping {
+	return grpcclient.CsvColumnMapping{
+		Date:     m["date"],
+		Amount:   m["amount"],
+		Currency: m["currency"],
+		Type:     m["type"],
+		Category: m["category"],
+		Comment:  m["comment"],
+	}
+}
+
+func (h *Handler) handleImport(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	locale := h.userLocale(ctx, userID)
+	if _, ok := h.getSessionWithErrorHandling(ctx, update.Message.Chat.ID, userID); !ok {
+		return
+	}
+	_ = h.states.SetState(ctx, userID, repository.StateWaitingForImportFile, nil, nil)
+	_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale,
+		"Отправьте CSV-файл выписки (до 5 МБ). Первая строка должна содержать заголовки колонок.\n\nДля отмены: /cancel",
+		"Send a CSV statement file (up to 5 MB). The first line must contain column headers.\n\nTo cancel: /cancel")))
+}
+
+// handleImportFile uploads a CSV document to ImportService and asks the user to confirm the column mapping.
+func (h *Handler) handleImportFile(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+	doc := update.Message.Document
+	if doc.FileSize > maxImportBytes {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Файл слишком большой (максимум 5 МБ)", "The file is too large (5 MB max)")))
+		return
+	}
+	data, err := h.files.Download(ctx, doc.FileID)
+	if err != nil {
+		h.logger.Warn("failed to download import file", zap.Int64("telegramID", userID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось загрузить файл", "Failed to download the file")))
+		return
+	}
+	headers, delimiter, encoding, body := readCSVHeader(data)
+	if len(headers) < 2 || len(body) > maxImportBytes {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось прочитать заголовок CSV-файла", "Could not read the CSV header")))
+		return
+	}
+
+	importID, err := h.imports.StartCsvImport(ctx, doc.FileName, delimiter, encoding, sess.AccessToken)
+	if err != nil {
+		h.logger.Error("failed to start csv import", zap.Int64("telegramID", userID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось начать импорт", "Failed to start the import")))
+		return
+	}
+	for offset := 0; offset < len(body); offset += importChunkBytes {
+		end := offset + importChunkBytes
+		if end > len(body) {
+			end = len(body)
+		}
+		if _, err := h.imports.UploadCsvChunk(ctx, importID, body[offset:end], end == len(body), sess.AccessToken); err != nil {
+			h.logger.Error("failed to upload csv chunk", zap.String("importID", importID), zap.Int("offset", offset), zap.Error(err))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось загрузить файл на сервер", "Failed to upload the file")))
+			return
+		}
+	}
+
+	mapping := guessImportMapping(headers)
+	_ = h.states.SetState(ctx, userID, repository.StateConfiguringImport, map[string]any{
+		"import_id": importID,
+		"headers":   headers,
+		"mapping":   mapping,
+	}, nil)
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("%s %s (%d %s)\n%s",
+		tr(locale, "📄 Файл загружен:", "📄 File uploaded:"),
+		doc.FileName,
+		len(body),
+		tr(locale, "байт", "bytes"),
+		tr(locale, "Проверьте сопоставление колонок. Нажмите на поле, чтобы выбрать другую колонку.", "Check the column mapping. Tap a field to pick another column.")))
+	msg.ReplyMarkup = ui.CreateImportMappingKeyboard(importMappingFields(mapping, locale), locale)
+	_, _ = h.send(ctx, msg)
+}
+
+// handleImportCallback handles mapping adjustments and preview/dry-run/commit/cancel actions.
+func (h *Handler) handleImportCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, action string) {
+	userID := cb.From.ID
+	locale := h.userLocale(ctx, userID)
+	rec, _ := h.states.GetState(ctx, userID)
+	importID, headers, mapping := importStateFrom(rec)
+	if importID == "" || cb.Message == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Импорт не найден", "Import not found")))
+		return
+	}
+	chatID := cb.Message.Chat.ID
+	messageID := cb.Message.MessageID
+	saveMapping := func() {
+		_ = h.states.SetState(ctx, userID, repository.StateConfiguringImport, map[string]any{
+			"import_id": importID,
+			"headers":   headers,
+			"mapping":   mapping,
+		}, nil)
+	}
+
+	switch {
+	case strings.HasPrefix(action, "field:"):
+		field := strings.TrimPrefix(action, "field:")
+		_, _ = h.bot.Request(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, ui.CreateImportColumnKeyboard(field, headers, locale)))
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, importFieldLabel(field, locale)))
+		return
+	case strings.HasPrefix(action, "col:"):
+		parts := strings.Split(strings.TrimPrefix(action, "col:"), ":")
+		idx := -1
+		if len(parts) == 2 {
+			idx, _ = strconv.Atoi(parts[1])
+		}
+		if idx >= 0 && idx < len(headers) {
+			for k, v := range mapping {
+				if v == headers[idx] {
+					delete(mapping, k)
+				}
+			}
+			mapping[parts[0]] = headers[idx]
+		} else {
+			delete(mapping, parts[0])
+		}
+		saveMapping()
+		_, _ = h.bot.Request(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, ui.CreateImportMappingKeyboard(importMappingFields(mapping, locale), locale)))
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, ""))
+		return
+	case action == "cancel":
+		_ = h.states.ClearState(ctx, userID)
+		_, _ = h.bot.Request(tgbotapi.NewEditMessageText(chatID, messageID, tr(locale, "Импорт отменён", "Import canceled")))
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, ""))
+		return
+	}
+
+	if mapping["date"] == "" || mapping["amount"] == "" {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Укажите колонки даты и суммы", "Choose date and amount columns")))
+		return
+	}
+	sess, err := h.auth.GetSession(ctx, userID)
+	if err != nil || sess == nil || time.Now().After(sess.AccessTokenExpiresAt) {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Нет сессии", "No session")))
+		return
+	}
+	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, ""))
+	if err := h.imports.ConfigureCsvMapping(ctx, importID, toCsvColumnMapping(mapping), sess.AccessToken); err != nil {
+		h.logger.Error("failed to configure csv mapping", zap.String("importID", importID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Сервер отклонил сопоставление колонок", "The server rejected the column mapping")))
+		return
+	}
+
+	switch action {
+	case "preview":
+		p, err := h.imports.PreviewCsvImport(ctx, importID, importPreviewLimit, sess.AccessToken)
+		if err != nil {
+			h.logger.Error("failed to preview csv import", zap.String("importID", importID), zap.Error(err))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить предпросмотр", "Failed to build a preview")))
+			return
+		}
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf("%s\n%s: %d\n%s: %d\n%s: %d",
+			tr(locale, "👀 Предпросмотр импорта", "👀 Import preview"),
+			tr(locale, "Всего строк", "Total rows"), p.TotalRows,
+			tr(locale, "Корректных", "Valid"), p.ValidRows,
+			tr(locale, "С ошибками", "Invalid"), p.InvalidRows)))
+	case "dry", "commit":
+		dryRun := action == "dry"
+		res, err := h.imports.CommitCsvImport(ctx, importID, dryRun, sess.AccessToken)
+		if err != nil {
+			h.logger.Error("failed to commit csv import", zap.String("importID", importID), zap.Bool("dryRun", dryRun), zap.Error(err))
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось выполнить импорт", "Import failed")))
+			return
+		}
+		if dryRun {
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf("%s\n%s: %d\n%s: %d",
+				tr(locale, "🧪 Пробный импорт, ничего не сохранено", "🧪 Dry run, nothing was saved"),
+				tr(locale, "Будет добавлено", "Would insert"), res.Inserted,
+				tr(locale, "С ошибками", "Failed"), res.Failed)))
+			return
+		}
+		_ = h.states.ClearState(ctx, userID)
+		_, _ = h.bot.Request(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf("%s\n%s: %d\n%s: %d",
+			tr(locale, "✅ Импорт завершён", "✅ Import finished"),
+			tr(locale, "Добавлено", "Inserted"), res.Inserted,
+			tr(locale, "С ошибками", "Failed"), res.Failed)))
+	}
+}
@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+	"golang.org/x/text/encoding/charmap"
+)
+
+type recordingImportClient struct {
+	grpcclient.FakeImportClient
+	delimiter string
+	encoding  string
+	chunks    int
+	mapping   grpcclient.CsvColumnMapping
+	dryRuns   int
+	commits   int
+}
+
+func (c *recordingImportClient) StartCsvImport(ctx context.Context, filename, delimiter, encoding, token string) (string, error) {
+	c.delimiter, c.encoding = delimiter, encoding
+	return c.FakeImportClient.StartCsvImport(ctx, filename, delimiter, encoding, token)
+}
+
+func (c *recordingImportClient) UploadCsvChunk(ctx context.Context, importID string, chunk []byte, last bool, token string) (int64, error) {
+	c.chunks++
+	return c.FakeImportClient.UploadCsvChunk(ctx, importID, chunk, last, token)
+}
+
+func (c *recordingImportClient) ConfigureCsvMapping(ctx context.Context, importID string, mapping grpcclient.CsvColumnMapping, token string) error {
+	c.mapping = mapping
+	return c.FakeImportClient.ConfigureCsvMapping(ctx, importID, mapping, token)
+}
+
+func (c *recordingImportClient) CommitCsvImport(ctx context.Context, importID string, dryRun bool, token string) (*grpcclient.ImportResult, error) {
+	if dryRun {
+		c.dryRuns++
+	} else {
+		c.commits++
+	}
+	return c.FakeImportClient.CommitCsvImport(ctx, importID, dryRun, token)
+}
+
+func TestReadCSVHeaderAndGuessMapping(t *testing.T) {
+	headers, delim, enc, _ := readCSVHeader([]byte("\xEF\xBB\xBFДата операции;Сумма операции;Валюта;Описание\r\n01.01.2025;-100;RUB;кофе\r\n"))
+	if delim != ";" || enc != "utf-8" || len(headers) != 4 || headers[0] != "Дата операции" {
+		t.Fatalf("unexpected header: %q %q %q", headers, delim, enc)
+	}
+	m := guessImportMapping(headers)
+	if m["date"] != "Дата операции" || m["amount"] != "Сумма операции" || m["currency"] != "Валюта" || m["comment"] != "Описание" || m["category"] != "" {
+		t.Fatalf("unexpected mapping: %v", m)
+	}
+
+	cp1251, _ := charmap.Windows1251.NewEncoder().Bytes([]byte("Дата\tСумма\tКатегория\n"))
+	headers, delim, enc, _ = readCSVHeader(cp1251)
+	if delim != "\t" || enc != "windows-1251" || len(headers) != 3 || headers[2] != "Категория" {
+		t.Fatalf("unexpected cp1251 header: %q %q %q", headers, delim, enc)
+	}
+
+	headers, delim, _, _ = readCSVHeader([]byte("Date,Amount,\"Payee, name\",Type\n"))
+	if delim != "," || len(headers) != 4 {
+		t.Fatalf("unexpected quoted header: %q", headers)
+	}
+	if m := guessImportMapping(headers); m["comment"] != "Payee, name" || m["type"] != "Type" {
+		t.Fatalf("unexpected mapping: %v", m)
+	}
+}
+
+func TestHandler_ImportFlow(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	states := repository.NewSQLiteDialogStateRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	imports := &recordingImportClient{}
+	csvData := "Дата;Сумма;Назначение платежа;Счёт\n" + strings.Repeat("01.01.2025;-100,50;кофе;40817\n", 3000)
+
+	h := NewHandler(bot, states, auth, mappings, nil, log).
+		WithImportClient(imports).
+		WithFileDownloader(staticFileDownloader{"csv-1": []byte(csvData)})
+
+	ctx := context.Background()
+	chatID := int64(8500)
+	userID := int64(85)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	callback := func(data string) {
+		h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: &tgbotapi.User{ID: userID}, Message: &tgbotapi.Message{MessageID: 50, Chat: &tgbotapi.Chat{ID: chatID}}, Data: data}})
+	}
+	lastText := func() string {
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+
+	h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: "/import", Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 7}}}})
+	if st, _ := states.GetState(ctx, userID); st == nil || st.State != repository.StateWaitingForImportFile {
+		t.Fatalf("expected waiting for file state, got %+v", st)
+	}
+	h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: "100 кофе"}})
+	if !strings.Contains(lastText(), "CSV") {
+		t.Fatalf("expected CSV reminder, got %q", lastText())
+	}
+
+	h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Document: &tgbotapi.Document{FileID: "csv-1", FileName: "statement.CSV"}}})
+	if imports.delimiter != ";" || imports.encoding != "utf-8" || imports.chunks < 2 {
+		t.Fatalf("unexpected upload: delimiter=%q encoding=%q chunks=%d", imports.delimiter, imports.encoding, imports.chunks)
+	}
+	st, _ := states.GetState(ctx, userID)
+	if st == nil || st.State != repository.StateConfiguringImport {
+		t.Fatalf("expected configuring state, got %+v", st)
+	}
+	sends := rec.Calls("sendMessage")
+	if markup := sends[len(sends)-1].Params.Get("reply_markup"); !strings.Contains(markup, "Дата: Дата") || !strings.Contains(markup, "Комментарий: Назначение платежа") {
+		t.Fatalf("unexpected mapping keyboard: %s", markup)
+	}
+
+	// Bind the category to the account column and unbind the comment
+	callback("v1:imp_field:category")
+	edits := rec.Calls("editMessageReplyMarkup")
+	if len(edits) == 0 || !strings.Contains(edits[len(edits)-1].Params.Get("reply_markup"), "v1:imp_col:category:3") {
+		t.Fatalf("column keyboard not shown: %+v", edits)
+	}
+	callback("v1:imp_col:category:3")
+	callback("v1:imp_col:comment:-1")
+
+	callback("v1:imp_preview")
+	if imports.mapping.Category != "Счёт" || imports.mapping.Comment != "" || imports.mapping.Date != "Дата" {
+		t.Fatalf("unexpected mapping sent: %+v", imports.mapping)
+	}
+	if !strings.Contains(lastText(), "3000") {
+		t.Fatalf("preview should report 3000 rows, got %q", lastText())
+	}
+
+	callback("v1:imp_dry")
+	if imports.dryRuns != 1 || imports.commits != 0 {
+		t.Fatalf("dry run must not commit")
+	}
+	if st, _ := states.GetState(ctx, userID); st == nil {
+		t.Fatalf("dry run must keep the import open")
+	}
+
+	// Another user cannot commit someone else's import
+	h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: &tgbotapi.User{ID: 999}, Message: &tgbotapi.Message{MessageID: 50, Chat: &tgbotapi.Chat{ID: chatID}}, Data: "v1:imp_commit"}})
+	if imports.commits != 0 {
+		t.Fatalf("foreign user must not commit the import")
+	}
+
+	callback("v1:imp_commit")
+	if imports.commits != 1 || !strings.Contains(lastText(), "3000") {
+		t.Fatalf("unexpected commit result: commits=%d text=%q", imports.commits, lastText())
+	}
+	if st, _ := states.GetState(ctx, userID); st != nil {
+		t.Fatalf("state should be cleared after commit, got %+v", st)
+	}
+}
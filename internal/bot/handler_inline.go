@@ -0,0 +1,256 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"budget-bot/internal/bot/ui"
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/metrics"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxInlineCategories limits how many categories are offered for an inline entry.
+const maxInlineCategories = 10
+
+// inlineStatsWords start an inline query for the month summary card: "@bot stats", "@bot итоги 2025-03".
+var inlineStatsWords = map[string]bool{"stats": true, "статистика": true, "итоги": true}
+
+// inlineSession returns a valid session for an inline query or nil.
+func (h *Handler) inlineSession(ctx context.Context, userID int64) *repository.UserSession {
+	sess, err := h.auth.GetSession(ctx, userID)
+	if err != nil || sess == nil || time.Now().After(sess.AccessTokenExpiresAt) {
+		return nil
+	}
+	return sess
+}
+
+// handleInlineQuery answers "@bot 350 кофе" with one result per category, the guessed one first, and
+// "@bot stats" with a month summary card that can be shared to any chat.
+// Choosing a transaction result saves it, see handleChosenInlineResult.
+func (h *Handler) handleInlineQuery(ctx context.Context, q *tgbotapi.InlineQuery) {
+	userID := q.From.ID
+	locale := h.userLocale(ctx, userID)
+	answer := tgbotapi.InlineConfig{InlineQueryID: q.ID, IsPersonal: true, Results: []interface{}{}}
+	sess := h.inlineSession(ctx, userID)
+	if sess == nil {
+		answer.SwitchPMText = tr(locale, "Войдите, чтобы записывать операции", "Log in to add transactions")
+		answer.SwitchPMParameter = "login"
+		h.answerInline(answer)
+		return
+	}
+
+	query := strings.TrimSpace(q.Query)
+	fields := strings.Fields(strings.ToLower(query))
+	if len(fields) == 0 || inlineStatsWords[fields[0]] {
+		if card := h.inlineStatsCard(ctx, sess, userID, fields, locale); card != "" {
+			title := tr(locale, "📊 Итоги месяца", "📊 Month summary")
+			article := tgbotapi.NewInlineQueryResultArticle("stats", title, card)
+			article.Description = strings.SplitN(card, "\n", 3)[1]
+			answer.Results = append(answer.Results, article)
+		}
+		h.answerInline(answer)
+		return
+	}
+
+	parsed, list, guessed := h.inlineEntry(ctx, sess, userID, query, locale, true)
+	if parsed == nil || len(list) == 0 {
+		h.answerInline(answer)
+		return
+	}
+	order := make([]*domain.Category, 0, len(list))
+	for _, c := range list {
+		if c.ID == guessed {
+			order = append([]*domain.Category{c}, order...)
+		} else {
+			order = append(order, c)
+		}
+	}
+	if len(order) > maxInlineCategories {
+		order = order[:maxInlineCategories]
+	}
+	amount := fmt.Sprintf("%s %s%s — %s", domain.FormatAmount(parsed.Amount.AmountMinor, parsed.Currency), parsed.Currency, expressionSuffix(parsed.Expression), parsed.Description)
+	for _, c := range order {
+		name := strings.TrimSpace(c.Emoji + " " + c.Name)
+		title := name
+		if c.ID == guessed {
+			title = "✅ " + name
+		}
+		text := fmt.Sprintf("%s %s\n%s: %s", txTypeLabel(string(parsed.Type), locale), amount, tr(locale, "Категория", "Category"), name)
+		article := tgbotapi.NewInlineQueryResultArticle("tx:"+c.ID, title, text)
+		article.Description = txTypeLabel(string(parsed.Type), locale) + " " + amount
+		answer.Results = append(answer.Results, article)
+	}
+	h.answerInline(answer)
+}
+
+func (h *Handler) answerInline(answer tgbotapi.InlineConfig) {
+	if _, err := h.bot.Request(answer); err != nil {
+		h.logger.Warn("failed to answer inline query", zap.Error(err))
+	}
+}
+
+// inlineEntry parses an inline query as a transaction and loads the categories of its type together with
+// the guessed category id. The LLM is only asked while answering the query, never when saving.
+func (h *Handler) inlineEntry(ctx context.Context, sess *repository.UserSession, userID int64, query, locale string, guess bool) (*ParsedTransaction, []*domain.Category, string) {
+	parsed, _ := h.parser.ParseMessageAt(query, h.userNow(ctx, userID))
+	if parsed == nil || !parsed.IsValid {
+		return nil, nil, ""
+	}
+	parsed.ApplyDefaultCurrency(h.defaultCurrency(ctx, userID))
+	list, err := h.categories.ListCategories(ctx, sess.TenantID, sess.AccessToken, parsed.Type, locale)
+	if err != nil {
+		h.logger.Warn("inline: failed to load categories", zap.Int64("telegramID", userID), zap.Error(err))
+		return parsed, nil, ""
+	}
+	if !guess {
+		return parsed, list, ""
+	}
+	if h.matcher != nil {
+		if m, err := h.matcher.FindCategory(ctx, sess.TenantID, parsed.Description); err == nil && m != nil {
+			return parsed, list, m.CategoryID
+		}
+	}
+	if h.llmEnabled && h.llm != nil {
+		catID, _, _ := h.suggestCategoryLLM(ctx, parsed.Description, parsed.Type, locale, list)
+		return parsed, list, catID
+	}
+	return parsed, list, ""
+}
+
+// handleChosenInlineResult saves the transaction of a chosen inline result "tx:<category id>".
+// Telegram only reports chosen results when inline feedback is enabled for the bot in BotFather.
+func (h *Handler) handleChosenInlineResult(ctx context.Context, r *tgbotapi.ChosenInlineResult) {
+	categoryID, ok := strings.CutPrefix(r.ResultID, "tx:")
+	if !ok || categoryID == "" {
+		return
+	}
+	userID := r.From.ID
+	locale := h.userLocale(ctx, userID)
+	sess := h.inlineSession(ctx, userID)
+	if sess == nil {
+		return
+	}
+	parsed, list, _ := h.inlineEntry(ctx, sess, userID, r.Query, locale, false)
+	var c *domain.Category
+	for _, item := range list {
+		if item.ID == categoryID {
+			c = item
+		}
+	}
+	if parsed == nil || c == nil {
+		h.logger.Warn("inline: chosen result no longer matches", zap.String("query", r.Query), zap.String("resultID", r.ResultID))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(userID, fmt.Sprintf(tr(locale, "Не удалось сохранить «%s»", "Failed to save “%s”"), r.Query)))
+		return
+	}
+	// the inline message is already sent, so an unusual amount is confirmed in the private chat with the bot
+	if h.holdIfUnusual(ctx, sess, userID, &repository.TransactionDraft{
+		TelegramID:  userID,
+		Type:        string(parsed.Type),
+		AmountMinor: parsed.Amount.AmountMinor,
+		Currency:    parsed.Currency,
+		Description: parsed.Description,
+		CategoryID:  c.ID,
+		OccurredAt:  parsed.OccurredAt,
+	}, parsed.Expression) {
+		return
+	}
+	occurredAt := time.Now()
+	if parsed.OccurredAt != nil {
+		occurredAt = *parsed.OccurredAt
+	}
+	txID, err := h.txClient.CreateTransaction(ctx, &grpcclient.CreateTransactionRequest{
+		TenantID:    sess.TenantID,
+		Type:        string(parsed.Type),
+		AmountMinor: parsed.Amount.AmountMinor,
+		Currency:    parsed.Currency,
+		Description: parsed.Description,
+		CategoryID:  c.ID,
+		OccurredAt:  occurredAt,
+	}, sess.AccessToken)
+	if err != nil {
+		h.logger.Error("inline: failed to create transaction", zap.Int64("telegramID", userID), zap.Error(err))
+		metrics.IncTransactionsSaved("error")
+		_, _ = h.send(ctx, tgbotapi.NewMessage(userID, fmt.Sprintf(tr(locale, "Не удалось сохранить «%s»", "Failed to save “%s”"), r.Query)))
+		return
+	}
+	metrics.IncCategorySelected("manual")
+	metrics.IncTransactionsSaved("ok")
+
+	// the chosen result only shows the entry in the other chat, so the confirmation with the edit buttons,
+	// the conversion and the budget alert go to the private chat
+	text := fmt.Sprintf("%s %s %s%s — %s\n%s: %s",
+		tr(locale, "✅ Сохранено:", "✅ Saved:"),
+		txTypeLabel(string(parsed.Type), locale), money(parsed.Amount.AmountMinor, parsed.Currency), expressionSuffix(parsed.Expression), parsed.Description,
+		tr(locale, "Выбрана категория", "Selected category"), c.Name)
+	preview, alert := h.savedNotes(ctx, sess, sess.TenantID, userID, c.ID, string(parsed.Type), parsed.Amount.AmountMinor, parsed.Currency, parsed.OccurredAt, locale)
+	if preview != "" {
+		text += "\n" + preview
+	}
+	if alert != "" {
+		text += "\n\n" + alert
+	}
+	msg := tgbotapi.NewMessage(userID, text)
+	if h.opCtxs == nil {
+		_, _ = h.send(ctx, msg)
+		return
+	}
+	opID := uuid.NewString()
+	_ = h.opCtxs.Create(ctx, &repository.OperationContext{
+		OpID:                 opID,
+		TelegramID:           userID,
+		TenantID:             sess.TenantID,
+		TransactionID:        &txID,
+		DescriptionOriginal:  strings.TrimSpace(parsed.Description),
+		CategoryIDSelected:   &c.ID,
+		CategoryNameSelected: &c.Name,
+		SelectionSource:      "manual",
+		TxType:               string(parsed.Type),
+		AmountMinor:          parsed.Amount.AmountMinor,
+		Currency:             parsed.Currency,
+		OccurredAt:           parsed.OccurredAt,
+	})
+	msg.ReplyMarkup = ui.CreatePostSelectionKeyboard("manual", opID, locale)
+	if sent, _ := h.send(ctx, msg); sent.MessageID != 0 {
+		_ = h.opCtxs.SetConfirmationMessageID(ctx, opID, sent.MessageID)
+	}
+}
+
+// inlineStatsCard renders the month summary card: "stats" for the current month, "stats 2025-03" for another one.
+func (h *Handler) inlineStatsCard(ctx context.Context, sess *repository.UserSession, userID int64, fields []string, locale string) string {
+	now := h.userNow(ctx, userID)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if len(fields) > 1 {
+		if m, err := time.ParseInLocation("2006-01", fields[1], now.Location()); err == nil {
+			from = m
+		}
+	}
+	to := from.AddDate(0, 1, -1)
+	st, err := h.report.GetStats(ctx, sess.TenantID, from, to, sess.AccessToken)
+	if err != nil {
+		h.logger.Warn("inline: failed to load stats", zap.Int64("telegramID", userID), zap.Error(err))
+		return ""
+	}
+	title := st.Period
+	if t := h.cachedTenant(ctx, sess); t != nil {
+		title = t.Name + " · " + st.Period
+	}
+	lines := []string{
+		"📊 " + title,
+		fmt.Sprintf("%s: %s · %s: %s", tr(locale, "Доходы", "Income"), h.fmt.FormatMoney(st.TotalIncome, st.Currency), tr(locale, "Расходы", "Expenses"), h.fmt.FormatMoney(st.TotalExpense, st.Currency)),
+		fmt.Sprintf("%s: %s", tr(locale, "Баланс", "Balance"), h.fmt.FormatMoney(st.TotalIncome-st.TotalExpense, st.Currency)),
+	}
+	if top, err := h.report.TopCategories(ctx, sess.TenantID, from, to, 3, sess.AccessToken); err == nil && len(top) > 0 {
+		lines = append(lines, "", tr(locale, "Больше всего трат:", "Top spending:"))
+		for i, c := range top {
+			lines = append(lines, fmt.Sprintf("%d. %s — %s", i+1, c.Name, h.fmt.FormatMoney(c.SumMinor, c.Currency)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
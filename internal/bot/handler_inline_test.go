@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestHandler_InlineMode(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &createRecordingTxClient{}
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, mappings, nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithOperationContexts(repository.NewSQLiteOperationContextRepository(db)).
+		WithTransactionClient(tx).
+		WithFxClient(&stubFxClient{rates: map[string]float64{"USD|RUB": 80}})
+
+	ctx := context.Background()
+	userID := int64(90)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "tenant-1", AccessToken: "access-token-90", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	if err := mappings.AddMapping(ctx, &repository.CategoryMapping{ID: "m1", TenantID: "tenant-1", Keyword: "такси", CategoryID: "cat-transport"}); err != nil {
+		t.Fatalf("add mapping: %v", err)
+	}
+
+	type result struct {
+		ID                  string `json:"id"`
+		Title               string `json:"title"`
+		InputMessageContent struct {
+			Text string `json:"message_text"`
+		} `json:"input_message_content"`
+	}
+	query := func(from int64, text string) ([]result, string) {
+		h.HandleUpdate(ctx, tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{ID: "q", From: &tgbotapi.User{ID: from}, Query: text}})
+		calls := rec.Calls("answerInlineQuery")
+		last := calls[len(calls)-1].Params
+		var out []result
+		if err := json.Unmarshal([]byte(last.Get("results")), &out); err != nil {
+			t.Fatalf("results: %v %q", err, last.Get("results"))
+		}
+		return out, last.Get("switch_pm_text")
+	}
+
+	res, _ := query(userID, "350 такси")
+	if len(res) < 2 || !strings.HasPrefix(res[0].Title, "✅") || !strings.Contains(res[0].Title, "Транспорт") {
+		t.Fatalf("guessed category must come first: %+v", res)
+	}
+	if !strings.Contains(res[0].InputMessageContent.Text, "350.00 RUB — такси") {
+		t.Fatalf("unexpected message text: %q", res[0].InputMessageContent.Text)
+	}
+	if len(tx.created) != 0 {
+		t.Fatalf("query must not save: %+v", tx.created)
+	}
+
+	chosen := res[1]
+	h.HandleUpdate(ctx, tgbotapi.Update{ChosenInlineResult: &tgbotapi.ChosenInlineResult{ResultID: chosen.ID, From: &tgbotapi.User{ID: userID}, Query: "350 такси"}})
+	if len(tx.created) != 1 || tx.created[0].AmountMinor != 35000 || tx.created[0].TenantID != "tenant-1" || !strings.Contains(chosen.Title, categoryNameByID(t, h, tx.created[0].CategoryID)) {
+		t.Fatalf("chosen result must be saved with its category: %+v %+v", tx.created, chosen)
+	}
+	if chosen.ID != "tx:"+tx.created[0].CategoryID {
+		t.Fatalf("result id must carry the category id: %q", chosen.ID)
+	}
+	// the confirmation with the edit buttons and the notes of a regular save goes to the private chat
+	h.HandleUpdate(ctx, tgbotapi.Update{ChosenInlineResult: &tgbotapi.ChosenInlineResult{ResultID: chosen.ID, From: &tgbotapi.User{ID: userID}, Query: "10 USD такси"}})
+	sends := rec.Calls("sendMessage")
+	if last := sends[len(sends)-1].Params; len(tx.created) != 2 || last.Get("chat_id") != "90" || !strings.HasPrefix(last.Get("text"), "✅ Сохранено: расход 10.00 USD — такси") ||
+		!strings.Contains(last.Get("text"), "💱 ≈ 800.00 RUB") || !strings.Contains(last.Get("reply_markup"), "v1:delete:") {
+		t.Fatalf("unexpected inline confirmation: %+v %v", tx.created, last)
+	}
+	// a category removed after the query was answered is not replaced by another one
+	h.HandleUpdate(ctx, tgbotapi.Update{ChosenInlineResult: &tgbotapi.ChosenInlineResult{ResultID: "tx:cat-removed", From: &tgbotapi.User{ID: userID}, Query: "350 такси"}})
+	if texts := rec.Texts(); len(tx.created) != 2 || !strings.HasPrefix(texts[len(texts)-1], "Не удалось сохранить «350 такси»") {
+		t.Fatalf("unknown category must not be saved: %+v", tx.created)
+	}
+
+	// an unusual amount chosen inline is confirmed in the private chat before saving
+	h.WithDrafts(repository.NewSQLiteDraftRepository(db))
+	if err := repository.NewSQLitePreferencesRepository(db).UpdateAmountCeiling(ctx, userID, 100000, "RUB"); err != nil {
+		t.Fatalf("set ceiling: %v", err)
+	}
+	h.HandleUpdate(ctx, tgbotapi.Update{ChosenInlineResult: &tgbotapi.ChosenInlineResult{ResultID: chosen.ID, From: &tgbotapi.User{ID: userID}, Query: "35000 такси"}})
+	sends = rec.Calls("sendMessage")
+	if last := sends[len(sends)-1]; len(tx.created) != 2 || last.Params.Get("chat_id") != "90" || !strings.Contains(last.Params.Get("reply_markup"), "v1:draft_save:") {
+		t.Fatalf("unusual inline amount must be held: %+v %v", tx.created, last.Params)
+	}
+	h.WithDrafts(nil)
+
+	if res, _ := query(userID, "привет"); len(res) != 0 {
+		t.Fatalf("no results expected: %+v", res)
+	}
+	res, _ = query(userID, "stats")
+	if len(res) != 1 || !strings.Contains(res[0].InputMessageContent.Text, "📊") || !strings.Contains(res[0].InputMessageContent.Text, "Баланс: 7500.00 RUB") {
+		t.Fatalf("unexpected stats card: %+v", res)
+	}
+	if res, pm := query(91, "350 такси"); len(res) != 0 || pm == "" {
+		t.Fatalf("users without a session must be sent to the bot: %+v %q", res, pm)
+	}
+}
+
+func categoryNameByID(t *testing.T, h *Handler, id string) string {
+	t.Helper()
+	list, _ := h.categories.ListCategories(context.Background(), "tenant-1", "", "expense", "ru")
+	for _, c := range list {
+		if c.ID == id {
+			return c.Name
+		}
+	}
+	t.Fatalf("unknown category %q", id)
+	return ""
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"budget-bot/internal/domain"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// receiptImageFileID returns the file_id of the largest photo or of an image sent as a document.
+func receiptImageFileID(m *tgbotapi.Message) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	if len(m.Photo) > 0 {
+		return m.Photo[len(m.Photo)-1].FileID, true
+	}
+	if m.Document != nil && strings.HasPrefix(m.Document.MimeType, "image/") {
+		return m.Document.FileID, true
+	}
+	return "", false
+}
+
+// handleReceipt decodes a fiscal receipt QR code from an image and saves it like a text transaction.
+// A photo caption, if present, replaces the default description.
+func (h *Handler) handleReceipt(ctx context.Context, update tgbotapi.Update, fileID string) {
+	locale := h.userLocale(ctx, update.Message.From.ID)
+	chatID := update.Message.Chat.ID
+	_, _ = h.bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+
+	data, err := h.files.Download(ctx, fileID)
+	if err != nil {
+		h.logger.Warn("failed to download receipt image", zap.Int64("telegramID", update.Message.From.ID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось загрузить изображение", "Failed to download the image")))
+		return
+	}
+	payload, err := DecodeReceiptQR(data)
+	if err != nil {
+		h.logger.Debug("receipt qr not decoded", zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось найти QR-код чека на изображении. Сфотографируйте QR-код крупнее.", "Could not find a receipt QR code on the image. Try a closer photo of the QR code.")))
+		return
+	}
+	loc := h.userLocation(ctx, update.Message.From.ID)
+	parsed, err := ParseReceiptQR(payload, loc)
+	if err != nil {
+		h.logger.Debug("receipt qr not parsed", zap.String("payload", payload), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "QR-код не похож на кассовый чек", "The QR code is not a fiscal receipt")))
+		return
+	}
+	if caption := strings.TrimSpace(update.Message.Caption); caption != "" {
+		parsed.Description = strings.ToLower(caption)
+	}
+
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf("%s %s %s, %s",
+		tr(locale, "🧾 Чек:", "🧾 Receipt:"),
+		domain.FormatAmount(parsed.Amount.AmountMinor, parsed.Currency),
+		parsed.Currency,
+		parsed.OccurredAt.In(loc).Format("02.01.2006 15:04"),
+	)))
+	h.saveParsedTransaction(ctx, update, parsed)
+}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+type staticFileDownloader map[string][]byte
+
+func (d staticFileDownloader) Download(_ context.Context, fileID string) ([]byte, error) {
+	data, ok := d[fileID]
+	if !ok {
+		return nil, fmt.Errorf("file %s not found", fileID)
+	}
+	return data, nil
+}
+
+type createRecordingTxClient struct {
+	grpcclient.FakeTransactionClient
+	created []*grpcclient.CreateTransactionRequest
+}
+
+func (c *createRecordingTxClient) CreateTransaction(ctx context.Context, req *grpcclient.CreateTransactionRequest, token string) (string, error) {
+	c.created = append(c.created, req)
+	return c.FakeTransactionClient.CreateTransaction(ctx, req, token)
+}
+
+func TestHandler_ReceiptPhoto(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	states := repository.NewSQLiteDialogStateRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	prefs := repository.NewSQLitePreferencesRepository(db)
+	opCtxs := repository.NewSQLiteOperationContextRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &createRecordingTxClient{}
+	files := staticFileDownloader{
+		"receipt": receiptQRPNG(t, "t=20240115T1830&s=1234.50&fn=9960440300000000&i=12345&fp=1234567890&n=1"),
+		"cat":     receiptQRPNG(t, "https://example.com/cat"),
+	}
+
+	h := NewHandler(bot, states, auth, mappings, nil, log).
+		WithPreferences(prefs).
+		WithOperationContexts(opCtxs).
+		WithTransactionClient(tx).
+		WithFileDownloader(files)
+
+	ctx := context.Background()
+	chatID := int64(8300)
+	userID := int64(83)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	if err := mappings.AddMapping(ctx, &repository.CategoryMapping{ID: "m1", TenantID: "t", Keyword: "продукты", CategoryID: "cat-food"}); err != nil {
+		t.Fatalf("add mapping: %v", err)
+	}
+	photo := func(fileID, caption string) {
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{
+			Chat:    &tgbotapi.Chat{ID: chatID},
+			From:    &tgbotapi.User{ID: userID},
+			Caption: caption,
+			Photo:   []tgbotapi.PhotoSize{{FileID: "thumb"}, {FileID: fileID}},
+		}})
+	}
+
+	photo("receipt", "Продукты")
+	if len(tx.created) != 1 {
+		t.Fatalf("expected one transaction, got %d", len(tx.created))
+	}
+	got := tx.created[0]
+	if got.AmountMinor != 123450 || got.Currency != "RUB" || got.CategoryID != "cat-food" || got.Description != "продукты" {
+		t.Fatalf("unexpected transaction: %+v", got)
+	}
+	if want := time.Date(2024, 1, 15, 18, 30, 0, 0, time.Local); !got.OccurredAt.Equal(want) {
+		t.Fatalf("unexpected time: %v", got.OccurredAt)
+	}
+
+	photo("cat", "")
+	photo("missing", "")
+	if len(tx.created) != 1 {
+		t.Fatalf("non-receipt images must not create transactions")
+	}
+	texts := strings.Join(rec.Texts(), "\n")
+	for _, want := range []string{"🧾 Чек: 1234.50 RUB", "не похож на кассовый чек", "Не удалось загрузить изображение"} {
+		if !strings.Contains(texts, want) {
+			t.Fatalf("replies %q do not contain %q", texts, want)
+		}
+	}
+}
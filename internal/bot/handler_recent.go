@@ -0,0 +1,240 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"budget-bot/internal/bot/ui"
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	pb "budget-bot/internal/pb/budget/v1"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Page sizes of the /recent list; the size can be set with /recent <n>.
+const (
+	recentPageSize    = 10
+	maxRecentPageSize = 20
+)
+
+// recentSource marks operation contexts opened from the /recent list, so edits re-render the detail card.
+const recentSource = "recent"
+
+// recentPage is one loaded page of the /recent list with category names resolved.
+type recentPage struct {
+	*grpcclient.TransactionPage
+	categories map[string]*domain.Category
+}
+
+// loadRecentPage loads a page of the newest transactions and resolves their categories in one lookup.
+func (h *Handler) loadRecentPage(ctx context.Context, sess *repository.UserSession, page, size int, locale string) (*recentPage, error) {
+	res, err := h.txClient.ListTransactions(ctx, &grpcclient.TransactionFilter{}, page, size, sess.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &recentPage{TransactionPage: res, categories: h.recentCategories(ctx, sess, locale)}, nil
+}
+
+// recentCategories loads the categories of the tenant by id; names fall back to ids when they are unavailable.
+func (h *Handler) recentCategories(ctx context.Context, sess *repository.UserSession, locale string) map[string]*domain.Category {
+	categories, err := h.nameMapper.CategoriesByID(ctx, sess.TenantID, sess.AccessToken, locale)
+	if err != nil {
+		h.logger.Warn("recent: failed to load categories", zap.String("tenantID", sess.TenantID), zap.Error(err))
+		return map[string]*domain.Category{}
+	}
+	return categories
+}
+
+// categoryLabel returns the emoji and name of a category, or its id when it is unknown.
+func categoryLabel(categories map[string]*domain.Category, id string) string {
+	if c := categories[id]; c != nil {
+		return strings.TrimSpace(c.Emoji + " " + c.Name)
+	}
+	return id
+}
+
+// txTypeOf converts a transaction type from the API to "income" or "expense".
+func txTypeOf(t *pb.Transaction) string {
+	if t.GetType() == pb.TransactionType_TRANSACTION_TYPE_INCOME {
+		return string(domain.TransactionIncome)
+	}
+	return string(domain.TransactionExpense)
+}
+
+// handleRecent shows the newest transactions page by page: /recent [page size].
+func (h *Handler) handleRecent(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	sess, err := h.auth.GetSession(ctx, userID)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Сначала выполните вход: /login", "Please login first: /login")))
+		return
+	}
+	size := recentPageSize
+	if n, err := strconv.Atoi(strings.TrimSpace(update.Message.CommandArguments())); err == nil && n > 0 {
+		size = min(n, maxRecentPageSize)
+	}
+	page, err := h.loadRecentPage(ctx, sess, 1, size, locale)
+	if err != nil {
+		h.logger.Error("failed to list recent transactions", zap.Int64("telegramID", userID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить последние транзакции", "Failed to load recent transactions")))
+		return
+	}
+	if len(page.Transactions) == 0 {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Нет данных", "No data")))
+		return
+	}
+	text, kb := h.renderRecentPage(ctx, userID, page, size, locale)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = kb
+	_, _ = h.send(ctx, msg)
+}
+
+// renderRecentPage formats a numbered page of transactions; the numbers match the buttons that open them.
+func (h *Handler) renderRecentPage(ctx context.Context, userID int64, page *recentPage, size int, locale string) (string, tgbotapi.InlineKeyboardMarkup) {
+	loc := h.userLocation(ctx, userID)
+	pages := max(page.TotalPages, page.Page)
+	var b strings.Builder
+	ids := make([]string, 0, len(page.Transactions))
+	fmt.Fprintf(&b, tr(locale, "🧾 Последние транзакции (страница %d/%d):\n\n", "🧾 Recent transactions (page %d/%d):\n\n"), page.Page, pages)
+	for i, t := range page.Transactions {
+		sign := "-"
+		if txTypeOf(t) == string(domain.TransactionIncome) {
+			sign = "+"
+		}
+		curr := t.GetAmount().GetCurrencyCode()
+		ids = append(ids, t.GetId())
+		fmt.Fprintf(&b, "%d. %s · %s%s %s · %s", i+1, t.GetOccurredAt().AsTime().In(loc).Format("02.01.2006"), sign, domain.FormatAmount(t.GetAmount().GetMinorUnits(), curr), curr, categoryLabel(page.categories, t.GetCategoryId()))
+		if t.GetComment() != "" {
+			b.WriteString(" — " + t.GetComment())
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(tr(locale, "\nНажмите номер, чтобы открыть операцию", "\nTap a number to open the transaction"))
+	return b.String(), ui.CreateRecentListKeyboard(page.Page, size, ids, pages, locale)
+}
+
+// parseRecentCallback reads the page and the page size of "v1:recent:<page>:<size>".
+func parseRecentCallback(payload string) (page, size int, ok bool) {
+	p, s, found := strings.Cut(payload, ":")
+	if !found {
+		return 0, 0, false
+	}
+	page, err1 := strconv.Atoi(p)
+	size, err2 := strconv.Atoi(s)
+	return page, size, err1 == nil && err2 == nil && page >= 1 && size >= 1 && size <= maxRecentPageSize
+}
+
+// parseRecentOpenCallback reads "v1:recent_tx:<page>:<size>:<id>"; the page is only kept for the way back.
+func parseRecentOpenCallback(payload string) (page, size int, txID string, ok bool) {
+	i := strings.LastIndex(payload, ":")
+	if i < 0 || i == len(payload)-1 {
+		return 0, 0, "", false
+	}
+	page, size, ok = parseRecentCallback(payload[:i])
+	return page, size, payload[i+1:], ok
+}
+
+// handleRecentPageCallback shows another page of the /recent list in place.
+func (h *Handler) handleRecentPageCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, payload string) {
+	locale := h.userLocale(ctx, cb.From.ID)
+	pageNum, size, ok := parseRecentCallback(payload)
+	if !ok || cb.Message == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Некорректные данные", "Invalid data")))
+		return
+	}
+	sess, err := h.auth.GetSession(ctx, cb.From.ID)
+	if err != nil || sess == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Нет сессии", "No session")))
+		return
+	}
+	page, err := h.loadRecentPage(ctx, sess, pageNum, size, locale)
+	if err != nil {
+		h.logger.Error("failed to list recent transactions", zap.Int64("telegramID", cb.From.ID), zap.Error(err))
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Ошибка", "Error")))
+		return
+	}
+	if len(page.Transactions) == 0 {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Нет данных", "No data")))
+		return
+	}
+	text, kb := h.renderRecentPage(ctx, cb.From.ID, page, size, locale)
+	_, _ = h.bot.Request(tgbotapi.NewEditMessageTextAndMarkup(cb.Message.Chat.ID, cb.Message.MessageID, text, kb))
+	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, ""))
+}
+
+// handleRecentOpenCallback opens a transaction of the /recent list in place. The transaction is loaded by id, so
+// rows shifted by newer transactions do not open another one. It gets an operation context, so changing the
+// category, amount, date, comment or deleting it reuses the confirmation actions.
+func (h *Handler) handleRecentOpenCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, payload string) {
+	locale := h.userLocale(ctx, cb.From.ID)
+	pageNum, size, txID, ok := parseRecentOpenCallback(payload)
+	if !ok || cb.Message == nil || h.opCtxs == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Некорректные данные", "Invalid data")))
+		return
+	}
+	sess, err := h.auth.GetSession(ctx, cb.From.ID)
+	if err != nil || sess == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Нет сессии", "No session")))
+		return
+	}
+	t, err := h.txClient.GetTransaction(ctx, txID, sess.AccessToken)
+	if err != nil || t == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Транзакция не найдена", "Transaction not found")))
+		return
+	}
+	categoryID := t.GetCategoryId()
+	categoryName := categoryLabel(h.recentCategories(ctx, sess, locale), categoryID)
+	occurredAt := t.GetOccurredAt().AsTime()
+	messageID := cb.Message.MessageID
+	op := &repository.OperationContext{
+		OpID:                  uuid.NewString(),
+		TelegramID:            cb.From.ID,
+		TenantID:              sess.TenantID,
+		TransactionID:         &txID,
+		DescriptionOriginal:   t.GetComment(),
+		CategoryIDSelected:    &categoryID,
+		CategoryNameSelected:  &categoryName,
+		SelectionSource:       recentSource,
+		TxType:                txTypeOf(t),
+		AmountMinor:           t.GetAmount().GetMinorUnits(),
+		Currency:              t.GetAmount().GetCurrencyCode(),
+		OccurredAt:            &occurredAt,
+		ConfirmationMessageID: &messageID,
+	}
+	if err := h.opCtxs.Create(ctx, op); err != nil {
+		h.logger.Error("failed to create operation context", zap.Int64("telegramID", cb.From.ID), zap.Error(err))
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Ошибка", "Error")))
+		return
+	}
+	text := formatRecentDetails(op, locale, h.userLocation(ctx, cb.From.ID))
+	kb := ui.CreateRecentDetailKeyboard(op.OpID, pageNum, size, locale)
+	_, _ = h.bot.Request(tgbotapi.NewEditMessageTextAndMarkup(cb.Message.Chat.ID, messageID, text, kb))
+	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, ""))
+}
+
+// formatRecentDetails renders a transaction opened from /recent, with the date shown in loc.
+func formatRecentDetails(op *repository.OperationContext, locale string, loc *time.Location) string {
+	categoryName := ""
+	if op.CategoryNameSelected != nil {
+		categoryName = *op.CategoryNameSelected
+	}
+	occurredAt := op.CreatedAt
+	if op.OccurredAt != nil {
+		occurredAt = *op.OccurredAt
+	}
+	text := fmt.Sprintf("🧾 %s %s %s", txTypeLabel(op.TxType, locale), domain.FormatAmount(op.AmountMinor, op.Currency), op.Currency)
+	if op.DescriptionOriginal != "" {
+		text += " — " + op.DescriptionOriginal
+	}
+	return fmt.Sprintf("%s\n%s: %s\n%s: %s", text,
+		tr(locale, "Категория", "Category"), categoryName,
+		tr(locale, "Дата", "Date"), occurredAt.In(loc).Format("02.01.2006"))
+}
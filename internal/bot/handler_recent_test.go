@@ -2,7 +2,10 @@ package bot
 
 import (
 	"context"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"budget-bot/internal/bot/ui"
 	grpcclient "budget-bot/internal/grpc"
@@ -134,3 +137,72 @@ func TestHandler_handleRecent(t *testing.T) {
 		h.handleRecent(ctx, upd)
 	})
 }
+
+func TestHandler_RecentInteractive(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, repository.NewSQLiteCategoryMappingRepository(db), nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithOperationContexts(repository.NewSQLiteOperationContextRepository(db)).
+		WithTransactionClient(&pagingTxClient{})
+
+	ctx := context.Background()
+	chatID, userID := int64(9200), int64(92)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "tenant-1", AccessToken: "access-token-92", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	tap := func(data string) url.Values {
+		h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			ID: "cb", From: &tgbotapi.User{ID: userID}, Data: data,
+			Message: &tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: chatID}},
+		}})
+		edits := rec.Calls("editMessageText")
+		if len(edits) == 0 {
+			t.Fatalf("%s: message must be edited in place", data)
+		}
+		return edits[len(edits)-1].Params
+	}
+
+	upd := tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: "/recent 5",
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 7}}}}
+	h.HandleUpdate(ctx, upd)
+	sends := rec.Calls("sendMessage")
+	first := sends[len(sends)-1].Params
+	if !strings.Contains(first.Get("text"), "страница 1/3") || !strings.Contains(first.Get("text"), "1. 01.02.2025 · -600.00 RUB · 🍽️ Питание — кофе") {
+		t.Fatalf("unexpected first page: %q", first.Get("text"))
+	}
+	if kb := first.Get("reply_markup"); !strings.Contains(kb, "v1:recent_tx:1:5:tx-4") || !strings.Contains(kb, "v1:recent:2:5") || strings.Contains(kb, "v1:recent:0:5") {
+		t.Fatalf("unexpected keyboard: %s", kb)
+	}
+
+	page3 := tap("v1:recent:3:5")
+	if !strings.Contains(page3.Get("text"), "страница 3/3") || !strings.Contains(page3.Get("text"), "2. 12.02.2025") || strings.Contains(page3.Get("reply_markup"), "v1:recent:4:5") {
+		t.Fatalf("unexpected last page: %v", page3)
+	}
+
+	if got := tap("v1:recent_tx:3:5:tx-missing"); got.Get("text") != page3.Get("text") {
+		t.Fatalf("an unknown transaction must not be opened: %v", got)
+	}
+	details := tap("v1:recent_tx:3:5:tx-11")
+	if !strings.Contains(details.Get("text"), "расход 600.00 RUB — кофе") || !strings.Contains(details.Get("text"), "Дата: 12.02.2025") || !strings.Contains(details.Get("reply_markup"), "v1:recent:3:5") {
+		t.Fatalf("unexpected details: %v", details)
+	}
+	kb := details.Get("reply_markup")
+	i := strings.Index(kb, "v1:edit_amount:")
+	if i < 0 {
+		t.Fatalf("edit actions expected: %s", kb)
+	}
+	opID := kb[i+len("v1:edit_amount:") : i+len("v1:edit_amount:")+36]
+
+	tap("v1:edit_amount:" + opID)
+	upd.Message.Text, upd.Message.Entities = "700", nil
+	h.HandleUpdate(ctx, upd)
+	edited := rec.Calls("editMessageText")
+	last := edited[len(edited)-1].Params
+	if !strings.Contains(last.Get("text"), "расход 700.00 RUB — кофе") || !strings.Contains(last.Get("text"), "Сумма обновлена") || !strings.Contains(last.Get("reply_markup"), "v1:recent:1:10") {
+		t.Fatalf("the opened card must be refreshed in place: %v", last)
+	}
+}
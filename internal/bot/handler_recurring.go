@@ -0,0 +1,471 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"budget-bot/internal/bot/ui"
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/metrics"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/scheduler"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// recurringBatchSize limits how many due rules a single worker pass processes.
+const recurringBatchSize = 50
+
+// recurringPendingTimeout is how long an occurrence may stay claimed; older pending runs were interrupted by a
+// crash or restart.
+const recurringPendingTimeout = 10 * time.Minute
+
+func recurringUsage(locale string) string {
+	return tr(locale,
+		"Повторяющиеся операции:\n"+
+			"/recurring list - список правил\n"+
+			"/recurring add <расписание>; <операция>[; confirm]\n"+
+			"/recurring pause <id>, /recurring resume <id>, /recurring delete <id>\n\n"+
+			"Расписание: daily, weekly mon, monthly 5, every 14 days, every 3 months on 1, cron 0 9 * * 1 (время: 10:00)\n"+
+			"Пример: /recurring add monthly 5 10:00; 50000 аренда\n"+
+			"confirm — спрашивать подтверждение перед сохранением",
+		"Recurring transactions:\n"+
+			"/recurring list - list rules\n"+
+			"/recurring add <schedule>; <transaction>[; confirm]\n"+
+			"/recurring pause <id>, /recurring resume <id>, /recurring delete <id>\n\n"+
+			"Schedule: daily, weekly mon, monthly 5, every 14 days, every 3 months on 1, cron 0 9 * * 1 (time: 10:00)\n"+
+			"Example: /recurring add monthly 5 10:00; 50000 rent\n"+
+			"confirm — ask before saving each occurrence")
+}
+
+func formatRecurringRule(r *repository.RecurringRule, locale string, loc *time.Location) string {
+	status := ""
+	if r.Paused {
+		status = tr(locale, " ⏸ на паузе", " ⏸ paused")
+	} else if r.CategoryID == nil {
+		status = tr(locale, " ❓ нет категории", " ❓ no category")
+	}
+	category := ""
+	if r.CategoryName != nil {
+		category = " [" + *r.CategoryName + "]"
+	}
+	confirm := ""
+	if r.Confirm {
+		confirm = tr(locale, ", с подтверждением", ", with confirmation")
+	}
+	return fmt.Sprintf("#%d %s %s %s — %s%s%s\n   %s%s; %s %s",
+		r.ID, txTypeLabel(r.TxType, locale), domain.FormatAmount(r.AmountMinor, r.Currency), r.Currency, r.Description, category, status,
+		r.Schedule, confirm,
+		tr(locale, "следующий запуск", "next run"), r.NextRunAt.In(loc).Format("02.01.2006 15:04"))
+}
+
+func (h *Handler) handleRecurring(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	if h.recurring == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Повторяющиеся операции недоступны", "Recurring transactions are unavailable")))
+		return
+	}
+	sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+	args := strings.TrimSpace(update.Message.CommandArguments())
+	sub, rest, _ := strings.Cut(args, " ")
+	switch strings.ToLower(sub) {
+	case "", "list":
+		h.listRecurring(ctx, chatID, userID, locale)
+	case "add":
+		h.addRecurring(ctx, chatID, userID, sess, strings.TrimSpace(rest), locale)
+	case "pause", "resume", "delete":
+		id, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(rest), "#"), 10, 64)
+		if err != nil {
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, recurringUsage(locale)))
+			return
+		}
+		rule, err := h.recurring.Get(ctx, id)
+		if err != nil || rule.TelegramID != userID {
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Правило не найдено", "Rule not found")))
+			return
+		}
+		var text string
+		switch strings.ToLower(sub) {
+		case "pause":
+			err = h.recurring.SetPaused(ctx, id, true, rule.NextRunAt)
+			text = fmt.Sprintf(tr(locale, "⏸ Правило #%d на паузе", "⏸ Rule #%d paused"), id)
+		case "resume":
+			// Resuming never replays occurrences missed while paused
+			next := rule.NextRunAt
+			if sched, perr := scheduler.ParseRule(rule.Schedule); perr == nil {
+				next = sched.Next(h.userNow(ctx, userID))
+			}
+			err = h.recurring.SetPaused(ctx, id, false, next)
+			text = fmt.Sprintf(tr(locale, "▶️ Правило #%d возобновлено, следующий запуск %s", "▶️ Rule #%d resumed, next run %s"), id, next.In(h.userLocation(ctx, userID)).Format("02.01.2006 15:04"))
+		default:
+			err = h.recurring.Delete(ctx, id)
+			text = fmt.Sprintf(tr(locale, "🗑 Правило #%d удалено", "🗑 Rule #%d deleted"), id)
+		}
+		if err != nil {
+			h.logger.Error("failed to update recurring rule", zap.Int64("ruleID", id), zap.String("action", sub), zap.Error(err))
+			text = tr(locale, "Не удалось изменить правило", "Failed to update the rule")
+		}
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, text))
+	default:
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, recurringUsage(locale)))
+	}
+}
+
+func (h *Handler) listRecurring(ctx context.Context, chatID, userID int64, locale string) {
+	rules, err := h.recurring.ListByUser(ctx, userID)
+	if err != nil {
+		h.logger.Error("failed to list recurring rules", zap.Int64("telegramID", userID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить правила", "Failed to load rules")))
+		return
+	}
+	if len(rules) == 0 {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Правил нет.\n\n", "No rules yet.\n\n")+recurringUsage(locale)))
+		return
+	}
+	lines := []string{tr(locale, "🔁 Повторяющиеся операции:", "🔁 Recurring transactions:")}
+	loc := h.userLocation(ctx, userID)
+	for _, r := range rules {
+		lines = append(lines, formatRecurringRule(r, locale, loc))
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, strings.Join(lines, "\n")))
+}
+
+func (h *Handler) addRecurring(ctx context.Context, chatID, userID int64, sess *repository.UserSession, args, locale string) {
+	parts := strings.Split(args, ";")
+	if len(parts) < 2 || len(parts) > 3 {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, recurringUsage(locale)))
+		return
+	}
+	sched, err := scheduler.ParseRule(parts[0])
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось разобрать расписание: ", "Could not parse the schedule: ")+err.Error()))
+		return
+	}
+	now := h.userNow(ctx, userID)
+	parsed, _ := h.parser.ParseMessageAt(strings.TrimSpace(parts[1]), now)
+	if parsed == nil || !parsed.IsValid {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось разобрать операцию, пример: 50000 аренда", "Could not parse the transaction, e.g. 50000 rent")))
+		return
+	}
+	confirm := false
+	if len(parts) == 3 {
+		switch strings.ToLower(strings.TrimSpace(parts[2])) {
+		case "confirm", "подтверждать", "подтверждение":
+			confirm = true
+		default:
+			_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, recurringUsage(locale)))
+			return
+		}
+	}
+	// Schedules fire at the wall-clock time of the user's timezone
+	next := sched.Next(now)
+	if next.IsZero() {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Расписание никогда не срабатывает", "The schedule never fires")))
+		return
+	}
+	parsed.ApplyDefaultCurrency(h.defaultCurrency(ctx, userID))
+	currency := parsed.Currency
+	rule := &repository.RecurringRule{
+		TelegramID:  userID,
+		ChatID:      chatID,
+		TenantID:    sess.TenantID,
+		Schedule:    sched.String(),
+		TxType:      string(parsed.Type),
+		AmountMinor: parsed.Amount.AmountMinor,
+		Currency:    currency,
+		Description: strings.TrimSpace(parsed.Description),
+		Confirm:     confirm,
+		NextRunAt:   next,
+	}
+	if h.matcher != nil {
+		if m, err := h.matcher.FindCategory(ctx, sess.TenantID, rule.Description); err == nil && m != nil {
+			name := m.CategoryID
+			if h.nameMapper != nil {
+				if n, err := h.nameMapper.GetCategoryNameByID(ctx, sess.TenantID, sess.AccessToken, m.CategoryID, parsed.Type, locale); err == nil && n != "" {
+					name = n
+				}
+			}
+			rule.CategoryID, rule.CategoryName = &m.CategoryID, &name
+		}
+	}
+	if _, err := h.recurring.Create(ctx, rule); err != nil {
+		h.logger.Error("failed to create recurring rule", zap.Int64("telegramID", userID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось сохранить правило", "Failed to save the rule")))
+		return
+	}
+
+	text := tr(locale, "🔁 Правило сохранено:\n", "🔁 Rule saved:\n") + formatRecurringRule(rule, locale, now.Location())
+	msg := tgbotapi.NewMessage(chatID, text)
+	if rule.CategoryID == nil {
+		list, err := h.categories.ListCategories(ctx, sess.TenantID, sess.AccessToken, parsed.Type, locale)
+		if err == nil && len(list) > 0 {
+			msg.Text += "\n\n" + tr(locale, "Выберите категорию, без неё правило не запустится:", "Choose a category, the rule will not run without it:")
+			msg.ReplyMarkup = ui.CreateRecurringCategoryKeyboard(list, rule.ID)
+		}
+	}
+	_, _ = h.send(ctx, msg)
+}
+
+// parseRecurringPayload splits "<rule id>:<rest>".
+func parseRecurringPayload(payload string) (int64, string, bool) {
+	idPart, rest, ok := strings.Cut(payload, ":")
+	if !ok {
+		return 0, "", false
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	return id, rest, err == nil
+}
+
+func (h *Handler) handleRecurringCategoryCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, payload string) {
+	locale := h.userLocale(ctx, cb.From.ID)
+	id, categoryID, ok := parseRecurringPayload(payload)
+	var rule *repository.RecurringRule
+	if ok && h.recurring != nil {
+		rule, _ = h.recurring.Get(ctx, id)
+	}
+	if rule == nil || rule.TelegramID != cb.From.ID {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Правило не найдено", "Rule not found")))
+		return
+	}
+	name := categoryID
+	if sess, err := h.auth.GetSession(ctx, cb.From.ID); err == nil && sess != nil && h.nameMapper != nil {
+		txType := domain.TransactionExpense
+		if rule.TxType == "income" {
+			txType = domain.TransactionIncome
+		}
+		if n, err := h.nameMapper.GetCategoryNameByID(ctx, rule.TenantID, sess.AccessToken, categoryID, txType, locale); err == nil && n != "" {
+			name = n
+		}
+	}
+	// occurrences that passed while the rule had no category are not back-filled
+	next := rule.NextRunAt
+	if now := h.userNow(ctx, rule.TelegramID); next.Before(now) {
+		if sched, err := scheduler.ParseRule(rule.Schedule); err == nil {
+			if n := sched.Next(now); !n.IsZero() {
+				next = n
+			}
+		}
+	}
+	if err := h.recurring.SetCategory(ctx, id, categoryID, name, next); err != nil {
+		h.logger.Error("failed to set recurring category", zap.Int64("ruleID", id), zap.Error(err))
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Ошибка", "Error")))
+		return
+	}
+	rule.CategoryID, rule.CategoryName, rule.NextRunAt = &categoryID, &name, next
+	if cb.Message != nil {
+		_, _ = h.bot.Request(tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, tr(locale, "🔁 Правило сохранено:\n", "🔁 Rule saved:\n")+formatRecurringRule(rule, locale, h.userLocation(ctx, rule.TelegramID))))
+	}
+	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Готово", "Done")))
+}
+
+// handleRecurringDecisionCallback saves or skips an occurrence that waits for confirmation.
+func (h *Handler) handleRecurringDecisionCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, payload string, save bool) {
+	locale := h.userLocale(ctx, cb.From.ID)
+	id, dueRaw, ok := parseRecurringPayload(payload)
+	dueUnix, err := strconv.ParseInt(dueRaw, 10, 64)
+	var rule *repository.RecurringRule
+	if ok && err == nil && h.recurring != nil {
+		rule, _ = h.recurring.Get(ctx, id)
+	}
+	if rule == nil || rule.TelegramID != cb.From.ID {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Правило не найдено", "Rule not found")))
+		return
+	}
+	due := time.Unix(dueUnix, 0)
+	if !save {
+		if done, _ := h.recurring.UpdateRunStatus(ctx, id, due, repository.RecurringRunAwaiting, repository.RecurringRunSkipped, nil); !done {
+			_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Уже обработано", "Already processed")))
+			return
+		}
+		if cb.Message != nil {
+			_, _ = h.bot.Request(tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, fmt.Sprintf(tr(locale, "⏭ Пропущено: #%d %s", "⏭ Skipped: #%d %s"), id, rule.Description)))
+		}
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, ""))
+		return
+	}
+	if done, _ := h.recurring.UpdateRunStatus(ctx, id, due, repository.RecurringRunAwaiting, repository.RecurringRunPending, nil); !done {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Уже обработано", "Already processed")))
+		return
+	}
+	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, ""))
+	if cb.Message != nil {
+		_, _ = h.bot.Request(tgbotapi.NewEditMessageReplyMarkup(cb.Message.Chat.ID, cb.Message.MessageID, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}))
+	}
+	h.createRecurringTransaction(ctx, rule, due)
+}
+
+// RunDueRecurring processes rules whose next run is due. It is safe to call repeatedly and after restarts:
+// every occurrence is claimed in recurring_runs before the transaction is created, so it is handled at most once.
+// Occurrences left claimed by an interrupted pass are reported to the user first.
+func (h *Handler) RunDueRecurring(ctx context.Context, now time.Time) error {
+	if h.recurring == nil {
+		return nil
+	}
+	h.failStaleRuns(ctx, now)
+	rules, err := h.recurring.ListDue(ctx, now, recurringBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		h.processRecurring(ctx, rule)
+	}
+	return nil
+}
+
+// failStaleRuns marks occurrences left pending longer than recurringPendingTimeout as failed and tells the user.
+// The transaction may or may not have been created before the interruption, so the run is not retried to avoid
+// saving it twice.
+func (h *Handler) failStaleRuns(ctx context.Context, now time.Time) {
+	runs, err := h.recurring.ListPendingRuns(ctx, now.Add(-recurringPendingTimeout))
+	if err != nil {
+		h.logger.Error("failed to list pending recurring runs", zap.Error(err))
+		return
+	}
+	for _, run := range runs {
+		if ok, err := h.recurring.UpdateRunStatus(ctx, run.RuleID, run.DueAt, repository.RecurringRunPending, repository.RecurringRunFailed, nil); err != nil || !ok {
+			continue
+		}
+		h.logger.Warn("recurring run interrupted", zap.Int64("ruleID", run.RuleID), zap.Time("due", run.DueAt))
+		rule, err := h.recurring.Get(ctx, run.RuleID)
+		if err != nil {
+			continue
+		}
+		locale := h.userLocale(ctx, rule.TelegramID)
+		_, _ = h.send(ctx, tgbotapi.NewMessage(rule.ChatID, fmt.Sprintf(tr(locale,
+			"⚠️ Повторяющаяся операция #%d (%s) за %s могла не сохраниться: бот перезапустился во время записи. Проверьте /recent и при необходимости добавьте её вручную.",
+			"⚠️ Recurring transaction #%d (%s) for %s may not have been saved: the bot restarted while saving it. Check /recent and add it manually if needed."),
+			rule.ID, rule.Description, run.DueAt.In(h.userLocation(ctx, rule.TelegramID)).Format("02.01.2006 15:04"))))
+	}
+}
+
+func (h *Handler) processRecurring(ctx context.Context, rule *repository.RecurringRule) {
+	due := rule.NextRunAt
+	sched, err := scheduler.ParseRule(rule.Schedule)
+	if err != nil {
+		h.logger.Error("invalid recurring schedule, pausing rule", zap.Int64("ruleID", rule.ID), zap.String("schedule", rule.Schedule), zap.Error(err))
+		_ = h.recurring.SetPaused(ctx, rule.ID, true, due)
+		return
+	}
+	claimed, err := h.recurring.ClaimRun(ctx, rule.ID, due)
+	if err != nil {
+		h.logger.Error("failed to claim recurring run", zap.Int64("ruleID", rule.ID), zap.Error(err))
+		return
+	}
+	if claimed {
+		// an unusual amount is confirmed with the buttons of the rule instead of a draft
+		reason := h.recurringAmountReason(ctx, rule)
+		if rule.Confirm || reason != "" {
+			_, _ = h.recurring.UpdateRunStatus(ctx, rule.ID, due, repository.RecurringRunPending, repository.RecurringRunAwaiting, nil)
+			locale := h.userLocale(ctx, rule.TelegramID)
+			text := fmt.Sprintf("%s\n%s %s %s — %s [%s]\n%s: %s",
+				fmt.Sprintf(tr(locale, "🔁 Повторяющаяся операция #%d ждёт подтверждения", "🔁 Recurring transaction #%d needs confirmation"), rule.ID),
+				txTypeLabel(rule.TxType, locale), domain.FormatAmount(rule.AmountMinor, rule.Currency), rule.Currency, rule.Description, derefString(rule.CategoryName),
+				tr(locale, "Дата", "Date"), due.In(h.userLocation(ctx, rule.TelegramID)).Format("02.01.2006 15:04"))
+			if reason != "" {
+				text += "\n" + fmt.Sprintf(tr(locale, "⚠️ Похоже на опечатку: %s.", "⚠️ This looks like a typo: %s."), reason)
+			}
+			msg := tgbotapi.NewMessage(rule.ChatID, text)
+			msg.ReplyMarkup = ui.CreateRecurringConfirmKeyboard(rule.ID, due.Unix(), locale)
+			_, _ = h.send(ctx, msg)
+		} else {
+			h.createRecurringTransaction(ctx, rule, due)
+		}
+	}
+	next := sched.Next(due.In(h.userLocation(ctx, rule.TelegramID)))
+	if next.IsZero() {
+		_ = h.recurring.SetPaused(ctx, rule.ID, true, due)
+		return
+	}
+	if _, err := h.recurring.AdvanceNextRun(ctx, rule.ID, due, next); err != nil {
+		h.logger.Error("failed to advance recurring rule", zap.Int64("ruleID", rule.ID), zap.Error(err))
+	}
+}
+
+// recurringAmountReason applies the amount guard to an occurrence of a rule that is saved without confirmation.
+func (h *Handler) recurringAmountReason(ctx context.Context, rule *repository.RecurringRule) string {
+	if rule.Confirm || h.drafts == nil {
+		return ""
+	}
+	sess, err := h.auth.GetSession(ctx, rule.TelegramID)
+	if err != nil || sess == nil {
+		return ""
+	}
+	return h.unusualAmount(ctx, sess, rule.TelegramID, rule.TxType, rule.AmountMinor, rule.Currency, derefString(rule.CategoryID), h.userLocale(ctx, rule.TelegramID))
+}
+
+// createRecurringTransaction saves a claimed (pending) occurrence and notifies the user.
+func (h *Handler) createRecurringTransaction(ctx context.Context, rule *repository.RecurringRule, due time.Time) {
+	locale := h.userLocale(ctx, rule.TelegramID)
+	fail := func(reason string) {
+		_, _ = h.recurring.UpdateRunStatus(ctx, rule.ID, due, repository.RecurringRunPending, repository.RecurringRunFailed, nil)
+		_, _ = h.send(ctx, tgbotapi.NewMessage(rule.ChatID, fmt.Sprintf(tr(locale, "⚠️ Не удалось сохранить повторяющуюся операцию #%d (%s): %s", "⚠️ Failed to save recurring transaction #%d (%s): %s"), rule.ID, rule.Description, reason)))
+	}
+	sess, err := h.auth.GetSession(ctx, rule.TelegramID)
+	if err != nil || sess == nil {
+		fail(tr(locale, "выполните вход: /login", "please login: /login"))
+		return
+	}
+	categoryID := derefString(rule.CategoryID)
+	txID, err := h.txClient.CreateTransaction(ctx, &grpcclient.CreateTransactionRequest{
+		TenantID:    rule.TenantID,
+		Type:        rule.TxType,
+		AmountMinor: rule.AmountMinor,
+		Currency:    rule.Currency,
+		Description: rule.Description,
+		CategoryID:  categoryID,
+		OccurredAt:  due,
+	}, sess.AccessToken)
+	if err != nil {
+		h.logger.Error("failed to create recurring transaction", zap.Int64("ruleID", rule.ID), zap.Error(err))
+		metrics.IncTransactionsSaved("error")
+		fail(tr(locale, "ошибка сервера", "server error"))
+		return
+	}
+	_, _ = h.recurring.UpdateRunStatus(ctx, rule.ID, due, repository.RecurringRunPending, repository.RecurringRunCreated, &txID)
+	metrics.IncTransactionsSaved("ok")
+
+	occurredAt := due
+	op := &repository.OperationContext{
+		OpID:                 uuid.NewString(),
+		TelegramID:           rule.TelegramID,
+		TenantID:             rule.TenantID,
+		TransactionID:        &txID,
+		DescriptionOriginal:  rule.Description,
+		CategoryIDSelected:   rule.CategoryID,
+		CategoryNameSelected: rule.CategoryName,
+		SelectionSource:      "recurring",
+		TxType:               rule.TxType,
+		AmountMinor:          rule.AmountMinor,
+		Currency:             rule.Currency,
+		OccurredAt:           &occurredAt,
+	}
+	text := fmt.Sprintf(tr(locale, "🔁 Повторяющаяся операция #%d\n", "🔁 Recurring transaction #%d\n"), rule.ID) + formatOperationSummary(op, locale, h.userLocation(ctx, rule.TelegramID))
+	if alert := h.budgetAlert(ctx, rule.TenantID, sess.AccessToken, categoryID, rule.TxType, rule.AmountMinor, rule.Currency, &occurredAt, h.userNow(ctx, rule.TelegramID), locale); alert != "" {
+		text += "\n\n" + alert
+	}
+	msg := tgbotapi.NewMessage(rule.ChatID, text)
+	if h.opCtxs != nil && h.opCtxs.Create(ctx, op) == nil {
+		msg.ReplyMarkup = ui.CreatePostSelectionKeyboard(op.SelectionSource, op.OpID, locale)
+	}
+	sent, _ := h.send(ctx, msg)
+	if h.opCtxs != nil && sent.MessageID != 0 {
+		_ = h.opCtxs.SetConfirmationMessageID(ctx, op.OpID, sent.MessageID)
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
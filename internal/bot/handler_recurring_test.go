@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestHandler_RecurringRules(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	states := repository.NewSQLiteDialogStateRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	recurring := repository.NewSQLiteRecurringRepository(db)
+	opCtxs := repository.NewSQLiteOperationContextRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &createRecordingTxClient{}
+
+	h := NewHandler(bot, states, auth, mappings, nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithOperationContexts(opCtxs).
+		WithTransactionClient(tx).
+		WithRecurring(recurring)
+
+	ctx := context.Background()
+	chatID := int64(8700)
+	userID := int64(87)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: 999, UserID: "other", TenantID: "t", AccessToken: "token2", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	if err := mappings.AddMapping(ctx, &repository.CategoryMapping{ID: "m1", TenantID: "t", Keyword: "кофе", CategoryID: "cat-food"}); err != nil {
+		t.Fatalf("add mapping: %v", err)
+	}
+	command := func(from int64, text string) string {
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: from}, Text: text, Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}}})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+	callback := func(data string) {
+		h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: &tgbotapi.User{ID: userID}, Message: &tgbotapi.Message{MessageID: 70, Chat: &tgbotapi.Chat{ID: chatID}}, Data: data}})
+	}
+
+	if got := command(userID, "/recurring add yearly; 100 x"); !strings.Contains(got, "расписание") {
+		t.Fatalf("invalid schedule must be rejected: %q", got)
+	}
+
+	// A rule without a mapping asks for a category and is not due until it has one
+	command(userID, "/recurring add monthly 5 10:00; 50000 аренда")
+	sends := rec.Calls("sendMessage")
+	if markup := sends[len(sends)-1].Params.Get("reply_markup"); !strings.Contains(markup, "v1:rec_cat:1:cat-home") {
+		t.Fatalf("category keyboard missing: %s", markup)
+	}
+	rent, err := recurring.Get(ctx, 1)
+	if err != nil || rent.Schedule != "monthly 5 10:00" || rent.NextRunAt.Day() != 5 || rent.NextRunAt.Hour() != 10 || !rent.NextRunAt.After(time.Now()) {
+		t.Fatalf("unexpected rule: %+v %v", rent, err)
+	}
+	due := rent.NextRunAt
+	if err := h.RunDueRecurring(ctx, due); err != nil || len(tx.created) != 0 {
+		t.Fatalf("rule without category must not run: %v", err)
+	}
+	callback("v1:rec_cat:1:cat-home")
+	if rent, _ = recurring.Get(ctx, 1); rent.CategoryName == nil || *rent.CategoryName != "Дом" {
+		t.Fatalf("category not bound: %+v", rent)
+	}
+
+	if err := h.RunDueRecurring(ctx, due.Add(-time.Minute)); err != nil || len(tx.created) != 0 {
+		t.Fatalf("rule must not run before it is due")
+	}
+	if err := h.RunDueRecurring(ctx, due); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(tx.created) != 1 || tx.created[0].CategoryID != "cat-home" || !tx.created[0].OccurredAt.Equal(due) || tx.created[0].AmountMinor != 5000000 {
+		t.Fatalf("unexpected created transactions: %+v", tx.created)
+	}
+	if got := rec.Texts()[len(rec.Texts())-1]; !strings.Contains(got, "Повторяющаяся операция #1") || !strings.Contains(got, "аренда") {
+		t.Fatalf("unexpected notification: %q", got)
+	}
+	if rent, _ = recurring.Get(ctx, 1); rent.NextRunAt.Month() == due.Month() || rent.NextRunAt.Day() != 5 {
+		t.Fatalf("next run not advanced: %s", rent.NextRunAt)
+	}
+
+	// Replaying the same occurrence, e.g. after a crash before the rule was advanced, creates nothing
+	if err := recurring.SetPaused(ctx, 1, false, due); err != nil {
+		t.Fatal(err)
+	}
+	_ = h.RunDueRecurring(ctx, due)
+	if len(tx.created) != 1 {
+		t.Fatalf("occurrence must be processed once, got %d transactions", len(tx.created))
+	}
+
+	// Confirmation mode
+	command(userID, "/recurring add daily 08:00; 300 кофе; confirm")
+	coffee, err := recurring.Get(ctx, 2)
+	if err != nil || !coffee.Confirm || coffee.CategoryID == nil || *coffee.CategoryID != "cat-food" {
+		t.Fatalf("unexpected confirm rule: %+v %v", coffee, err)
+	}
+	first := coffee.NextRunAt
+	_ = h.RunDueRecurring(ctx, first)
+	if len(tx.created) != 1 {
+		t.Fatalf("confirm rule must wait for the user")
+	}
+	okData := fmt.Sprintf("v1:rec_ok:2:%d", first.Unix())
+	sends = rec.Calls("sendMessage")
+	if markup := sends[len(sends)-1].Params.Get("reply_markup"); !strings.Contains(markup, okData) {
+		t.Fatalf("confirmation keyboard missing: %s", markup)
+	}
+	h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: &tgbotapi.User{ID: 999}, Data: okData}})
+	callback(okData)
+	callback(okData)
+	if len(tx.created) != 2 || tx.created[1].Description != "кофе" {
+		t.Fatalf("confirmed occurrence must be saved exactly once: %+v", tx.created)
+	}
+	second := first.AddDate(0, 0, 1)
+	_ = h.RunDueRecurring(ctx, second)
+	callback(fmt.Sprintf("v1:rec_skip:2:%d", second.Unix()))
+	if run, err := recurring.GetRun(ctx, 2, second); err != nil || run.Status != repository.RecurringRunSkipped || len(tx.created) != 2 {
+		t.Fatalf("occurrence should be skipped: %+v %v", run, err)
+	}
+
+	// Management commands
+	if got := command(999, "/recurring pause 1"); !strings.Contains(got, "не найдено") {
+		t.Fatalf("foreign user must not pause the rule: %q", got)
+	}
+	command(userID, "/recurring pause 1")
+	if got := command(userID, "/recurring list"); !strings.Contains(got, "#1") || !strings.Contains(got, "на паузе") || !strings.Contains(got, "#2") {
+		t.Fatalf("unexpected list: %q", got)
+	}
+	command(userID, "/recurring resume 1")
+	if rent, _ = recurring.Get(ctx, 1); rent.Paused || !rent.NextRunAt.After(time.Now()) {
+		t.Fatalf("resumed rule must be scheduled in the future: %+v", rent)
+	}
+	command(userID, "/recurring delete 2")
+	if _, err := recurring.Get(ctx, 2); err == nil {
+		t.Fatalf("rule should be deleted")
+	}
+
+	// An occurrence left pending by a crash is marked as failed and reported, not saved again
+	command(userID, "/recurring pause 1")
+	stale := due.AddDate(0, 2, 0)
+	if _, err := recurring.ClaimRun(ctx, 1, stale); err != nil {
+		t.Fatal(err)
+	}
+	_ = h.RunDueRecurring(ctx, time.Now())
+	if run, _ := recurring.GetRun(ctx, 1, stale); run.Status != repository.RecurringRunPending {
+		t.Fatalf("a fresh claim must be left alone: %+v", run)
+	}
+	_ = h.RunDueRecurring(ctx, time.Now().Add(recurringPendingTimeout+time.Minute))
+	if run, _ := recurring.GetRun(ctx, 1, stale); run.Status != repository.RecurringRunFailed || len(tx.created) != 2 {
+		t.Fatalf("stale run must fail without saving: %+v %d", run, len(tx.created))
+	}
+	if got := rec.Texts()[len(rec.Texts())-1]; !strings.Contains(got, "Повторяющаяся операция #1 (аренда)") || !strings.Contains(got, "могла не сохраниться") {
+		t.Fatalf("unexpected notification: %q", got)
+	}
+
+	// Occurrences that passed while a rule had no category are not back-filled
+	command(userID, "/recurring add daily 09:00; 100 газета")
+	if err := recurring.SetPaused(ctx, 3, false, time.Now().AddDate(0, 0, -10)); err != nil {
+		t.Fatal(err)
+	}
+	callback("v1:rec_cat:3:cat-home")
+	if paper, _ := recurring.Get(ctx, 3); paper.CategoryID == nil || !paper.NextRunAt.After(time.Now()) {
+		t.Fatalf("next run must move to the future when the category is set: %+v", paper)
+	}
+	_ = h.RunDueRecurring(ctx, time.Now())
+	if len(tx.created) != 2 {
+		t.Fatalf("missed occurrences must not be created: %+v", tx.created)
+	}
+}
@@ -0,0 +1,490 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"budget-bot/internal/bot/ui"
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// tenantCacheTTL is how long cachedTenant reuses the tenants listed for an access token.
+const tenantCacheTTL = time.Minute
+
+// cachedTenants is a tenant list remembered for an access token.
+type cachedTenants struct {
+	list     []*grpcclient.Tenant
+	storedAt time.Time
+}
+
+// currentTenant returns the session's tenant with the user's role in it, nil if it is not among the user's tenants.
+func (h *Handler) currentTenant(ctx context.Context, sess *repository.UserSession) *grpcclient.Tenant {
+	if sess == nil {
+		return nil
+	}
+	return h.tenantByID(ctx, sess, sess.TenantID)
+}
+
+// cachedTenant is currentTenant for lookups made on every message, like the base currency of a saved transaction:
+// the tenant list is reused for tenantCacheTTL. Role checks before changes use currentTenant.
+func (h *Handler) cachedTenant(ctx context.Context, sess *repository.UserSession) *grpcclient.Tenant {
+	if sess == nil {
+		return nil
+	}
+	if v, ok := h.tenantLists.Load(sess.AccessToken); ok {
+		if c := v.(cachedTenants); time.Since(c.storedAt) < tenantCacheTTL {
+			return findTenant(c.list, sess.TenantID)
+		}
+	}
+	return h.currentTenant(ctx, sess)
+}
+
+// tenantByID finds one of the user's tenants.
+func (h *Handler) tenantByID(ctx context.Context, sess *repository.UserSession, tenantID string) *grpcclient.Tenant {
+	if h.tenants == nil || sess == nil {
+		return nil
+	}
+	list, err := h.listTenants(ctx, sess)
+	if err != nil {
+		h.logger.Warn("failed to load tenants", zap.Error(err))
+		return nil
+	}
+	return findTenant(list, tenantID)
+}
+
+// listTenants lists the user's tenants and remembers them for cachedTenant.
+func (h *Handler) listTenants(ctx context.Context, sess *repository.UserSession) ([]*grpcclient.Tenant, error) {
+	list, err := h.tenants.ListTenants(ctx, sess.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	// drop the lists of tokens that are no longer used
+	h.tenantLists.Range(func(key, v any) bool {
+		if now.Sub(v.(cachedTenants).storedAt) >= tenantCacheTTL {
+			h.tenantLists.Delete(key)
+		}
+		return true
+	})
+	h.tenantLists.Store(sess.AccessToken, cachedTenants{list: list, storedAt: now})
+	return list, nil
+}
+
+func findTenant(list []*grpcclient.Tenant, tenantID string) *grpcclient.Tenant {
+	for _, t := range list {
+		if t.ID == tenantID {
+			return t
+		}
+	}
+	return nil
+}
+
+// isTenantAdmin reports whether the user is an owner or an admin of the session's tenant.
+func (h *Handler) isTenantAdmin(ctx context.Context, sess *repository.UserSession) bool {
+	t := h.currentTenant(ctx, sess)
+	return t != nil && isAdminRole(t.Role)
+}
+
+func isAdminRole(role string) bool {
+	return role == grpcclient.TenantRoleOwner || role == grpcclient.TenantRoleAdmin
+}
+
+// roleRank orders roles by privileges.
+func roleRank(role string) int {
+	switch role {
+	case grpcclient.TenantRoleOwner:
+		return 3
+	case grpcclient.TenantRoleAdmin:
+		return 2
+	case grpcclient.TenantRoleMember:
+		return 1
+	}
+	return 0
+}
+
+// canManageMember reports whether actorRole may give a member with role from the role to (to is empty on removal).
+// Owners manage everyone; admins only manage plain members and cannot grant more than member.
+func canManageMember(actorRole, from, to string) bool {
+	switch actorRole {
+	case grpcclient.TenantRoleOwner:
+		return true
+	case grpcclient.TenantRoleAdmin:
+		return (from == "" || from == grpcclient.TenantRoleMember) && (to == "" || to == grpcclient.TenantRoleMember)
+	}
+	return false
+}
+
+// parseTenantRole recognizes a role in English or Russian.
+func parseTenantRole(s string) (string, bool) {
+	switch strings.ToLower(s) {
+	case "owner", "владелец":
+		return grpcclient.TenantRoleOwner, true
+	case "admin", "админ", "администратор":
+		return grpcclient.TenantRoleAdmin, true
+	case "member", "участник":
+		return grpcclient.TenantRoleMember, true
+	}
+	return "", false
+}
+
+func roleLabel(role, locale string) string {
+	switch role {
+	case grpcclient.TenantRoleOwner:
+		return tr(locale, "владелец", "owner")
+	case grpcclient.TenantRoleAdmin:
+		return tr(locale, "администратор", "admin")
+	case grpcclient.TenantRoleMember:
+		return tr(locale, "участник", "member")
+	}
+	return tr(locale, "без роли", "no role")
+}
+
+func memberLabel(m *grpcclient.TenantMember) string {
+	if m.Name != "" && m.Email != "" {
+		return fmt.Sprintf("%s <%s>", m.Name, m.Email)
+	}
+	if m.Email != "" {
+		return m.Email
+	}
+	return m.UserID
+}
+
+// findMemberByEmail looks a member up by email or user id.
+func findMemberByEmail(list []*grpcclient.TenantMember, query string) *grpcclient.TenantMember {
+	for _, m := range list {
+		if strings.EqualFold(m.Email, query) || m.UserID == query {
+			return m
+		}
+	}
+	return nil
+}
+
+// tenantContext loads the session and the current tenant, replying with an error when either is missing.
+func (h *Handler) tenantContext(ctx context.Context, update tgbotapi.Update) (*repository.UserSession, *grpcclient.Tenant, bool) {
+	userID := update.Message.From.ID
+	sess, ok := h.getSessionWithErrorHandling(ctx, update.Message.Chat.ID, userID)
+	if !ok {
+		return nil, nil, false
+	}
+	t := h.currentTenant(ctx, sess)
+	if t == nil {
+		locale := h.userLocale(ctx, userID)
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Не удалось получить организацию. Выберите её: /switch_tenant", "Failed to load the tenant. Choose one: /switch_tenant")))
+		return nil, nil, false
+	}
+	return sess, t, true
+}
+
+// handleMembers lists members of the current tenant.
+func (h *Handler) handleMembers(ctx context.Context, update tgbotapi.Update) {
+	locale := h.userLocale(ctx, update.Message.From.ID)
+	chatID := update.Message.Chat.ID
+	sess, t, ok := h.tenantContext(ctx, update)
+	if !ok {
+		return
+	}
+	list, err := h.tenants.ListMembers(ctx, t.ID, sess.AccessToken)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить участников", "Failed to load members")))
+		return
+	}
+	lines := []string{fmt.Sprintf(tr(locale, "👥 Участники «%s»:", "👥 Members of “%s”:"), t.Name)}
+	for _, m := range list {
+		line := fmt.Sprintf("• %s — %s", memberLabel(m), roleLabel(m.Role, locale))
+		if m.UserID == sess.UserID {
+			line += tr(locale, " (вы)", " (you)")
+		}
+		lines = append(lines, line)
+	}
+	if isAdminRole(t.Role) {
+		lines = append(lines, "", tr(locale,
+			"Пригласить: /invite email [роль]\nРоль: /role email роль\nИсключить: /kick email\nРоли: owner, admin, member",
+			"Invite: /invite email [role]\nChange role: /role email role\nRemove: /kick email\nRoles: owner, admin, member"))
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, strings.Join(lines, "\n")))
+}
+
+// handleInvite adds an existing user to the current tenant: /invite email [role].
+func (h *Handler) handleInvite(ctx context.Context, update tgbotapi.Update) {
+	locale := h.userLocale(ctx, update.Message.From.ID)
+	chatID := update.Message.Chat.ID
+	args := strings.Fields(update.Message.CommandArguments())
+	role := grpcclient.TenantRoleMember
+	if len(args) == 2 {
+		r, ok := parseTenantRole(args[1])
+		if !ok {
+			args = nil
+		}
+		role = r
+	}
+	if len(args) < 1 || len(args) > 2 || !strings.Contains(args[0], "@") {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Формат: /invite email [owner|admin|member]", "Usage: /invite email [owner|admin|member]")))
+		return
+	}
+	sess, t, ok := h.tenantContext(ctx, update)
+	if !ok {
+		return
+	}
+	if !canManageMember(t.Role, "", role) {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, h.memberDenied(t.Role, locale)))
+		return
+	}
+	m, err := h.tenants.AddMember(ctx, t.ID, args[0], role, sess.AccessToken)
+	if err != nil {
+		h.logger.Warn("add member failed", zap.String("tenantID", t.ID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось добавить участника. Пользователь должен быть зарегистрирован и ещё не состоять в организации", "Failed to add the member. The user must be registered and not yet in the tenant")))
+		return
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "✅ %s добавлен(а) в «%s» как %s", "✅ %s added to “%s” as %s"), memberLabel(m), t.Name, roleLabel(m.Role, locale))))
+}
+
+// handleRole changes a member's role: /role email role. Demotions ask for confirmation.
+func (h *Handler) handleRole(ctx context.Context, update tgbotapi.Update) {
+	locale := h.userLocale(ctx, update.Message.From.ID)
+	chatID := update.Message.Chat.ID
+	args := strings.Fields(update.Message.CommandArguments())
+	var role string
+	ok := len(args) == 2
+	if ok {
+		role, ok = parseTenantRole(args[1])
+	}
+	if !ok {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Формат: /role email owner|admin|member", "Usage: /role email owner|admin|member")))
+		return
+	}
+	h.requestMemberChange(ctx, update, args[0], role)
+}
+
+// handleKick removes a member after confirmation: /kick email.
+func (h *Handler) handleKick(ctx context.Context, update tgbotapi.Update) {
+	locale := h.userLocale(ctx, update.Message.From.ID)
+	args := strings.Fields(update.Message.CommandArguments())
+	if len(args) != 1 {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(update.Message.Chat.ID, tr(locale, "Формат: /kick email", "Usage: /kick email")))
+		return
+	}
+	h.requestMemberChange(ctx, update, args[0], "")
+}
+
+// requestMemberChange validates a role change (role) or removal (empty role), applies promotions at once
+// and asks to confirm removals and demotions.
+func (h *Handler) requestMemberChange(ctx context.Context, update tgbotapi.Update, query, role string) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	sess, t, ok := h.tenantContext(ctx, update)
+	if !ok {
+		return
+	}
+	if !isAdminRole(t.Role) {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, h.memberDenied(t.Role, locale)))
+		return
+	}
+	list, err := h.tenants.ListMembers(ctx, t.ID, sess.AccessToken)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось получить участников", "Failed to load members")))
+		return
+	}
+	m := findMemberByEmail(list, query)
+	switch {
+	case m == nil:
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "Участник %s не найден. Список: /members", "Member %s not found. See /members"), query)))
+		return
+	case m.UserID == sess.UserID:
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Нельзя изменить собственную роль или исключить себя", "You cannot change your own role or remove yourself")))
+		return
+	case m.Role == role:
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "У %s уже роль %s", "%s already has the %s role"), memberLabel(m), roleLabel(role, locale))))
+		return
+	case !canManageMember(t.Role, m.Role, role):
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, h.memberDenied(t.Role, locale)))
+		return
+	}
+
+	if role != "" && roleRank(role) > roleRank(m.Role) {
+		h.applyMemberChange(ctx, chatID, 0, sess, t, m, role, locale)
+		return
+	}
+	_ = h.states.SetState(ctx, userID, repository.StateConfirmingMemberChange, map[string]any{
+		"tenant_id": t.ID,
+		"user_id":   m.UserID,
+		"role":      role,
+	}, nil)
+	question := fmt.Sprintf(tr(locale, "Исключить %s из «%s»?", "Remove %s from “%s”?"), memberLabel(m), t.Name)
+	if role != "" {
+		question = fmt.Sprintf(tr(locale, "Понизить %s в «%s»: %s → %s?", "Demote %s in “%s”: %s → %s?"), memberLabel(m), t.Name, roleLabel(m.Role, locale), roleLabel(role, locale))
+	}
+	msg := tgbotapi.NewMessage(chatID, question)
+	msg.ReplyMarkup = ui.CreateMemberConfirmKeyboard(locale)
+	_, _ = h.send(ctx, msg)
+}
+
+// handleMemberConfirmCallback applies or cancels a change stored by requestMemberChange.
+func (h *Handler) handleMemberConfirmCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, confirmed bool) {
+	locale := h.userLocale(ctx, cb.From.ID)
+	rec, _ := h.states.GetState(ctx, cb.From.ID)
+	if rec == nil || rec.State != repository.StateConfirmingMemberChange || rec.Context == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Контекст не найден", "Context not found")))
+		return
+	}
+	_ = h.states.ClearState(ctx, cb.From.ID)
+	if !confirmed {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Отменено", "Canceled")))
+		if cb.Message != nil {
+			_, _ = h.bot.Request(tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, tr(locale, "Изменение отменено", "Change canceled")))
+		}
+		return
+	}
+	sess, err := h.auth.GetSession(ctx, cb.From.ID)
+	if err != nil || sess == nil {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Нет сессии", "No session")))
+		return
+	}
+	tenantID, _ := rec.Context["tenant_id"].(string)
+	memberID, _ := rec.Context["user_id"].(string)
+	role, _ := rec.Context["role"].(string)
+	// Roles may have changed since the question was asked, so check them again
+	t := h.tenantByID(ctx, sess, tenantID)
+	var m *grpcclient.TenantMember
+	if t != nil {
+		if list, err := h.tenants.ListMembers(ctx, t.ID, sess.AccessToken); err == nil {
+			m = findMemberByEmail(list, memberID)
+		}
+	}
+	if t == nil || m == nil || !canManageMember(t.Role, m.Role, role) {
+		_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Недоступно", "Unavailable")))
+		return
+	}
+	_, _ = h.bot.Request(tgbotapi.NewCallback(cb.ID, tr(locale, "Готово", "Done")))
+	if cb.Message != nil {
+		h.applyMemberChange(ctx, cb.Message.Chat.ID, cb.Message.MessageID, sess, t, m, role, locale)
+	}
+}
+
+// applyMemberChange sets the member's role or removes the member when role is empty. The result replaces
+// the confirmation message when messageID is set.
+func (h *Handler) applyMemberChange(ctx context.Context, chatID int64, messageID int, sess *repository.UserSession, t *grpcclient.Tenant, m *grpcclient.TenantMember, role, locale string) {
+	var text string
+	if role == "" {
+		if err := h.tenants.RemoveMember(ctx, t.ID, m.UserID, sess.AccessToken); err != nil {
+			h.logger.Warn("remove member failed", zap.String("tenantID", t.ID), zap.Error(err))
+			text = tr(locale, "Не удалось исключить участника", "Failed to remove the member")
+		} else {
+			text = fmt.Sprintf(tr(locale, "🚪 %s исключён(а) из «%s»", "🚪 %s removed from “%s”"), memberLabel(m), t.Name)
+		}
+	} else {
+		if _, err := h.tenants.UpdateMemberRole(ctx, t.ID, m.UserID, role, sess.AccessToken); err != nil {
+			h.logger.Warn("update member role failed", zap.String("tenantID", t.ID), zap.Error(err))
+			text = tr(locale, "Не удалось изменить роль", "Failed to change the role")
+		} else {
+			text = fmt.Sprintf(tr(locale, "✅ %s теперь %s в «%s»", "✅ %s is now %s in “%s”"), memberLabel(m), roleLabel(role, locale), t.Name)
+		}
+	}
+	if messageID != 0 {
+		_, _ = h.bot.Request(tgbotapi.NewEditMessageText(chatID, messageID, text))
+		return
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, text))
+}
+
+func (h *Handler) memberDenied(actorRole, locale string) string {
+	if actorRole == grpcclient.TenantRoleAdmin {
+		return tr(locale, "Администратор может управлять только участниками с ролью member", "Admins can only manage members with the member role")
+	}
+	return tr(locale, "Управлять участниками могут только владельцы и администраторы организации", "Only tenant owners and admins can manage members")
+}
+
+// handleNewTenant creates a tenant owned by the user: /new_tenant name [currency].
+func (h *Handler) handleNewTenant(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	args := strings.Fields(update.Message.CommandArguments())
+	currency := h.defaultCurrency(ctx, userID)
+	if len(args) > 1 {
+		if c, ok := domain.LookupCurrency(args[len(args)-1]); ok {
+			currency = c.Code
+			args = args[:len(args)-1]
+		}
+	}
+	name := strings.Join(args, " ")
+	if name == "" {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Формат: /new_tenant название [валюта], например /new_tenant Путешествия EUR", "Usage: /new_tenant name [currency], e.g. /new_tenant Travel EUR")))
+		return
+	}
+	sess, ok := h.getSessionWithErrorHandling(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+	t, err := h.tenants.CreateTenant(ctx, name, currency, sess.AccessToken)
+	if err != nil {
+		h.logger.Warn("create tenant failed", zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось создать организацию", "Failed to create the tenant")))
+		return
+	}
+	h.tenantLists.Delete(sess.AccessToken)
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "✅ Организация «%s» создана, базовая валюта %s. Переключиться на неё:", "✅ Tenant “%s” created with base currency %s. Switch to it:"), t.Name, t.DefaultCurrency))
+	msg.ReplyMarkup = ui.CreateTenantKeyboard([]*grpcclient.Tenant{t})
+	_, _ = h.send(ctx, msg)
+}
+
+// handleTenantSettings shows the current tenant and lets owners and admins change it:
+// /tenant_settings name <name> or /tenant_settings currency <code>.
+func (h *Handler) handleTenantSettings(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	args := strings.Fields(update.Message.CommandArguments())
+	sess, t, ok := h.tenantContext(ctx, update)
+	if !ok {
+		return
+	}
+	if len(args) == 0 {
+		lines := []string{
+			fmt.Sprintf(tr(locale, "🏢 Организация: %s", "🏢 Tenant: %s"), t.Name),
+			fmt.Sprintf(tr(locale, "Базовая валюта: %s", "Base currency: %s"), t.DefaultCurrency),
+			fmt.Sprintf(tr(locale, "Ваша роль: %s", "Your role: %s"), roleLabel(t.Role, locale)),
+		}
+		if isAdminRole(t.Role) {
+			lines = append(lines, "", tr(locale,
+				"Изменить: /tenant_settings name <название> или /tenant_settings currency <код>",
+				"Change: /tenant_settings name <name> or /tenant_settings currency <code>"))
+		}
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, strings.Join(lines, "\n")))
+		return
+	}
+	usage := tr(locale, "Формат: /tenant_settings name <название> или /tenant_settings currency <код>", "Usage: /tenant_settings name <name> or /tenant_settings currency <code>")
+	if !isAdminRole(t.Role) {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Менять настройки могут только владельцы и администраторы организации", "Only tenant owners and admins can change settings")))
+		return
+	}
+	upd := &grpcclient.Tenant{ID: t.ID, Role: t.Role}
+	switch strings.ToLower(args[0]) {
+	case "name", "название":
+		upd.Name = strings.Join(args[1:], " ")
+	case "currency", "валюта":
+		if len(args) == 2 {
+			if c, ok := domain.LookupCurrency(args[1]); ok {
+				upd.DefaultCurrency = c.Code
+			}
+		}
+	}
+	if upd.Name == "" && upd.DefaultCurrency == "" {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, usage))
+		return
+	}
+	updated, err := h.tenants.UpdateTenant(ctx, upd, sess.AccessToken)
+	if err != nil {
+		h.logger.Warn("update tenant failed", zap.String("tenantID", t.ID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось изменить организацию", "Failed to update the tenant")))
+		return
+	}
+	h.tenantLists.Delete(sess.AccessToken)
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "✅ Сохранено: «%s», базовая валюта %s", "✅ Saved: “%s”, base currency %s"), updated.Name, updated.DefaultCurrency)))
+}
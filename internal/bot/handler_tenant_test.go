@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestHandler_TenantMembers(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tenants := &grpcclient.FakeTenantClient{}
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, repository.NewSQLiteCategoryMappingRepository(db), nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithTenantClient(tenants)
+
+	ctx := context.Background()
+	chatID := int64(8660)
+	userID := int64(89)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "user-1", TenantID: "tenant-1", AccessToken: "access-token-89", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	send := func(text string) string {
+		msg := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text}
+		if strings.HasPrefix(text, "/") {
+			msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}
+		}
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: msg})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+	click := func(data string) string {
+		h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: &tgbotapi.User{ID: userID}, Message: &tgbotapi.Message{MessageID: 100, Chat: &tgbotapi.Chat{ID: chatID}}, Data: data}})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+	role := func(email string) string {
+		list, _ := tenants.ListMembers(ctx, "tenant-1", "")
+		if m := findMemberByEmail(list, email); m != nil {
+			return m.Role
+		}
+		return ""
+	}
+
+	if got := send("/members"); !strings.Contains(got, "user@example.com> — владелец (вы)") || !strings.Contains(got, "/invite") {
+		t.Fatalf("unexpected members: %q", got)
+	}
+	if got := send("/invite anna@example.com boss"); !strings.Contains(got, "Формат") {
+		t.Fatalf("expected usage: %q", got)
+	}
+	if got := send("/invite anna@example.com админ"); !strings.Contains(got, "anna@example.com добавлен(а) в «Личный» как администратор") {
+		t.Fatalf("unexpected invite reply: %q", got)
+	}
+	if got := send("/kick user@example.com"); !strings.Contains(got, "Нельзя") {
+		t.Fatalf("self removal must be rejected: %q", got)
+	}
+
+	// demotion asks for confirmation
+	if got := send("/role anna@example.com member"); !strings.Contains(got, "Понизить") {
+		t.Fatalf("expected confirmation: %q", got)
+	}
+	if role("anna@example.com") != grpcclient.TenantRoleAdmin {
+		t.Fatalf("role changed before confirmation")
+	}
+	if got := click("v1:member_yes"); !strings.Contains(got, "теперь участник") || role("anna@example.com") != grpcclient.TenantRoleMember {
+		t.Fatalf("unexpected demotion result: %q", got)
+	}
+	// promotion is applied at once
+	if got := send("/role anna@example.com admin"); !strings.Contains(got, "теперь администратор") {
+		t.Fatalf("unexpected promotion reply: %q", got)
+	}
+
+	send("/kick anna@example.com")
+	if got := click("v1:member_no"); !strings.Contains(got, "отменено") || role("anna@example.com") == "" {
+		t.Fatalf("cancel must keep the member: %q", got)
+	}
+	if got := click("v1:member_yes"); strings.Contains(got, "исключён") {
+		t.Fatalf("stale confirmation must be ignored: %q", got)
+	}
+	send("/kick anna@example.com")
+	if got := click("v1:member_yes"); !strings.Contains(got, "исключён(а)") || role("anna@example.com") != "" {
+		t.Fatalf("unexpected kick result: %q", got)
+	}
+
+	if got := send("/new_tenant Путешествия в Грузию GEL"); !strings.Contains(got, "«Путешествия в Грузию» создана, базовая валюта GEL") {
+		t.Fatalf("unexpected new tenant reply: %q", got)
+	}
+	if got := send("/tenant_settings currency eur"); !strings.Contains(got, "базовая валюта EUR") {
+		t.Fatalf("unexpected settings reply: %q", got)
+	}
+	if got := send("/tenant_settings"); !strings.Contains(got, "Базовая валюта: EUR") || !strings.Contains(got, "Ваша роль: владелец") {
+		t.Fatalf("unexpected settings: %q", got)
+	}
+
+	// plain members cannot manage the tenant
+	if err := sessions.UpdateTenantID(ctx, userID, "tenant-2"); err != nil {
+		t.Fatalf("switch tenant: %v", err)
+	}
+	if got := send("/invite bob@example.com"); !strings.Contains(got, "только владельцы и администраторы") {
+		t.Fatalf("members must not invite: %q", got)
+	}
+	if got := send("/tenant_settings name Моя семья"); !strings.Contains(got, "только владельцы и администраторы") {
+		t.Fatalf("members must not change settings: %q", got)
+	}
+}
+
+func TestCanManageMember(t *testing.T) {
+	owner, admin, member := grpcclient.TenantRoleOwner, grpcclient.TenantRoleAdmin, grpcclient.TenantRoleMember
+	cases := []struct {
+		actor, from, to string
+		want            bool
+	}{
+		{owner, admin, "", true},
+		{owner, member, owner, true},
+		{admin, "", member, true},
+		{admin, "", admin, false},
+		{admin, member, "", true},
+		{admin, admin, member, false},
+		{admin, owner, "", false},
+		{member, "", member, false},
+	}
+	for _, c := range cases {
+		if got := canManageMember(c.actor, c.from, c.to); got != c.want {
+			t.Errorf("canManageMember(%q, %q, %q) = %v", c.actor, c.from, c.to, got)
+		}
+	}
+}
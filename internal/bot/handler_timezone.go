@@ -0,0 +1,150 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"budget-bot/internal/bot/ui"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// locations caches loaded time zones; time.LoadLocation reads the zoneinfo database on every call.
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// parseTimezone accepts an IANA name (Europe/Moscow), UTC or a whole-hour offset (UTC+3, +3, GMT-5)
+// and returns a name loadable with time.LoadLocation.
+func parseTimezone(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	upper := strings.ToUpper(s)
+	if upper == "UTC" || upper == "GMT" || upper == "Z" {
+		return "UTC", nil
+	}
+	offset := strings.TrimPrefix(strings.TrimPrefix(upper, "UTC"), "GMT")
+	if strings.HasPrefix(offset, "+") || strings.HasPrefix(offset, "-") {
+		hours, err := strconv.Atoi(strings.TrimSuffix(offset[1:], ":00"))
+		if err != nil || hours > 14 {
+			return "", fmt.Errorf("invalid offset %q", s)
+		}
+		if offset[0] == '-' {
+			hours = -hours
+		}
+		return etcZone(hours), nil
+	}
+	if _, err := loadLocation(s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// etcZone returns the IANA fixed-offset zone for a UTC offset in hours. Etc/GMT names have inverted signs.
+func etcZone(hours int) string {
+	if hours == 0 {
+		return "UTC"
+	}
+	return fmt.Sprintf("Etc/GMT%+d", -hours)
+}
+
+// timezoneFromLocation approximates a time zone from a shared location by its solar offset.
+// It ignores political borders and daylight saving, so users can refine it with /timezone <name>.
+func timezoneFromLocation(longitude float64) string {
+	hours := int(math.Round(longitude / 15))
+	if hours > 12 {
+		hours = 12
+	}
+	if hours < -12 {
+		hours = -12
+	}
+	return etcZone(hours)
+}
+
+// userLocation returns the user's configured time zone, falling back to the server zone.
+func (h *Handler) userLocation(ctx context.Context, telegramID int64) *time.Location {
+	if h.prefs == nil {
+		return time.Local
+	}
+	pref, _ := h.prefs.GetPreferences(ctx, telegramID)
+	if pref == nil || pref.Timezone == "" {
+		return time.Local
+	}
+	loc, err := loadLocation(pref.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// userNow returns the current time in the user's time zone; period boundaries and relative dates derive from it.
+func (h *Handler) userNow(ctx context.Context, telegramID int64) time.Time {
+	return time.Now().In(h.userLocation(ctx, telegramID))
+}
+
+func (h *Handler) handleTimezone(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	chatID := update.Message.Chat.ID
+	locale := h.userLocale(ctx, userID)
+	if h.prefs == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Настройки недоступны", "Settings are unavailable")))
+		return
+	}
+	arg := strings.TrimSpace(update.Message.CommandArguments())
+	if arg == "" {
+		now := h.userNow(ctx, userID)
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale,
+			"Часовой пояс: %s (сейчас %s)\n\nИзменить: /timezone Europe/Moscow, /timezone UTC+3 или отправьте местоположение",
+			"Timezone: %s (now %s)\n\nChange: /timezone Europe/Moscow, /timezone UTC+3 or share your location"),
+			now.Location().String(), now.Format("02.01.2006 15:04")))
+		if !update.Message.Chat.IsGroup() && !update.Message.Chat.IsSuperGroup() {
+			msg.ReplyMarkup = ui.CreateLocationRequestKeyboard(locale)
+		}
+		_, _ = h.send(ctx, msg)
+		return
+	}
+	name, err := parseTimezone(arg)
+	if err != nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "Неизвестный часовой пояс %q. Пример: /timezone Europe/Moscow", "Unknown timezone %q. Example: /timezone Europe/Moscow"), arg)))
+		return
+	}
+	h.setTimezone(ctx, chatID, userID, name, locale, nil)
+}
+
+// handleLocation sets the time zone from a location shared after /timezone.
+func (h *Handler) handleLocation(ctx context.Context, update tgbotapi.Update) {
+	userID := update.Message.From.ID
+	locale := h.userLocale(ctx, userID)
+	if h.prefs == nil {
+		return
+	}
+	h.setTimezone(ctx, update.Message.Chat.ID, userID, timezoneFromLocation(update.Message.Location.Longitude), locale, ui.CreateMainMenuKeyboard())
+}
+
+func (h *Handler) setTimezone(ctx context.Context, chatID, userID int64, name, locale string, markup any) {
+	if err := h.prefs.UpdateTimezone(ctx, userID, name); err != nil {
+		h.logger.Error("failed to save timezone", zap.Int64("telegramID", userID), zap.String("timezone", name), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось сохранить часовой пояс", "Failed to save the timezone")))
+		return
+	}
+	now := h.userNow(ctx, userID)
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "🕒 Часовой пояс: %s, сейчас %s", "🕒 Timezone: %s, now %s"), name, now.Format("02.01.2006 15:04")))
+	if markup != nil {
+		msg.ReplyMarkup = markup
+	}
+	_, _ = h.send(ctx, msg)
+}
@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// periodReportClient records the ranges requested from the report service.
+type periodReportClient struct {
+	grpcclient.FakeReportClient
+	froms []time.Time
+}
+
+func (c *periodReportClient) GetStats(ctx context.Context, tenantID string, from, to time.Time, token string) (*domain.Stats, error) {
+	c.froms = append(c.froms, from)
+	return c.FakeReportClient.GetStats(ctx, tenantID, from, to, token)
+}
+
+func TestParseTimezone(t *testing.T) {
+	cases := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"Europe/Moscow", "Europe/Moscow", true},
+		{"utc", "UTC", true},
+		{"UTC+3", "Etc/GMT-3", true},
+		{"+5", "Etc/GMT-5", true},
+		{"GMT-8", "Etc/GMT+8", true},
+		{"UTC+0", "UTC", true},
+		{"Mars/Olympus", "", false},
+		{"+5:30", "", false},
+	}
+	for _, c := range cases {
+		got, err := parseTimezone(c.in)
+		if (err == nil) != c.ok || got != c.want {
+			t.Errorf("parseTimezone(%q) = %q, %v", c.in, got, err)
+		}
+	}
+	if got := timezoneFromLocation(37.6); got != "Etc/GMT-3" {
+		t.Errorf("Moscow longitude: %s", got)
+	}
+	if got := timezoneFromLocation(-74); got != "Etc/GMT+5" {
+		t.Errorf("New York longitude: %s", got)
+	}
+}
+
+func TestHandler_UserTimezone(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	prefs := repository.NewSQLitePreferencesRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	report := &periodReportClient{}
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, repository.NewSQLiteCategoryMappingRepository(db), nil, log).
+		WithPreferences(prefs).
+		WithReportClient(report)
+
+	ctx := context.Background()
+	chatID := int64(8900)
+	userID := int64(89)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token1234567", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	command := func(text string) string {
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID, Type: "private"}, From: &tgbotapi.User{ID: userID}, Text: text, Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}}})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+
+	if got := command("/timezone"); !strings.Contains(got, "Часовой пояс: Local") {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+	sends := rec.Calls("sendMessage")
+	if markup := sends[len(sends)-1].Params.Get("reply_markup"); !strings.Contains(markup, `"request_location":true`) {
+		t.Fatalf("location keyboard missing: %s", markup)
+	}
+	if got := command("/timezone Mars/Olympus"); !strings.Contains(got, "Неизвестный часовой пояс") {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+	if got := command("/timezone Asia/Tokyo"); !strings.Contains(got, "Asia/Tokyo") {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+
+	// Changing the language keeps the timezone
+	h.HandleUpdate(ctx, tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{ID: "cb", From: &tgbotapi.User{ID: userID}, Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}}, Data: "lang:en"}})
+	if p, _ := prefs.GetPreferences(ctx, userID); p.Timezone != "Asia/Tokyo" || p.Language != "en" {
+		t.Fatalf("unexpected preferences: %+v", p)
+	}
+
+	// Month boundaries are computed in the user's timezone
+	command("/stats")
+	if len(report.froms) != 1 {
+		t.Fatalf("expected one GetStats call, got %d", len(report.froms))
+	}
+	from := report.froms[0]
+	if from.Location().String() != "Asia/Tokyo" || from.Day() != 1 || from.Hour() != 0 {
+		t.Fatalf("unexpected period start: %s", from)
+	}
+	if _, offset := from.Zone(); offset != 9*3600 {
+		t.Fatalf("report request must carry the user's offset, got %d", offset)
+	}
+
+	// A shared location sets an approximate zone
+	h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID, Type: "private"}, From: &tgbotapi.User{ID: userID}, Location: &tgbotapi.Location{Latitude: 55.75, Longitude: 37.62}}})
+	if p, _ := prefs.GetPreferences(ctx, userID); p.Timezone != "Etc/GMT-3" {
+		t.Fatalf("unexpected timezone from location: %+v", p)
+	}
+	if got := command("/profile"); !strings.Contains(got, "Timezone: Etc/GMT-3") {
+		t.Fatalf("profile must show the timezone: %q", got)
+	}
+}
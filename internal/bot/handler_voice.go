@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"budget-bot/internal/stt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// maxVoiceSeconds limits the length of voice messages sent to speech recognition.
+const maxVoiceSeconds = 60
+
+// handleVoice transcribes a voice message and saves the recognized text like a typed transaction.
+func (h *Handler) handleVoice(ctx context.Context, update tgbotapi.Update) {
+	locale := h.userLocale(ctx, update.Message.From.ID)
+	chatID := update.Message.Chat.ID
+	voice := update.Message.Voice
+	if h.stt == nil {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Голосовой ввод не настроен. Отправьте транзакцию текстом.", "Voice input is not configured. Please send the transaction as text.")))
+		return
+	}
+	if voice.Duration > maxVoiceSeconds {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, fmt.Sprintf(tr(locale, "Голосовое сообщение слишком длинное (максимум %d секунд)", "Voice message is too long (max %d seconds)"), maxVoiceSeconds)))
+		return
+	}
+	_, _ = h.bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+
+	audio, err := h.files.Download(ctx, voice.FileID)
+	if err != nil {
+		h.logger.Warn("failed to download voice message", zap.Int64("telegramID", update.Message.From.ID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось загрузить голосовое сообщение", "Failed to download the voice message")))
+		return
+	}
+	text, err := h.stt.Transcribe(ctx, stt.TranscribeRequest{
+		Audio:    audio,
+		Filename: "voice.ogg",
+		MimeType: voice.MimeType,
+		Language: locale,
+	})
+	if err != nil {
+		h.logger.Warn("speech-to-text failed", zap.Int64("telegramID", update.Message.From.ID), zap.Error(err))
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось распознать речь, попробуйте ещё раз или отправьте текстом", "Could not recognize speech, try again or send text")))
+		return
+	}
+	_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "🎙 Распознано: ", "🎙 Recognized: ")+"«"+text+"»"))
+
+	parsed, _ := h.parser.ParseMessageAt(text, h.userNow(ctx, update.Message.From.ID))
+	if parsed == nil || !parsed.IsValid {
+		_, _ = h.send(ctx, tgbotapi.NewMessage(chatID, tr(locale, "Не удалось найти сумму. Скажите сумму и описание, например: «450 шаурма»", "Could not find an amount. Say the amount and description, e.g. \"450 shawarma\"")))
+		return
+	}
+	h.saveParsedTransaction(ctx, update, parsed)
+}
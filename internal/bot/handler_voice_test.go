@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-bot/internal/repository"
+	"budget-bot/internal/stt"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestHandler_VoiceMessage(t *testing.T) {
+	transcripts := map[string]string{"voice-1": "450 шаурма", "voice-2": "привет"}
+	sttServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		buf := make([]byte, 16)
+		n, _ := f.Read(buf)
+		_, _ = w.Write([]byte(`{"text":"` + transcripts[string(buf[:n])] + `"}`))
+	}))
+	defer sttServer.Close()
+
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	states := repository.NewSQLiteDialogStateRepository(db)
+	mappings := repository.NewSQLiteCategoryMappingRepository(db)
+	prefs := repository.NewSQLitePreferencesRepository(db)
+	opCtxs := repository.NewSQLiteOperationContextRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &createRecordingTxClient{}
+
+	h := NewHandler(bot, states, auth, mappings, nil, log).
+		WithPreferences(prefs).
+		WithOperationContexts(opCtxs).
+		WithTransactionClient(tx).
+		WithFileDownloader(staticFileDownloader{"voice-1": []byte("voice-1"), "voice-2": []byte("voice-2")})
+
+	ctx := context.Background()
+	chatID := int64(8400)
+	userID := int64(84)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	if err := mappings.AddMapping(ctx, &repository.CategoryMapping{ID: "m1", TenantID: "t", Keyword: "шаурма", CategoryID: "cat-food"}); err != nil {
+		t.Fatalf("add mapping: %v", err)
+	}
+	voice := func(fileID string, duration int) {
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{
+			Chat:  &tgbotapi.Chat{ID: chatID},
+			From:  &tgbotapi.User{ID: userID},
+			Voice: &tgbotapi.Voice{FileID: fileID, Duration: duration, MimeType: "audio/ogg"},
+		}})
+	}
+
+	// Without a recognizer the user gets a hint
+	voice("voice-1", 3)
+	if len(tx.created) != 0 || !strings.Contains(strings.Join(rec.Texts(), "\n"), "Голосовой ввод не настроен") {
+		t.Fatalf("expected not configured reply, got %v", rec.Texts())
+	}
+
+	h.WithSpeechToText(stt.NewHTTPClient(sttServer.URL, "k", "whisper-1", time.Second))
+	voice("voice-1", 3)
+	if len(tx.created) != 1 || tx.created[0].AmountMinor != 45000 || tx.created[0].CategoryID != "cat-food" {
+		t.Fatalf("unexpected transactions: %+v", tx.created)
+	}
+	voice("voice-2", 3)
+	voice("voice-1", maxVoiceSeconds+1)
+	if len(tx.created) != 1 {
+		t.Fatalf("unparsable or too long voice must not create transactions")
+	}
+	texts := strings.Join(rec.Texts(), "\n")
+	for _, want := range []string{"🎙 Распознано: «450 шаурма»", "🎙 Распознано: «привет»", "Не удалось найти сумму", "слишком длинное"} {
+		if !strings.Contains(texts, want) {
+			t.Fatalf("replies %q do not contain %q", texts, want)
+		}
+	}
+}
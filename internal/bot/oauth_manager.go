@@ -227,7 +227,8 @@ func (om *OAuthManager) GetSession(ctx context.Context, telegramID int64) (*repo
 			zap.Duration("timeUntilExpiry", session.AccessTokenExpiresAt.Sub(now)))
 	}
 	
-	return session, nil
+	// In a group chat bound to a tenant the member works in that tenant
+	return scopedSession(ctx, session), nil
 }
 
 // RefreshTokens refreshes auth tokens and stores them.
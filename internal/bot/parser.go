@@ -2,8 +2,7 @@
 package bot
 
 import (
-	"regexp"
-	"strconv"
+	"fmt"
 	"strings"
 	"time"
 
@@ -11,7 +10,10 @@ import (
 )
 
 // MessageParser parses free-form text into structured transactions.
-type MessageParser struct{ currency *CurrencyParser }
+type MessageParser struct {
+	currency *CurrencyParser
+	dates    DateExtractor
+}
 
 // ValidationError describes invalid user input.
 type ValidationError struct {
@@ -26,20 +28,37 @@ type ParsedTransaction struct {
 	Currency    string
 	Description string
 	OccurredAt  *time.Time
+	// Expression is the arithmetic expression the amount was computed from ("2x350"), empty for a plain number
+	Expression  string
+	// CategoryID is a category chosen before saving (an inline result, a held draft); empty to detect it
+	CategoryID  string
+	// BatchID is the multi-line message the transaction came from, so its undo covers it; empty for a single one
+	BatchID     string
 	IsValid     bool
 	Errors      []string
 }
 
 // NewMessageParser constructs a MessageParser with default currency parser.
-func NewMessageParser() *MessageParser { return &MessageParser{currency: NewCurrencyParser()} }
+func NewMessageParser() *MessageParser {
+	return &MessageParser{currency: NewCurrencyParser(), dates: NewNaturalDateExtractor()}
+}
 
-var (
-	amountRe   = regexp.MustCompile(`(?i)([+\-]?\d+[\.,]?\d*)`)
-	dateDDMMYY = regexp.MustCompile(`\b(\d{1,2})[\./](\d{1,2})(?:[\./](\d{2,4}))?\b`)
-)
+// WithDateExtractor replaces the date extraction component.
+func (p *MessageParser) WithDateExtractor(d DateExtractor) *MessageParser {
+	if d != nil {
+		p.dates = d
+	}
+	return p
+}
 
-// ParseMessage converts input text into a ParsedTransaction.
+// ParseMessage converts input text into a ParsedTransaction, resolving dates in the server timezone.
 func (p *MessageParser) ParseMessage(text string) (*ParsedTransaction, error) {
+	return p.ParseMessageAt(text, time.Now())
+}
+
+// ParseMessageAt converts input text into a ParsedTransaction. Relative and partial dates
+// are resolved against now and interpreted in its location, i.e. the user's timezone.
+func (p *MessageParser) ParseMessageAt(text string, now time.Time) (*ParsedTransaction, error) {
 	original := strings.TrimSpace(text)
 	result := &ParsedTransaction{IsValid: false}
 	if original == "" {
@@ -47,48 +66,10 @@ func (p *MessageParser) ParseMessage(text string) (*ParsedTransaction, error) {
 		return result, nil
 	}
 
-	// Date parsing: today/yesterday/day before yesterday
 	lower := strings.ToLower(original)
-	now := time.Now()
-	if strings.Contains(lower, "сегодня") {
-		localTime := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		utcTime := localTime.UTC()
-		result.OccurredAt = &utcTime
-		lower = strings.ReplaceAll(lower, "сегодня", "")
-	} else if strings.Contains(lower, "вчера") {
-		localTime := now.AddDate(0, 0, -1)
-		localTime = time.Date(localTime.Year(), localTime.Month(), localTime.Day(), 0, 0, 0, 0, localTime.Location())
-		utcTime := localTime.UTC()
-		result.OccurredAt = &utcTime
-		lower = strings.ReplaceAll(lower, "вчера", "")
-	} else if strings.Contains(lower, "позавчера") {
-		localTime := now.AddDate(0, 0, -2)
-		localTime = time.Date(localTime.Year(), localTime.Month(), localTime.Day(), 0, 0, 0, 0, localTime.Location())
-		utcTime := localTime.UTC()
-		result.OccurredAt = &utcTime
-		lower = strings.ReplaceAll(lower, "позавчера", "")
-	}
-
-	// DD.MM(.YYYY)
-	if m := dateDDMMYY.FindStringSubmatch(lower); len(m) > 0 {
-		day, _ := strconv.Atoi(m[1])
-		mon, _ := strconv.Atoi(m[2])
-		year := now.Year()
-		if m[3] != "" {
-			y, _ := strconv.Atoi(m[3])
-			if y < 100 { // YY -> 20YY heuristic
-				y = 2000 + y
-			}
-			year = y
-		}
-		if day >= 1 && day <= 31 && mon >= 1 && mon <= 12 {
-			// Create time in local timezone first, then convert to UTC
-			// This ensures that "01.08" always means August 1st in user's timezone
-			localTime := time.Date(year, time.Month(mon), day, 0, 0, 0, 0, now.Location())
-			utcTime := localTime.UTC()
-			result.OccurredAt = &utcTime
-			lower = strings.Replace(lower, m[0], "", 1)
-		}
+	if occurredAt, rest := p.dates.Extract(lower, now); occurredAt != nil {
+		result.OccurredAt = occurredAt
+		lower = rest
 	}
 
 	// Currency (optional)
@@ -97,40 +78,19 @@ func (p *MessageParser) ParseMessage(text string) (*ParsedTransaction, error) {
 		lower = cleaned
 	}
 
-	// Amount (+/-) and type inference from the matched token
-	if m := amountRe.FindStringSubmatch(lower); len(m) > 0 {
-		token := strings.TrimSpace(m[1])
-		var sign int64 = 1
-		if strings.HasPrefix(token, "-") {
-			sign = -1
-		} else if strings.HasPrefix(token, "+") {
-			sign = 1
-		}
-		numeric := strings.ReplaceAll(token, ",", ".")
-		numericAbs := strings.TrimLeft(numeric, "+-")
-		if strings.Contains(numericAbs, ".") {
-			parts := strings.SplitN(numericAbs, ".", 2)
-			wholeAbs, _ := strconv.ParseInt(parts[0], 10, 64)
-			frac := parts[1]
-			if len(frac) == 1 {
-				frac = frac + "0"
-			}
-			if len(frac) > 2 {
-				frac = frac[:2]
-			}
-			minorAbs, _ := strconv.ParseInt(frac, 10, 64)
-			amountMinor := (wholeAbs*100)+minorAbs
-			result.Amount = domain.NewMoney(amountMinor, result.Currency)
-		} else {
-			wholeAbs, _ := strconv.ParseInt(numericAbs, 10, 64)
-			result.Amount = domain.NewMoney(wholeAbs*100, result.Currency)
-		}
-		lower = strings.Replace(lower, m[0], "", 1)
+	// Amount (+/-) and type inference from the matched expression. Without a currency the amount
+	// is kept in hundredths until ApplyDefaultCurrency rescales it.
+	exp := 2
+	if result.Currency != "" {
+		exp = domain.CurrencyExponent(result.Currency)
+	}
+	if a, ok := parseAmountExpr(lower, exp); ok {
+		result.Amount = domain.NewMoney(a.minor, result.Currency)
+		result.Expression = a.expression
+		lower = lower[:a.start] + lower[a.end:]
 
 		// Determine type by sign; default to expense
-		if sign < 0 {
-			result.Type = domain.TransactionExpense
-		} else if strings.HasPrefix(token, "+") {
+		if a.sign == "+" {
 			result.Type = domain.TransactionIncome
 		} else {
 			result.Type = domain.TransactionExpense
@@ -154,6 +114,56 @@ func (p *MessageParser) ParseMessage(text string) (*ParsedTransaction, error) {
 	return result, nil
 }
 
+// ParseAmount parses a standalone amount (e.g. "350", "1 200,50" or "2x350") into hundredths.
+func (p *MessageParser) ParseAmount(text string) (int64, error) {
+	return p.ParseAmountIn(text, "")
+}
+
+// ParseAmountIn parses a standalone amount into minor units of the currency.
+func (p *MessageParser) ParseAmountIn(text, currency string) (int64, error) {
+	amountMinor, _, _, ok := extractAmount(strings.ToLower(strings.TrimSpace(text)), domain.CurrencyExponent(currency))
+	if !ok || amountMinor <= 0 {
+		return 0, fmt.Errorf("amount not found")
+	}
+	return amountMinor, nil
+}
+
+// ParseDate parses a standalone date (e.g. "вчера" or "15.03.2025") into a UTC midnight timestamp.
+func (p *MessageParser) ParseDate(text string) (*time.Time, error) {
+	return p.ParseDateAt(text, time.Now())
+}
+
+// ParseDateAt is ParseDate with dates resolved against now and its location.
+func (p *MessageParser) ParseDateAt(text string, now time.Time) (*time.Time, error) {
+	occurredAt, _ := p.dates.Extract(strings.ToLower(strings.TrimSpace(text)), now)
+	if occurredAt == nil {
+		return nil, fmt.Errorf("date not found")
+	}
+	return occurredAt, nil
+}
+
+// extractAmount finds the first amount expression in text and returns its absolute value in minor units
+// with exp fraction digits, the explicit sign ("+", "-" or ""), and the text without the matched expression.
+func extractAmount(lower string, exp int) (int64, string, string, bool) {
+	a, ok := parseAmountExpr(lower, exp)
+	if !ok {
+		return 0, "", lower, false
+	}
+	return a.minor, a.sign, lower[:a.start] + lower[a.end:], true
+}
+
+// ApplyDefaultCurrency sets the currency of a transaction whose message did not name one and
+// rescales the amount from hundredths to the currency's precision.
+func (t *ParsedTransaction) ApplyDefaultCurrency(code string) {
+	if t.Currency != "" || code == "" {
+		return
+	}
+	t.Currency = code
+	if t.Amount != nil {
+		t.Amount = domain.NewMoney(domain.RescaleMinor(t.Amount.AmountMinor, 2, domain.CurrencyExponent(code)), code)
+	}
+}
+
 // Validate performs basic validation of the parsed transaction.
 func (p *MessageParser) Validate(parsed *ParsedTransaction) []ValidationError {
 	var errs []ValidationError
@@ -86,8 +86,8 @@ func TestMessageParser_Validate_EdgeCases(t *testing.T) {
 		},
 		{
 			transaction: &ParsedTransaction{
-				Amount:   domain.NewMoney(10000, "CNY"), // unsupported currency
-				Currency: "CNY",
+				Amount:   domain.NewMoney(10000, "XYZ"), // not an ISO 4217 currency
+				Currency: "XYZ",
 			},
 			shouldBeValid: false,
 		},
@@ -116,8 +116,9 @@ func TestMessageParser_Validate_CurrencyValidation(t *testing.T) {
 		{"GBP", true},
 		{"JPY", true},
 		{"", true}, // empty currency is valid
-		{"CNY", false}, // not supported
-		{"CAD", false}, // not supported
+		{"CNY", true},
+		{"GEL", true},
+		{"XYZ", false}, // not an ISO 4217 currency
 		{"INVALID", false},
 	}
 
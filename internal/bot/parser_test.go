@@ -139,4 +139,30 @@ func TestMessageParser_DateParsing_Timezone(t *testing.T) {
 	t.Logf("Successfully parsed date: %v (UTC) = %v (Local)", parsed.OccurredAt, localTimeFromUTC)
 }
 
-
+func TestMessageParser_ParseMessageAt_UserTimezone(t *testing.T) {
+	p := NewMessageParser()
+	vladivostok := time.FixedZone("UTC+10", 10*3600)
+	// 01:30 on March 10 in the user's zone is still March 9 in UTC
+	now := time.Date(2025, 3, 10, 1, 30, 0, 0, vladivostok)
+	cases := []struct {
+		text string
+		want time.Time
+	}{
+		{"сегодня 100 кофе", time.Date(2025, 3, 10, 0, 0, 0, 0, vladivostok)},
+		{"вчера 100 кофе", time.Date(2025, 3, 9, 0, 0, 0, 0, vladivostok)},
+		{"05.03 100 кофе", time.Date(2025, 3, 5, 0, 0, 0, 0, vladivostok)},
+	}
+	for _, c := range cases {
+		res, err := p.ParseMessageAt(c.text, now)
+		if err != nil || res.OccurredAt == nil {
+			t.Fatalf("%q: %v %+v", c.text, err, res)
+		}
+		if !res.OccurredAt.Equal(c.want) {
+			t.Errorf("%q: got %s, want %s", c.text, res.OccurredAt, c.want.UTC())
+		}
+	}
+	d, err := p.ParseDateAt("вчера", now)
+	if err != nil || d.In(vladivostok).Day() != 9 {
+		t.Fatalf("ParseDateAt: %v %v", d, err)
+	}
+}
@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register JPEG decoder for receipt photos
+	_ "image/png"  // register PNG decoder for receipt documents
+	"net/url"
+	"strings"
+	"time"
+
+	"budget-bot/internal/domain"
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// receiptTimeLayouts are the timestamp formats used in the "t" field of fiscal receipt QR codes.
+var receiptTimeLayouts = []string{"20060102T150405", "20060102T1504"}
+
+// DecodeReceiptQR finds a QR code on the image and returns its text payload.
+func DecodeReceiptQR(data []byte) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("decode image: %w", err)
+	}
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", fmt.Errorf("prepare image: %w", err)
+	}
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	res, err := qrcode.NewQRCodeReader().Decode(bmp, hints)
+	if err != nil {
+		return "", fmt.Errorf("qr code not found: %w", err)
+	}
+	return res.GetText(), nil
+}
+
+// ParseReceiptQR converts a fiscal receipt QR payload (t=…&s=…&fn=…&i=…&fp=…&n=…) into a transaction.
+// The receipt time has no zone and is interpreted in loc.
+func ParseReceiptQR(payload string, loc *time.Location) (*ParsedTransaction, error) {
+	values, err := url.ParseQuery(strings.TrimSpace(payload))
+	if err != nil {
+		return nil, fmt.Errorf("invalid receipt payload: %w", err)
+	}
+	if values.Get("fn") == "" || values.Get("s") == "" || values.Get("t") == "" {
+		return nil, fmt.Errorf("not a fiscal receipt")
+	}
+	amountMinor, sign, _, ok := extractAmount(values.Get("s"), domain.CurrencyExponent("RUB"))
+	if !ok || sign != "" || amountMinor <= 0 {
+		return nil, fmt.Errorf("invalid receipt sum %q", values.Get("s"))
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	var occurredAt *time.Time
+	for _, layout := range receiptTimeLayouts {
+		if t, err := time.ParseInLocation(layout, values.Get("t"), loc); err == nil {
+			utc := t.UTC()
+			occurredAt = &utc
+			break
+		}
+	}
+	if occurredAt == nil {
+		return nil, fmt.Errorf("invalid receipt time %q", values.Get("t"))
+	}
+	// n=2 is a refund of a purchase, everything else is treated as a purchase
+	txType := domain.TransactionExpense
+	if values.Get("n") == "2" {
+		txType = domain.TransactionIncome
+	}
+	return &ParsedTransaction{
+		Type:        txType,
+		Amount:      domain.NewMoney(amountMinor, "RUB"),
+		Currency:    "RUB",
+		Description: "чек",
+		OccurredAt:  occurredAt,
+		IsValid:     true,
+	}, nil
+}
@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"budget-bot/internal/domain"
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+func receiptQRPNG(t *testing.T, payload string) []byte {
+	t.Helper()
+	matrix, err := qrcode.NewQRCodeWriter().Encode(payload, gozxing.BarcodeFormat_QR_CODE, 300, 300, nil)
+	if err != nil {
+		t.Fatalf("encode qr: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, matrix); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseReceiptQR(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	cases := []struct {
+		name    string
+		payload string
+		amount  int64
+		txType  domain.TransactionType
+		when    time.Time
+		wantErr bool
+	}{
+		{name: "purchase", payload: "t=20240115T1830&s=1234.50&fn=9960440300000000&i=12345&fp=1234567890&n=1", amount: 123450, txType: domain.TransactionExpense, when: time.Date(2024, 1, 15, 15, 30, 0, 0, time.UTC)},
+		{name: "with seconds", payload: "t=20240115T183045&s=99.00&fn=1&i=1&fp=1&n=1", amount: 9900, txType: domain.TransactionExpense, when: time.Date(2024, 1, 15, 15, 30, 45, 0, time.UTC)},
+		{name: "refund", payload: "t=20240115T1830&s=10&fn=1&i=1&fp=1&n=2", amount: 1000, txType: domain.TransactionIncome, when: time.Date(2024, 1, 15, 15, 30, 0, 0, time.UTC)},
+		{name: "no fiscal number", payload: "t=20240115T1830&s=10", wantErr: true},
+		{name: "bad time", payload: "t=2024-01-15&s=10&fn=1", wantErr: true},
+		{name: "zero sum", payload: "t=20240115T1830&s=0&fn=1", wantErr: true},
+		{name: "url", payload: "https://example.com", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseReceiptQR(tc.payload, msk)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Amount.AmountMinor != tc.amount || got.Type != tc.txType || got.Currency != "RUB" || !got.IsValid {
+				t.Fatalf("unexpected result: %+v", got)
+			}
+			if got.OccurredAt == nil || !got.OccurredAt.Equal(tc.when) {
+				t.Fatalf("unexpected time: %v", got.OccurredAt)
+			}
+		})
+	}
+}
+
+func TestDecodeReceiptQR(t *testing.T) {
+	payload := "t=20240115T1830&s=1234.50&fn=9960440300000000&i=12345&fp=1234567890&n=1"
+	got, err := DecodeReceiptQR(receiptQRPNG(t, payload))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got != payload {
+		t.Fatalf("unexpected payload: %q", got)
+	}
+	if _, err := DecodeReceiptQR([]byte("not an image")); err == nil {
+		t.Fatalf("expected error for invalid image")
+	}
+}
@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	grpcclient "budget-bot/internal/grpc"
+)
+
+// reportPeriod is the range of /stats and /top_categories. To is the last instant of the range.
+type reportPeriod struct {
+	From, To time.Time
+	Label    string
+}
+
+// dayRange builds a period from the first to the last day, both inclusive.
+func dayRange(first, last time.Time, label string) reportPeriod {
+	loc := first.Location()
+	from := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+	to := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1).Add(-time.Nanosecond)
+	return reportPeriod{From: from, To: to, Label: label}
+}
+
+// monthPeriod is the calendar month of t.
+func monthPeriod(t time.Time) reportPeriod {
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return dayRange(from, from.AddDate(0, 1, -1), from.Format("2006-01"))
+}
+
+// dates renders the period as "01.01.2025–31.03.2025".
+func (p reportPeriod) dates() string {
+	return p.From.Format("02.01.2006") + "–" + p.To.Format("02.01.2006")
+}
+
+// withDates appends the dates to a relative label, e.g. "week (13.10.2025–19.10.2025)".
+func (p reportPeriod) withDates(label string) reportPeriod {
+	p.Label = label + " (" + p.dates() + ")"
+	return p
+}
+
+// parseLastSpan reads the "30d", "2w" and "3m" spans of "last 30d"; the Russian д/н/м suffixes work too.
+func parseLastSpan(s string, today time.Time) (time.Time, bool) {
+	rs := []rune(strings.ToLower(s))
+	if len(rs) < 2 {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(string(rs[:len(rs)-1]))
+	if err != nil || n <= 0 || n > 3660 {
+		return time.Time{}, false
+	}
+	switch rs[len(rs)-1] {
+	case 'd', 'д':
+		return today.AddDate(0, 0, -(n - 1)), true
+	case 'w', 'н':
+		return today.AddDate(0, 0, -(7*n - 1)), true
+	case 'm', 'м':
+		return today.AddDate(0, -n, 1), true
+	}
+	return time.Time{}, false
+}
+
+// parseQuarter reads "q1", "2025-q1" and "q1-2025"; the year defaults to the current one.
+func parseQuarter(s string, now time.Time) (int, int, bool) {
+	s = strings.ToLower(s)
+	year := now.Year()
+	if y, q, ok := strings.Cut(s, "-"); ok {
+		if strings.HasPrefix(y, "q") {
+			y, q = q, y
+		}
+		v, err := strconv.Atoi(y)
+		if err != nil || v < 1900 || v > 2999 {
+			return 0, 0, false
+		}
+		year, s = v, q
+	}
+	if len(s) != 2 || s[0] != 'q' || s[1] < '1' || s[1] > '4' {
+		return 0, 0, false
+	}
+	return year, int(s[1] - '0'), true
+}
+
+// parseReportPeriod reads the period of /stats and /top_categories from args and returns the tokens it did not use.
+// Supported: week, month, YYYY-MM, YYYY, q1 (or 2025-q1), "last 30d" (also 2w, 3m) and from..to date ranges.
+// The current month is used when no period is given.
+func (h *Handler) parseReportPeriod(args []string, now time.Time, locale string) (reportPeriod, []string, error) {
+	period := monthPeriod(now)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		lower := strings.ToLower(arg)
+		switch {
+		case lower == "week" || lower == "неделя":
+			wd := int(now.Weekday())
+			if wd == 0 {
+				wd = 7
+			}
+			from := today.AddDate(0, 0, -(wd - 1))
+			period = dayRange(from, from.AddDate(0, 0, 6), "").withDates(tr(locale, "неделя", "week"))
+		case lower == "month" || lower == "месяц":
+			period = monthPeriod(now)
+		case lower == "last" || lower == "последние":
+			if i+1 == len(args) {
+				return period, nil, fmt.Errorf(tr(locale, "после %q укажите срок, например 30d", "%q needs a span, e.g. 30d"), arg)
+			}
+			from, ok := parseLastSpan(args[i+1], today)
+			if !ok {
+				return period, nil, fmt.Errorf(tr(locale, "непонятный срок %q", "unknown span %q"), args[i+1])
+			}
+			period = dayRange(from, today, "").withDates(tr(locale, "последние ", "last ") + args[i+1])
+			i++
+		case strings.Contains(arg, ".."):
+			start, end, _ := strings.Cut(arg, "..")
+			f, err := h.parser.ParseDateAt(start, now)
+			if err != nil {
+				return period, nil, fmt.Errorf(tr(locale, "непонятная дата %q", "unknown date %q"), start)
+			}
+			t, err := h.parser.ParseDateAt(end, now)
+			if err != nil {
+				return period, nil, fmt.Errorf(tr(locale, "непонятная дата %q", "unknown date %q"), end)
+			}
+			if t.Before(*f) {
+				return period, nil, fmt.Errorf(tr(locale, "период %q заканчивается раньше, чем начинается", "range %q ends before it starts"), arg)
+			}
+			// the parser returns UTC instants of local midnight
+			period = dayRange(f.In(now.Location()), t.In(now.Location()), "")
+			period.Label = period.dates()
+		default:
+			if y, q, ok := parseQuarter(arg, now); ok {
+				from := time.Date(y, time.Month(3*q-2), 1, 0, 0, 0, 0, now.Location())
+				period = dayRange(from, from.AddDate(0, 3, -1), "").withDates(fmt.Sprintf("Q%d %d", q, y))
+			} else if m, err := time.ParseInLocation("2006-01", arg, now.Location()); err == nil {
+				period = monthPeriod(m)
+			} else if y, err := time.ParseInLocation("2006", arg, now.Location()); err == nil && len(arg) == 4 && y.Year() >= 1900 {
+				period = dayRange(y, y.AddDate(1, 0, -1), arg)
+			} else {
+				rest = append(rest, arg)
+			}
+		}
+	}
+	return period, rest, nil
+}
+
+// statsUsage lists the period forms accepted by /stats and /top_categories.
+func statsUsage(locale, command string) string {
+	return fmt.Sprintf(tr(locale,
+		"Периоды: week, month, 2025-03, 2025, q1, last 30d, 2025-01-01..2025-03-31, например: %s last 30d",
+		"Periods: week, month, 2025-03, 2025, q1, last 30d, 2025-01-01..2025-03-31, e.g. %s last 30d"), command)
+}
+
+// splitPeriodArgs groups the arguments into single periods: "last 30d" is one period of two words.
+func splitPeriodArgs(args []string) [][]string {
+	var out [][]string
+	for i := 0; i < len(args); i++ {
+		lower := strings.ToLower(args[i])
+		if (lower == "last" || lower == "последние") && i+1 < len(args) {
+			out = append(out, args[i:i+2])
+			i++
+			continue
+		}
+		out = append(out, args[i:i+1])
+	}
+	return out
+}
+
+// previousReportPeriod returns the period of the same kind right before p: the previous month, quarter or year,
+// or the same number of days before it.
+func previousReportPeriod(p reportPeriod) reportPeriod {
+	from := p.From
+	switch {
+	case grpcclient.IsWholeMonth(p.From, p.To):
+		return monthPeriod(from.AddDate(0, -1, 0))
+	case from.Day() == 1 && from.Month() == time.January && p.To.Add(time.Nanosecond).Equal(from.AddDate(1, 0, 0)):
+		prev := from.AddDate(-1, 0, 0)
+		return dayRange(prev, from.AddDate(0, 0, -1), prev.Format("2006"))
+	case from.Day() == 1 && from.Month()%3 == 1 && p.To.Add(time.Nanosecond).Equal(from.AddDate(0, 3, 0)):
+		prev := from.AddDate(0, -3, 0)
+		return dayRange(prev, from.AddDate(0, 0, -1), "").withDates(fmt.Sprintf("Q%d %d", (int(prev.Month())+2)/3, prev.Year()))
+	}
+	days := int(math.Round(p.To.Add(time.Nanosecond).Sub(from).Hours() / 24))
+	prev := dayRange(from.AddDate(0, 0, -days), from.AddDate(0, 0, -1), "")
+	prev.Label = prev.dates()
+	return prev
+}
@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	grpcclient "budget-bot/internal/grpc"
+	pb "budget-bot/internal/pb/budget/v1"
+	"budget-bot/internal/repository"
+	"budget-bot/internal/testutil"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+func TestParseReportPeriod(t *testing.T) {
+	h := &Handler{parser: NewMessageParser()}
+	now := time.Date(2025, 10, 15, 18, 0, 0, 0, time.FixedZone("MSK", 3*3600)) // Wednesday
+	cases := []struct {
+		args, from, to, label string
+		rest                  []string
+	}{
+		{"", "2025-10-01", "2025-10-31", "2025-10", nil},
+		{"week USD", "2025-10-13", "2025-10-19", "неделя (13.10.2025–19.10.2025)", []string{"USD"}},
+		{"last 30d", "2025-09-16", "2025-10-15", "последние 30d (16.09.2025–15.10.2025)", nil},
+		{"last 2w", "2025-10-02", "2025-10-15", "последние 2w (02.10.2025–15.10.2025)", nil},
+		{"last 3m", "2025-07-16", "2025-10-15", "последние 3m (16.07.2025–15.10.2025)", nil},
+		{"2025-01-01..2025-03-31 10", "2025-01-01", "2025-03-31", "01.01.2025–31.03.2025", []string{"10"}},
+		{"q1", "2025-01-01", "2025-03-31", "Q1 2025 (01.01.2025–31.03.2025)", nil},
+		{"2024-q4", "2024-10-01", "2024-12-31", "Q4 2024 (01.10.2024–31.12.2024)", nil},
+		{"2024", "2024-01-01", "2024-12-31", "2024", nil},
+		{"2024-02", "2024-02-01", "2024-02-29", "2024-02", nil},
+	}
+	for _, c := range cases {
+		p, rest, err := h.parseReportPeriod(strings.Fields(c.args), now, "ru")
+		if err != nil {
+			t.Fatalf("%q: %v", c.args, err)
+		}
+		if got := p.From.Format("2006-01-02"); got != c.from || p.To.Format("2006-01-02") != c.to || p.Label != c.label || strings.Join(rest, " ") != strings.Join(c.rest, " ") {
+			t.Errorf("%q: got %s..%s %q %q", c.args, got, p.To.Format("2006-01-02"), p.Label, rest)
+		}
+		if p.From.Location() != now.Location() || !p.To.Add(time.Nanosecond).Equal(time.Date(p.To.Year(), p.To.Month(), p.To.Day()+1, 0, 0, 0, 0, now.Location())) {
+			t.Errorf("%q: the period must cover whole local days: %s..%s", c.args, p.From, p.To)
+		}
+	}
+	for _, bad := range []string{"last", "last 30x", "2025-03-31..2025-01-01", "a..b"} {
+		if _, _, err := h.parseReportPeriod(strings.Fields(bad), now, "ru"); err == nil {
+			t.Errorf("%q: error expected", bad)
+		}
+	}
+	if p, _, _ := h.parseReportPeriod([]string{"month"}, now, "ru"); !grpcclient.IsWholeMonth(p.From, p.To) {
+		t.Errorf("a month must go to the report service: %s..%s", p.From, p.To)
+	}
+}
+
+func TestHandler_StatsArbitraryRange(t *testing.T) {
+	log := zap.NewNop()
+	db := testutil.OpenMigratedSQLite(t)
+	sessions := repository.NewSQLiteSessionRepository(db)
+	auth := NewOAuthManager(&TestOAuthClient{}, sessions, log, "http://localhost:3000")
+	bot, rec := testutil.NewRecordingTestBot(t)
+	tx := &periodTotalsTxClient{totals: map[string]*grpcclient.TransactionTotals{
+		"2025-01-01/": {IncomeMinor: 30000000, ExpenseMinor: -450000, Currency: "RUB"},
+	}, lists: map[string][]*pb.Transaction{
+		"2025-01-01": {
+			{CategoryId: "cat-transport", Amount: &pb.Money{CurrencyCode: "RUB", MinorUnits: 100000}},
+			{CategoryId: "cat-food", Amount: &pb.Money{CurrencyCode: "RUB", MinorUnits: 350000}},
+		},
+	}}
+	report := &periodReportClient{}
+	h := NewHandler(bot, repository.NewSQLiteDialogStateRepository(db), auth, repository.NewSQLiteCategoryMappingRepository(db), nil, log).
+		WithPreferences(repository.NewSQLitePreferencesRepository(db)).
+		WithTransactionClient(tx).
+		WithReportClient(report)
+
+	ctx := context.Background()
+	chatID, userID := int64(9300), int64(93)
+	if err := sessions.SaveSession(ctx, &repository.UserSession{TelegramID: userID, UserID: "u", TenantID: "t", AccessToken: "token1234567", RefreshToken: "r", AccessTokenExpiresAt: time.Now().Add(time.Hour), RefreshTokenExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("save session: %v", err)
+	}
+	command := func(text string) string {
+		h.HandleUpdate(ctx, tgbotapi.Update{Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: userID}, Text: text, Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(strings.Fields(text)[0])}}}})
+		texts := rec.Texts()
+		return texts[len(texts)-1]
+	}
+
+	got := command("/stats 2025-01-01..2025-03-31")
+	if len(report.froms) != 0 {
+		t.Fatalf("a quarter must not be asked from the monthly report: %v", report.froms)
+	}
+	if !strings.Contains(got, "Статистика 01.01.2025–31.03.2025") || !strings.Contains(got, "300000.00") || !strings.Contains(got, "4500.00") {
+		t.Fatalf("unexpected stats: %q", got)
+	}
+	if got := command("/stats 2025-08"); len(report.froms) != 1 || !strings.Contains(got, "Статистика 2025-08") {
+		t.Fatalf("a whole month goes to the report service: %q", got)
+	}
+	got = command("/top_categories 2025-01-01..2025-03-31 1")
+	if !strings.HasPrefix(got, "Топ категорий за 01.01.2025–31.03.2025:\n1) Питание — 3500.00 RUB") || strings.Contains(got, "2)") {
+		t.Fatalf("unexpected top categories: %q", got)
+	}
+	if got := command("/stats someday"); !strings.Contains(got, `непонятный аргумент "someday"`) || !strings.Contains(got, "last 30d") {
+		t.Fatalf("unknown arguments must be reported: %q", got)
+	}
+}
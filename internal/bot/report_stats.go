@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"budget-bot/internal/domain"
+	grpcclient "budget-bot/internal/grpc"
+	"budget-bot/internal/repository"
+	"go.uber.org/zap"
+)
+
+// statsPageSize is the page size used to aggregate transactions of ranges the report service does not summarize.
+const statsPageSize = 200
+
+// periodStats returns income and expense for a range. Whole calendar months go through the report
+// service; other ranges are summed with GetTransactionsTotals, as the report service only knows months.
+func (h *Handler) periodStats(ctx context.Context, sess *repository.UserSession, from, to time.Time) (*domain.Stats, error) {
+	if grpcclient.IsWholeMonth(from, to) {
+		return h.report.GetStats(ctx, sess.TenantID, from, to, sess.AccessToken)
+	}
+	totals, err := h.txClient.GetTransactionsTotals(ctx, &grpcclient.TransactionFilter{From: from, To: to}, sess.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Stats{
+		Period:       from.Format("2006-01-02") + ".." + to.Format("2006-01-02"),
+		TotalIncome:  absMinor(totals.IncomeMinor),
+		TotalExpense: absMinor(totals.ExpenseMinor),
+		Currency:     totals.Currency,
+	}, nil
+}
+
+// periodTopCategories returns the largest expense categories for a range, see periodStats. Other ranges are
+// aggregated from the listed expenses; categories spent in a currency other than the base one are summed
+// with GetTransactionsTotals, which converts them.
+func (h *Handler) periodTopCategories(ctx context.Context, sess *repository.UserSession, from, to time.Time, limit int, locale string) ([]*domain.CategoryTotal, error) {
+	if grpcclient.IsWholeMonth(from, to) {
+		return h.report.TopCategories(ctx, sess.TenantID, from, to, limit, sess.AccessToken)
+	}
+	filter := &grpcclient.TransactionFilter{From: from, To: to, Type: string(domain.TransactionExpense)}
+	totals, err := h.txClient.GetTransactionsTotals(ctx, filter, sess.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	sums := map[string]int64{}
+	foreign := map[string]bool{}
+	for page := 1; ; page++ {
+		res, err := h.txClient.ListTransactions(ctx, filter, page, statsPageSize, sess.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range res.Transactions {
+			id := t.GetCategoryId()
+			sums[id] += absMinor(t.GetAmount().GetMinorUnits())
+			if t.GetAmount().GetCurrencyCode() != totals.Currency {
+				foreign[id] = true
+			}
+		}
+		if len(res.Transactions) == 0 || page >= res.TotalPages {
+			break
+		}
+	}
+	for id := range foreign {
+		ct, err := h.txClient.GetTransactionsTotals(ctx, &grpcclient.TransactionFilter{From: from, To: to, CategoryIDs: []string{id}, Type: filter.Type}, sess.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		sums[id] = absMinor(ct.ExpenseMinor)
+	}
+	categories, err := h.nameMapper.CategoriesByID(ctx, sess.TenantID, sess.AccessToken, locale)
+	if err != nil {
+		h.logger.Warn("failed to load category names", zap.String("tenantID", sess.TenantID), zap.Error(err))
+		categories = map[string]*domain.Category{}
+	}
+	out := make([]*domain.CategoryTotal, 0, len(sums))
+	for id, sum := range sums {
+		if sum == 0 {
+			continue
+		}
+		name := id
+		if c := categories[id]; c != nil {
+			name = c.Name
+		}
+		out = append(out, &domain.CategoryTotal{CategoryID: id, Name: name, SumMinor: sum, Currency: totals.Currency})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SumMinor != out[j].SumMinor {
+			return out[i].SumMinor > out[j].SumMinor
+		}
+		return out[i].Name < out[j].Name
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
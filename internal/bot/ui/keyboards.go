@@ -2,6 +2,9 @@
 package ui
 
 import (
+	"fmt"
+	"strconv"
+
 	"budget-bot/internal/domain"
 	grpcclient "budget-bot/internal/grpc"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -28,12 +31,244 @@ func CreatePostSelectionKeyboard(source, opID, locale string) tgbotapi.InlineKey
 		rememberLabel = "Remember choice"
 	}
 	change := tgbotapi.NewInlineKeyboardButtonData(changeLabel, "v1:change:"+opID)
+	first := tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(rememberLabel, "v1:remember:"+opID), change)
 	if source == "mapping" {
-		forget := tgbotapi.NewInlineKeyboardButtonData(forgetLabel, "v1:forget:"+opID)
-		return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(forget, change))
+		first = tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(forgetLabel, "v1:forget:"+opID), change)
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{first}
+	rows = append(rows, createEditRows(opID, locale)...)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// createEditRows builds edit/delete actions for a saved transaction.
+func createEditRows(opID, locale string) [][]tgbotapi.InlineKeyboardButton {
+	amountLabel := "✏️ Сумма"
+	dateLabel := "📅 Дата"
+	commentLabel := "💬 Комментарий"
+	deleteLabel := "🗑 Удалить"
+	if locale == "en" {
+		amountLabel = "✏️ Amount"
+		dateLabel = "📅 Date"
+		commentLabel = "💬 Comment"
+		deleteLabel = "🗑 Delete"
+	}
+	return [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(amountLabel, "v1:edit_amount:"+opID),
+			tgbotapi.NewInlineKeyboardButtonData(dateLabel, "v1:edit_date:"+opID),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(commentLabel, "v1:edit_comment:"+opID),
+			tgbotapi.NewInlineKeyboardButtonData(deleteLabel, "v1:delete:"+opID),
+		),
+	}
+}
+
+// CreateDeleteConfirmKeyboard asks to confirm deletion of a saved transaction.
+func CreateDeleteConfirmKeyboard(opID, locale string) tgbotapi.InlineKeyboardMarkup {
+	yesLabel := "Да, удалить"
+	noLabel := "Отмена"
+	if locale == "en" {
+		yesLabel = "Yes, delete"
+		noLabel = "Cancel"
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(yesLabel, "v1:delete_yes:"+opID),
+		tgbotapi.NewInlineKeyboardButtonData(noLabel, "v1:delete_no:"+opID),
+	))
+}
+
+// CreateAmountCheckKeyboard asks what to do with a transaction held because of an unusual amount.
+func CreateAmountCheckKeyboard(draftID, locale string) tgbotapi.InlineKeyboardMarkup {
+	saveLabel := "✅ Сохранить"
+	fixLabel := "✏️ Исправить сумму"
+	cancelLabel := "✖️ Отмена"
+	if locale == "en" {
+		saveLabel = "✅ Save"
+		fixLabel = "✏️ Fix amount"
+		cancelLabel = "✖️ Cancel"
 	}
-	remember := tgbotapi.NewInlineKeyboardButtonData(rememberLabel, "v1:remember:"+opID)
-	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(remember, change))
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(saveLabel, "v1:draft_save:"+draftID),
+		tgbotapi.NewInlineKeyboardButtonData(fixLabel, "v1:draft_fix:"+draftID),
+		tgbotapi.NewInlineKeyboardButtonData(cancelLabel, "v1:draft_cancel:"+draftID),
+	))
+}
+
+// CreateRecentListKeyboard builds the /recent page: one button per listed transaction and page navigation.
+// Callbacks carry the page, the page size and the transaction id: v1:recent:<page>:<size>, v1:recent_tx:<page>:<size>:<id>.
+func CreateRecentListKeyboard(page, size int, txIDs []string, totalPages int, locale string) tgbotapi.InlineKeyboardMarkup {
+	prevLabel := "◀️ Назад"
+	nextLabel := "Вперёд ▶️"
+	if locale == "en" {
+		prevLabel = "◀️ Prev"
+		nextLabel = "Next ▶️"
+	}
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for i, id := range txIDs {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(strconv.Itoa(i+1), fmt.Sprintf("v1:recent_tx:%d:%d:%s", page, size, id)))
+		if len(row) == 5 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(prevLabel, fmt.Sprintf("v1:recent:%d:%d", page-1, size)))
+	}
+	if page < totalPages {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(nextLabel, fmt.Sprintf("v1:recent:%d:%d", page+1, size)))
+	}
+	if len(nav) > 0 {
+		keyboard = append(keyboard, nav)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
+// CreateFindPageKeyboard builds the prev/next buttons of a /find result; queryID is the stored query.
+func CreateFindPageKeyboard(queryID string, page, totalPages int, locale string) tgbotapi.InlineKeyboardMarkup {
+	prevLabel := "◀️ Назад"
+	nextLabel := "Вперёд ▶️"
+	if locale == "en" {
+		prevLabel = "◀️ Prev"
+		nextLabel = "Next ▶️"
+	}
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(prevLabel, fmt.Sprintf("v1:find:%s:%d", queryID, page-1)))
+	}
+	if page < totalPages {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(nextLabel, fmt.Sprintf("v1:find:%s:%d", queryID, page+1)))
+	}
+	if len(row) == 0 {
+		return tgbotapi.NewInlineKeyboardMarkup()
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// CreateRecentDetailKeyboard builds actions for a transaction opened from /recent with a way back to its page.
+func CreateRecentDetailKeyboard(opID string, page, size int, locale string) tgbotapi.InlineKeyboardMarkup {
+	changeLabel := "🏷 Категория"
+	backLabel := "↩️ К списку"
+	if locale == "en" {
+		changeLabel = "🏷 Category"
+		backLabel = "↩️ Back to list"
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(changeLabel, "v1:change:"+opID),
+		tgbotapi.NewInlineKeyboardButtonData(backLabel, fmt.Sprintf("v1:recent:%d:%d", page, size)),
+	)}
+	rows = append(rows, createEditRows(opID, locale)...)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// CreateMemberConfirmKeyboard asks to confirm removing or demoting a tenant member.
+func CreateMemberConfirmKeyboard(locale string) tgbotapi.InlineKeyboardMarkup {
+	yesLabel := "Да, подтверждаю"
+	noLabel := "Отмена"
+	if locale == "en" {
+		yesLabel = "Yes, confirm"
+		noLabel = "Cancel"
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(yesLabel, "v1:member_yes"),
+		tgbotapi.NewInlineKeyboardButtonData(noLabel, "v1:member_no"),
+	))
+}
+
+// CreateBatchUndoKeyboard offers to revert all transactions saved from one multi-line message.
+func CreateBatchUndoKeyboard(batchID, locale string) tgbotapi.InlineKeyboardMarkup {
+	label := "↩️ Отменить все"
+	if locale == "en" {
+		label = "↩️ Undo all"
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(label, "v1:batch_undo:"+batchID),
+	))
+}
+
+// ImportField describes a transaction field that can be bound to a CSV column.
+type ImportField struct {
+	Key    string
+	Label  string
+	Column string
+}
+
+// CreateImportMappingKeyboard shows the current CSV column mapping and import actions.
+func CreateImportMappingKeyboard(fields []ImportField, locale string) tgbotapi.InlineKeyboardMarkup {
+	unused := "—"
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, f := range fields {
+		column := f.Column
+		if column == "" {
+			column = unused
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(f.Label+": "+column, "v1:imp_field:"+f.Key),
+		))
+	}
+	previewLabel, dryLabel, commitLabel, cancelLabel := "👀 Предпросмотр", "🧪 Пробный импорт", "✅ Импортировать", "Отмена"
+	if locale == "en" {
+		previewLabel, dryLabel, commitLabel, cancelLabel = "👀 Preview", "🧪 Dry run", "✅ Import", "Cancel"
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(previewLabel, "v1:imp_preview"),
+			tgbotapi.NewInlineKeyboardButtonData(dryLabel, "v1:imp_dry"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(commitLabel, "v1:imp_commit"),
+			tgbotapi.NewInlineKeyboardButtonData(cancelLabel, "v1:imp_cancel"),
+		),
+	)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// CreateImportColumnKeyboard lists CSV header columns to bind to a field; index -1 unbinds it.
+func CreateImportColumnKeyboard(field string, headers []string, locale string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, name := range headers {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(name, fmt.Sprintf("v1:imp_col:%s:%d", field, i)),
+		))
+	}
+	label := "— Не использовать"
+	if locale == "en" {
+		label = "— Not used"
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(label, "v1:imp_col:"+field+":-1"),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// CreateRecurringCategoryKeyboard lists categories to bind to a recurring rule.
+func CreateRecurringCategoryKeyboard(categories []*domain.Category, ruleID int64) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, c := range categories {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(c.Emoji+" "+c.Name, fmt.Sprintf("v1:rec_cat:%d:%s", ruleID, c.ID)),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// CreateRecurringConfirmKeyboard asks to save or skip one occurrence of a recurring rule.
+func CreateRecurringConfirmKeyboard(ruleID, dueUnix int64, locale string) tgbotapi.InlineKeyboardMarkup {
+	saveLabel := "✅ Сохранить"
+	skipLabel := "⏭ Пропустить"
+	if locale == "en" {
+		saveLabel = "✅ Save"
+		skipLabel = "⏭ Skip"
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(saveLabel, fmt.Sprintf("v1:rec_ok:%d:%d", ruleID, dueUnix)),
+		tgbotapi.NewInlineKeyboardButtonData(skipLabel, fmt.Sprintf("v1:rec_skip:%d:%d", ruleID, dueUnix)),
+	))
 }
 
 // CreateChangeCategoryKeyboard builds category keyboard bound to operation id.
@@ -95,6 +330,18 @@ func CreateMainMenuKeyboard() tgbotapi.ReplyKeyboardMarkup {
 	return kb
 }
 
+// CreateLocationRequestKeyboard builds a one-time keyboard asking the user to share their location.
+func CreateLocationRequestKeyboard(locale string) tgbotapi.ReplyKeyboardMarkup {
+	label := "📍 Отправить местоположение"
+	if locale == "en" {
+		label = "📍 Share location"
+	}
+	kb := tgbotapi.NewReplyKeyboard(tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButtonLocation(label)))
+	kb.ResizeKeyboard = true
+	kb.OneTimeKeyboard = true
+	return kb
+}
+
 // CreateHelpKeyboard builds the main help menu keyboard.
 func CreateHelpKeyboard(locale string) tgbotapi.InlineKeyboardMarkup {
 	authLabel := "🔐 Аутентификация"
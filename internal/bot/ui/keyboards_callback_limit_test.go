@@ -18,3 +18,95 @@ func TestCreateChangeCategoryKeyboard_CallbackDataLength(t *testing.T) {
 		t.Fatalf("callback length too long: %d", len(*got))
 	}
 }
+
+func TestCreatePostSelectionKeyboard_EditActions(t *testing.T) {
+	opID := strings.Repeat("b", 36)
+	kb := CreatePostSelectionKeyboard("manual", opID, "en")
+	if len(kb.InlineKeyboard) != 3 {
+		t.Fatalf("rows: %d", len(kb.InlineKeyboard))
+	}
+	for _, row := range kb.InlineKeyboard {
+		for _, btn := range row {
+			if btn.CallbackData == nil || len(*btn.CallbackData) > 64 {
+				t.Fatalf("bad callback for %q", btn.Text)
+			}
+		}
+	}
+	if got := *kb.InlineKeyboard[2][1].CallbackData; got != "v1:delete:"+opID {
+		t.Fatalf("unexpected delete callback: %s", got)
+	}
+}
+
+func TestCreateBatchUndoKeyboard_CallbackDataLength(t *testing.T) {
+	batchID := strings.Repeat("c", 36)
+	kb := CreateBatchUndoKeyboard(batchID, "ru")
+	got := kb.InlineKeyboard[0][0].CallbackData
+	if got == nil || *got != "v1:batch_undo:"+batchID || len(*got) > 64 {
+		t.Fatalf("unexpected callback: %v", got)
+	}
+}
+
+func TestCreateImportKeyboards(t *testing.T) {
+	kb := CreateImportMappingKeyboard([]ImportField{{Key: "date", Label: "Date", Column: "Дата операции"}, {Key: "comment", Label: "Comment"}}, "en")
+	if len(kb.InlineKeyboard) != 4 {
+		t.Fatalf("rows: %d", len(kb.InlineKeyboard))
+	}
+	if kb.InlineKeyboard[1][0].Text != "Comment: —" || *kb.InlineKeyboard[1][0].CallbackData != "v1:imp_field:comment" {
+		t.Fatalf("unexpected field button: %+v", kb.InlineKeyboard[1][0])
+	}
+	cols := CreateImportColumnKeyboard("category", []string{"a", "b"}, "ru")
+	if len(cols.InlineKeyboard) != 3 || *cols.InlineKeyboard[1][0].CallbackData != "v1:imp_col:category:1" || *cols.InlineKeyboard[2][0].CallbackData != "v1:imp_col:category:-1" {
+		t.Fatalf("unexpected column keyboard: %+v", cols.InlineKeyboard)
+	}
+}
+
+func TestCreateRecurringKeyboards_CallbackDataLength(t *testing.T) {
+	cats := []*domain.Category{{ID: strings.Repeat("a", 36), Name: "Дом"}}
+	kb := CreateRecurringCategoryKeyboard(cats, 123456789)
+	if got := *kb.InlineKeyboard[0][0].CallbackData; got != "v1:rec_cat:123456789:"+cats[0].ID || len(got) > 64 {
+		t.Fatalf("unexpected callback: %s", got)
+	}
+	confirm := CreateRecurringConfirmKeyboard(123456789, 4102444800, "en")
+	for _, btn := range confirm.InlineKeyboard[0] {
+		if btn.CallbackData == nil || len(*btn.CallbackData) > 64 {
+			t.Fatalf("bad callback for %q", btn.Text)
+		}
+	}
+	if *confirm.InlineKeyboard[0][1].CallbackData != "v1:rec_skip:123456789:4102444800" {
+		t.Fatalf("unexpected skip callback: %s", *confirm.InlineKeyboard[0][1].CallbackData)
+	}
+}
+
+func TestCreateRecentListKeyboard_CallbackDataLength(t *testing.T) {
+	id := strings.Repeat("d", 36)
+	kb := CreateRecentListKeyboard(99999, 20, []string{id}, 100000, "ru")
+	for _, row := range kb.InlineKeyboard {
+		for _, btn := range row {
+			if btn.CallbackData == nil || len(*btn.CallbackData) > 64 {
+				t.Fatalf("bad callback for %q", btn.Text)
+			}
+		}
+	}
+	if got := *kb.InlineKeyboard[0][0].CallbackData; got != "v1:recent_tx:99999:20:"+id {
+		t.Fatalf("unexpected callback: %s", got)
+	}
+}
+
+func TestCreateFindPageKeyboard_CallbackDataLength(t *testing.T) {
+	id := strings.Repeat("e", 36)
+	kb := CreateFindPageKeyboard(id, 99998, 99999, "ru")
+	if len(kb.InlineKeyboard) != 1 || len(kb.InlineKeyboard[0]) != 2 {
+		t.Fatalf("unexpected keyboard: %+v", kb.InlineKeyboard)
+	}
+	for _, btn := range kb.InlineKeyboard[0] {
+		if btn.CallbackData == nil || len(*btn.CallbackData) > 64 {
+			t.Fatalf("bad callback for %q", btn.Text)
+		}
+	}
+	if got := *kb.InlineKeyboard[0][1].CallbackData; got != "v1:find:"+id+":99999" {
+		t.Fatalf("unexpected callback: %s", got)
+	}
+	if kb := CreateFindPageKeyboard(id, 1, 1, "en"); len(kb.InlineKeyboard) != 0 {
+		t.Fatalf("a single page needs no buttons: %+v", kb.InlineKeyboard)
+	}
+}
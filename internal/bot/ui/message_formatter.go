@@ -3,6 +3,7 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 
 	"budget-bot/internal/domain"
 )
@@ -13,9 +14,9 @@ type MessageFormatter struct{}
 // NewMessageFormatter constructs a MessageFormatter.
 func NewMessageFormatter() *MessageFormatter { return &MessageFormatter{} }
 
-// FormatMoney renders money from minor units with currency code, e.g., 1234 RUB.
+// FormatMoney renders money from minor units with currency code using the currency's precision, e.g., 12.34 RUB or 1234 JPY.
 func (mf *MessageFormatter) FormatMoney(amountMinor int64, currency string) string {
-	return fmt.Sprintf("%.2f %s", float64(amountMinor)/100.0, currency)
+	return fmt.Sprintf("%s %s", domain.FormatAmount(amountMinor, currency), currency)
 }
 
 // FormatStats renders a compact stats summary.
@@ -42,7 +43,20 @@ func (mf *MessageFormatter) FormatCategoriesList(categories []*domain.Category)
 
 // FormatTransactionLine renders a one-line transaction entry.
 func (mf *MessageFormatter) FormatTransactionLine(sign string, amountMinor int64, currency, comment string) string {
-	return fmt.Sprintf("%s %.2f %s %s", sign, float64(amountMinor)/100.0, currency, comment)
+	return fmt.Sprintf("%s %s %s %s", sign, domain.FormatAmount(amountMinor, currency), currency, comment)
 }
 
 
+
+// FormatProgressBar renders a 10-cell bar for a percentage; values above 100 fill the bar.
+func (mf *MessageFormatter) FormatProgressBar(percent float64) string {
+	const cells = 10
+	filled := int(percent/100*cells + 0.5)
+	if filled < 0 {
+		filled = 0
+	}
+	if filled > cells {
+		filled = cells
+	}
+	return strings.Repeat("▓", filled) + strings.Repeat("░", cells-filled)
+}
@@ -20,3 +20,13 @@ func TestMessageFormatter_Formatters(t *testing.T) {
 	line := mf.FormatTransactionLine("-", 100, "USD", "coffee")
 	if line == "" { t.Fatalf("line format empty") }
 }
+
+func TestMessageFormatter_FormatProgressBar(t *testing.T) {
+	mf := NewMessageFormatter()
+	cases := map[float64]string{0: "░░░░░░░░░░", 45: "▓▓▓▓▓░░░░░", 80: "▓▓▓▓▓▓▓▓░░", 250: "▓▓▓▓▓▓▓▓▓▓", -5: "░░░░░░░░░░"}
+	for pct, want := range cases {
+		if got := mf.FormatProgressBar(pct); got != want {
+			t.Fatalf("progress %.0f: got %s want %s", pct, got, want)
+		}
+	}
+}
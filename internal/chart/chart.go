@@ -0,0 +1,182 @@
+// Package chart renders PNG charts with the standard library only, so no chart service or font files are needed.
+// Axis labels are numbers drawn with a built-in bitmap font; names (categories, months) go to the message caption
+// next to the emoji marker of their color.
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"sort"
+)
+
+// ErrNoData is returned when there is nothing to draw.
+var ErrNoData = errors.New("chart: no data")
+
+// Color is a chart color with the emoji square that stands for it in captions.
+type Color struct {
+	RGBA   color.RGBA
+	Marker string
+}
+
+// Palette colors the slices of share charts in order; the colors follow the emoji squares.
+var Palette = []Color{
+	{color.RGBA{0x33, 0x7a, 0xe8, 0xff}, "🟦"},
+	{color.RGBA{0xf5, 0x8a, 0x1f, 0xff}, "🟧"},
+	{color.RGBA{0x3f, 0xb9, 0x50, 0xff}, "🟩"},
+	{color.RGBA{0xe5, 0x3e, 0x3e, 0xff}, "🟥"},
+	{color.RGBA{0x9b, 0x59, 0xd0, 0xff}, "🟪"},
+	{color.RGBA{0xf2, 0xc9, 0x1f, 0xff}, "🟨"},
+	{color.RGBA{0x8d, 0x5b, 0x3c, 0xff}, "🟫"},
+}
+
+// Named colors of the income/expense charts and of merged small shares.
+var (
+	Income  = Palette[2]
+	Expense = Palette[3]
+	Other   = Color{color.RGBA{0xbd, 0xbd, 0xbd, 0xff}, "⬜"}
+)
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	ink        = color.RGBA{0x44, 0x44, 0x44, 0xff}
+	grid       = color.RGBA{0xe3, 0xe3, 0xe3, 0xff}
+)
+
+// canvas is a white RGBA image with the drawing primitives the charts need.
+type canvas struct {
+	img *image.RGBA
+}
+
+func newCanvas(w, h int) *canvas {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+	return &canvas{img: img}
+}
+
+// blend paints the pixel with the color at the given coverage (0..1).
+func (c *canvas) blend(x, y int, col color.RGBA, coverage float64) {
+	if !(image.Point{X: x, Y: y}.In(c.img.Bounds())) || coverage <= 0 {
+		return
+	}
+	if coverage >= 1 {
+		c.img.SetRGBA(x, y, col)
+		return
+	}
+	dst := c.img.RGBAAt(x, y)
+	mix := func(a, b uint8) uint8 { return uint8(float64(a)*(1-coverage) + float64(b)*coverage + 0.5) }
+	c.img.SetRGBA(x, y, color.RGBA{mix(dst.R, col.R), mix(dst.G, col.G), mix(dst.B, col.B), 0xff})
+}
+
+// rect fills [x0, x1) x [y0, y1).
+func (c *canvas) rect(x0, y0, x1, y1 int, col color.RGBA) {
+	draw.Draw(c.img, image.Rect(x0, y0, x1, y1), &image.Uniform{C: col}, image.Point{}, draw.Src)
+}
+
+// line draws a segment of the given width with soft edges.
+func (c *canvas) line(x0, y0, x1, y1, width float64, col color.RGBA) {
+	half := width / 2
+	minX, maxX := int(math.Floor(math.Min(x0, x1)-half-1)), int(math.Ceil(math.Max(x0, x1)+half+1))
+	minY, maxY := int(math.Floor(math.Min(y0, y1)-half-1)), int(math.Ceil(math.Max(y0, y1)+half+1))
+	dx, dy := x1-x0, y1-y0
+	length2 := dx*dx + dy*dy
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			t := 0.0
+			if length2 > 0 {
+				t = math.Max(0, math.Min(1, ((px-x0)*dx+(py-y0)*dy)/length2))
+			}
+			d := math.Hypot(px-(x0+t*dx), py-(y0+t*dy))
+			c.blend(x, y, col, half+0.5-d)
+		}
+	}
+}
+
+func (c *canvas) png() ([]byte, error) {
+	var b bytes.Buffer
+	if err := png.Encode(&b, c.img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Compact formats a value for axis labels: 950, 1.2k, 45k, 1.3M.
+func Compact(v float64) string {
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	trim := func(f float64, suffix string) string {
+		if f >= 10 {
+			return fmt.Sprintf("%s%.0f%s", sign, f, suffix)
+		}
+		s := fmt.Sprintf("%.1f", f)
+		if s[len(s)-2:] == ".0" {
+			s = s[:len(s)-2]
+		}
+		return sign + s + suffix
+	}
+	switch {
+	case v >= 1e6:
+		return trim(v/1e6, "M")
+	case v >= 1e3:
+		return trim(v/1e3, "k")
+	}
+	return fmt.Sprintf("%s%.0f", sign, v)
+}
+
+// niceAxis picks an axis maximum of at most six round steps (1, 2, 2.5 or 5 times a power of ten) that covers v.
+func niceAxis(v float64) (top float64, ticks int) {
+	if v <= 0 {
+		return 1, 1
+	}
+	raw := v / 6
+	p := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := 10 * p
+	for _, m := range []float64{1, 2, 2.5, 5} {
+		if raw <= m*p {
+			step = m * p
+			break
+		}
+	}
+	ticks = int(math.Ceil(v/step - 1e-9))
+	return step * float64(ticks), ticks
+}
+
+// Slice is one share of a share chart.
+type Slice struct {
+	Label string
+	Value float64
+	Color Color
+}
+
+// Shares sorts the items by value, colors the largest ones with the palette and merges the rest into one slice
+// labelled other. Non-positive values are dropped.
+func Shares(items []Slice, other string) []Slice {
+	out := make([]Slice, 0, len(items))
+	for _, it := range items {
+		if it.Value > 0 {
+			out = append(out, it)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Value > out[j].Value })
+	if len(out) > len(Palette) {
+		rest := Slice{Label: other, Color: Other}
+		for _, it := range out[len(Palette)-1:] {
+			rest.Value += it.Value
+		}
+		out = append(out[:len(Palette)-1], rest)
+	}
+	for i := range out {
+		if out[i].Color.Marker == "" {
+			out[i].Color = Palette[i]
+		}
+	}
+	return out
+}
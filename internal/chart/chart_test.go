@@ -0,0 +1,100 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+// decode checks the result of a chart function and decodes the png: decode(t)(Donut(...)).
+func decode(t *testing.T) func([]byte, error) image.Image {
+	return func(b []byte, err error) image.Image {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("render: %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("chart must be a png: %v", err)
+		}
+		return img
+	}
+}
+
+func rgb(img image.Image, x, y int) [3]uint32 {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return [3]uint32{r >> 8, g >> 8, b >> 8}
+}
+
+func same(img image.Image, x, y int, c Color) bool {
+	return rgb(img, x, y) == [3]uint32{uint32(c.RGBA.R), uint32(c.RGBA.G), uint32(c.RGBA.B)}
+}
+
+func TestCompactAndAxis(t *testing.T) {
+	for v, want := range map[float64]string{0: "0", 950: "950", 1200: "1.2k", 45000: "45k", 1000: "1k", 1300000: "1.3M", -2500: "-2.5k"} {
+		if got := Compact(v); got != want {
+			t.Errorf("Compact(%v) = %s, want %s", v, got, want)
+		}
+	}
+	for v, want := range map[float64][2]float64{23700: {25000, 5}, 102000: {120000, 6}, 7: {8, 4}, 0: {1, 1}} {
+		if top, ticks := niceAxis(v); top != want[0] || float64(ticks) != want[1] {
+			t.Errorf("niceAxis(%v) = %v/%d, want %v", v, top, ticks, want)
+		}
+	}
+}
+
+func TestShares(t *testing.T) {
+	var items []Slice
+	for i, v := range []float64{5, 90, 0, 40, 30, 20, 10, 8, 7, 6} {
+		items = append(items, Slice{Label: string(rune('a' + i)), Value: v})
+	}
+	got := Shares(items, "other")
+	if len(got) != len(Palette) || got[0].Label != "b" || got[0].Color != Palette[0] {
+		t.Fatalf("unexpected shares: %+v", got)
+	}
+	if last := got[len(got)-1]; last.Label != "other" || last.Value != 5+7+6 || last.Color != Other {
+		t.Fatalf("small shares must be merged: %+v", last)
+	}
+}
+
+func TestDonut(t *testing.T) {
+	img := decode(t)(Donut(Shares([]Slice{{Label: "a", Value: 3}, {Label: "b", Value: 1}}, "other"), "4", 200))
+	// a takes the right and bottom, b the top left quarter; the hole stays white
+	if !same(img, 170, 100, Palette[0]) || !same(img, 100, 170, Palette[0]) || !same(img, 40, 60, Palette[1]) || !same(img, 100, 70, Color{RGBA: background}) {
+		t.Fatalf("unexpected donut colors: %v %v %v", rgb(img, 170, 100), rgb(img, 40, 60), rgb(img, 100, 70))
+	}
+	if _, err := Donut(nil, "", 200); err != ErrNoData {
+		t.Fatalf("empty donut: %v", err)
+	}
+}
+
+func TestBarsAndLine(t *testing.T) {
+	img := decode(t)(Bars([]BarGroup{{Label: "01", Values: []float64{100, 50}}, {Label: "02", Values: []float64{0, 100}}}, []Color{Income, Expense}, 400, 200))
+	if img.Bounds().Dx() != 400 || img.Bounds().Dy() != 200 {
+		t.Fatalf("unexpected size: %v", img.Bounds())
+	}
+	var income, expense int
+	for x := 0; x < 400; x++ {
+		if same(img, x, 150, Income) {
+			income++
+		}
+		if same(img, x, 150, Expense) {
+			expense++
+		}
+	}
+	if income == 0 || expense <= income {
+		t.Fatalf("expected an income bar and two expense bars, got %d/%d pixels", income, expense)
+	}
+	img = decode(t)(Line([]float64{0, 10, 20}, []string{"01", "02", "03"}, Expense, 400, 200))
+	found := false
+	for y := 0; y < 200 && !found; y++ {
+		found = same(img, 390, y, Expense) || same(img, 380, y, Expense)
+	}
+	if !found {
+		t.Fatal("the line must reach the right edge")
+	}
+	if _, err := Line([]float64{0, 0}, nil, Expense, 400, 200); err != ErrNoData {
+		t.Fatalf("flat zero line: %v", err)
+	}
+}
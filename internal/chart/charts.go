@@ -0,0 +1,178 @@
+package chart
+
+import (
+	"image/color"
+	"math"
+)
+
+// Donut draws the slices as a ring of size x size pixels with center (e.g. the total) in the hole.
+func Donut(slices []Slice, center string, size int) ([]byte, error) {
+	var total float64
+	for _, s := range slices {
+		total += math.Max(s.Value, 0)
+	}
+	if total <= 0 {
+		return nil, ErrNoData
+	}
+	c := newCanvas(size, size)
+	cx, cy := float64(size)/2, float64(size)/2
+	outer := float64(size)/2 - 8
+	inner := outer * 0.55
+	// ends of the slices as fractions of the turn, clockwise from 12 o'clock
+	ends := make([]float64, len(slices))
+	var acc float64
+	for i, s := range slices {
+		acc += math.Max(s.Value, 0)
+		ends[i] = acc / total
+	}
+	const samples = 3 // per axis, to smooth the edges
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var r, g, b, hits float64
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					px := float64(x) + (float64(sx)+0.5)/samples - cx
+					py := float64(y) + (float64(sy)+0.5)/samples - cy
+					d := math.Hypot(px, py)
+					if d < inner || d > outer {
+						continue
+					}
+					turn := math.Atan2(px, -py) / (2 * math.Pi)
+					if turn < 0 {
+						turn++
+					}
+					i := 0
+					for i < len(ends)-1 && turn > ends[i] {
+						i++
+					}
+					col := slices[i].Color.RGBA
+					r, g, b, hits = r+float64(col.R), g+float64(col.G), b+float64(col.B), hits+1
+				}
+			}
+			if hits > 0 {
+				c.blend(x, y, color.RGBA{uint8(r / hits), uint8(g / hits), uint8(b / hits), 0xff}, hits/(samples*samples))
+			}
+		}
+	}
+	// white separators between the slices
+	if len(slices) > 1 {
+		for _, e := range ends {
+			a := e * 2 * math.Pi
+			c.line(cx+inner*math.Sin(a), cy-inner*math.Cos(a), cx+outer*math.Sin(a), cy-outer*math.Cos(a), 2, background)
+		}
+	}
+	scale := max(1, min(6, int(inner*1.4)/max(textWidth(center, 1), 1)))
+	c.text(int(cx)-textWidth(center, scale)/2, int(cy)-5*scale/2, center, scale, ink)
+	return c.png()
+}
+
+// BarGroup is one x-axis position of a bar chart with a bar per series.
+type BarGroup struct {
+	Label  string
+	Values []float64
+}
+
+// plot is the drawing area of the axis charts and its value scale.
+type plot struct {
+	*canvas
+	left, top, right, bottom int
+	max                      float64
+}
+
+// newPlot draws the horizontal grid with value labels for values in [0, maxValue].
+func newPlot(width, height int, maxValue float64) *plot {
+	p := &plot{canvas: newCanvas(width, height), top: 16, right: width - 16, bottom: height - 34}
+	var ticks int
+	p.max, ticks = niceAxis(maxValue)
+	p.left = 16 + textWidth(Compact(p.max), 2) + 8
+	for i := 0; i <= ticks; i++ {
+		v := p.max * float64(i) / float64(ticks)
+		y := p.y(v)
+		p.rect(p.left, int(y), p.right, int(y)+1, grid)
+		label := Compact(v)
+		p.text(p.left-8-textWidth(label, 2), int(y)-5, label, 2, ink)
+	}
+	return p
+}
+
+// y maps a value to the pixel row.
+func (p *plot) y(v float64) float64 {
+	return float64(p.bottom) - v/p.max*float64(p.bottom-p.top)
+}
+
+// xLabel centers a label under x.
+func (p *plot) xLabel(x int, label string) {
+	p.text(x-textWidth(label, 2)/2, p.bottom+10, label, 2, ink)
+}
+
+// Bars draws grouped bars, one color per series, e.g. income and expense by month.
+func Bars(groups []BarGroup, colors []Color, width, height int) ([]byte, error) {
+	var top float64
+	for _, g := range groups {
+		for _, v := range g.Values {
+			top = math.Max(top, v)
+		}
+	}
+	if len(groups) == 0 || top <= 0 {
+		return nil, ErrNoData
+	}
+	p := newPlot(width, height, top)
+	slot := float64(p.right-p.left) / float64(len(groups))
+	barWidth := slot * 0.8 / float64(max(len(colors), 1))
+	for i, g := range groups {
+		x0 := float64(p.left) + slot*float64(i) + slot*0.1
+		for j, v := range g.Values {
+			if j >= len(colors) || v <= 0 {
+				continue
+			}
+			bx := x0 + barWidth*float64(j)
+			p.rect(int(bx)+1, int(p.y(v)), int(bx+barWidth), p.bottom, colors[j].RGBA)
+		}
+		p.xLabel(int(float64(p.left)+slot*(float64(i)+0.5)), g.Label)
+	}
+	return p.png()
+}
+
+// Line draws the values as a line with a tinted area below it; labels are shown under the points at most
+// every few points so that they do not overlap. Empty labels are skipped.
+func Line(values []float64, labels []string, col Color, width, height int) ([]byte, error) {
+	var top float64
+	for _, v := range values {
+		top = math.Max(top, v)
+	}
+	if len(values) == 0 || top <= 0 {
+		return nil, ErrNoData
+	}
+	p := newPlot(width, height, top)
+	step := float64(p.right-p.left) / float64(max(len(values)-1, 1))
+	x := func(i int) float64 { return float64(p.left) + step*float64(i) }
+	if len(values) == 1 {
+		x = func(int) float64 { return float64(p.left+p.right) / 2 }
+	}
+	for i := 0; i+1 < len(values); i++ {
+		for px := int(x(i)); px < int(x(i+1)); px++ {
+			t := (float64(px) - x(i)) / step
+			v := values[i] + (values[i+1]-values[i])*t
+			for py := int(p.y(v)); py < p.bottom; py++ {
+				p.blend(px, py, col.RGBA, 0.18)
+			}
+		}
+	}
+	for i := 0; i+1 < len(values); i++ {
+		p.line(x(i), p.y(values[i]), x(i+1), p.y(values[i+1]), 3, col.RGBA)
+	}
+	if len(values) == 1 {
+		p.line(x(0)-2, p.y(values[0]), x(0)+2, p.y(values[0]), 6, col.RGBA)
+	}
+	labelWidth := 0
+	for _, l := range labels {
+		labelWidth = max(labelWidth, textWidth(l, 2))
+	}
+	every := max(1, int(math.Ceil(float64(labelWidth+12)/step)))
+	for i, l := range labels {
+		if i < len(values) && l != "" && (i%every == 0 || i == len(values)-1 && i%every > every/2) {
+			p.xLabel(int(x(i)), l)
+		}
+	}
+	return p.png()
+}
@@ -0,0 +1,50 @@
+package chart
+
+import "image/color"
+
+// glyphs is a 3x5 bitmap font for the characters of axis labels; other characters are drawn as spaces.
+var glyphs = map[rune][5]string{
+	'0': {"111", "101", "101", "101", "111"},
+	'1': {"010", "110", "010", "010", "111"},
+	'2': {"111", "001", "111", "100", "111"},
+	'3': {"111", "001", "111", "001", "111"},
+	'4': {"101", "101", "111", "001", "001"},
+	'5': {"111", "100", "111", "001", "111"},
+	'6': {"111", "100", "111", "101", "111"},
+	'7': {"111", "001", "010", "010", "010"},
+	'8': {"111", "101", "111", "101", "111"},
+	'9': {"111", "101", "111", "001", "111"},
+	'.': {"000", "000", "000", "000", "010"},
+	',': {"000", "000", "000", "010", "100"},
+	':': {"000", "010", "000", "010", "000"},
+	'-': {"000", "000", "111", "000", "000"},
+	'/': {"001", "001", "010", "100", "100"},
+	'%': {"101", "001", "010", "100", "101"},
+	'k': {"100", "101", "110", "101", "101"},
+	'M': {"101", "111", "111", "101", "101"},
+}
+
+// textWidth is the width in pixels of s drawn at the given scale.
+func textWidth(s string, scale int) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return (4*n - 1) * scale
+}
+
+// text draws s with its top left corner at (x, y); every font pixel is a scale x scale square.
+func (c *canvas) text(x, y int, s string, scale int, col color.RGBA) {
+	for _, r := range s {
+		if g, ok := glyphs[r]; ok {
+			for row, bits := range g {
+				for i, b := range bits {
+					if b == '1' {
+						c.rect(x+i*scale, y+row*scale, x+(i+1)*scale, y+(row+1)*scale, col)
+					}
+				}
+			}
+		}
+		x += 4 * scale
+	}
+}
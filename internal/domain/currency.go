@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Currency describes an ISO 4217 currency.
+type Currency struct {
+	Code string
+	// Exponent is the number of minor-unit digits: 2 for USD, 0 for JPY, 3 for KWD.
+	Exponent int
+	// Symbol is the common sign used in messages, empty if the currency has none.
+	Symbol string
+	// Aliases are lower-case words recognized in messages ("руб", "доллар", "лари").
+	Aliases []string
+}
+
+// isoCodes lists active ISO 4217 currency codes with minor units (funds, metals and test codes excluded).
+const isoCodes = `AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BRL BSD BTN BWP
+BYN BZD CAD CDF CHF CLF CLP CNY COP CRC CUP CVE CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP
+GMD GNF GTQ GYD HKD HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT LAK
+LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MYR MZN NAD NGN NIO NOK NPR NZD OMR PAB
+PEN PGK PHP PKR PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB
+TJS TMT TND TOP TRY TTD TWD TZS UAH UGX USD UYU UYW UZS VES VND VUV WST XAF XCD XCG XOF XPF YER ZAR ZMW ZWG`
+
+// nonDecimalExponents overrides the default exponent of 2.
+var nonDecimalExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0, "RWF": 0,
+	"UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+var currencySymbols = map[string]string{
+	"RUB": "₽", "USD": "$", "EUR": "€", "GBP": "£", "JPY": "¥", "GEL": "₾", "TRY": "₺", "KZT": "₸",
+	"AMD": "֏", "THB": "฿", "UAH": "₴", "INR": "₹", "KRW": "₩", "VND": "₫", "ILS": "₪", "AZN": "₼",
+	"PHP": "₱", "NGN": "₦", "CRC": "₡", "PYG": "₲", "LAK": "₭", "MNT": "₮", "GHS": "₵",
+}
+
+var currencyAliases = map[string][]string{
+	"RUB": {"р", "руб", "рубль", "рубля", "рублей", "ruble", "rubles", "rouble", "roubles"},
+	"USD": {"долл", "доллар", "доллара", "долларов", "бакс", "бакса", "баксов", "dollar", "dollars", "bucks"},
+	"EUR": {"евро", "euro", "euros"},
+	"GBP": {"фунт", "фунта", "фунтов", "pound", "pounds"},
+	"JPY": {"иена", "иены", "иен", "йена", "йены", "йен", "yen"},
+	"CNY": {"юань", "юаня", "юаней", "yuan", "rmb"},
+	"GEL": {"лари", "lari"},
+	"TRY": {"лира", "лиры", "лир", "lira", "liras"},
+	"KZT": {"тенге", "tenge"},
+	"AMD": {"драм", "драма", "драмов", "dram", "drams"},
+	"THB": {"бат", "бата", "батов", "baht"},
+	"UAH": {"грн", "гривна", "гривны", "гривен", "hryvnia"},
+	"AED": {"дирхам", "дирхама", "дирхамов", "dirham", "dirhams"},
+	"INR": {"рупия", "рупии", "рупий", "rupee", "rupees"},
+	"VND": {"донг", "донга", "донгов", "dong"},
+	"PLN": {"злотый", "злотых", "zł", "zloty"},
+	"UZS": {"сум", "сума", "сумов"},
+	"KGS": {"сом", "сома", "сомов"},
+	"TJS": {"сомони"},
+	"AZN": {"манат", "маната", "манатов", "manat"},
+	"ILS": {"шекель", "шекеля", "шекелей", "shekel", "shekels"},
+	"MNT": {"тугрик", "тугрика", "тугриков"},
+	"RSD": {"динар", "динара", "динаров"},
+	"CZK": {"kč"},
+}
+
+var currencies = func() map[string]*Currency {
+	m := make(map[string]*Currency)
+	for _, code := range strings.Fields(isoCodes) {
+		exp, ok := nonDecimalExponents[code]
+		if !ok {
+			exp = 2
+		}
+		m[code] = &Currency{Code: code, Exponent: exp, Symbol: currencySymbols[code], Aliases: currencyAliases[code]}
+	}
+	return m
+}()
+
+// LookupCurrency returns the registry entry for an ISO 4217 code (case-insensitive).
+func LookupCurrency(code string) (*Currency, bool) {
+	c, ok := currencies[strings.ToUpper(code)]
+	return c, ok
+}
+
+// Currencies returns all known currency codes.
+func Currencies() []string {
+	return strings.Fields(isoCodes)
+}
+
+// CurrencyExponent returns the number of minor-unit digits of a currency, 2 if it is unknown.
+func CurrencyExponent(code string) int {
+	if c, ok := LookupCurrency(code); ok {
+		return c.Exponent
+	}
+	return 2
+}
+
+// FormatAmount renders minor units as a decimal number with the currency's precision, e.g. "1234.50" or "1500" for JPY.
+func FormatAmount(minor int64, code string) string {
+	exp := CurrencyExponent(code)
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	s := strconv.FormatInt(minor, 10)
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+// RescaleMinor converts minor units between precisions, e.g. 150000 hundredths to 1500 JPY. Extra digits are truncated.
+func RescaleMinor(minor int64, fromExp, toExp int) int64 {
+	for ; fromExp < toExp; fromExp++ {
+		minor *= 10
+	}
+	for ; fromExp > toExp; fromExp-- {
+		minor /= 10
+	}
+	return minor
+}
@@ -0,0 +1,58 @@
+package domain
+
+import "testing"
+
+func TestLookupCurrency(t *testing.T) {
+	cases := []struct {
+		code     string
+		exponent int
+		symbol   string
+	}{
+		{"RUB", 2, "₽"},
+		{"usd", 2, "$"},
+		{"JPY", 0, "¥"},
+		{"GEL", 2, "₾"},
+		{"KWD", 3, ""},
+		{"CLF", 4, ""},
+	}
+	for _, c := range cases {
+		got, ok := LookupCurrency(c.code)
+		if !ok || got.Exponent != c.exponent || got.Symbol != c.symbol {
+			t.Errorf("LookupCurrency(%q) = %+v, %v", c.code, got, ok)
+		}
+	}
+	for _, code := range []string{"", "XXX", "XAU", "RU", "RUBL"} {
+		if _, ok := LookupCurrency(code); ok {
+			t.Errorf("%q must not be a currency", code)
+		}
+	}
+	if len(Currencies()) < 150 {
+		t.Fatalf("registry looks incomplete: %d codes", len(Currencies()))
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	cases := []struct {
+		minor int64
+		code  string
+		want  string
+	}{
+		{123456, "RUB", "1234.56"},
+		{5, "USD", "0.05"},
+		{-150, "EUR", "-1.50"},
+		{1500, "JPY", "1500"},
+		{1234, "KWD", "1.234"},
+		{100, "???", "1.00"},
+	}
+	for _, c := range cases {
+		if got := FormatAmount(c.minor, c.code); got != c.want {
+			t.Errorf("FormatAmount(%d, %s) = %q, want %q", c.minor, c.code, got, c.want)
+		}
+	}
+	if got := RescaleMinor(150050, 2, 0); got != 1500 {
+		t.Errorf("to JPY: %d", got)
+	}
+	if got := RescaleMinor(150, 2, 3); got != 1500 {
+		t.Errorf("to KWD: %d", got)
+	}
+}
@@ -0,0 +1,100 @@
+// Package exporter renders transactions into files for spreadsheets, desktop finance apps and plain-text journals.
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"budget-bot/internal/domain"
+)
+
+// Row is one exported transaction. Amounts are absolute; Type tells the direction.
+type Row struct {
+	ID          string
+	Date        time.Time
+	Type        domain.TransactionType
+	AmountMinor int64
+	Currency    string
+	CategoryID  string
+	Category    string
+	Comment     string
+}
+
+// SignedMinor returns the amount negative for expenses and positive for income.
+func (r Row) SignedMinor() int64 {
+	if r.Type == domain.TransactionIncome {
+		return r.AmountMinor
+	}
+	return -r.AmountMinor
+}
+
+// Data is an export: the rows of a period in the user's location, oldest first.
+type Data struct {
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+	Rows     []Row
+}
+
+// date formats the row date in the export location.
+func (d *Data) date(r Row, layout string) string {
+	if d.Location == nil {
+		return r.Date.Format(layout)
+	}
+	return r.Date.In(d.Location).Format(layout)
+}
+
+// currencies returns the currencies present in the export, sorted.
+func (d *Data) currencies() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, r := range d.Rows {
+		if !seen[r.Currency] {
+			seen[r.Currency] = true
+			out = append(out, r.Currency)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Exporter writes an export in one file format.
+type Exporter interface {
+	// Format is the name used in the format= argument, e.g. "csv".
+	Format() string
+	// Extension is the file name extension without the dot.
+	Extension() string
+	Write(w io.Writer, data *Data) error
+}
+
+var registry = map[string]Exporter{}
+
+// Register makes an exporter available by its format name; formats are registered by the files of this package.
+func Register(e Exporter) {
+	registry[strings.ToLower(e.Format())] = e
+}
+
+// Lookup returns the exporter of a format, case-insensitively; "ledger" is an alias of hledger.
+func Lookup(format string) (Exporter, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "ledger" {
+		format = "hledger"
+	}
+	if e, ok := registry[format]; ok {
+		return e, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q, supported: %s", format, strings.Join(Formats(), ", "))
+}
+
+// Formats lists the registered format names, sorted.
+func Formats() []string {
+	out := make([]string, 0, len(registry))
+	for f := range registry {
+		out = append(out, f)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,130 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"budget-bot/internal/domain"
+)
+
+func sampleData() *Data {
+	msk := time.FixedZone("MSK", 3*3600)
+	day := func(d int) time.Time { return time.Date(2025, 2, d, 21, 30, 0, 0, time.UTC) }
+	return &Data{
+		From:     time.Date(2025, 2, 1, 0, 0, 0, 0, msk),
+		To:       time.Date(2025, 2, 28, 0, 0, 0, 0, msk),
+		Location: msk,
+		Rows: []Row{
+			{ID: "t1", Date: day(1), Type: domain.TransactionExpense, AmountMinor: 60050, Currency: "RUB", CategoryID: "cat-food", Category: "Кафе: бары", Comment: `кофе, "большой"`},
+			{ID: "t2", Date: day(5), Type: domain.TransactionIncome, AmountMinor: 10000000, Currency: "RUB", CategoryID: "cat-salary", Category: "Зарплата", Comment: "аванс"},
+			{ID: "t3", Date: day(10), Type: domain.TransactionExpense, AmountMinor: 1250, Currency: "USD", CategoryID: "cat-food", Category: "Кафе: бары", Comment: "lunch; team"},
+		},
+	}
+}
+
+func render(t *testing.T, format string) string {
+	t.Helper()
+	e, err := Lookup(format)
+	if err != nil {
+		t.Fatalf("lookup %s: %v", format, err)
+	}
+	var b bytes.Buffer
+	if err := e.Write(&b, sampleData()); err != nil {
+		t.Fatalf("write %s: %v", format, err)
+	}
+	return b.String()
+}
+
+func TestLookup(t *testing.T) {
+	want := "beancount,csv,hledger,json,ofx,qif,xlsx"
+	if got := strings.Join(Formats(), ","); got != want {
+		t.Fatalf("formats: %s", got)
+	}
+	if e, err := Lookup("Ledger"); err != nil || e.Format() != "hledger" {
+		t.Fatalf("ledger alias: %v %v", e, err)
+	}
+	if _, err := Lookup("pdf"); err == nil || !strings.Contains(err.Error(), "xlsx") {
+		t.Fatalf("unknown format must list supported ones: %v", err)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	records, err := csv.NewReader(strings.NewReader(render(t, "csv"))).ReadAll()
+	if err != nil {
+		t.Fatalf("csv must be valid: %v", err)
+	}
+	if len(records) != 4 || strings.Join(records[1], "|") != `2025-02-02|expense|-600.50|RUB|Кафе: бары|кофе, "большой"` || records[2][2] != "100000.00" {
+		t.Fatalf("unexpected csv: %q", records)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var doc struct {
+		From         string
+		Transactions []jsonTransaction
+	}
+	if err := json.Unmarshal([]byte(render(t, "json")), &doc); err != nil {
+		t.Fatalf("json must be valid: %v", err)
+	}
+	if doc.From != "2025-02-01" || len(doc.Transactions) != 3 || doc.Transactions[2].Amount != "12.50" || doc.Transactions[0].Category != "Кафе: бары" {
+		t.Fatalf("unexpected json: %+v", doc)
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	out := render(t, "xlsx")
+	zr, err := zip.NewReader(strings.NewReader(out), int64(len(out)))
+	if err != nil {
+		t.Fatalf("xlsx must be a zip: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		b, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		parts[f.Name] = string(b)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if parts[name] == "" {
+			t.Fatalf("missing part %s", name)
+		}
+	}
+	if s := parts["xl/worksheets/sheet1.xml"]; !strings.Contains(s, `<c r="C2"><v>-600.50</v></c>`) || !strings.Contains(s, "кофе, &#34;большой&#34;") {
+		t.Fatalf("unexpected transactions sheet: %s", s)
+	}
+	if s := parts["xl/worksheets/sheet2.xml"]; !strings.Contains(s, "<t xml:space=\"preserve\">RUB</t></is></c><c r=\"B4\"><v>100000.00</v></c><c r=\"C4\"><v>600.50</v></c><c r=\"D4\"><v>99399.50</v>") {
+		t.Fatalf("unexpected summary sheet: %s", s)
+	}
+}
+
+func TestOFXAndQIF(t *testing.T) {
+	ofx := render(t, "ofx")
+	if strings.Count(ofx, "<STMTRS>") != 2 || !strings.Contains(ofx, "<TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20250202</DTPOSTED><TRNAMT>-600.50</TRNAMT><FITID>t1</FITID>") ||
+		!strings.Contains(ofx, "<BALAMT>99399.50</BALAMT>") || !strings.Contains(ofx, "<MEMO>кофе, &#34;большой&#34;</MEMO>") {
+		t.Fatalf("unexpected ofx: %s", ofx)
+	}
+	qif := render(t, "qif")
+	if !strings.Contains(qif, "!Account\nNBudget USD\nTBank\n^\n!Type:Bank\nD02/11/2025\nT-12.50\nPlunch; team\nLКафе- бары\n^\n") {
+		t.Fatalf("unexpected qif: %s", qif)
+	}
+}
+
+func TestJournals(t *testing.T) {
+	hledger := render(t, "hledger")
+	if !strings.Contains(hledger, "\n2025-02-02 кофе, \"большой\"\n    expenses:Кафе- бары  600.50 RUB\n    assets:budget\n") ||
+		!strings.Contains(hledger, "\n2025-02-06 аванс\n    assets:budget  100000.00 RUB\n    income:Зарплата\n") ||
+		!strings.Contains(hledger, "2025-02-11 lunch, team\n") {
+		t.Fatalf("unexpected hledger journal: %s", hledger)
+	}
+	beancount := render(t, "beancount")
+	if !strings.Contains(beancount, "2025-02-01 open Expenses:Кафе--бары\n") || !strings.Contains(beancount, "2025-02-01 open Income:Зарплата\n") ||
+		!strings.Contains(beancount, "\n2025-02-02 * \"кофе, \\\"большой\\\"\"\n  Expenses:Кафе--бары  600.50 RUB\n  Assets:Budget\n") {
+		t.Fatalf("unexpected beancount ledger: %s", beancount)
+	}
+}
@@ -0,0 +1,112 @@
+package exporter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"budget-bot/internal/domain"
+)
+
+func init() {
+	Register(ofxExporter{})
+	Register(qifExporter{})
+}
+
+// ofxExporter writes an OFX 2.2 bank statement per currency, since a statement has a single currency.
+type ofxExporter struct{}
+
+func (ofxExporter) Format() string    { return "ofx" }
+func (ofxExporter) Extension() string { return "ofx" }
+
+// ofxNameLimit is the maximum length of the OFX NAME element.
+const ofxNameLimit = 32
+
+func xmlText(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func truncateRunes(s string, n int) string {
+	if rs := []rune(s); len(rs) > n {
+		return string(rs[:n])
+	}
+	return s
+}
+
+func (ofxExporter) Write(w io.Writer, data *Data) error {
+	bw := bufio.NewWriter(w)
+	from, to := data.From.Format("20060102"), data.To.Format("20060102")
+	fmt.Fprint(bw, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`+"\n")
+	fmt.Fprint(bw, `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`+"\n")
+	fmt.Fprint(bw, "<OFX>\n<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>")
+	fmt.Fprintf(bw, "<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>\n<BANKMSGSRSV1>\n", to)
+	for i, currency := range data.currencies() {
+		fmt.Fprintf(bw, "<STMTTRNRS><TRNUID>%d</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n", i+1)
+		fmt.Fprintf(bw, "<STMTRS><CURDEF>%s</CURDEF><BANKACCTFROM><BANKID>budget-bot</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n", currency, currency)
+		fmt.Fprintf(bw, "<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", from, to)
+		var balance int64
+		for j, r := range data.Rows {
+			if r.Currency != currency {
+				continue
+			}
+			balance += r.SignedMinor()
+			kind := "DEBIT"
+			if r.Type == domain.TransactionIncome {
+				kind = "CREDIT"
+			}
+			id := r.ID
+			if id == "" {
+				id = strconv.Itoa(j + 1)
+			}
+			name := r.Category
+			if name == "" {
+				name = r.Comment
+			}
+			fmt.Fprintf(bw, "<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%s</FITID><NAME>%s</NAME><MEMO>%s</MEMO></STMTTRN>\n",
+				kind, data.date(r, "20060102"), domain.FormatAmount(r.SignedMinor(), r.Currency), xmlText(id), xmlText(truncateRunes(name, ofxNameLimit)), xmlText(r.Comment))
+		}
+		fmt.Fprint(bw, "</BANKTRANLIST>\n")
+		fmt.Fprintf(bw, "<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n</STMTRS></STMTTRNRS>\n", domain.FormatAmount(balance, currency), to)
+	}
+	fmt.Fprint(bw, "</BANKMSGSRSV1>\n</OFX>\n")
+	return bw.Flush()
+}
+
+// qifExporter writes a QIF bank account per currency with US dates (MM/DD/YYYY), which QIF readers expect by default.
+type qifExporter struct{}
+
+func (qifExporter) Format() string    { return "qif" }
+func (qifExporter) Extension() string { return "qif" }
+
+// qifLine drops line breaks that would end a QIF field early.
+func qifLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+func (qifExporter) Write(w io.Writer, data *Data) error {
+	bw := bufio.NewWriter(w)
+	for _, currency := range data.currencies() {
+		fmt.Fprintf(bw, "!Account\nNBudget %s\nTBank\n^\n!Type:Bank\n", currency)
+		for _, r := range data.Rows {
+			if r.Currency != currency {
+				continue
+			}
+			fmt.Fprintf(bw, "D%s\nT%s\n", data.date(r, "01/02/2006"), domain.FormatAmount(r.SignedMinor(), r.Currency))
+			if r.Comment != "" {
+				fmt.Fprintf(bw, "P%s\n", qifLine(r.Comment))
+			}
+			if r.Category != "" {
+				// ":" separates subcategories and "/" a class in QIF
+				fmt.Fprintf(bw, "L%s\n", strings.NewReplacer(":", "-", "/", "-").Replace(qifLine(r.Category)))
+			}
+			fmt.Fprint(bw, "^\n")
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"budget-bot/internal/domain"
+)
+
+func init() {
+	Register(hledgerExporter{})
+	Register(beancountExporter{})
+}
+
+// uncategorized names the account of transactions without a category.
+const uncategorized = "Uncategorized"
+
+// hledgerExporter writes a plain-text hledger journal: expenses:<category> and income:<category> against assets:budget.
+type hledgerExporter struct{}
+
+func (hledgerExporter) Format() string    { return "hledger" }
+func (hledgerExporter) Extension() string { return "journal" }
+
+// hledgerAccountPart makes a category name safe as one account component: no ":" (subaccounts), no double spaces.
+func hledgerAccountPart(s string) string {
+	s = strings.Join(strings.Fields(strings.ReplaceAll(s, ":", "-")), " ")
+	if s == "" {
+		return uncategorized
+	}
+	return s
+}
+
+func (hledgerExporter) Write(w io.Writer, data *Data) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "; budget-bot export %s..%s\n", data.From.Format("2006-01-02"), data.To.Format("2006-01-02"))
+	for _, r := range data.Rows {
+		// ";" would start a comment in the description
+		desc := strings.ReplaceAll(strings.Join(strings.Fields(r.Comment), " "), ";", ",")
+		amount := domain.FormatAmount(r.AmountMinor, r.Currency) + " " + r.Currency
+		fmt.Fprintf(bw, "\n%s %s\n", data.date(r, "2006-01-02"), desc)
+		if r.Type == domain.TransactionIncome {
+			fmt.Fprintf(bw, "    assets:budget  %s\n    income:%s\n", amount, hledgerAccountPart(r.Category))
+		} else {
+			fmt.Fprintf(bw, "    expenses:%s  %s\n    assets:budget\n", hledgerAccountPart(r.Category), amount)
+		}
+	}
+	return bw.Flush()
+}
+
+// beancountExporter writes a beancount ledger with open directives for every account used.
+type beancountExporter struct{}
+
+func (beancountExporter) Format() string    { return "beancount" }
+func (beancountExporter) Extension() string { return "beancount" }
+
+// beancountAccountPart makes a category name a valid account component: letters, digits and dashes,
+// starting with a capital letter or a digit.
+func beancountAccountPart(s string) string {
+	rs := []rune(strings.TrimSpace(s))
+	for i, r := range rs {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			rs[i] = '-'
+		}
+	}
+	out := strings.Trim(string(rs), "-")
+	if out == "" {
+		return uncategorized
+	}
+	rs = []rune(out)
+	rs[0] = unicode.ToUpper(rs[0])
+	return string(rs)
+}
+
+func beancountString(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (beancountExporter) Write(w io.Writer, data *Data) error {
+	type posting struct{ account, other string }
+	postings := make([]posting, len(data.Rows))
+	accounts := map[string]bool{"Assets:Budget": true}
+	for i, r := range data.Rows {
+		if r.Type == domain.TransactionIncome {
+			postings[i] = posting{"Assets:Budget", "Income:" + beancountAccountPart(r.Category)}
+		} else {
+			postings[i] = posting{"Expenses:" + beancountAccountPart(r.Category), "Assets:Budget"}
+		}
+		accounts[postings[i].account] = true
+		accounts[postings[i].other] = true
+	}
+	names := make([]string, 0, len(accounts))
+	for a := range accounts {
+		names = append(names, a)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "; budget-bot export %s..%s\n\n", data.From.Format("2006-01-02"), data.To.Format("2006-01-02"))
+	opened := data.From.Format("2006-01-02")
+	for _, a := range names {
+		fmt.Fprintf(bw, "%s open %s\n", opened, a)
+	}
+	for i, r := range data.Rows {
+		fmt.Fprintf(bw, "\n%s * %s\n  %s  %s %s\n  %s\n", data.date(r, "2006-01-02"), beancountString(r.Comment),
+			postings[i].account, domain.FormatAmount(r.AmountMinor, r.Currency), r.Currency, postings[i].other)
+	}
+	return bw.Flush()
+}
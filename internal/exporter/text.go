@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	"budget-bot/internal/domain"
+)
+
+func init() {
+	Register(csvExporter{})
+	Register(jsonExporter{})
+}
+
+// csvExporter writes RFC 4180 CSV with a header row; amounts are signed decimals.
+type csvExporter struct{}
+
+func (csvExporter) Format() string    { return "csv" }
+func (csvExporter) Extension() string { return "csv" }
+
+func (csvExporter) Write(w io.Writer, data *Data) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"date", "type", "amount", "currency", "category", "comment"})
+	for _, r := range data.Rows {
+		_ = cw.Write([]string{data.date(r, "2006-01-02"), string(r.Type), domain.FormatAmount(r.SignedMinor(), r.Currency), r.Currency, r.Category, r.Comment})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// jsonExporter writes the period and the transactions as one JSON document.
+type jsonExporter struct{}
+
+func (jsonExporter) Format() string    { return "json" }
+func (jsonExporter) Extension() string { return "json" }
+
+type jsonTransaction struct {
+	ID          string `json:"id,omitempty"`
+	Date        string `json:"date"`
+	Type        string `json:"type"`
+	Amount      string `json:"amount"`
+	AmountMinor int64  `json:"amount_minor"`
+	Currency    string `json:"currency"`
+	CategoryID  string `json:"category_id,omitempty"`
+	Category    string `json:"category,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+func (jsonExporter) Write(w io.Writer, data *Data) error {
+	doc := struct {
+		From         string            `json:"from"`
+		To           string            `json:"to"`
+		Transactions []jsonTransaction `json:"transactions"`
+	}{From: data.From.Format("2006-01-02"), To: data.To.Format("2006-01-02"), Transactions: []jsonTransaction{}}
+	for _, r := range data.Rows {
+		doc.Transactions = append(doc.Transactions, jsonTransaction{
+			ID:          r.ID,
+			Date:        data.date(r, "2006-01-02"),
+			Type:        string(r.Type),
+			Amount:      domain.FormatAmount(r.AmountMinor, r.Currency),
+			AmountMinor: r.AmountMinor,
+			Currency:    r.Currency,
+			CategoryID:  r.CategoryID,
+			Category:    r.Category,
+			Comment:     r.Comment,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
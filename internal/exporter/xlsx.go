@@ -0,0 +1,148 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strconv"
+
+	"budget-bot/internal/domain"
+)
+
+func init() { Register(xlsxExporter{}) }
+
+// xlsxExporter writes an Office Open XML workbook with a Transactions sheet and a Summary sheet
+// of totals per currency and per category. Only the parts Excel, LibreOffice and Google Sheets need are written.
+type xlsxExporter struct{}
+
+func (xlsxExporter) Format() string    { return "xlsx" }
+func (xlsxExporter) Extension() string { return "xlsx" }
+
+// xlsxCell is a string or, when number is set, a numeric cell.
+type xlsxCell struct {
+	text   string
+	number bool
+}
+
+func str(s string) xlsxCell { return xlsxCell{text: s} }
+
+func num(minor int64, currency string) xlsxCell {
+	return xlsxCell{text: domain.FormatAmount(minor, currency), number: true}
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/worksheets/sheet2.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Transactions" sheetId="1" r:id="rId1"/><sheet name="Summary" sheetId="2" r:id="rId2"/></sheets></workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/></Relationships>`
+
+func (xlsxExporter) Write(w io.Writer, data *Data) error {
+	transactions := [][]xlsxCell{{str("date"), str("type"), str("amount"), str("currency"), str("category"), str("comment")}}
+	for _, r := range data.Rows {
+		transactions = append(transactions, []xlsxCell{
+			str(data.date(r, "2006-01-02")), str(string(r.Type)), num(r.SignedMinor(), r.Currency), str(r.Currency), str(r.Category), str(r.Comment),
+		})
+	}
+
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/worksheets/sheet1.xml", xlsxSheet(transactions)},
+		{"xl/worksheets/sheet2.xml", xlsxSheet(summaryRows(data))},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// summaryRows totals income and expenses per currency, then per category and currency.
+func summaryRows(data *Data) [][]xlsxCell {
+	type key struct{ category, currency string }
+	type totals struct{ income, expense int64 }
+	byCurrency := map[string]*totals{}
+	byCategory := map[key]*totals{}
+	for _, r := range data.Rows {
+		k := key{r.Category, r.Currency}
+		if byCurrency[r.Currency] == nil {
+			byCurrency[r.Currency] = &totals{}
+		}
+		if byCategory[k] == nil {
+			byCategory[k] = &totals{}
+		}
+		if r.Type == domain.TransactionIncome {
+			byCurrency[r.Currency].income += r.AmountMinor
+			byCategory[k].income += r.AmountMinor
+		} else {
+			byCurrency[r.Currency].expense += r.AmountMinor
+			byCategory[k].expense += r.AmountMinor
+		}
+	}
+
+	rows := [][]xlsxCell{
+		{str("period"), str(data.From.Format("2006-01-02") + ".." + data.To.Format("2006-01-02"))},
+		{},
+		{str("currency"), str("income"), str("expenses"), str("balance")},
+	}
+	for _, c := range data.currencies() {
+		t := byCurrency[c]
+		rows = append(rows, []xlsxCell{str(c), num(t.income, c), num(t.expense, c), num(t.income-t.expense, c)})
+	}
+	rows = append(rows, []xlsxCell{}, []xlsxCell{str("category"), str("currency"), str("income"), str("expenses")})
+	keys := make([]key, 0, len(byCategory))
+	for k := range byCategory {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].currency != keys[j].currency {
+			return keys[i].currency < keys[j].currency
+		}
+		return byCategory[keys[i]].expense > byCategory[keys[j]].expense
+	})
+	for _, k := range keys {
+		t := byCategory[k]
+		rows = append(rows, []xlsxCell{str(k.category), str(k.currency), num(t.income, k.currency), num(t.expense, k.currency)})
+	}
+	return rows
+}
+
+// xlsxSheet renders a worksheet with inline strings, so no shared string table is needed.
+func xlsxSheet(rows [][]xlsxCell) string {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		n := strconv.Itoa(i + 1)
+		b.WriteString(`<row r="` + n + `">`)
+		for j, c := range row {
+			ref := string(rune('A'+j)) + n
+			if c.number {
+				b.WriteString(`<c r="` + ref + `"><v>` + c.text + `</v></c>`)
+				continue
+			}
+			b.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+			_ = xml.EscapeText(&b, []byte(c.text))
+			b.WriteString(`</t></is></c>`)
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
@@ -4,6 +4,7 @@ package grpc
 import (
 	"context"
 	"strconv"
+	"sync"
 	"time"
 
 	pb "budget-bot/internal/pb/budget/v1"
@@ -11,23 +12,93 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// FxRate is an exchange rate together with the date it applies to and its source.
+type FxRate struct {
+	FromCurrency string
+	ToCurrency   string
+	Rate         float64
+	AsOf         time.Time
+	// Provider names the rate source, e.g. "cbr", "ecb" or "manual".
+	Provider string
+}
+
 // FxClient provides access to foreign exchange rates.
 type FxClient interface {
 	// GetRate returns a decimal exchange rate from -> to at a given date.
 	// If accessToken is non-empty, it will be attached as authorization metadata.
 	GetRate(ctx context.Context, fromCurrency, toCurrency string, asOf time.Time, accessToken string) (float64, error)
+	// GetRateInfo is like GetRate but also reports the effective date and the provider of the rate.
+	GetRateInfo(ctx context.Context, fromCurrency, toCurrency string, asOf time.Time, accessToken string) (*FxRate, error)
+	// UpsertRate stores a manual rate for its date, replacing the provider's one, and returns the stored rate.
+	UpsertRate(ctx context.Context, rate *FxRate, accessToken string) (*FxRate, error)
+	// BatchGetRates returns rates of several currencies to toCurrency at a given date.
+	BatchGetRates(ctx context.Context, fromCurrencies []string, toCurrency string, asOf time.Time, accessToken string) ([]*FxRate, error)
 }
 
-// FakeFxClient is a stubbed client returning a 1.0 rate.
-type FakeFxClient struct{}
+// ManualRateProvider is the provider name of rates entered by users.
+const ManualRateProvider = "manual"
+
+// FakeFxClient is a stubbed client returning a 1.0 rate unless a rate was upserted for that date.
+type FakeFxClient struct {
+	mu    sync.Mutex
+	rates map[string]*FxRate // key: from|to|YYYY-MM-DD
+}
+
+func fakeFxKey(from, to string, asOf time.Time) string {
+	return from + "|" + to + "|" + asOf.Format("2006-01-02")
+}
 
 // GetRate returns a decimal exchange rate; stubbed to 1.0 for now.
-func (f *FakeFxClient) GetRate(_ context.Context, fromCurrency, toCurrency string, _ time.Time, _ string) (float64, error) {
+func (f *FakeFxClient) GetRate(ctx context.Context, fromCurrency, toCurrency string, asOf time.Time, accessToken string) (float64, error) {
 	if fromCurrency == toCurrency {
 		return 1.0, nil
 	}
+	r, err := f.GetRateInfo(ctx, fromCurrency, toCurrency, asOf, accessToken)
+	if err != nil {
+		return 0, err
+	}
+	return r.Rate, nil
+}
+
+// GetRateInfo returns an upserted rate or the stubbed 1.0 rate attributed to the "fake" provider.
+func (f *FakeFxClient) GetRateInfo(_ context.Context, fromCurrency, toCurrency string, asOf time.Time, _ string) (*FxRate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r, ok := f.rates[fakeFxKey(fromCurrency, toCurrency, asOf)]; ok {
+		out := *r
+		return &out, nil
+	}
 	// Default placeholder rate
-	return 1.0, nil
+	return &FxRate{FromCurrency: fromCurrency, ToCurrency: toCurrency, Rate: 1.0, AsOf: asOf, Provider: "fake"}, nil
+}
+
+// UpsertRate stores the rate in memory.
+func (f *FakeFxClient) UpsertRate(_ context.Context, rate *FxRate, _ string) (*FxRate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.rates == nil {
+		f.rates = map[string]*FxRate{}
+	}
+	stored := *rate
+	if stored.Provider == "" {
+		stored.Provider = ManualRateProvider
+	}
+	f.rates[fakeFxKey(rate.FromCurrency, rate.ToCurrency, rate.AsOf)] = &stored
+	out := stored
+	return &out, nil
+}
+
+// BatchGetRates returns GetRateInfo for every currency.
+func (f *FakeFxClient) BatchGetRates(ctx context.Context, fromCurrencies []string, toCurrency string, asOf time.Time, accessToken string) ([]*FxRate, error) {
+	out := make([]*FxRate, 0, len(fromCurrencies))
+	for _, from := range fromCurrencies {
+		r, err := f.GetRateInfo(ctx, from, toCurrency, asOf, accessToken)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
 }
 
 // FxGRPCClient calls Fx service via gRPC.
@@ -38,6 +109,15 @@ func NewGRPCFxClient(c pb.FxServiceClient) *FxGRPCClient { return &FxGRPCClient{
 
 // GetRate returns a decimal exchange rate for given currencies/date.
 func (g *FxGRPCClient) GetRate(ctx context.Context, fromCurrency, toCurrency string, asOf time.Time, accessToken string) (float64, error) {
+	r, err := g.GetRateInfo(ctx, fromCurrency, toCurrency, asOf, accessToken)
+	if err != nil {
+		return 0, err
+	}
+	return r.Rate, nil
+}
+
+// GetRateInfo returns the exchange rate for given currencies/date with its effective date and provider.
+func (g *FxGRPCClient) GetRateInfo(ctx context.Context, fromCurrency, toCurrency string, asOf time.Time, accessToken string) (*FxRate, error) {
 	if accessToken != "" {
 		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
 	}
@@ -47,17 +127,76 @@ func (g *FxGRPCClient) GetRate(ctx context.Context, fromCurrency, toCurrency str
 		AsOf:             timestamppb.New(asOf),
 	})
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	rateStr := "1"
-	if res.GetRate() != nil && res.GetRate().GetRateDecimal() != "" {
-		rateStr = res.GetRate().GetRateDecimal()
+	return fxRateFromPB(res.GetRate(), fromCurrency, toCurrency, asOf)
+}
+
+// UpsertRate stores a manual rate via FxService.UpsertRate.
+func (g *FxGRPCClient) UpsertRate(ctx context.Context, rate *FxRate, accessToken string) (*FxRate, error) {
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
 	}
-	rate, err := strconv.ParseFloat(rateStr, 64)
+	res, err := g.client.UpsertRate(ctx, &pb.UpsertRateRequest{Rate: &pb.FxRate{
+		FromCurrencyCode: rate.FromCurrency,
+		ToCurrencyCode:   rate.ToCurrency,
+		RateDecimal:      strconv.FormatFloat(rate.Rate, 'f', -1, 64),
+		AsOf:             timestamppb.New(rate.AsOf),
+		Provider:         rate.Provider,
+	}})
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	return rate, nil
+	if res.GetRate() == nil {
+		out := *rate
+		return &out, nil
+	}
+	return fxRateFromPB(res.GetRate(), rate.FromCurrency, rate.ToCurrency, rate.AsOf)
 }
 
+// BatchGetRates returns rates of several currencies to toCurrency via FxService.BatchGetRates.
+func (g *FxGRPCClient) BatchGetRates(ctx context.Context, fromCurrencies []string, toCurrency string, asOf time.Time, accessToken string) ([]*FxRate, error) {
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+	}
+	res, err := g.client.BatchGetRates(ctx, &pb.BatchGetRatesRequest{
+		FromCurrencyCodes: fromCurrencies,
+		ToCurrencyCode:    toCurrency,
+		AsOf:              timestamppb.New(asOf),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*FxRate, 0, len(res.GetRates()))
+	for _, r := range res.GetRates() {
+		rate, err := fxRateFromPB(r, r.GetFromCurrencyCode(), toCurrency, asOf)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rate)
+	}
+	return out, nil
+}
 
+// fxRateFromPB converts a backend rate, defaulting to 1 when the rate is missing.
+func fxRateFromPB(r *pb.FxRate, fromCurrency, toCurrency string, asOf time.Time) (*FxRate, error) {
+	rateStr := "1"
+	if r.GetRateDecimal() != "" {
+		rateStr = r.GetRateDecimal()
+	}
+	rate, err := strconv.ParseFloat(rateStr, 64)
+	if err != nil {
+		return nil, err
+	}
+	out := &FxRate{FromCurrency: fromCurrency, ToCurrency: toCurrency, Rate: rate, AsOf: asOf, Provider: r.GetProvider()}
+	if r.GetFromCurrencyCode() != "" {
+		out.FromCurrency = r.GetFromCurrencyCode()
+	}
+	if r.GetToCurrencyCode() != "" {
+		out.ToCurrency = r.GetToCurrencyCode()
+	}
+	if r.GetAsOf() != nil {
+		out.AsOf = r.GetAsOf().AsTime()
+	}
+	return out, nil
+}
@@ -13,3 +13,13 @@ func TestFakeFxClient_GetRate(t *testing.T) {
 	r2, err := fx.GetRate(context.Background(), "RUB", "USD", time.Now(), "")
 	if err != nil || r2 != 1.0 { t.Fatalf("diff cur placeholder: %v %v", r2, err) }
 }
+
+func TestFakeFxClient_UpsertRate(t *testing.T) {
+	fx := &FakeFxClient{}
+	day := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+	if _, err := fx.UpsertRate(context.Background(), &FxRate{FromCurrency: "USD", ToCurrency: "RUB", Rate: 92.5, AsOf: day}, ""); err != nil { t.Fatal(err) }
+	r, err := fx.GetRateInfo(context.Background(), "USD", "RUB", day, "")
+	if err != nil || r.Rate != 92.5 || r.Provider != ManualRateProvider { t.Fatalf("upserted rate: %+v %v", r, err) }
+	rates, err := fx.BatchGetRates(context.Background(), []string{"USD", "EUR"}, "RUB", day, "")
+	if err != nil || len(rates) != 2 || rates[0].Rate != 92.5 || rates[1].Rate != 1.0 { t.Fatalf("batch: %+v %v", rates, err) }
+}
@@ -19,7 +19,19 @@ func (s *fakeFxServer) GetRate(ctx context.Context, _ *pb.GetRateRequest) (*pb.G
         vals := md.Get("authorization")
         if len(vals) > 0 { s.sawAuth = vals[0] }
     }
-    return &pb.GetRateResponse{Rate: &pb.FxRate{RateDecimal: "2.50"}}, nil
+    return &pb.GetRateResponse{Rate: &pb.FxRate{FromCurrencyCode: "USD", ToCurrencyCode: "RUB", RateDecimal: "2.50", Provider: "cbr"}}, nil
+}
+
+func (s *fakeFxServer) UpsertRate(_ context.Context, req *pb.UpsertRateRequest) (*pb.UpsertRateResponse, error) {
+    return &pb.UpsertRateResponse{Rate: req.GetRate()}, nil
+}
+
+func (s *fakeFxServer) BatchGetRates(_ context.Context, req *pb.BatchGetRatesRequest) (*pb.BatchGetRatesResponse, error) {
+    res := &pb.BatchGetRatesResponse{}
+    for _, c := range req.GetFromCurrencyCodes() {
+        res.Rates = append(res.Rates, &pb.FxRate{FromCurrencyCode: c, ToCurrencyCode: req.GetToCurrencyCode(), RateDecimal: "3", Provider: "ecb"})
+    }
+    return res, nil
 }
 
 func startFxServer(t *testing.T) (*grpc.Server, string, *fakeFxServer) {
@@ -47,3 +59,33 @@ func TestFxGRPCClient_GetRate(t *testing.T) {
 }
 
 
+
+func TestFxGRPCClient_GetRateInfo(t *testing.T) {
+    srv, addr, _ := startFxServer(t)
+    defer srv.Stop()
+    conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil { t.Fatal(err) }
+    defer func(){ _ = conn.Close() }()
+    c := NewGRPCFxClient(pb.NewFxServiceClient(conn))
+    asOf := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+    r, err := c.GetRateInfo(context.Background(), "USD", "RUB", asOf, "")
+    if err != nil { t.Fatalf("get rate info: %v", err) }
+    if r.Rate != 2.5 || r.Provider != "cbr" || r.FromCurrency != "USD" || r.ToCurrency != "RUB" { t.Fatalf("unexpected rate: %+v", r) }
+    if !r.AsOf.Equal(asOf) { t.Fatalf("as-of must default to the requested date: %v", r.AsOf) }
+}
+
+func TestFxGRPCClient_UpsertAndBatch(t *testing.T) {
+    srv, addr, _ := startFxServer(t)
+    defer srv.Stop()
+    conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil { t.Fatal(err) }
+    defer func(){ _ = conn.Close() }()
+    c := NewGRPCFxClient(pb.NewFxServiceClient(conn))
+    asOf := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+    stored, err := c.UpsertRate(context.Background(), &FxRate{FromCurrency: "USD", ToCurrency: "RUB", Rate: 92.5, AsOf: asOf, Provider: ManualRateProvider}, "tok")
+    if err != nil { t.Fatalf("upsert: %v", err) }
+    if stored.Rate != 92.5 || stored.Provider != ManualRateProvider || !stored.AsOf.Equal(asOf) { t.Fatalf("unexpected stored rate: %+v", stored) }
+    rates, err := c.BatchGetRates(context.Background(), []string{"USD", "EUR"}, "RUB", asOf, "tok")
+    if err != nil || len(rates) != 2 { t.Fatalf("batch: %v %d", err, len(rates)) }
+    if rates[1].FromCurrency != "EUR" || rates[1].Rate != 3 || rates[1].Provider != "ecb" { t.Fatalf("unexpected rate: %+v", rates[1]) }
+}
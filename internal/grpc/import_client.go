@@ -0,0 +1,223 @@
+// Package grpc contains gRPC client facades used by the bot.
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	pb "budget-bot/internal/pb/budget/v1"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+)
+
+// CsvColumnMapping maps CSV header names to transaction fields. Empty values mean "not used".
+type CsvColumnMapping struct {
+	Date     string
+	Amount   string
+	Currency string
+	Type     string
+	Category string
+	Comment  string
+}
+
+// ImportPreview summarizes rows of an uploaded CSV file.
+type ImportPreview struct {
+	TotalRows   int
+	ValidRows   int
+	InvalidRows int
+}
+
+// ImportResult is the outcome of committing an import.
+type ImportResult struct {
+	Inserted int
+	Failed   int
+}
+
+// ImportClient exposes CSV import operations.
+type ImportClient interface {
+	StartCsvImport(ctx context.Context, filename, delimiter, encoding, accessToken string) (string, error)
+	UploadCsvChunk(ctx context.Context, importID string, chunk []byte, last bool, accessToken string) (int64, error)
+	ConfigureCsvMapping(ctx context.Context, importID string, mapping CsvColumnMapping, accessToken string) error
+	PreviewCsvImport(ctx context.Context, importID string, limit int, accessToken string) (*ImportPreview, error)
+	CommitCsvImport(ctx context.Context, importID string, dryRun bool, accessToken string) (*ImportResult, error)
+}
+
+// FakeImportClient keeps uploads in memory and counts non-empty data rows.
+type FakeImportClient struct {
+	mu      sync.Mutex
+	uploads map[string]*bytes.Buffer
+}
+
+// StartCsvImport registers a new in-memory upload.
+func (f *FakeImportClient) StartCsvImport(_ context.Context, _, _, _, _ string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.uploads == nil {
+		f.uploads = map[string]*bytes.Buffer{}
+	}
+	id := uuid.NewString()
+	f.uploads[id] = &bytes.Buffer{}
+	return id, nil
+}
+
+// UploadCsvChunk appends a chunk and returns the total number of received bytes.
+func (f *FakeImportClient) UploadCsvChunk(_ context.Context, importID string, chunk []byte, _ bool, _ string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	buf, ok := f.uploads[importID]
+	if !ok {
+		return 0, fmt.Errorf("import %s not found", importID)
+	}
+	buf.Write(chunk)
+	return int64(buf.Len()), nil
+}
+
+// ConfigureCsvMapping requires date and amount columns.
+func (f *FakeImportClient) ConfigureCsvMapping(_ context.Context, importID string, mapping CsvColumnMapping, _ string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.uploads[importID]; !ok {
+		return fmt.Errorf("import %s not found", importID)
+	}
+	if mapping.Date == "" || mapping.Amount == "" {
+		return fmt.Errorf("date and amount columns are required")
+	}
+	return nil
+}
+
+// PreviewCsvImport treats every non-empty line after the header as a valid row.
+func (f *FakeImportClient) PreviewCsvImport(_ context.Context, importID string, _ int, _ string) (*ImportPreview, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	buf, ok := f.uploads[importID]
+	if !ok {
+		return nil, fmt.Errorf("import %s not found", importID)
+	}
+	rows := 0
+	for i, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+		if i > 0 && len(bytes.TrimSpace(line)) > 0 {
+			rows++
+		}
+	}
+	return &ImportPreview{TotalRows: rows, ValidRows: rows}, nil
+}
+
+// CommitCsvImport reports all rows as inserted; a real commit forgets the upload.
+func (f *FakeImportClient) CommitCsvImport(ctx context.Context, importID string, dryRun bool, accessToken string) (*ImportResult, error) {
+	p, err := f.PreviewCsvImport(ctx, importID, 0, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		f.mu.Lock()
+		delete(f.uploads, importID)
+		f.mu.Unlock()
+	}
+	return &ImportResult{Inserted: p.ValidRows, Failed: p.InvalidRows}, nil
+}
+
+// ImportGRPCClient calls Import service via gRPC.
+type ImportGRPCClient struct {
+	client pb.ImportServiceClient
+	logger *zap.Logger
+}
+
+// NewGRPCImportClient constructs an ImportGRPCClient.
+func NewGRPCImportClient(c pb.ImportServiceClient, logger *zap.Logger) *ImportGRPCClient {
+	return &ImportGRPCClient{client: c, logger: logger}
+}
+
+// StartCsvImport creates an import session and returns its id.
+func (g *ImportGRPCClient) StartCsvImport(ctx context.Context, filename, delimiter, encoding, accessToken string) (string, error) {
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+	}
+	res, err := g.client.StartCsvImport(ctx, &pb.StartCsvImportRequest{
+		Filename:  filename,
+		Delimiter: delimiter,
+		Encoding:  encoding,
+	})
+	if err != nil {
+		g.logger.Error("StartCsvImport gRPC call failed", zap.Error(err))
+		return "", err
+	}
+	return res.GetImportId(), nil
+}
+
+// UploadCsvChunk sends a part of the file; last marks the final chunk.
+func (g *ImportGRPCClient) UploadCsvChunk(ctx context.Context, importID string, chunk []byte, last bool, accessToken string) (int64, error) {
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+	}
+	res, err := g.client.UploadCsvChunk(ctx, &pb.UploadCsvChunkRequest{
+		ImportId: importID,
+		Chunk:    chunk,
+		Last:     last,
+	})
+	if err != nil {
+		g.logger.Error("UploadCsvChunk gRPC call failed", zap.String("importID", importID), zap.Error(err))
+		return 0, err
+	}
+	return res.GetReceivedBytes(), nil
+}
+
+// ConfigureCsvMapping sets which columns hold transaction fields.
+func (g *ImportGRPCClient) ConfigureCsvMapping(ctx context.Context, importID string, mapping CsvColumnMapping, accessToken string) error {
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+	}
+	_, err := g.client.ConfigureCsvMapping(ctx, &pb.ConfigureCsvMappingRequest{
+		ImportId: importID,
+		Mapping: &pb.CsvColumnMapping{
+			DateColumn:         mapping.Date,
+			AmountColumn:       mapping.Amount,
+			CurrencyCodeColumn: mapping.Currency,
+			TypeColumn:         mapping.Type,
+			CategoryColumn:     mapping.Category,
+			CommentColumn:      mapping.Comment,
+		},
+	})
+	if err != nil {
+		g.logger.Error("ConfigureCsvMapping gRPC call failed", zap.String("importID", importID), zap.Error(err))
+	}
+	return err
+}
+
+// PreviewCsvImport validates rows with the current mapping.
+func (g *ImportGRPCClient) PreviewCsvImport(ctx context.Context, importID string, limit int, accessToken string) (*ImportPreview, error) {
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+	}
+	res, err := g.client.PreviewCsvImport(ctx, &pb.PreviewCsvImportRequest{
+		ImportId: importID,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		g.logger.Error("PreviewCsvImport gRPC call failed", zap.String("importID", importID), zap.Error(err))
+		return nil, err
+	}
+	return &ImportPreview{
+		TotalRows:   int(res.GetTotalRows()),
+		ValidRows:   int(res.GetValidRows()),
+		InvalidRows: int(res.GetInvalidRows()),
+	}, nil
+}
+
+// CommitCsvImport inserts transactions, or only validates them when dryRun is set.
+func (g *ImportGRPCClient) CommitCsvImport(ctx context.Context, importID string, dryRun bool, accessToken string) (*ImportResult, error) {
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+	}
+	res, err := g.client.CommitCsvImport(ctx, &pb.CommitCsvImportRequest{
+		ImportId: importID,
+		DryRun:   dryRun,
+	})
+	if err != nil {
+		g.logger.Error("CommitCsvImport gRPC call failed", zap.String("importID", importID), zap.Bool("dryRun", dryRun), zap.Error(err))
+		return nil, err
+	}
+	return &ImportResult{Inserted: int(res.GetInserted()), Failed: int(res.GetFailed())}, nil
+}
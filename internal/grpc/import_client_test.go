@@ -0,0 +1,116 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	pb "budget-bot/internal/pb/budget/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+type fakeImportServer struct {
+	pb.UnimplementedImportServiceServer
+	sawAuth  string
+	received []byte
+	last     bool
+	mapping  *pb.CsvColumnMapping
+	dryRun   bool
+}
+
+func (s *fakeImportServer) StartCsvImport(ctx context.Context, req *pb.StartCsvImportRequest) (*pb.StartCsvImportResponse, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("authorization")) > 0 {
+		s.sawAuth = md.Get("authorization")[0]
+	}
+	return &pb.StartCsvImportResponse{ImportId: "imp-" + req.GetDelimiter()}, nil
+}
+
+func (s *fakeImportServer) UploadCsvChunk(_ context.Context, req *pb.UploadCsvChunkRequest) (*pb.UploadCsvChunkResponse, error) {
+	s.received = append(s.received, req.GetChunk()...)
+	s.last = req.GetLast()
+	return &pb.UploadCsvChunkResponse{ReceivedBytes: int64(len(s.received))}, nil
+}
+
+func (s *fakeImportServer) ConfigureCsvMapping(_ context.Context, req *pb.ConfigureCsvMappingRequest) (*pb.ConfigureCsvMappingResponse, error) {
+	s.mapping = req.GetMapping()
+	return &pb.ConfigureCsvMappingResponse{}, nil
+}
+
+func (s *fakeImportServer) PreviewCsvImport(_ context.Context, _ *pb.PreviewCsvImportRequest) (*pb.PreviewCsvImportResponse, error) {
+	return &pb.PreviewCsvImportResponse{TotalRows: 3, ValidRows: 2, InvalidRows: 1}, nil
+}
+
+func (s *fakeImportServer) CommitCsvImport(_ context.Context, req *pb.CommitCsvImportRequest) (*pb.CommitCsvImportResponse, error) {
+	s.dryRun = req.GetDryRun()
+	return &pb.CommitCsvImportResponse{Inserted: 2, Failed: 1}, nil
+}
+
+func TestImportGRPCClient_Flow(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	impl := &fakeImportServer{}
+	pb.RegisterImportServiceServer(srv, impl)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	c := NewGRPCImportClient(pb.NewImportServiceClient(conn), zap.NewNop())
+	ctx := context.Background()
+
+	id, err := c.StartCsvImport(ctx, "bank.csv", ";", "utf-8", "tok")
+	if err != nil || id != "imp-;" || impl.sawAuth != "Bearer tok" {
+		t.Fatalf("start: id=%q err=%v auth=%q", id, err, impl.sawAuth)
+	}
+	if _, err := c.UploadCsvChunk(ctx, id, []byte("a;b\n"), false, "tok"); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	n, err := c.UploadCsvChunk(ctx, id, []byte("1;2\n"), true, "tok")
+	if err != nil || n != 8 || !impl.last {
+		t.Fatalf("upload last: n=%d err=%v", n, err)
+	}
+	if err := c.ConfigureCsvMapping(ctx, id, CsvColumnMapping{Date: "a", Amount: "b", Comment: "c"}, "tok"); err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+	if impl.mapping.GetDateColumn() != "a" || impl.mapping.GetAmountColumn() != "b" || impl.mapping.GetCommentColumn() != "c" {
+		t.Fatalf("unexpected mapping: %+v", impl.mapping)
+	}
+	p, err := c.PreviewCsvImport(ctx, id, 10, "tok")
+	if err != nil || p.TotalRows != 3 || p.ValidRows != 2 || p.InvalidRows != 1 {
+		t.Fatalf("preview: %+v %v", p, err)
+	}
+	r, err := c.CommitCsvImport(ctx, id, true, "tok")
+	if err != nil || r.Inserted != 2 || r.Failed != 1 || !impl.dryRun {
+		t.Fatalf("commit: %+v %v", r, err)
+	}
+}
+
+func TestFakeImportClient(t *testing.T) {
+	c := &FakeImportClient{}
+	ctx := context.Background()
+	id, _ := c.StartCsvImport(ctx, "f.csv", ",", "utf-8", "")
+	_, _ = c.UploadCsvChunk(ctx, id, []byte("date,amount\n01.01.2025,100\n"), false, "")
+	_, _ = c.UploadCsvChunk(ctx, id, []byte("02.01.2025,200\n\n"), true, "")
+	if err := c.ConfigureCsvMapping(ctx, id, CsvColumnMapping{Date: "date"}, ""); err == nil {
+		t.Fatalf("amount column must be required")
+	}
+	r, err := c.CommitCsvImport(ctx, id, true, "")
+	if err != nil || r.Inserted != 2 {
+		t.Fatalf("dry run: %+v %v", r, err)
+	}
+	if _, err := c.CommitCsvImport(ctx, id, false, ""); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if _, err := c.PreviewCsvImport(ctx, id, 0, ""); err == nil {
+		t.Fatalf("committed import must be forgotten")
+	}
+}
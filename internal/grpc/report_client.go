@@ -3,6 +3,8 @@ package grpc
 
 import (
     "context"
+    "errors"
+    "fmt"
     "math"
     "time"
     "sort"
@@ -41,6 +43,27 @@ func (f *FakeReportClient) Recent(_ context.Context, tenantID string, limit int,
     return []string{"-1000 продукты", "-300 такси", "+50000 зарплата"}, nil
 }
 
+// ErrUnsupportedRange is returned by ReportGRPCClient for ranges other than a whole calendar month,
+// the only period GetMonthlySummary knows. Other ranges are summed from transactions by the caller.
+var ErrUnsupportedRange = errors.New("report service summarizes whole calendar months only")
+
+// IsWholeMonth reports whether [from, to] is the calendar month of from, the only range the report service
+// summarizes; to is its last day or the last instant of it.
+func IsWholeMonth(from, to time.Time) bool {
+    last := from.AddDate(0, 1, -1)
+    to = to.In(from.Location())
+    return from.Day() == 1 && from.Hour() == 0 && from.Minute() == 0 && from.Second() == 0 &&
+        to.Year() == last.Year() && to.Month() == last.Month() && to.Day() == last.Day()
+}
+
+// checkWholeMonth fails with ErrUnsupportedRange unless IsWholeMonth(from, to).
+func checkWholeMonth(from, to time.Time) error {
+    if IsWholeMonth(from, to) {
+        return nil
+    }
+    return fmt.Errorf("%w: %s..%s", ErrUnsupportedRange, from.Format("2006-01-02"), to.Format("2006-01-02"))
+}
+
 // ReportGRPCClient calls remote Report service via gRPC.
 type ReportGRPCClient struct{ 
     client pb.ReportServiceClient 
@@ -52,8 +75,11 @@ func NewGRPCReportClient(c pb.ReportServiceClient, logger *zap.Logger) *ReportGR
     return &ReportGRPCClient{client: c, logger: logger} 
 }
 
-// GetStats fetches monthly stats for a period.
-func (g *ReportGRPCClient) GetStats(ctx context.Context, tenantID string, from, _ time.Time, accessToken string) (*domain.Stats, error) {
+// GetStats fetches stats for a whole calendar month, see ErrUnsupportedRange.
+func (g *ReportGRPCClient) GetStats(ctx context.Context, tenantID string, from, to time.Time, accessToken string) (*domain.Stats, error) {
+    if err := checkWholeMonth(from, to); err != nil {
+        return nil, err
+    }
     g.logger.Debug("GetStats request", 
         zap.String("tenantID", tenantID),
         zap.Time("from", from),
@@ -96,8 +122,11 @@ func (g *ReportGRPCClient) GetStats(ctx context.Context, tenantID string, from,
     }, nil
 }
 
-// TopCategories returns top expense categories for the period.
-func (g *ReportGRPCClient) TopCategories(ctx context.Context, tenantID string, from, _ time.Time, limit int, accessToken string) ([]*domain.CategoryTotal, error) {
+// TopCategories returns top expense categories for a whole calendar month, see ErrUnsupportedRange.
+func (g *ReportGRPCClient) TopCategories(ctx context.Context, tenantID string, from, to time.Time, limit int, accessToken string) ([]*domain.CategoryTotal, error) {
+    if err := checkWholeMonth(from, to); err != nil {
+        return nil, err
+    }
     g.logger.Debug("TopCategories request", 
         zap.String("tenantID", tenantID),
         zap.Time("from", from),
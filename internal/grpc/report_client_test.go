@@ -2,6 +2,7 @@ package grpc
 
 import (
     "context"
+    "errors"
     "net"
     "testing"
     "time"
@@ -60,4 +61,21 @@ func TestGRPCReportClient_GetStatsAndTop(t *testing.T) {
     if impl.sawAuth != "Bearer tok" { t.Fatalf("auth metadata not set: %q", impl.sawAuth) }
 }
 
-
+func TestGRPCReportClient_RejectsPartialMonths(t *testing.T) {
+    c := NewGRPCReportClient(nil, zap.NewNop())
+    ctx := context.Background()
+    from := time.Date(2025, 8, 4, 0, 0, 0, 0, time.UTC)
+    if _, err := c.GetStats(ctx, "tenant", from, from.AddDate(0, 0, 6), "tok"); !errors.Is(err, ErrUnsupportedRange) {
+        t.Fatalf("a week must be rejected: %v", err)
+    }
+    from = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+    if _, err := c.TopCategories(ctx, "tenant", from, from.AddDate(0, 3, 0).Add(-time.Nanosecond), 5, "tok"); !errors.Is(err, ErrUnsupportedRange) {
+        t.Fatalf("a quarter must be rejected: %v", err)
+    }
+    if err := checkWholeMonth(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)); err != nil {
+        t.Fatalf("a month ending at its last instant is whole: %v", err)
+    }
+    if IsWholeMonth(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 27, 0, 0, 0, 0, time.UTC)) {
+        t.Fatalf("a month without its last day is not whole")
+    }
+}
@@ -3,7 +3,10 @@ package grpc
 
 import (
     "context"
+    "fmt"
     "math"
+    "strings"
+    "sync"
 
     pb "budget-bot/internal/pb/budget/v1"
     "google.golang.org/grpc/metadata"
@@ -14,20 +17,201 @@ import (
 type Tenant struct {
     ID   string
     Name string
+    // DefaultCurrency is the tenant's base currency (ISO 4217), empty if not set.
+    DefaultCurrency string
+    // Role is the current user's role in the tenant: TenantRoleOwner, TenantRoleAdmin or TenantRoleMember.
+    Role string
+}
+
+// Tenant roles of the current user.
+const (
+    TenantRoleOwner  = "owner"
+    TenantRoleAdmin  = "admin"
+    TenantRoleMember = "member"
+)
+
+// TenantMember is a user's membership in a tenant.
+type TenantMember struct {
+    UserID string
+    Email  string
+    Name   string
+    Role   string
+}
+
+// tenantRoleName converts a backend role into TenantRole* constants, empty if unspecified.
+func tenantRoleName(r pb.TenantRole) string {
+    if r == pb.TenantRole_TENANT_ROLE_UNSPECIFIED {
+        return ""
+    }
+    return strings.ToLower(strings.TrimPrefix(r.String(), "TENANT_ROLE_"))
+}
+
+// tenantRoleFromName converts TenantRole* constants into a backend role.
+func tenantRoleFromName(role string) pb.TenantRole {
+    return pb.TenantRole(pb.TenantRole_value["TENANT_ROLE_"+strings.ToUpper(role)])
+}
+
+func memberFromPB(m *pb.TenantMember) *TenantMember {
+    out := &TenantMember{Role: tenantRoleName(m.GetRole())}
+    if u := m.GetUser(); u != nil {
+        out.UserID, out.Email, out.Name = u.GetId(), u.GetEmail(), u.GetName()
+    }
+    return out
+}
+
+func tenantFromPB(t *pb.Tenant, role string) *Tenant {
+    return &Tenant{ID: t.GetId(), Name: t.GetName(), DefaultCurrency: t.GetDefaultCurrencyCode(), Role: role}
 }
 
 // TenantClient exposes tenant operations.
 type TenantClient interface {
     ListTenants(ctx context.Context, accessToken string) ([]*Tenant, error)
+    // CreateTenant creates a tenant owned by the current user.
+    CreateTenant(ctx context.Context, name, currency, accessToken string) (*Tenant, error)
+    // UpdateTenant changes name and base currency of tenant t.ID; empty fields are left unchanged.
+    UpdateTenant(ctx context.Context, t *Tenant, accessToken string) (*Tenant, error)
+    ListMembers(ctx context.Context, tenantID, accessToken string) ([]*TenantMember, error)
+    // AddMember adds an existing user found by email with the given role.
+    AddMember(ctx context.Context, tenantID, email, role, accessToken string) (*TenantMember, error)
+    UpdateMemberRole(ctx context.Context, tenantID, userID, role, accessToken string) (*TenantMember, error)
+    RemoveMember(ctx context.Context, tenantID, userID, accessToken string) error
+}
+
+// FakeTenantClient is an in-memory stub seeded with two tenants of user-1.
+type FakeTenantClient struct {
+    mu      sync.Mutex
+    tenants []*Tenant
+    members map[string][]*TenantMember // by tenant id
 }
 
-// FakeTenantClient is a temporary stub returning two tenants.
-// FakeTenantClient is a temporary stub returning static tenants.
-type FakeTenantClient struct{}
+func (f *FakeTenantClient) seed() {
+    if f.tenants != nil {
+        return
+    }
+    f.tenants = []*Tenant{{ID: "tenant-1", Name: "Личный", DefaultCurrency: "RUB", Role: TenantRoleOwner}, {ID: "tenant-2", Name: "Семья", DefaultCurrency: "RUB", Role: TenantRoleMember}}
+    f.members = map[string][]*TenantMember{
+        "tenant-1": {{UserID: "user-1", Email: "user@example.com", Name: "User", Role: TenantRoleOwner}},
+        "tenant-2": {
+            {UserID: "user-2", Email: "owner@example.com", Name: "Owner", Role: TenantRoleOwner},
+            {UserID: "user-1", Email: "user@example.com", Name: "User", Role: TenantRoleMember},
+        },
+    }
+}
+
+func (f *FakeTenantClient) findMember(tenantID, userID string) (*TenantMember, error) {
+    for _, m := range f.members[tenantID] {
+        if m.UserID == userID {
+            return m, nil
+        }
+    }
+    return nil, fmt.Errorf("member %s not found", userID)
+}
 
-// ListTenants returns a static list of tenants.
+// ListTenants returns the in-memory tenants.
 func (f *FakeTenantClient) ListTenants(_ context.Context, _ string) ([]*Tenant, error) {
-    return []*Tenant{{ID: "tenant-1", Name: "Личный"}, {ID: "tenant-2", Name: "Семья"}}, nil
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.seed()
+    out := make([]*Tenant, 0, len(f.tenants))
+    for _, t := range f.tenants {
+        c := *t
+        out = append(out, &c)
+    }
+    return out, nil
+}
+
+// CreateTenant adds a tenant owned by user-1.
+func (f *FakeTenantClient) CreateTenant(_ context.Context, name, currency, _ string) (*Tenant, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.seed()
+    t := &Tenant{ID: fmt.Sprintf("tenant-%d", len(f.tenants)+1), Name: name, DefaultCurrency: currency, Role: TenantRoleOwner}
+    f.tenants = append(f.tenants, t)
+    f.members[t.ID] = []*TenantMember{{UserID: "user-1", Email: "user@example.com", Name: "User", Role: TenantRoleOwner}}
+    c := *t
+    return &c, nil
+}
+
+// UpdateTenant changes the in-memory tenant.
+func (f *FakeTenantClient) UpdateTenant(_ context.Context, t *Tenant, _ string) (*Tenant, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.seed()
+    for _, cur := range f.tenants {
+        if cur.ID != t.ID {
+            continue
+        }
+        if t.Name != "" {
+            cur.Name = t.Name
+        }
+        if t.DefaultCurrency != "" {
+            cur.DefaultCurrency = t.DefaultCurrency
+        }
+        c := *cur
+        return &c, nil
+    }
+    return nil, fmt.Errorf("tenant %s not found", t.ID)
+}
+
+// ListMembers returns the in-memory members of a tenant.
+func (f *FakeTenantClient) ListMembers(_ context.Context, tenantID, _ string) ([]*TenantMember, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.seed()
+    out := make([]*TenantMember, 0, len(f.members[tenantID]))
+    for _, m := range f.members[tenantID] {
+        c := *m
+        out = append(out, &c)
+    }
+    return out, nil
+}
+
+// AddMember adds a user with a generated id, or fails if the email is already a member.
+func (f *FakeTenantClient) AddMember(_ context.Context, tenantID, email, role, _ string) (*TenantMember, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.seed()
+    for _, m := range f.members[tenantID] {
+        if strings.EqualFold(m.Email, email) {
+            return nil, fmt.Errorf("%s is already a member", email)
+        }
+    }
+    m := &TenantMember{UserID: fmt.Sprintf("user-%d", len(f.members[tenantID])+10), Email: email, Role: role}
+    f.members[tenantID] = append(f.members[tenantID], m)
+    c := *m
+    return &c, nil
+}
+
+// UpdateMemberRole changes the in-memory role.
+func (f *FakeTenantClient) UpdateMemberRole(_ context.Context, tenantID, userID, role, _ string) (*TenantMember, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.seed()
+    m, err := f.findMember(tenantID, userID)
+    if err != nil {
+        return nil, err
+    }
+    m.Role = role
+    c := *m
+    return &c, nil
+}
+
+// RemoveMember deletes the in-memory membership.
+func (f *FakeTenantClient) RemoveMember(_ context.Context, tenantID, userID, _ string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.seed()
+    if _, err := f.findMember(tenantID, userID); err != nil {
+        return err
+    }
+    list := f.members[tenantID][:0]
+    for _, m := range f.members[tenantID] {
+        if m.UserID != userID {
+            list = append(list, m)
+        }
+    }
+    f.members[tenantID] = list
+    return nil
 }
 
 // TenantGRPCClient calls Tenant service via gRPC.
@@ -62,7 +246,7 @@ func (g *TenantGRPCClient) ListTenants(ctx context.Context, accessToken string)
     
     var out []*Tenant
     for _, m := range res.Memberships {
-        out = append(out, &Tenant{ID: m.Tenant.Id, Name: m.Tenant.Name})
+        out = append(out, tenantFromPB(m.Tenant, tenantRoleName(m.Role)))
     }
     
     g.logger.Debug("ListTenants processed", 
@@ -71,4 +255,72 @@ func (g *TenantGRPCClient) ListTenants(ctx context.Context, accessToken string)
     return out, nil
 }
 
+func (g *TenantGRPCClient) authContext(ctx context.Context, accessToken string) context.Context {
+    if accessToken != "" {
+        ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+    }
+    return ctx
+}
+
+// CreateTenant creates a tenant; the current user becomes its owner.
+func (g *TenantGRPCClient) CreateTenant(ctx context.Context, name, currency, accessToken string) (*Tenant, error) {
+    res, err := g.client.CreateTenant(g.authContext(ctx, accessToken), &pb.CreateTenantRequest{Name: name, DefaultCurrencyCode: currency})
+    if err != nil {
+        g.logger.Error("CreateTenant gRPC call failed", zap.Error(err))
+        return nil, err
+    }
+    return tenantFromPB(res.GetTenant(), TenantRoleOwner), nil
+}
+
+// UpdateTenant changes tenant name and base currency.
+func (g *TenantGRPCClient) UpdateTenant(ctx context.Context, t *Tenant, accessToken string) (*Tenant, error) {
+    res, err := g.client.UpdateTenant(g.authContext(ctx, accessToken), &pb.UpdateTenantRequest{Id: t.ID, Name: t.Name, DefaultCurrencyCode: t.DefaultCurrency})
+    if err != nil {
+        g.logger.Error("UpdateTenant gRPC call failed", zap.String("tenantID", t.ID), zap.Error(err))
+        return nil, err
+    }
+    return tenantFromPB(res.GetTenant(), t.Role), nil
+}
+
+// ListMembers returns tenant members with their roles.
+func (g *TenantGRPCClient) ListMembers(ctx context.Context, tenantID, accessToken string) ([]*TenantMember, error) {
+    res, err := g.client.ListMembers(g.authContext(ctx, accessToken), &pb.ListMembersRequest{TenantId: tenantID})
+    if err != nil {
+        g.logger.Error("ListMembers gRPC call failed", zap.String("tenantID", tenantID), zap.Error(err))
+        return nil, err
+    }
+    out := make([]*TenantMember, 0, len(res.GetMembers()))
+    for _, m := range res.GetMembers() {
+        out = append(out, memberFromPB(m))
+    }
+    return out, nil
+}
+
+// AddMember adds an existing user by email.
+func (g *TenantGRPCClient) AddMember(ctx context.Context, tenantID, email, role, accessToken string) (*TenantMember, error) {
+    res, err := g.client.AddMember(g.authContext(ctx, accessToken), &pb.AddMemberRequest{TenantId: tenantID, Email: email, Role: tenantRoleFromName(role)})
+    if err != nil {
+        g.logger.Error("AddMember gRPC call failed", zap.String("tenantID", tenantID), zap.Error(err))
+        return nil, err
+    }
+    return memberFromPB(res.GetMember()), nil
+}
+
+// UpdateMemberRole changes a member's role.
+func (g *TenantGRPCClient) UpdateMemberRole(ctx context.Context, tenantID, userID, role, accessToken string) (*TenantMember, error) {
+    res, err := g.client.UpdateMemberRole(g.authContext(ctx, accessToken), &pb.UpdateMemberRoleRequest{TenantId: tenantID, UserId: userID, Role: tenantRoleFromName(role)})
+    if err != nil {
+        g.logger.Error("UpdateMemberRole gRPC call failed", zap.String("tenantID", tenantID), zap.Error(err))
+        return nil, err
+    }
+    return memberFromPB(res.GetMember()), nil
+}
 
+// RemoveMember removes a user from the tenant.
+func (g *TenantGRPCClient) RemoveMember(ctx context.Context, tenantID, userID, accessToken string) error {
+    if _, err := g.client.RemoveMember(g.authContext(ctx, accessToken), &pb.RemoveMemberRequest{TenantId: tenantID, UserId: userID}); err != nil {
+        g.logger.Error("RemoveMember gRPC call failed", zap.String("tenantID", tenantID), zap.Error(err))
+        return err
+    }
+    return nil
+}
@@ -14,7 +14,31 @@ import (
 type fakeTenantServer struct{ pb.UnimplementedTenantServiceServer }
 
 func (s *fakeTenantServer) ListMyTenants(_ context.Context, _ *pb.ListMyTenantsRequest) (*pb.ListMyTenantsResponse, error) {
-    return &pb.ListMyTenantsResponse{Memberships: []*pb.TenantMembership{{Tenant: &pb.Tenant{Id: "t1", Name: "Личный"}}}}, nil
+    return &pb.ListMyTenantsResponse{Memberships: []*pb.TenantMembership{{Tenant: &pb.Tenant{Id: "t1", Name: "Личный", DefaultCurrencyCode: "EUR"}, Role: pb.TenantRole_TENANT_ROLE_ADMIN}}}, nil
+}
+
+func (s *fakeTenantServer) CreateTenant(_ context.Context, req *pb.CreateTenantRequest) (*pb.CreateTenantResponse, error) {
+    return &pb.CreateTenantResponse{Tenant: &pb.Tenant{Id: "t2", Name: req.GetName(), DefaultCurrencyCode: req.GetDefaultCurrencyCode()}}, nil
+}
+
+func (s *fakeTenantServer) UpdateTenant(_ context.Context, req *pb.UpdateTenantRequest) (*pb.UpdateTenantResponse, error) {
+    return &pb.UpdateTenantResponse{Tenant: &pb.Tenant{Id: req.GetId(), Name: "Личный", DefaultCurrencyCode: req.GetDefaultCurrencyCode()}}, nil
+}
+
+func (s *fakeTenantServer) ListMembers(_ context.Context, _ *pb.ListMembersRequest) (*pb.ListMembersResponse, error) {
+    return &pb.ListMembersResponse{Members: []*pb.TenantMember{{User: &pb.User{Id: "u1", Email: "a@b.c", Name: "Anna"}, Role: pb.TenantRole_TENANT_ROLE_OWNER}}}, nil
+}
+
+func (s *fakeTenantServer) AddMember(_ context.Context, req *pb.AddMemberRequest) (*pb.AddMemberResponse, error) {
+    return &pb.AddMemberResponse{Member: &pb.TenantMember{User: &pb.User{Id: "u2", Email: req.GetEmail()}, Role: req.GetRole()}}, nil
+}
+
+func (s *fakeTenantServer) UpdateMemberRole(_ context.Context, req *pb.UpdateMemberRoleRequest) (*pb.UpdateMemberRoleResponse, error) {
+    return &pb.UpdateMemberRoleResponse{Member: &pb.TenantMember{User: &pb.User{Id: req.GetUserId()}, Role: req.GetRole()}}, nil
+}
+
+func (s *fakeTenantServer) RemoveMember(_ context.Context, _ *pb.RemoveMemberRequest) (*pb.RemoveMemberResponse, error) {
+    return &pb.RemoveMemberResponse{}, nil
 }
 
 func startTenantServer(t *testing.T) (*grpc.Server, string) {
@@ -36,6 +60,29 @@ func TestGRPCTenantClient_ListTenants(t *testing.T) {
     c := NewGRPCTenantClient(pb.NewTenantServiceClient(conn), zap.NewNop())
     list, err := c.ListTenants(context.Background(), "tok")
     if err != nil || len(list) == 0 { t.Fatalf("tenants: %v n=%d", err, len(list)) }
+    if list[0].DefaultCurrency != "EUR" || list[0].Role != TenantRoleAdmin { t.Fatalf("unexpected tenant: %+v", list[0]) }
 }
 
 
+
+func TestGRPCTenantClient_Members(t *testing.T) {
+    srv, addr := startTenantServer(t)
+    defer srv.Stop()
+    conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+    if err != nil { t.Fatal(err) }
+    defer func(){ _ = conn.Close() }()
+    c := NewGRPCTenantClient(pb.NewTenantServiceClient(conn), zap.NewNop())
+    ctx := context.Background()
+
+    created, err := c.CreateTenant(ctx, "Семья", "EUR", "tok")
+    if err != nil || created.ID != "t2" || created.DefaultCurrency != "EUR" || created.Role != TenantRoleOwner { t.Fatalf("create: %+v %v", created, err) }
+    updated, err := c.UpdateTenant(ctx, &Tenant{ID: "t1", DefaultCurrency: "GEL"}, "tok")
+    if err != nil || updated.DefaultCurrency != "GEL" { t.Fatalf("update: %+v %v", updated, err) }
+    members, err := c.ListMembers(ctx, "t1", "tok")
+    if err != nil || len(members) != 1 || members[0].Email != "a@b.c" || members[0].Role != TenantRoleOwner { t.Fatalf("members: %+v %v", members, err) }
+    added, err := c.AddMember(ctx, "t1", "x@y.z", TenantRoleAdmin, "tok")
+    if err != nil || added.Email != "x@y.z" || added.Role != TenantRoleAdmin { t.Fatalf("add: %+v %v", added, err) }
+    changed, err := c.UpdateMemberRole(ctx, "t1", "u2", TenantRoleMember, "tok")
+    if err != nil || changed.UserID != "u2" || changed.Role != TenantRoleMember { t.Fatalf("role: %+v %v", changed, err) }
+    if err := c.RemoveMember(ctx, "t1", "u2", "tok"); err != nil { t.Fatalf("remove: %v", err) }
+}
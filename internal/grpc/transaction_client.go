@@ -24,12 +24,54 @@ type CreateTransactionRequest struct {
 	OccurredAt  time.Time
 }
 
+// UpdateTransactionRequest is an app-level partial update of a transaction.
+// Only non-nil fields are sent and listed in the update mask.
+type UpdateTransactionRequest struct {
+	AmountMinor *int64
+	Currency    string
+	Description *string
+	OccurredAt  *time.Time
+}
+
+// TransactionFilter narrows the transactions listed by ListTransactions and summed by GetTransactionsTotals.
+// Zero values mean "no filter"; amount bounds apply to the original amount.
+type TransactionFilter struct {
+	From        time.Time
+	To          time.Time
+	CategoryIDs []string
+	Type        string
+	MinMinor    int64
+	MaxMinor    int64
+	Currency    string
+	Search      string
+}
+
+// TransactionPage is one page of ListTransactions results.
+type TransactionPage struct {
+	Transactions []*pb.Transaction
+	Page         int
+	TotalPages   int
+	TotalItems   int64
+}
+
+// TransactionTotals are filtered totals in the tenant base currency.
+type TransactionTotals struct {
+	IncomeMinor  int64
+	ExpenseMinor int64
+	Currency     string
+}
+
 // TransactionClient exposes transaction operations.
 type TransactionClient interface {
 	CreateTransaction(ctx context.Context, req *CreateTransactionRequest, accessToken string) (string, error)
 	UpdateTransactionCategory(ctx context.Context, txID, categoryID, accessToken string) error
+	UpdateTransaction(ctx context.Context, txID string, req *UpdateTransactionRequest, accessToken string) error
+	DeleteTransaction(ctx context.Context, txID, accessToken string) error
+	GetTransaction(ctx context.Context, txID, accessToken string) (*pb.Transaction, error)
 	ListRecent(ctx context.Context, tenantID string, limit int, accessToken string) ([]*pb.Transaction, error)
 	ListForExport(ctx context.Context, tenantID string, from, to time.Time, limit int, accessToken string) ([]*pb.Transaction, error)
+	GetTransactionsTotals(ctx context.Context, filter *TransactionFilter, accessToken string) (*TransactionTotals, error)
+	ListTransactions(ctx context.Context, filter *TransactionFilter, page, pageSize int, accessToken string) (*TransactionPage, error)
 }
 
 // FakeTransactionClient is a temporary stub.
@@ -48,6 +90,24 @@ func (f *FakeTransactionClient) UpdateTransactionCategory(_ context.Context, _,
 	return nil
 }
 
+// UpdateTransaction accepts any partial update in the fake client.
+func (f *FakeTransactionClient) UpdateTransaction(_ context.Context, _ string, req *UpdateTransactionRequest, _ string) error {
+	if req != nil && req.Description != nil && *req.Description == "FAIL" {
+		return fmt.Errorf("forced failure")
+	}
+	return nil
+}
+
+// DeleteTransaction is a no-op in the fake client.
+func (f *FakeTransactionClient) DeleteTransaction(_ context.Context, _ string, _ string) error {
+	return nil
+}
+
+// GetTransaction reports that the fake client stores no transactions.
+func (f *FakeTransactionClient) GetTransaction(_ context.Context, txID string, _ string) (*pb.Transaction, error) {
+	return nil, fmt.Errorf("transaction %s not found", txID)
+}
+
 // ListRecent returns an empty list in the fake client.
 func (f *FakeTransactionClient) ListRecent(_ context.Context, _ string, _ int, _ string) ([]*pb.Transaction, error) {
 	return []*pb.Transaction{}, nil
@@ -58,6 +118,16 @@ func (f *FakeTransactionClient) ListForExport(_ context.Context, _ string, _, _
 	return []*pb.Transaction{}, nil
 }
 
+// GetTransactionsTotals returns zero totals in the fake client.
+func (f *FakeTransactionClient) GetTransactionsTotals(_ context.Context, _ *TransactionFilter, _ string) (*TransactionTotals, error) {
+	return &TransactionTotals{Currency: "RUB"}, nil
+}
+
+// ListTransactions returns an empty page in the fake client.
+func (f *FakeTransactionClient) ListTransactions(_ context.Context, _ *TransactionFilter, page, _ int, _ string) (*TransactionPage, error) {
+	return &TransactionPage{Page: page}, nil
+}
+
 // TransactionGRPCClient calls Transaction service via gRPC.
 type TransactionGRPCClient struct {
 	client pb.TransactionServiceClient
@@ -126,6 +196,69 @@ func (g *TransactionGRPCClient) UpdateTransactionCategory(ctx context.Context, t
 	return err
 }
 
+// UpdateTransaction applies a partial update using a field mask built from non-nil fields.
+func (g *TransactionGRPCClient) UpdateTransaction(ctx context.Context, txID string, req *UpdateTransactionRequest, accessToken string) error {
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+	}
+	tx := &pb.Transaction{}
+	var paths []string
+	if req.AmountMinor != nil {
+		tx.Amount = &pb.Money{CurrencyCode: req.Currency, MinorUnits: *req.AmountMinor}
+		paths = append(paths, "amount")
+	}
+	if req.Description != nil {
+		tx.Comment = *req.Description
+		paths = append(paths, "comment")
+	}
+	if req.OccurredAt != nil {
+		tx.OccurredAt = timestamppb.New(*req.OccurredAt)
+		paths = append(paths, "occurred_at")
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	g.logger.Debug("UpdateTransaction gRPC request",
+		zap.String("id", txID),
+		zap.Strings("paths", paths))
+
+	_, err := g.client.UpdateTransaction(ctx, &pb.UpdateTransactionRequest{
+		Id:          txID,
+		Transaction: tx,
+		UpdateMask:  &fieldmaskpb.FieldMask{Paths: paths},
+	})
+	if err != nil {
+		g.logger.Error("UpdateTransaction gRPC call failed", zap.Error(err))
+	}
+	return err
+}
+
+// DeleteTransaction removes a transaction by id.
+func (g *TransactionGRPCClient) DeleteTransaction(ctx context.Context, txID, accessToken string) error {
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+	}
+	_, err := g.client.DeleteTransaction(ctx, &pb.DeleteTransactionRequest{Id: txID})
+	if err != nil {
+		g.logger.Error("DeleteTransaction gRPC call failed", zap.Error(err))
+	}
+	return err
+}
+
+// GetTransaction fetches a transaction by id.
+func (g *TransactionGRPCClient) GetTransaction(ctx context.Context, txID, accessToken string) (*pb.Transaction, error) {
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+	}
+	res, err := g.client.GetTransaction(ctx, &pb.GetTransactionRequest{Id: txID})
+	if err != nil {
+		g.logger.Error("GetTransaction gRPC call failed", zap.String("id", txID), zap.Error(err))
+		return nil, err
+	}
+	return res.GetTransaction(), nil
+}
+
 // ListRecent returns recent transactions.
 func (g *TransactionGRPCClient) ListRecent(ctx context.Context, tenantID string, limit int, accessToken string) ([]*pb.Transaction, error) {
 	g.logger.Debug("ListRecent request",
@@ -203,6 +336,85 @@ func (g *TransactionGRPCClient) ListForExport(ctx context.Context, tenantID stri
 	return transactions, nil
 }
 
+// GetTransactionsTotals sums income and expense of transactions matching the filter.
+func (g *TransactionGRPCClient) GetTransactionsTotals(ctx context.Context, filter *TransactionFilter, accessToken string) (*TransactionTotals, error) {
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+	}
+	req := &pb.GetTransactionsTotalsRequest{}
+	if filter != nil {
+		req.DateRange = filter.dateRange()
+		req.CategoryIds = filter.CategoryIDs
+		req.Type = mapType(filter.Type)
+		req.MinMinorUnits = filter.MinMinor
+		req.MaxMinorUnits = filter.MaxMinor
+		req.CurrencyCode = filter.Currency
+		req.Search = filter.Search
+	}
+	res, err := g.client.GetTransactionsTotals(ctx, req)
+	if err != nil {
+		g.logger.Error("GetTransactionsTotals gRPC call failed", zap.Strings("categoryIDs", req.GetCategoryIds()), zap.Error(err))
+		return nil, err
+	}
+	totals := &TransactionTotals{
+		IncomeMinor:  res.GetTotalIncome().GetMinorUnits(),
+		ExpenseMinor: res.GetTotalExpense().GetMinorUnits(),
+		Currency:     res.GetTotalExpense().GetCurrencyCode(),
+	}
+	if totals.Currency == "" {
+		totals.Currency = res.GetTotalIncome().GetCurrencyCode()
+	}
+	return totals, nil
+}
+
+// ListTransactions returns a page of transactions matching the filter, newest first.
+func (g *TransactionGRPCClient) ListTransactions(ctx context.Context, filter *TransactionFilter, page, pageSize int, accessToken string) (*TransactionPage, error) {
+	if accessToken != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	req := &pb.ListTransactionsRequest{Page: &pb.PageRequest{Page: int32(page), PageSize: int32(pageSize), Sort: "occurred_at desc"}}
+	if filter != nil {
+		req.DateRange = filter.dateRange()
+		req.CategoryIds = filter.CategoryIDs
+		req.Type = mapType(filter.Type)
+		req.MinMinorUnits = filter.MinMinor
+		req.MaxMinorUnits = filter.MaxMinor
+		req.CurrencyCode = filter.Currency
+		req.Search = filter.Search
+	}
+	res, err := g.client.ListTransactions(ctx, req)
+	if err != nil {
+		g.logger.Error("ListTransactions gRPC call failed", zap.Int("page", page), zap.Error(err))
+		return nil, err
+	}
+	out := &TransactionPage{Transactions: res.GetTransactions(), Page: page, TotalPages: int(res.GetPage().GetTotalPages()), TotalItems: res.GetPage().GetTotalItems()}
+	if p := res.GetPage().GetPage(); p > 0 {
+		out.Page = int(p)
+	}
+	return out, nil
+}
+
+// dateRange converts the filter period, nil when it is unbounded.
+func (f *TransactionFilter) dateRange() *pb.DateRange {
+	if f.From.IsZero() && f.To.IsZero() {
+		return nil
+	}
+	dr := &pb.DateRange{}
+	if !f.From.IsZero() {
+		dr.From = timestamppb.New(f.From)
+	}
+	if !f.To.IsZero() {
+		dr.To = timestamppb.New(f.To)
+	}
+	return dr
+}
+
 func mapType(t string) pb.TransactionType {
 	switch t {
 	case "income":
@@ -0,0 +1,108 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "budget-bot/internal/pb/budget/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type fakeTxTotalsServer struct {
+	pb.UnimplementedTransactionServiceServer
+	last *pb.GetTransactionsTotalsRequest
+}
+
+func (s *fakeTxTotalsServer) GetTransactionsTotals(_ context.Context, req *pb.GetTransactionsTotalsRequest) (*pb.GetTransactionsTotalsResponse, error) {
+	s.last = req
+	return &pb.GetTransactionsTotalsResponse{
+		TotalIncome:  &pb.Money{CurrencyCode: "RUB", MinorUnits: 1000},
+		TotalExpense: &pb.Money{CurrencyCode: "RUB", MinorUnits: 2500},
+	}, nil
+}
+
+func TestGRPCTransactionClient_GetTransactionsTotals(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	impl := &fakeTxTotalsServer{}
+	pb.RegisterTransactionServiceServer(srv, impl)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	c := NewGRPCTransactionClient(pb.NewTransactionServiceClient(conn), zap.NewNop())
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	totals, err := c.GetTransactionsTotals(context.Background(), &TransactionFilter{From: from, CategoryIDs: []string{"cat-food"}, Type: "expense"}, "tok")
+	if err != nil {
+		t.Fatalf("totals: %v", err)
+	}
+	if totals.ExpenseMinor != 2500 || totals.IncomeMinor != 1000 || totals.Currency != "RUB" {
+		t.Fatalf("unexpected totals: %+v", totals)
+	}
+	if got := impl.last.GetCategoryIds(); len(got) != 1 || got[0] != "cat-food" {
+		t.Fatalf("unexpected category filter: %v", got)
+	}
+	if impl.last.GetType() != pb.TransactionType_TRANSACTION_TYPE_EXPENSE || !impl.last.GetDateRange().GetFrom().AsTime().Equal(from) || impl.last.GetDateRange().GetTo() != nil {
+		t.Fatalf("unexpected request: %+v", impl.last)
+	}
+}
+
+type fakeTxSearchServer struct {
+	pb.UnimplementedTransactionServiceServer
+	last *pb.ListTransactionsRequest
+}
+
+func (s *fakeTxSearchServer) ListTransactions(_ context.Context, req *pb.ListTransactionsRequest) (*pb.ListTransactionsResponse, error) {
+	s.last = req
+	return &pb.ListTransactionsResponse{
+		Transactions: []*pb.Transaction{{Id: "tx-1", Comment: "кофе"}},
+		Page:         &pb.PageResponse{Page: req.GetPage().GetPage(), PageSize: req.GetPage().GetPageSize(), TotalItems: 21, TotalPages: 3},
+	}, nil
+}
+
+func TestGRPCTransactionClient_ListTransactions(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	impl := &fakeTxSearchServer{}
+	pb.RegisterTransactionServiceServer(srv, impl)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	c := NewGRPCTransactionClient(pb.NewTransactionServiceClient(conn), zap.NewNop())
+
+	filter := &TransactionFilter{Search: "кофе", MinMinor: 50000, Currency: "USD", Type: "expense"}
+	page, err := c.ListTransactions(context.Background(), filter, 2, 10, "tok")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(page.Transactions) != 1 || page.Page != 2 || page.TotalPages != 3 || page.TotalItems != 21 {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	req := impl.last
+	if req.GetSearch() != "кофе" || req.GetMinMinorUnits() != 50000 || req.GetMaxMinorUnits() != 0 || req.GetCurrencyCode() != "USD" || req.GetDateRange() != nil {
+		t.Fatalf("unexpected filter: %+v", req)
+	}
+	if req.GetPage().GetPage() != 2 || req.GetPage().GetPageSize() != 10 || req.GetPage().GetSort() != "occurred_at desc" {
+		t.Fatalf("unexpected paging: %+v", req.GetPage())
+	}
+}
@@ -0,0 +1,86 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "budget-bot/internal/pb/budget/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type fakeTxUpdateServer struct {
+	pb.UnimplementedTransactionServiceServer
+	lastUpdate *pb.UpdateTransactionRequest
+	deletedID  string
+}
+
+func (s *fakeTxUpdateServer) UpdateTransaction(_ context.Context, req *pb.UpdateTransactionRequest) (*pb.UpdateTransactionResponse, error) {
+	s.lastUpdate = req
+	return &pb.UpdateTransactionResponse{Transaction: &pb.Transaction{Id: req.GetId()}}, nil
+}
+
+func (s *fakeTxUpdateServer) DeleteTransaction(_ context.Context, req *pb.DeleteTransactionRequest) (*pb.DeleteTransactionResponse, error) {
+	s.deletedID = req.GetId()
+	return &pb.DeleteTransactionResponse{}, nil
+}
+
+func (s *fakeTxUpdateServer) GetTransaction(_ context.Context, req *pb.GetTransactionRequest) (*pb.GetTransactionResponse, error) {
+	return &pb.GetTransactionResponse{Transaction: &pb.Transaction{Id: req.GetId(), Comment: "кофе"}}, nil
+}
+
+func TestGRPCTransactionClient_UpdateAndDelete(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	impl := &fakeTxUpdateServer{}
+	pb.RegisterTransactionServiceServer(srv, impl)
+	go func() { _ = srv.Serve(lis) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	c := NewGRPCTransactionClient(pb.NewTransactionServiceClient(conn), zap.NewNop())
+	ctx := context.Background()
+
+	amount := int64(35000)
+	comment := "кофе"
+	when := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+	if err := c.UpdateTransaction(ctx, "tx-1", &UpdateTransactionRequest{AmountMinor: &amount, Currency: "RUB", Description: &comment, OccurredAt: &when}, "tok"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	paths := impl.lastUpdate.GetUpdateMask().GetPaths()
+	if len(paths) != 3 || paths[0] != "amount" || paths[1] != "comment" || paths[2] != "occurred_at" {
+		t.Fatalf("unexpected mask: %v", paths)
+	}
+	if impl.lastUpdate.GetTransaction().GetAmount().GetMinorUnits() != amount {
+		t.Fatalf("amount not sent: %+v", impl.lastUpdate.GetTransaction())
+	}
+
+	impl.lastUpdate = nil
+	if err := c.UpdateTransaction(ctx, "tx-1", &UpdateTransactionRequest{}, "tok"); err != nil {
+		t.Fatalf("empty update: %v", err)
+	}
+	if impl.lastUpdate != nil {
+		t.Fatalf("empty update must not call backend")
+	}
+
+	if tx, err := c.GetTransaction(ctx, "tx-3", "tok"); err != nil || tx.GetId() != "tx-3" || tx.GetComment() != "кофе" {
+		t.Fatalf("get: %+v, %v", tx, err)
+	}
+
+	if err := c.DeleteTransaction(ctx, "tx-2", "tok"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if impl.deletedID != "tx-2" {
+		t.Fatalf("unexpected deleted id: %q", impl.deletedID)
+	}
+}
@@ -121,8 +121,6 @@ func (f *FakeOAuthClient) GetAuthLogs(_ context.Context, telegramUserID int64, _
 
 // WireClients (default build) returns nil clients so the app uses fakes.
 // To enable real clients, build with -tags withgrpc and ensure proto is generated.
-func WireClients(_ *zap.Logger) (CategoryClient, ReportClient, TenantClient, TransactionClient, OAuthClient, AuthClientInterface) {
-    return nil, nil, nil, nil, &FakeOAuthClient{}, &FakeAuthClient{}
+func WireClients(_ *zap.Logger) (CategoryClient, ReportClient, TenantClient, TransactionClient, OAuthClient, AuthClientInterface, FxClient, ImportClient) {
+    return nil, nil, nil, nil, &FakeOAuthClient{}, &FakeAuthClient{}, &FakeFxClient{}, nil
 }
-
-
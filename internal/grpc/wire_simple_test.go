@@ -100,7 +100,7 @@ func TestFakeOAuthClient_GetAuthLogs(t *testing.T) {
 func TestWireClients(t *testing.T) {
 	logger := zap.NewNop()
 	
-	categoryClient, reportClient, tenantClient, transactionClient, oauthClient, authClient := WireClients(logger)
+	categoryClient, reportClient, tenantClient, transactionClient, oauthClient, authClient, fxClient, importClient := WireClients(logger)
 	
 	assert.Nil(t, categoryClient)
 	assert.Nil(t, reportClient)
@@ -110,6 +110,8 @@ func TestWireClients(t *testing.T) {
 	assert.IsType(t, &FakeOAuthClient{}, oauthClient)
 	assert.NotNil(t, authClient)
 	assert.IsType(t, &FakeAuthClient{}, authClient)
+	assert.IsType(t, &FakeFxClient{}, fxClient)
+	assert.Nil(t, importClient)
 }
 
 func TestFakeAuthClient_Register(t *testing.T) {
@@ -18,14 +18,14 @@ import (
 
 // We will wire actual pb clients to our adapters
 
-func WireClients(log *zap.Logger) (CategoryClient, ReportClient, TenantClient, TransactionClient, OAuthClient, AuthClientInterface) {
+func WireClients(log *zap.Logger) (CategoryClient, ReportClient, TenantClient, TransactionClient, OAuthClient, AuthClientInterface, FxClient, ImportClient) {
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
     
     cfg, err := botcfg.Load()
     if err != nil {
         log.Fatal("failed to load config", zap.Error(err))
-        return nil, nil, nil, nil, nil, nil
+        return nil, nil, nil, nil, nil, nil, nil, nil
     }
     
     var creds credentials.TransportCredentials
@@ -39,7 +39,7 @@ func WireClients(log *zap.Logger) (CategoryClient, ReportClient, TenantClient, T
     conn, err := grpc.DialContext(ctx, cfg.GRPC.Address, grpc.WithTransportCredentials(creds))
     if err != nil {
         log.Warn("grpc dial failed, falling back to fakes", zap.Error(err))
-        return nil, nil, nil, nil, nil, nil
+        return nil, nil, nil, nil, nil, nil, nil, nil
     }
     log.Info("successfully connected to gRPC server", zap.String("address", cfg.GRPC.Address))
     
@@ -49,33 +49,7 @@ func WireClients(log *zap.Logger) (CategoryClient, ReportClient, TenantClient, T
     tx := NewGRPCTransactionClient(pb.NewTransactionServiceClient(conn), log)
     oauth := NewOAuthClient(pb.NewOAuthServiceClient(conn), log)
     auth := NewAuthClient(pb.NewAuthServiceClient(conn), log)
-    return cat, rep, ten, tx, oauth, auth
+    fx := NewGRPCFxClient(pb.NewFxServiceClient(conn))
+    imp := NewGRPCImportClient(pb.NewImportServiceClient(conn), log)
+    return cat, rep, ten, tx, oauth, auth, fx, imp
 }
-
-// WireFxClient dials gRPC and returns a real FxClient when available; otherwise a fake.
-func WireFxClient(log *zap.Logger) FxClient {
-    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-    defer cancel()
-    
-    cfg, err := botcfg.Load()
-    if err != nil {
-        log.Fatal("failed to load config for fx client", zap.Error(err))
-        return &FakeFxClient{}
-    }
-    
-    var creds credentials.TransportCredentials
-    if cfg.GRPC.Insecure {
-        creds = insecure.NewCredentials()
-    } else {
-        creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
-    }
-    
-    conn, err := grpc.DialContext(ctx, cfg.GRPC.Address, grpc.WithTransportCredentials(creds))
-    if err != nil {
-        log.Warn("grpc dial failed for fx, using fake", zap.Error(err))
-        return &FakeFxClient{}
-    }
-    return NewGRPCFxClient(pb.NewFxServiceClient(conn))
-}
-
-
@@ -44,6 +44,13 @@ var (
 		},
 		[]string{"action"},
 	)
+	transactionMutationTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bot_transaction_mutation_total",
+			Help: "Edits and deletions of saved transactions",
+		},
+		[]string{"action"},
+	)
 )
 
 func init() {
@@ -52,16 +59,18 @@ func init() {
 	prometheus.MustRegister(categorySelectedTotal)
 	prometheus.MustRegister(llmSuggestionTotal)
 	prometheus.MustRegister(mappingMutationTotal)
+	prometheus.MustRegister(transactionMutationTotal)
 }
 
 // IncUpdate increments updates counter.
 func IncUpdate() { updatesTotal.Inc() }
 
 // IncTransactionsSaved increments saved counter with a status label.
-func IncTransactionsSaved(status string) { transactionsSaved.WithLabelValues(status).Inc() }
-func IncCategorySelected(source string)  { categorySelectedTotal.WithLabelValues(source).Inc() }
-func IncLLMSuggestion(result string)     { llmSuggestionTotal.WithLabelValues(result).Inc() }
-func IncMappingMutation(action string)   { mappingMutationTotal.WithLabelValues(action).Inc() }
+func IncTransactionsSaved(status string)   { transactionsSaved.WithLabelValues(status).Inc() }
+func IncCategorySelected(source string)    { categorySelectedTotal.WithLabelValues(source).Inc() }
+func IncLLMSuggestion(result string)       { llmSuggestionTotal.WithLabelValues(result).Inc() }
+func IncMappingMutation(action string)     { mappingMutationTotal.WithLabelValues(action).Inc() }
+func IncTransactionMutation(action string) { transactionMutationTotal.WithLabelValues(action).Inc() }
 
 // Handler returns the HTTP handler for /metrics.
 func Handler() http.Handler { return promhttp.Handler() }
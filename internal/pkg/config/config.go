@@ -12,14 +12,15 @@ import (
 
 // Config is the root application configuration loaded from YAML/env.
 type Config struct {
-	Telegram   TelegramConfig   `mapstructure:"telegram"`
-	GRPC       GRPCConfig       `mapstructure:"grpc"`
-	Database   DatabaseConfig   `mapstructure:"database"`
-	Logging    LoggingConfig    `mapstructure:"logging"`
-	Metrics    MetricsConfig    `mapstructure:"metrics"`
-	Server     ServerConfig     `mapstructure:"server"`
-	OAuth      OAuthConfig      `mapstructure:"oauth"`
-	OpenRouter OpenRouterConfig `mapstructure:"openrouter"`
+	Telegram     TelegramConfig     `mapstructure:"telegram"`
+	GRPC         GRPCConfig         `mapstructure:"grpc"`
+	Database     DatabaseConfig     `mapstructure:"database"`
+	Logging      LoggingConfig      `mapstructure:"logging"`
+	Metrics      MetricsConfig      `mapstructure:"metrics"`
+	Server       ServerConfig       `mapstructure:"server"`
+	OAuth        OAuthConfig        `mapstructure:"oauth"`
+	OpenRouter   OpenRouterConfig   `mapstructure:"openrouter"`
+	SpeechToText SpeechToTextConfig `mapstructure:"speech_to_text"`
 }
 
 // TelegramConfig holds Telegram Bot API settings.
@@ -99,6 +100,15 @@ type OpenRouterConfig struct {
 	Timeout time.Duration `mapstructure:"timeout"`
 }
 
+// SpeechToTextConfig holds voice message recognition settings.
+type SpeechToTextConfig struct {
+	Enable  bool          `mapstructure:"enable"`
+	APIKey  string        `mapstructure:"api_key"`
+	Model   string        `mapstructure:"model"`
+	BaseURL string        `mapstructure:"base_url"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
 // Load loads configuration from configs/config.yaml and environment variables.
 func Load() (*Config, error) {
 	v := viper.New()
@@ -124,6 +134,10 @@ func Load() (*Config, error) {
 	v.SetDefault("openrouter.enable", false)
 	v.SetDefault("openrouter.base_url", "https://openrouter.ai/api/v1")
 	v.SetDefault("openrouter.timeout", "10s")
+	v.SetDefault("speech_to_text.enable", false)
+	v.SetDefault("speech_to_text.base_url", "https://api.openai.com/v1")
+	v.SetDefault("speech_to_text.model", "whisper-1")
+	v.SetDefault("speech_to_text.timeout", "30s")
 
 	// Files
 	v.SetConfigName("config")
@@ -162,6 +176,11 @@ func Load() (*Config, error) {
 	_ = v.BindEnv("openrouter.model", "OPENROUTER_MODEL")
 	_ = v.BindEnv("openrouter.base_url", "OPENROUTER_BASE_URL")
 	_ = v.BindEnv("openrouter.timeout", "OPENROUTER_TIMEOUT")
+	_ = v.BindEnv("speech_to_text.enable", "SPEECH_TO_TEXT_ENABLE")
+	_ = v.BindEnv("speech_to_text.api_key", "SPEECH_TO_TEXT_API_KEY")
+	_ = v.BindEnv("speech_to_text.model", "SPEECH_TO_TEXT_MODEL")
+	_ = v.BindEnv("speech_to_text.base_url", "SPEECH_TO_TEXT_BASE_URL")
+	_ = v.BindEnv("speech_to_text.timeout", "SPEECH_TO_TEXT_TIMEOUT")
 
 	// Read file if present
 	if err := v.ReadInConfig(); err != nil {
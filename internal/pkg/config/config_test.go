@@ -36,3 +36,24 @@ func TestLoad_DefaultsAndEnvOverride(t *testing.T) {
 		t.Fatalf("openrouter model env override not applied: %s", cfg.OpenRouter.Model)
 	}
 }
+
+func TestLoad_SpeechToText(t *testing.T) {
+	_ = os.Unsetenv("SPEECH_TO_TEXT_ENABLE")
+	_ = os.Unsetenv("SPEECH_TO_TEXT_MODEL")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.SpeechToText.Enable || cfg.SpeechToText.Model != "whisper-1" || cfg.SpeechToText.Timeout.Seconds() != 30 {
+		t.Fatalf("unexpected speech-to-text defaults: %+v", cfg.SpeechToText)
+	}
+	t.Setenv("SPEECH_TO_TEXT_ENABLE", "true")
+	t.Setenv("SPEECH_TO_TEXT_BASE_URL", "http://127.0.0.1:9000/v1")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if !cfg.SpeechToText.Enable || cfg.SpeechToText.BaseURL != "http://127.0.0.1:9000/v1" {
+		t.Fatalf("speech-to-text env override not applied: %+v", cfg.SpeechToText)
+	}
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Budget periods.
+const (
+	BudgetPeriodMonth = "month"
+	BudgetPeriodWeek  = "week"
+)
+
+// Budget is a spending limit for one expense category of a tenant.
+type Budget struct {
+	TenantID     string
+	CategoryID   string
+	CategoryName string
+	AmountMinor  int64
+	Currency     string
+	Period       string
+	CreatedBy    int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// BudgetRepository stores category budgets.
+type BudgetRepository interface {
+	Upsert(ctx context.Context, b *Budget) error
+	Get(ctx context.Context, tenantID, categoryID string) (*Budget, error)
+	ListByTenant(ctx context.Context, tenantID string) ([]*Budget, error)
+	Delete(ctx context.Context, tenantID, categoryID string) error
+}
+
+// SQLiteBudgetRepository implements BudgetRepository over SQLite.
+type SQLiteBudgetRepository struct{ db *sql.DB }
+
+// NewSQLiteBudgetRepository constructs a repository.
+func NewSQLiteBudgetRepository(db *sql.DB) *SQLiteBudgetRepository {
+	return &SQLiteBudgetRepository{db: db}
+}
+
+const budgetColumns = `tenant_id, category_id, category_name, amount_minor, currency, period, created_by, created_at, updated_at`
+
+func scanBudget(s rowScanner) (*Budget, error) {
+	var b Budget
+	if err := s.Scan(&b.TenantID, &b.CategoryID, &b.CategoryName, &b.AmountMinor, &b.Currency, &b.Period, &b.CreatedBy, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Upsert creates or replaces the budget of a category.
+func (r *SQLiteBudgetRepository) Upsert(ctx context.Context, b *Budget) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO budgets (tenant_id, category_id, category_name, amount_minor, currency, period, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(tenant_id, category_id) DO UPDATE SET
+			category_name = excluded.category_name,
+			amount_minor = excluded.amount_minor,
+			currency = excluded.currency,
+			period = excluded.period,
+			updated_at = CURRENT_TIMESTAMP
+	`, b.TenantID, b.CategoryID, b.CategoryName, b.AmountMinor, b.Currency, b.Period, b.CreatedBy)
+	return err
+}
+
+// Get returns the budget of a category.
+func (r *SQLiteBudgetRepository) Get(ctx context.Context, tenantID, categoryID string) (*Budget, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+budgetColumns+` FROM budgets WHERE tenant_id = ? AND category_id = ?`, tenantID, categoryID)
+	return scanBudget(row)
+}
+
+// ListByTenant returns all budgets of a tenant ordered by category name.
+func (r *SQLiteBudgetRepository) ListByTenant(ctx context.Context, tenantID string) ([]*Budget, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+budgetColumns+` FROM budgets WHERE tenant_id = ? ORDER BY category_name`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []*Budget
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// Delete removes the budget of a category.
+func (r *SQLiteBudgetRepository) Delete(ctx context.Context, tenantID, categoryID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE tenant_id = ? AND category_id = ?`, tenantID, categoryID)
+	return err
+}
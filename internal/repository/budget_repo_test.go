@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"budget-bot/internal/testutil"
+)
+
+func TestBudgetRepository_UpsertListDelete(t *testing.T) {
+	db := testutil.OpenMigratedSQLite(t)
+	r := NewSQLiteBudgetRepository(db)
+	ctx := context.Background()
+
+	if err := r.Upsert(ctx, &Budget{TenantID: "t", CategoryID: "cat-food", CategoryName: "Питание", AmountMinor: 1000000, Currency: "RUB", Period: BudgetPeriodMonth, CreatedBy: 1}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := r.Upsert(ctx, &Budget{TenantID: "t", CategoryID: "cat-food", CategoryName: "Питание", AmountMinor: 500000, Currency: "RUB", Period: BudgetPeriodWeek, CreatedBy: 2}); err != nil {
+		t.Fatalf("upsert again: %v", err)
+	}
+	if err := r.Upsert(ctx, &Budget{TenantID: "other", CategoryID: "cat-home", CategoryName: "Дом", AmountMinor: 1, Currency: "RUB", Period: BudgetPeriodMonth, CreatedBy: 3}); err != nil {
+		t.Fatalf("upsert other tenant: %v", err)
+	}
+	got, err := r.Get(ctx, "t", "cat-food")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.AmountMinor != 500000 || got.Period != BudgetPeriodWeek || got.CreatedBy != 1 {
+		t.Fatalf("unexpected budget: %+v", got)
+	}
+	list, err := r.ListByTenant(ctx, "t")
+	if err != nil || len(list) != 1 {
+		t.Fatalf("expected 1 budget, got %d (%v)", len(list), err)
+	}
+	if err := r.Delete(ctx, "t", "cat-food"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := r.Get(ctx, "t", "cat-food"); err == nil {
+		t.Fatalf("budget should be deleted")
+	}
+}
@@ -26,6 +26,20 @@ const (
 
 	// StateWaitingForCategory when user chooses a category
 	StateWaitingForCategory DialogState = "waiting_for_category"
+	// StateWaitingForEditAmount when user enters a new amount for a saved transaction
+	StateWaitingForEditAmount DialogState = "waiting_for_edit_amount"
+	// StateWaitingForEditDate when user enters a new date for a saved transaction
+	StateWaitingForEditDate DialogState = "waiting_for_edit_date"
+	// StateWaitingForEditComment when user enters a new comment for a saved transaction
+	StateWaitingForEditComment DialogState = "waiting_for_edit_comment"
+	// StateWaitingForDraftAmount when user corrects the amount of a transaction held for confirmation
+	StateWaitingForDraftAmount DialogState = "waiting_for_draft_amount"
+	// StateWaitingForImportFile when user is expected to upload a CSV file
+	StateWaitingForImportFile DialogState = "waiting_for_import_file"
+	// StateConfiguringImport when user adjusts CSV column mapping before commit
+	StateConfiguringImport DialogState = "configuring_import"
+	// StateConfirmingMemberChange when a tenant admin is asked to confirm removing or demoting a member
+	StateConfirmingMemberChange DialogState = "confirming_member_change"
 	// OAuth States
 	StateWaitingForOAuthEmail DialogState = "waiting_for_oauth_email"
 	StateWaitingForOAuthCode DialogState = "waiting_for_oauth_code"
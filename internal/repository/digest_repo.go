@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Digest frequencies; a user has at most one subscription per frequency.
+const (
+	DigestDaily   = "daily"
+	DigestWeekly  = "weekly"
+	DigestMonthly = "monthly"
+)
+
+// DigestSubscription is a periodic push of a spending summary.
+type DigestSubscription struct {
+	TelegramID int64
+	ChatID     int64
+	Frequency  string
+	Schedule   string
+	Timezone   string
+	NextRunAt  time.Time
+	LastSentAt *time.Time
+}
+
+// DigestRepository stores digest subscriptions.
+type DigestRepository interface {
+	Upsert(ctx context.Context, s *DigestSubscription) error
+	ListByUser(ctx context.Context, telegramID int64) ([]*DigestSubscription, error)
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*DigestSubscription, error)
+	Delete(ctx context.Context, telegramID int64, frequency string) error
+	AdvanceNextRun(ctx context.Context, telegramID int64, frequency string, from, to time.Time) (bool, error)
+	MarkSent(ctx context.Context, telegramID int64, frequency string, sentAt time.Time) error
+}
+
+// SQLiteDigestRepository implements DigestRepository over SQLite.
+// Times are stored as unix seconds, like recurring rules.
+type SQLiteDigestRepository struct{ db *sql.DB }
+
+// NewSQLiteDigestRepository constructs a repository.
+func NewSQLiteDigestRepository(db *sql.DB) *SQLiteDigestRepository {
+	return &SQLiteDigestRepository{db: db}
+}
+
+const digestColumns = `telegram_id, chat_id, frequency, schedule, timezone, next_run_at, last_sent_at`
+
+func scanDigest(s rowScanner) (*DigestSubscription, error) {
+	var d DigestSubscription
+	var nextRun int64
+	var lastSent sql.NullInt64
+	if err := s.Scan(&d.TelegramID, &d.ChatID, &d.Frequency, &d.Schedule, &d.Timezone, &nextRun, &lastSent); err != nil {
+		return nil, err
+	}
+	d.NextRunAt = time.Unix(nextRun, 0)
+	if lastSent.Valid {
+		t := time.Unix(lastSent.Int64, 0)
+		d.LastSentAt = &t
+	}
+	return &d, nil
+}
+
+func (r *SQLiteDigestRepository) query(ctx context.Context, query string, args ...any) ([]*DigestSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []*DigestSubscription
+	for rows.Next() {
+		d, err := scanDigest(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// Upsert creates or replaces the subscription of the given frequency.
+func (r *SQLiteDigestRepository) Upsert(ctx context.Context, s *DigestSubscription) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO digest_subscriptions (telegram_id, chat_id, frequency, schedule, timezone, next_run_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(telegram_id, frequency) DO UPDATE SET
+			chat_id = excluded.chat_id,
+			schedule = excluded.schedule,
+			timezone = excluded.timezone,
+			next_run_at = excluded.next_run_at
+	`, s.TelegramID, s.ChatID, s.Frequency, s.Schedule, s.Timezone, s.NextRunAt.Unix())
+	return err
+}
+
+// ListByUser returns subscriptions of a user.
+func (r *SQLiteDigestRepository) ListByUser(ctx context.Context, telegramID int64) ([]*DigestSubscription, error) {
+	return r.query(ctx, `SELECT `+digestColumns+` FROM digest_subscriptions WHERE telegram_id = ? ORDER BY next_run_at`, telegramID)
+}
+
+// ListDue returns subscriptions whose next run is not after now, oldest first.
+func (r *SQLiteDigestRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*DigestSubscription, error) {
+	return r.query(ctx, `SELECT `+digestColumns+` FROM digest_subscriptions WHERE next_run_at <= ? ORDER BY next_run_at LIMIT ?`, now.Unix(), limit)
+}
+
+// Delete removes a subscription.
+func (r *SQLiteDigestRepository) Delete(ctx context.Context, telegramID int64, frequency string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM digest_subscriptions WHERE telegram_id = ? AND frequency = ?`, telegramID, frequency)
+	return err
+}
+
+// AdvanceNextRun moves the next run from one occurrence to another.
+// It reports false if another worker has already advanced the subscription.
+func (r *SQLiteDigestRepository) AdvanceNextRun(ctx context.Context, telegramID int64, frequency string, from, to time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE digest_subscriptions SET next_run_at = ? WHERE telegram_id = ? AND frequency = ? AND next_run_at = ?`, to.Unix(), telegramID, frequency, from.Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// MarkSent records the time of the last delivered digest.
+func (r *SQLiteDigestRepository) MarkSent(ctx context.Context, telegramID int64, frequency string, sentAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE digest_subscriptions SET last_sent_at = ? WHERE telegram_id = ? AND frequency = ?`, sentAt.Unix(), telegramID, frequency)
+	return err
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"budget-bot/internal/testutil"
+)
+
+func TestDigestRepository(t *testing.T) {
+	db := testutil.OpenMigratedSQLite(t)
+	r := NewSQLiteDigestRepository(db)
+	ctx := context.Background()
+	next := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	s := &DigestSubscription{TelegramID: 1, ChatID: 10, Frequency: DigestWeekly, Schedule: "weekly mon 09:00", Timezone: "UTC", NextRunAt: next}
+	if err := r.Upsert(ctx, s); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	s.Schedule, s.NextRunAt = "weekly fri 18:00", next.Add(4*24*time.Hour+9*time.Hour)
+	if err := r.Upsert(ctx, s); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if err := r.Upsert(ctx, &DigestSubscription{TelegramID: 1, ChatID: 10, Frequency: DigestDaily, Schedule: "daily 21:00", Timezone: "UTC", NextRunAt: next.Add(12 * time.Hour)}); err != nil {
+		t.Fatalf("upsert daily: %v", err)
+	}
+	list, err := r.ListByUser(ctx, 1)
+	if err != nil || len(list) != 2 || list[0].Frequency != DigestDaily || list[1].Schedule != "weekly fri 18:00" || list[1].LastSentAt != nil {
+		t.Fatalf("unexpected list: %+v %v", list, err)
+	}
+
+	due, err := r.ListDue(ctx, next.Add(12*time.Hour), 10)
+	if err != nil || len(due) != 1 || due[0].Frequency != DigestDaily {
+		t.Fatalf("unexpected due: %+v %v", due, err)
+	}
+	from := due[0].NextRunAt
+	if ok, _ := r.AdvanceNextRun(ctx, 1, DigestDaily, from, from.AddDate(0, 0, 1)); !ok {
+		t.Fatalf("advance failed")
+	}
+	if ok, _ := r.AdvanceNextRun(ctx, 1, DigestDaily, from, from.AddDate(0, 0, 1)); ok {
+		t.Fatalf("stale advance must be ignored")
+	}
+	if err := r.MarkSent(ctx, 1, DigestDaily, from); err != nil {
+		t.Fatalf("mark sent: %v", err)
+	}
+	list, _ = r.ListByUser(ctx, 1)
+	if list[0].LastSentAt == nil || !list[0].LastSentAt.Equal(from) {
+		t.Fatalf("last sent not stored: %+v", list[0])
+	}
+
+	if err := r.Delete(ctx, 1, DigestWeekly); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if list, _ := r.ListByUser(ctx, 1); len(list) != 1 {
+		t.Fatalf("expected one subscription left, got %d", len(list))
+	}
+}
@@ -17,6 +17,10 @@ type TransactionDraft struct {
     Description string
     CategoryID  string
     OccurredAt  *time.Time
+    // OpID is the operation context of a saved transaction whose new amount waits for confirmation, empty for a new transaction
+    OpID        string
+    // BatchID is the multi-line message the held transaction came from, empty for a single transaction
+    BatchID     string
     CreatedAt   time.Time
 }
 
@@ -25,6 +29,7 @@ type DraftRepository interface {
     Create(ctx context.Context, d *TransactionDraft) error
     Get(ctx context.Context, id string) (*TransactionDraft, error)
     Delete(ctx context.Context, id string) error
+    DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error)
 }
 
 // SQLiteDraftRepository implements DraftRepository over SQLite.
@@ -35,15 +40,15 @@ func NewSQLiteDraftRepository(db *sql.DB) *SQLiteDraftRepository { return &SQLit
 
 // Create inserts a new draft row.
 func (r *SQLiteDraftRepository) Create(ctx context.Context, d *TransactionDraft) error {
-    _, err := r.db.ExecContext(ctx, `INSERT INTO transaction_drafts (id, telegram_id, type, amount_minor, currency, description, category_id, occurred_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, d.ID, d.TelegramID, d.Type, d.AmountMinor, d.Currency, d.Description, d.CategoryID, d.OccurredAt)
+    _, err := r.db.ExecContext(ctx, `INSERT INTO transaction_drafts (id, telegram_id, type, amount_minor, currency, description, category_id, occurred_at, op_id, batch_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, d.ID, d.TelegramID, d.Type, d.AmountMinor, d.Currency, d.Description, d.CategoryID, d.OccurredAt, d.OpID, d.BatchID)
     return err
 }
 
 // Get fetches a draft by id.
 func (r *SQLiteDraftRepository) Get(ctx context.Context, id string) (*TransactionDraft, error) {
-    row := r.db.QueryRowContext(ctx, `SELECT id, telegram_id, type, amount_minor, currency, description, category_id, occurred_at, created_at FROM transaction_drafts WHERE id = ?`, id)
+    row := r.db.QueryRowContext(ctx, `SELECT id, telegram_id, type, amount_minor, currency, description, category_id, occurred_at, op_id, batch_id, created_at FROM transaction_drafts WHERE id = ?`, id)
     var d TransactionDraft
-    if err := row.Scan(&d.ID, &d.TelegramID, &d.Type, &d.AmountMinor, &d.Currency, &d.Description, &d.CategoryID, &d.OccurredAt, &d.CreatedAt); err != nil {
+    if err := row.Scan(&d.ID, &d.TelegramID, &d.Type, &d.AmountMinor, &d.Currency, &d.Description, &d.CategoryID, &d.OccurredAt, &d.OpID, &d.BatchID, &d.CreatedAt); err != nil {
         return nil, err
     }
     return &d, nil
@@ -55,4 +60,12 @@ func (r *SQLiteDraftRepository) Delete(ctx context.Context, id string) error {
     return err
 }
 
-
+// DeleteCreatedBefore removes drafts created before the given time and returns how many were removed.
+func (r *SQLiteDraftRepository) DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error) {
+    // created_at is filled by SQLite with CURRENT_TIMESTAMP, i.e. UTC text
+    res, err := r.db.ExecContext(ctx, `DELETE FROM transaction_drafts WHERE created_at < ?`, before.UTC().Format("2006-01-02 15:04:05"))
+    if err != nil {
+        return 0, err
+    }
+    return res.RowsAffected()
+}
@@ -14,11 +14,35 @@ func TestSQLiteDraftRepository_CRUD(t *testing.T) {
 	ctx := context.Background()
 	id := "d1"
 	now := time.Now()
-	d := &TransactionDraft{ID: id, TelegramID: 5, Type: "expense", AmountMinor: 123, Currency: "RUB", Description: "t", CategoryID: "c", OccurredAt: &now}
+	d := &TransactionDraft{ID: id, TelegramID: 5, Type: "expense", AmountMinor: 123, Currency: "RUB", Description: "t", CategoryID: "c", OccurredAt: &now, OpID: "op-1", BatchID: "b-1"}
 	if err := repo.Create(ctx, d); err != nil { t.Fatalf("create: %v", err) }
 	got, err := repo.Get(ctx, id)
 	if err != nil { t.Fatalf("get: %v", err) }
-	if got == nil || got.ID != id || got.AmountMinor != 123 { t.Fatalf("unexpected: %+v", got) }
+	if got == nil || got.ID != id || got.AmountMinor != 123 || got.OpID != "op-1" || got.BatchID != "b-1" { t.Fatalf("unexpected: %+v", got) }
 	if err := repo.Delete(ctx, id); err != nil { t.Fatalf("delete: %v", err) }
 	if _, err := repo.Get(ctx, id); err == nil { t.Fatalf("expected error after delete") }
 }
+
+func TestSQLiteDraftRepository_DeleteCreatedBefore(t *testing.T) {
+	db := testutil.OpenMigratedSQLite(t)
+	repo := NewSQLiteDraftRepository(db)
+	ctx := context.Background()
+	for _, id := range []string{"old", "new"} {
+		if err := repo.Create(ctx, &TransactionDraft{ID: id, TelegramID: 5, Type: "expense", AmountMinor: 100, Currency: "RUB"}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE transaction_drafts SET created_at = datetime('now', '-2 days') WHERE id = 'old'`); err != nil {
+		t.Fatalf("age draft: %v", err)
+	}
+	n, err := repo.DeleteCreatedBefore(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("delete: %d %v", n, err)
+	}
+	if _, err := repo.Get(ctx, "old"); err == nil {
+		t.Fatalf("old draft must be removed")
+	}
+	if _, err := repo.Get(ctx, "new"); err != nil {
+		t.Fatalf("new draft must be kept: %v", err)
+	}
+}
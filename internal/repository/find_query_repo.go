@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// FindQuery is a /find query kept for the page buttons of its result.
+type FindQuery struct {
+	ID         string
+	TelegramID int64
+	Query      string
+	CreatedAt  time.Time
+}
+
+// FindQueryRepository stores /find queries.
+type FindQueryRepository interface {
+	Create(ctx context.Context, q *FindQuery) error
+	Get(ctx context.Context, id string) (*FindQuery, error)
+	DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// SQLiteFindQueryRepository implements FindQueryRepository over SQLite.
+type SQLiteFindQueryRepository struct{ db *sql.DB }
+
+// NewSQLiteFindQueryRepository constructs a repository.
+func NewSQLiteFindQueryRepository(db *sql.DB) *SQLiteFindQueryRepository {
+	return &SQLiteFindQueryRepository{db: db}
+}
+
+// Create inserts a query.
+func (r *SQLiteFindQueryRepository) Create(ctx context.Context, q *FindQuery) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO find_queries (id, telegram_id, query) VALUES (?, ?, ?)`, q.ID, q.TelegramID, q.Query)
+	return err
+}
+
+// Get fetches a query by id; sql.ErrNoRows if it is unknown or already removed.
+func (r *SQLiteFindQueryRepository) Get(ctx context.Context, id string) (*FindQuery, error) {
+	var q FindQuery
+	row := r.db.QueryRowContext(ctx, `SELECT id, telegram_id, query, created_at FROM find_queries WHERE id = ?`, id)
+	if err := row.Scan(&q.ID, &q.TelegramID, &q.Query, &q.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// DeleteCreatedBefore removes queries created before the given time and returns how many were removed.
+func (r *SQLiteFindQueryRepository) DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error) {
+	// created_at is filled by SQLite with CURRENT_TIMESTAMP, i.e. UTC text
+	res, err := r.db.ExecContext(ctx, `DELETE FROM find_queries WHERE created_at < ?`, before.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
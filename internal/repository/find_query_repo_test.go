@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"budget-bot/internal/testutil"
+)
+
+func TestSQLiteFindQueryRepository(t *testing.T) {
+	db := testutil.OpenMigratedSQLite(t)
+	repo := NewSQLiteFindQueryRepository(db)
+	ctx := context.Background()
+	for _, id := range []string{"old", "new"} {
+		if err := repo.Create(ctx, &FindQuery{ID: id, TelegramID: 5, Query: `кофе >500 cat:"Кафе и бары"`}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	q, err := repo.Get(ctx, "new")
+	if err != nil || q.TelegramID != 5 || q.Query != `кофе >500 cat:"Кафе и бары"` {
+		t.Fatalf("unexpected query: %+v %v", q, err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE find_queries SET created_at = datetime('now', '-8 days') WHERE id = 'old'`); err != nil {
+		t.Fatalf("age query: %v", err)
+	}
+	n, err := repo.DeleteCreatedBefore(ctx, time.Now().Add(-7*24*time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("delete: %d %v", n, err)
+	}
+	if _, err := repo.Get(ctx, "old"); err == nil {
+		t.Fatalf("old query must be removed")
+	}
+	if _, err := repo.Get(ctx, "new"); err != nil {
+		t.Fatalf("new query must be kept: %v", err)
+	}
+}
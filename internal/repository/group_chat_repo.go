@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// GroupChat binds a Telegram group to a tenant. Members' messages in the group are saved to that tenant
+// under each member's own session; Language and DefaultCurrency override personal preferences in the chat.
+type GroupChat struct {
+	ChatID          int64
+	TenantID        string
+	BoundBy         int64
+	Language        string
+	DefaultCurrency string
+}
+
+// GroupChatRepository stores group chat bindings and per-chat settings.
+type GroupChatRepository interface {
+	Bind(ctx context.Context, c *GroupChat) error
+	Get(ctx context.Context, chatID int64) (*GroupChat, error)
+	UpdateSettings(ctx context.Context, chatID int64, language, currency string) error
+	Unbind(ctx context.Context, chatID int64) error
+}
+
+// SQLiteGroupChatRepository implements GroupChatRepository over SQLite.
+type SQLiteGroupChatRepository struct{ db *sql.DB }
+
+// NewSQLiteGroupChatRepository constructs a repository.
+func NewSQLiteGroupChatRepository(db *sql.DB) *SQLiteGroupChatRepository {
+	return &SQLiteGroupChatRepository{db: db}
+}
+
+// Bind creates the binding or moves the chat to another tenant keeping its settings.
+func (r *SQLiteGroupChatRepository) Bind(ctx context.Context, c *GroupChat) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO group_chats (chat_id, tenant_id, bound_by, language, default_currency)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET
+			tenant_id = excluded.tenant_id,
+			bound_by = excluded.bound_by,
+			updated_at = CURRENT_TIMESTAMP
+	`, c.ChatID, c.TenantID, c.BoundBy, c.Language, c.DefaultCurrency)
+	return err
+}
+
+// Get returns the binding of a chat; sql.ErrNoRows if the chat is not bound.
+func (r *SQLiteGroupChatRepository) Get(ctx context.Context, chatID int64) (*GroupChat, error) {
+	var c GroupChat
+	row := r.db.QueryRowContext(ctx, `SELECT chat_id, tenant_id, bound_by, language, default_currency FROM group_chats WHERE chat_id = ?`, chatID)
+	if err := row.Scan(&c.ChatID, &c.TenantID, &c.BoundBy, &c.Language, &c.DefaultCurrency); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// UpdateSettings replaces the chat's language and default currency; empty values fall back to personal preferences.
+func (r *SQLiteGroupChatRepository) UpdateSettings(ctx context.Context, chatID int64, language, currency string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE group_chats SET language = ?, default_currency = ?, updated_at = CURRENT_TIMESTAMP WHERE chat_id = ?`, language, currency, chatID)
+	return err
+}
+
+// Unbind removes the binding.
+func (r *SQLiteGroupChatRepository) Unbind(ctx context.Context, chatID int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM group_chats WHERE chat_id = ?`, chatID)
+	return err
+}
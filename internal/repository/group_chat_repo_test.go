@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"budget-bot/internal/testutil"
+)
+
+func TestGroupChatRepository(t *testing.T) {
+	db := testutil.OpenMigratedSQLite(t)
+	r := NewSQLiteGroupChatRepository(db)
+	ctx := context.Background()
+
+	if _, err := r.Get(ctx, -100); err == nil {
+		t.Fatalf("unbound chat must return an error")
+	}
+	if err := r.Bind(ctx, &GroupChat{ChatID: -100, TenantID: "t1", BoundBy: 1}); err != nil {
+		t.Fatalf("bind: %v", err)
+	}
+	if err := r.UpdateSettings(ctx, -100, "en", "EUR"); err != nil {
+		t.Fatalf("settings: %v", err)
+	}
+	if err := r.Bind(ctx, &GroupChat{ChatID: -100, TenantID: "t2", BoundBy: 2}); err != nil {
+		t.Fatalf("rebind: %v", err)
+	}
+	c, err := r.Get(ctx, -100)
+	if err != nil || c.TenantID != "t2" || c.BoundBy != 2 || c.Language != "en" || c.DefaultCurrency != "EUR" {
+		t.Fatalf("rebinding must keep settings: %+v %v", c, err)
+	}
+	if err := r.Unbind(ctx, -100); err != nil {
+		t.Fatalf("unbind: %v", err)
+	}
+	if _, err := r.Get(ctx, -100); err == nil {
+		t.Fatalf("binding not removed")
+	}
+}
@@ -22,6 +22,7 @@ type OperationContext struct {
 	OccurredAt            *time.Time
 	CategoryListMessageID *int
 	ConfirmationMessageID *int
+	BatchID               *string
 	CreatedAt             time.Time
 	UpdatedAt             time.Time
 }
@@ -34,6 +35,8 @@ type OperationContextRepository interface {
 	SetTransactionID(ctx context.Context, opID, transactionID string) error
 	SetCategoryListMessageID(ctx context.Context, opID string, messageID int) error
 	SetConfirmationMessageID(ctx context.Context, opID string, messageID int) error
+	UpdateDetails(ctx context.Context, opID string, amountMinor int64, description string, occurredAt *time.Time) error
+	ListByBatch(ctx context.Context, batchID string) ([]*OperationContext, error)
 	Delete(ctx context.Context, opID string) error
 }
 
@@ -49,35 +52,61 @@ func (r *SQLiteOperationContextRepository) Create(ctx context.Context, op *Opera
 		op_id, telegram_id, tenant_id, transaction_id, description_original,
 		category_id_selected, category_name_selected, selection_source,
 		tx_type, amount_minor, currency, occurred_at,
-		category_list_message_id, confirmation_message_id
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		category_list_message_id, confirmation_message_id, batch_id
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		op.OpID, op.TelegramID, op.TenantID, op.TransactionID, op.DescriptionOriginal,
 		op.CategoryIDSelected, op.CategoryNameSelected, op.SelectionSource,
 		op.TxType, op.AmountMinor, op.Currency, op.OccurredAt,
-		op.CategoryListMessageID, op.ConfirmationMessageID,
+		op.CategoryListMessageID, op.ConfirmationMessageID, op.BatchID,
 	)
 	return err
 }
 
-func (r *SQLiteOperationContextRepository) Get(ctx context.Context, opID string) (*OperationContext, error) {
-	row := r.db.QueryRowContext(ctx, `SELECT
-		op_id, telegram_id, tenant_id, transaction_id, description_original,
+const operationContextColumns = `op_id, telegram_id, tenant_id, transaction_id, description_original,
 		category_id_selected, category_name_selected, selection_source,
 		tx_type, amount_minor, currency, occurred_at,
-		category_list_message_id, confirmation_message_id, created_at, updated_at
-	FROM operation_contexts WHERE op_id = ?`, opID)
+		category_list_message_id, confirmation_message_id, batch_id, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOperationContext(row rowScanner) (*OperationContext, error) {
 	var op OperationContext
 	if err := row.Scan(
 		&op.OpID, &op.TelegramID, &op.TenantID, &op.TransactionID, &op.DescriptionOriginal,
 		&op.CategoryIDSelected, &op.CategoryNameSelected, &op.SelectionSource,
 		&op.TxType, &op.AmountMinor, &op.Currency, &op.OccurredAt,
-		&op.CategoryListMessageID, &op.ConfirmationMessageID, &op.CreatedAt, &op.UpdatedAt,
+		&op.CategoryListMessageID, &op.ConfirmationMessageID, &op.BatchID, &op.CreatedAt, &op.UpdatedAt,
 	); err != nil {
 		return nil, err
 	}
 	return &op, nil
 }
 
+func (r *SQLiteOperationContextRepository) Get(ctx context.Context, opID string) (*OperationContext, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+operationContextColumns+` FROM operation_contexts WHERE op_id = ?`, opID)
+	return scanOperationContext(row)
+}
+
+// ListByBatch returns all operation contexts created by one multi-line message, in insertion order.
+func (r *SQLiteOperationContextRepository) ListByBatch(ctx context.Context, batchID string) ([]*OperationContext, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+operationContextColumns+` FROM operation_contexts WHERE batch_id = ? ORDER BY rowid`, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []*OperationContext
+	for rows.Next() {
+		op, err := scanOperationContext(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, op)
+	}
+	return out, rows.Err()
+}
+
 func (r *SQLiteOperationContextRepository) UpdateSelection(ctx context.Context, opID, categoryID, categoryName, source string) error {
 	_, err := r.db.ExecContext(ctx, `UPDATE operation_contexts SET category_id_selected = ?, category_name_selected = ?, selection_source = ?, updated_at = CURRENT_TIMESTAMP WHERE op_id = ?`, categoryID, categoryName, source, opID)
 	return err
@@ -98,6 +127,11 @@ func (r *SQLiteOperationContextRepository) SetConfirmationMessageID(ctx context.
 	return err
 }
 
+func (r *SQLiteOperationContextRepository) UpdateDetails(ctx context.Context, opID string, amountMinor int64, description string, occurredAt *time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE operation_contexts SET amount_minor = ?, description_original = ?, occurred_at = ?, updated_at = CURRENT_TIMESTAMP WHERE op_id = ?`, amountMinor, description, occurredAt, opID)
+	return err
+}
+
 func (r *SQLiteOperationContextRepository) Delete(ctx context.Context, opID string) error {
 	_, err := r.db.ExecContext(ctx, `DELETE FROM operation_contexts WHERE op_id = ?`, opID)
 	return err
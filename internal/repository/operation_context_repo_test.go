@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"testing"
+	"time"
 
 	"budget-bot/internal/testutil"
 )
@@ -39,3 +40,48 @@ func TestOperationContextRepository_CreateGetUpdate(t *testing.T) {
 		t.Fatalf("unexpected context: %+v", got)
 	}
 }
+
+func TestOperationContextRepository_UpdateDetails(t *testing.T) {
+	db := testutil.OpenMigratedSQLite(t)
+	r := NewSQLiteOperationContextRepository(db)
+	ctx := context.Background()
+
+	if err := r.Create(ctx, &OperationContext{OpID: "op-2", TelegramID: 1, TenantID: "tenant-1", DescriptionOriginal: "кофе", SelectionSource: "manual", TxType: "expense", AmountMinor: 100, Currency: "RUB"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	when := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+	if err := r.UpdateDetails(ctx, "op-2", 35000, "капучино", &when); err != nil {
+		t.Fatalf("update details: %v", err)
+	}
+	got, err := r.Get(ctx, "op-2")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.AmountMinor != 35000 || got.DescriptionOriginal != "капучино" || got.OccurredAt == nil || !got.OccurredAt.Equal(when) {
+		t.Fatalf("unexpected context: %+v", got)
+	}
+}
+
+func TestOperationContextRepository_ListByBatch(t *testing.T) {
+	db := testutil.OpenMigratedSQLite(t)
+	r := NewSQLiteOperationContextRepository(db)
+	ctx := context.Background()
+
+	batch := "batch-1"
+	for _, id := range []string{"op-a", "op-b"} {
+		if err := r.Create(ctx, &OperationContext{OpID: id, TelegramID: 1, TenantID: "t", DescriptionOriginal: id, SelectionSource: "manual", TxType: "expense", AmountMinor: 100, Currency: "RUB", BatchID: &batch}); err != nil {
+			t.Fatalf("create %s: %v", id, err)
+		}
+	}
+	if err := r.Create(ctx, &OperationContext{OpID: "op-single", TelegramID: 1, TenantID: "t", DescriptionOriginal: "x", SelectionSource: "manual", TxType: "expense", AmountMinor: 100, Currency: "RUB"}); err != nil {
+		t.Fatalf("create single: %v", err)
+	}
+
+	got, err := r.ListByBatch(ctx, batch)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(got) != 2 || got[0].OpID != "op-a" || got[1].OpID != "op-b" || got[0].BatchID == nil || *got[0].BatchID != batch {
+		t.Fatalf("unexpected batch: %+v", got)
+	}
+}
@@ -11,6 +11,12 @@ type UserPreferences struct {
 	TelegramID      int64
 	Language        string
 	DefaultCurrency string
+	// Timezone is an IANA name such as Europe/Moscow; empty means the server zone
+	Timezone string
+	// AmountCeilingMinor is the expense amount above which saving asks for confirmation; 0 means no ceiling.
+	// It is set with UpdateAmountCeiling only, SavePreferences leaves it unchanged.
+	AmountCeilingMinor    int64
+	AmountCeilingCurrency string
 }
 
 // PreferencesRepository defines CRUD for user preferences.
@@ -19,6 +25,8 @@ type PreferencesRepository interface {
 	GetPreferences(ctx context.Context, telegramID int64) (*UserPreferences, error)
 	UpdateLanguage(ctx context.Context, telegramID int64, language string) error
 	UpdateDefaultCurrency(ctx context.Context, telegramID int64, currency string) error
+	UpdateTimezone(ctx context.Context, telegramID int64, timezone string) error
+	UpdateAmountCeiling(ctx context.Context, telegramID int64, amountMinor int64, currency string) error
 }
 
 // SQLitePreferencesRepository implements PreferencesRepository over SQLite.
@@ -34,20 +42,21 @@ func NewSQLitePreferencesRepository(db *sql.DB) *SQLitePreferencesRepository {
 // SavePreferences upserts user preferences.
 func (r *SQLitePreferencesRepository) SavePreferences(ctx context.Context, p *UserPreferences) error {
 	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO user_preferences (telegram_id, language, default_currency)
-		VALUES (?, ?, ?)
+		INSERT INTO user_preferences (telegram_id, language, default_currency, timezone)
+		VALUES (?, ?, ?, ?)
 		ON CONFLICT(telegram_id) DO UPDATE SET
 			language = excluded.language,
-			default_currency = excluded.default_currency
-	`, p.TelegramID, p.Language, p.DefaultCurrency)
+			default_currency = excluded.default_currency,
+			timezone = excluded.timezone
+	`, p.TelegramID, p.Language, p.DefaultCurrency, p.Timezone)
 	return err
 }
 
 // GetPreferences returns preferences for a user.
 func (r *SQLitePreferencesRepository) GetPreferences(ctx context.Context, telegramID int64) (*UserPreferences, error) {
-	row := r.db.QueryRowContext(ctx, `SELECT telegram_id, language, default_currency FROM user_preferences WHERE telegram_id = ?`, telegramID)
+	row := r.db.QueryRowContext(ctx, `SELECT telegram_id, language, default_currency, timezone, amount_ceiling_minor, amount_ceiling_currency FROM user_preferences WHERE telegram_id = ?`, telegramID)
 	var p UserPreferences
-	if err := row.Scan(&p.TelegramID, &p.Language, &p.DefaultCurrency); err != nil {
+	if err := row.Scan(&p.TelegramID, &p.Language, &p.DefaultCurrency, &p.Timezone, &p.AmountCeilingMinor, &p.AmountCeilingCurrency); err != nil {
 		return nil, err
 	}
 	return &p, nil
@@ -65,4 +74,22 @@ func (r *SQLitePreferencesRepository) UpdateDefaultCurrency(ctx context.Context,
 	return err
 }
 
+// UpdateTimezone sets user's timezone, creating preferences if needed.
+func (r *SQLitePreferencesRepository) UpdateTimezone(ctx context.Context, telegramID int64, timezone string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_preferences (telegram_id, default_currency, timezone)
+		VALUES (?, '', ?)
+		ON CONFLICT(telegram_id) DO UPDATE SET timezone = excluded.timezone
+	`, telegramID, timezone)
+	return err
+}
 
+// UpdateAmountCeiling sets user's amount ceiling, creating preferences if needed; 0 removes the ceiling.
+func (r *SQLitePreferencesRepository) UpdateAmountCeiling(ctx context.Context, telegramID int64, amountMinor int64, currency string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_preferences (telegram_id, default_currency, amount_ceiling_minor, amount_ceiling_currency)
+		VALUES (?, '', ?, ?)
+		ON CONFLICT(telegram_id) DO UPDATE SET amount_ceiling_minor = excluded.amount_ceiling_minor, amount_ceiling_currency = excluded.amount_ceiling_currency
+	`, telegramID, amountMinor, currency)
+	return err
+}
@@ -21,3 +21,29 @@ func TestSQLitePreferencesRepository_CRUD(t *testing.T) {
 	got, _ = repo.GetPreferences(ctx, 77)
 	if got.Language != "ru" || got.DefaultCurrency != "RUB" { t.Fatalf("unexpected after upd: %+v", got) }
 }
+
+func TestSQLitePreferencesRepository_Timezone(t *testing.T) {
+	db := testutil.OpenMigratedSQLite(t)
+	repo := NewSQLitePreferencesRepository(db)
+	ctx := context.Background()
+	if err := repo.UpdateTimezone(ctx, 78, "Asia/Tokyo"); err != nil { t.Fatalf("create with timezone: %v", err) }
+	got, err := repo.GetPreferences(ctx, 78)
+	if err != nil || got.Timezone != "Asia/Tokyo" || got.Language != "ru" { t.Fatalf("unexpected: %+v %v", got, err) }
+	if err := repo.SavePreferences(ctx, &UserPreferences{TelegramID: 78, Language: "en", DefaultCurrency: "JPY", Timezone: got.Timezone}); err != nil { t.Fatalf("save: %v", err) }
+	if err := repo.UpdateTimezone(ctx, 78, "Europe/Moscow"); err != nil { t.Fatalf("update: %v", err) }
+	got, _ = repo.GetPreferences(ctx, 78)
+	if got.Timezone != "Europe/Moscow" || got.Language != "en" || got.DefaultCurrency != "JPY" { t.Fatalf("unexpected after update: %+v", got) }
+}
+
+func TestSQLitePreferencesRepository_AmountCeiling(t *testing.T) {
+	db := testutil.OpenMigratedSQLite(t)
+	repo := NewSQLitePreferencesRepository(db)
+	ctx := context.Background()
+	if err := repo.UpdateAmountCeiling(ctx, 79, 2000000, "RUB"); err != nil { t.Fatalf("create with ceiling: %v", err) }
+	if err := repo.SavePreferences(ctx, &UserPreferences{TelegramID: 79, Language: "en", DefaultCurrency: "RUB"}); err != nil { t.Fatalf("save: %v", err) }
+	got, err := repo.GetPreferences(ctx, 79)
+	if err != nil || got.AmountCeilingMinor != 2000000 || got.AmountCeilingCurrency != "RUB" || got.Language != "en" { t.Fatalf("saving preferences must keep the ceiling: %+v %v", got, err) }
+	if err := repo.UpdateAmountCeiling(ctx, 79, 0, ""); err != nil { t.Fatalf("clear: %v", err) }
+	got, _ = repo.GetPreferences(ctx, 79)
+	if got.AmountCeilingMinor != 0 || got.Language != "en" { t.Fatalf("unexpected after clear: %+v", got) }
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Recurring run statuses.
+const (
+	// RecurringRunPending means the occurrence is claimed and being processed
+	RecurringRunPending = "pending"
+	// RecurringRunAwaiting means the user has to confirm the occurrence
+	RecurringRunAwaiting = "awaiting"
+	// RecurringRunCreated means the transaction was created
+	RecurringRunCreated = "created"
+	// RecurringRunSkipped means the user skipped the occurrence
+	RecurringRunSkipped = "skipped"
+	// RecurringRunFailed means the transaction could not be created
+	RecurringRunFailed = "failed"
+)
+
+// RecurringRule is a transaction template repeated on a schedule.
+type RecurringRule struct {
+	ID           int64
+	TelegramID   int64
+	ChatID       int64
+	TenantID     string
+	Schedule     string
+	TxType       string
+	AmountMinor  int64
+	Currency     string
+	Description  string
+	CategoryID   *string
+	CategoryName *string
+	Confirm      bool
+	Paused       bool
+	NextRunAt    time.Time
+	CreatedAt    time.Time
+}
+
+// RecurringRun records the processing of one occurrence of a rule.
+type RecurringRun struct {
+	RuleID        int64
+	DueAt         time.Time
+	Status        string
+	TransactionID *string
+}
+
+// RecurringRepository stores recurring rules and their processed occurrences.
+type RecurringRepository interface {
+	Create(ctx context.Context, r *RecurringRule) (int64, error)
+	Get(ctx context.Context, id int64) (*RecurringRule, error)
+	ListByUser(ctx context.Context, telegramID int64) ([]*RecurringRule, error)
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*RecurringRule, error)
+	SetPaused(ctx context.Context, id int64, paused bool, nextRunAt time.Time) error
+	SetCategory(ctx context.Context, id int64, categoryID, categoryName string, nextRunAt time.Time) error
+	AdvanceNextRun(ctx context.Context, id int64, from, to time.Time) (bool, error)
+	Delete(ctx context.Context, id int64) error
+	ClaimRun(ctx context.Context, ruleID int64, dueAt time.Time) (bool, error)
+	GetRun(ctx context.Context, ruleID int64, dueAt time.Time) (*RecurringRun, error)
+	ListPendingRuns(ctx context.Context, updatedBefore time.Time) ([]*RecurringRun, error)
+	ListRuns(ctx context.Context, ruleID int64, from, to time.Time) ([]*RecurringRun, error)
+	UpdateRunStatus(ctx context.Context, ruleID int64, dueAt time.Time, from, to string, transactionID *string) (bool, error)
+}
+
+// SQLiteRecurringRepository implements RecurringRepository over SQLite.
+// Times are stored as unix seconds so that due rules can be selected with plain comparisons.
+type SQLiteRecurringRepository struct{ db *sql.DB }
+
+// NewSQLiteRecurringRepository constructs a repository.
+func NewSQLiteRecurringRepository(db *sql.DB) *SQLiteRecurringRepository {
+	return &SQLiteRecurringRepository{db: db}
+}
+
+const recurringColumns = `id, telegram_id, chat_id, tenant_id, schedule, tx_type, amount_minor, currency, description, category_id, category_name, confirm, paused, next_run_at, created_at`
+
+func scanRecurringRule(s rowScanner) (*RecurringRule, error) {
+	var r RecurringRule
+	var nextRun int64
+	if err := s.Scan(&r.ID, &r.TelegramID, &r.ChatID, &r.TenantID, &r.Schedule, &r.TxType, &r.AmountMinor, &r.Currency, &r.Description, &r.CategoryID, &r.CategoryName, &r.Confirm, &r.Paused, &nextRun, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	r.NextRunAt = time.Unix(nextRun, 0)
+	return &r, nil
+}
+
+func (r *SQLiteRecurringRepository) queryRules(ctx context.Context, query string, args ...any) ([]*RecurringRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []*RecurringRule
+	for rows.Next() {
+		rule, err := scanRecurringRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rule)
+	}
+	return out, rows.Err()
+}
+
+// Create inserts a rule and returns its id.
+func (r *SQLiteRecurringRepository) Create(ctx context.Context, rule *RecurringRule) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO recurring_transactions (telegram_id, chat_id, tenant_id, schedule, tx_type, amount_minor, currency, description, category_id, category_name, confirm, paused, next_run_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, rule.TelegramID, rule.ChatID, rule.TenantID, rule.Schedule, rule.TxType, rule.AmountMinor, rule.Currency, rule.Description, rule.CategoryID, rule.CategoryName, rule.Confirm, rule.Paused, rule.NextRunAt.Unix())
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	rule.ID = id
+	return id, nil
+}
+
+// Get fetches a rule by id.
+func (r *SQLiteRecurringRepository) Get(ctx context.Context, id int64) (*RecurringRule, error) {
+	return scanRecurringRule(r.db.QueryRowContext(ctx, `SELECT `+recurringColumns+` FROM recurring_transactions WHERE id = ?`, id))
+}
+
+// ListByUser returns rules of a user ordered by id.
+func (r *SQLiteRecurringRepository) ListByUser(ctx context.Context, telegramID int64) ([]*RecurringRule, error) {
+	return r.queryRules(ctx, `SELECT `+recurringColumns+` FROM recurring_transactions WHERE telegram_id = ? ORDER BY id`, telegramID)
+}
+
+// ListDue returns active rules with a category whose next run is not after now, oldest first.
+func (r *SQLiteRecurringRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*RecurringRule, error) {
+	return r.queryRules(ctx, `SELECT `+recurringColumns+` FROM recurring_transactions WHERE paused = 0 AND category_id IS NOT NULL AND next_run_at <= ? ORDER BY next_run_at LIMIT ?`, now.Unix(), limit)
+}
+
+// SetPaused pauses or resumes a rule; nextRunAt replaces the schedule position.
+func (r *SQLiteRecurringRepository) SetPaused(ctx context.Context, id int64, paused bool, nextRunAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE recurring_transactions SET paused = ?, next_run_at = ? WHERE id = ?`, paused, nextRunAt.Unix(), id)
+	return err
+}
+
+// SetCategory binds a category to a rule; nextRunAt replaces the schedule position.
+func (r *SQLiteRecurringRepository) SetCategory(ctx context.Context, id int64, categoryID, categoryName string, nextRunAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE recurring_transactions SET category_id = ?, category_name = ?, next_run_at = ? WHERE id = ?`, categoryID, categoryName, nextRunAt.Unix(), id)
+	return err
+}
+
+// AdvanceNextRun moves the next run from one occurrence to the following one.
+// It reports false if another worker has already advanced the rule.
+func (r *SQLiteRecurringRepository) AdvanceNextRun(ctx context.Context, id int64, from, to time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE recurring_transactions SET next_run_at = ? WHERE id = ? AND next_run_at = ?`, to.Unix(), id, from.Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// Delete removes a rule and its run history.
+func (r *SQLiteRecurringRepository) Delete(ctx context.Context, id int64) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM recurring_runs WHERE rule_id = ?`, id); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `DELETE FROM recurring_transactions WHERE id = ?`, id)
+	return err
+}
+
+// ClaimRun records an occurrence as pending. It reports false if the occurrence was already claimed,
+// which makes processing idempotent across restarts.
+func (r *SQLiteRecurringRepository) ClaimRun(ctx context.Context, ruleID int64, dueAt time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `INSERT OR IGNORE INTO recurring_runs (rule_id, due_at, status) VALUES (?, ?, ?)`, ruleID, dueAt.Unix(), RecurringRunPending)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// GetRun fetches a processed occurrence.
+func (r *SQLiteRecurringRepository) GetRun(ctx context.Context, ruleID int64, dueAt time.Time) (*RecurringRun, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT rule_id, due_at, status, transaction_id FROM recurring_runs WHERE rule_id = ? AND due_at = ?`, ruleID, dueAt.Unix())
+	var run RecurringRun
+	var due int64
+	if err := row.Scan(&run.RuleID, &due, &run.Status, &run.TransactionID); err != nil {
+		return nil, err
+	}
+	run.DueAt = time.Unix(due, 0)
+	return &run, nil
+}
+
+// ListRuns returns the processed occurrences of a rule due in [from, to), oldest first.
+func (r *SQLiteRecurringRepository) ListRuns(ctx context.Context, ruleID int64, from, to time.Time) ([]*RecurringRun, error) {
+	return r.queryRuns(ctx, `SELECT rule_id, due_at, status, transaction_id FROM recurring_runs WHERE rule_id = ? AND due_at >= ? AND due_at < ? ORDER BY due_at`, ruleID, from.Unix(), to.Unix())
+}
+
+// ListPendingRuns returns occurrences that were claimed before updatedBefore and are still pending, i.e. whose
+// processing was interrupted, oldest first.
+func (r *SQLiteRecurringRepository) ListPendingRuns(ctx context.Context, updatedBefore time.Time) ([]*RecurringRun, error) {
+	return r.queryRuns(ctx, `SELECT rule_id, due_at, status, transaction_id FROM recurring_runs WHERE status = ? AND updated_at < ? ORDER BY due_at`, RecurringRunPending, updatedBefore.UTC().Format("2006-01-02 15:04:05"))
+}
+
+func (r *SQLiteRecurringRepository) queryRuns(ctx context.Context, query string, args ...any) ([]*RecurringRun, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var out []*RecurringRun
+	for rows.Next() {
+		var run RecurringRun
+		var due int64
+		if err := rows.Scan(&run.RuleID, &due, &run.Status, &run.TransactionID); err != nil {
+			return nil, err
+		}
+		run.DueAt = time.Unix(due, 0)
+		out = append(out, &run)
+	}
+	return out, rows.Err()
+}
+
+// UpdateRunStatus switches an occurrence from one status to another.
+// It reports false if the occurrence is not in the expected status.
+func (r *SQLiteRecurringRepository) UpdateRunStatus(ctx context.Context, ruleID int64, dueAt time.Time, from, to string, transactionID *string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE recurring_runs SET status = ?, transaction_id = COALESCE(?, transaction_id), updated_at = CURRENT_TIMESTAMP
+		WHERE rule_id = ? AND due_at = ? AND status = ?
+	`, to, transactionID, ruleID, dueAt.Unix(), from)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
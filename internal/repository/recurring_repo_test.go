@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"budget-bot/internal/testutil"
+)
+
+func TestRecurringRepository_RulesAndRuns(t *testing.T) {
+	db := testutil.OpenMigratedSQLite(t)
+	r := NewSQLiteRecurringRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)
+
+	id, err := r.Create(ctx, &RecurringRule{TelegramID: 1, ChatID: 10, TenantID: "t", Schedule: "monthly 5 09:00", TxType: "expense", AmountMinor: 5000000, Currency: "RUB", Description: "аренда", NextRunAt: now})
+	if err != nil || id == 0 {
+		t.Fatalf("create: %d %v", id, err)
+	}
+	if due, _ := r.ListDue(ctx, now, 10); len(due) != 0 {
+		t.Fatalf("rule without category must not be due")
+	}
+	if err := r.SetCategory(ctx, id, "cat-home", "Дом", now); err != nil {
+		t.Fatalf("set category: %v", err)
+	}
+	due, err := r.ListDue(ctx, now, 10)
+	if err != nil || len(due) != 1 || !due[0].NextRunAt.Equal(now) || *due[0].CategoryName != "Дом" {
+		t.Fatalf("unexpected due rules: %+v %v", due, err)
+	}
+	if due, _ := r.ListDue(ctx, now.Add(-time.Second), 10); len(due) != 0 {
+		t.Fatalf("rule must not be due before next_run_at")
+	}
+
+	claimed, err := r.ClaimRun(ctx, id, now)
+	if err != nil || !claimed {
+		t.Fatalf("first claim: %v %v", claimed, err)
+	}
+	if claimed, _ := r.ClaimRun(ctx, id, now); claimed {
+		t.Fatalf("second claim must be ignored")
+	}
+	if runs, err := r.ListPendingRuns(ctx, time.Now().Add(time.Minute)); err != nil || len(runs) != 1 || runs[0].Status != RecurringRunPending {
+		t.Fatalf("unexpected pending runs: %+v %v", runs, err)
+	}
+	if runs, _ := r.ListPendingRuns(ctx, time.Now().Add(-time.Minute)); len(runs) != 0 {
+		t.Fatalf("runs claimed after the cutoff must be left out: %+v", runs)
+	}
+	txID := "tx-1"
+	if ok, _ := r.UpdateRunStatus(ctx, id, now, RecurringRunAwaiting, RecurringRunCreated, &txID); ok {
+		t.Fatalf("status switch from a wrong status must fail")
+	}
+	if ok, err := r.UpdateRunStatus(ctx, id, now, RecurringRunPending, RecurringRunCreated, &txID); !ok || err != nil {
+		t.Fatalf("status switch: %v %v", ok, err)
+	}
+	if runs, _ := r.ListPendingRuns(ctx, time.Now().Add(time.Minute)); len(runs) != 0 {
+		t.Fatalf("finished runs are not pending: %+v", runs)
+	}
+	run, err := r.GetRun(ctx, id, now)
+	if err != nil || run.Status != RecurringRunCreated || run.TransactionID == nil || *run.TransactionID != txID {
+		t.Fatalf("unexpected run: %+v %v", run, err)
+	}
+	if runs, err := r.ListRuns(ctx, id, now.AddDate(0, 0, -4), now.AddDate(0, 0, 1)); err != nil || len(runs) != 1 || runs[0].Status != RecurringRunCreated {
+		t.Fatalf("unexpected runs: %+v %v", runs, err)
+	}
+	if runs, _ := r.ListRuns(ctx, id, now.Add(time.Second), now.AddDate(0, 1, 0)); len(runs) != 0 {
+		t.Fatalf("runs outside the range must be left out: %+v", runs)
+	}
+
+	next := now.AddDate(0, 1, 0)
+	if ok, _ := r.AdvanceNextRun(ctx, id, now, next); !ok {
+		t.Fatalf("advance failed")
+	}
+	if ok, _ := r.AdvanceNextRun(ctx, id, now, next); ok {
+		t.Fatalf("stale advance must be ignored")
+	}
+	if err := r.SetPaused(ctx, id, true, next); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if due, _ := r.ListDue(ctx, next, 10); len(due) != 0 {
+		t.Fatalf("paused rule must not be due")
+	}
+	list, err := r.ListByUser(ctx, 1)
+	if err != nil || len(list) != 1 || !list[0].Paused || list[0].NextRunAt.Unix() != next.Unix() {
+		t.Fatalf("unexpected list: %+v %v", list, err)
+	}
+	if err := r.Delete(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := r.GetRun(ctx, id, now); err == nil {
+		t.Fatalf("runs should be deleted with the rule")
+	}
+}
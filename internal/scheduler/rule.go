@@ -0,0 +1,336 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultHour and defaultMinute are used when a rule does not specify a time of day.
+const (
+	defaultHour   = 9
+	defaultMinute = 0
+)
+
+type ruleKind int
+
+const (
+	kindDays ruleKind = iota
+	kindWeekly
+	kindMonths
+	kindCron
+)
+
+// Rule is a parsed recurrence: "daily", "weekly mon", "monthly 5", "every N days",
+// "every N months on D" (all optionally followed by HH:MM or "at HH:MM") or a 5-field cron expression.
+type Rule struct {
+	kind    ruleKind
+	every   int
+	day     int
+	weekday time.Weekday
+	hour    int
+	minute  int
+	cron    *cronSpec
+}
+
+var weekdays = map[string]time.Weekday{
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+	"пн": time.Monday, "вт": time.Tuesday, "ср": time.Wednesday, "чт": time.Thursday,
+	"пт": time.Friday, "сб": time.Saturday, "вс": time.Sunday,
+}
+
+// russianWeekdays are the stems of full Russian weekday names, so every case form matches ("пятница", "пятницу").
+var russianWeekdays = map[string]time.Weekday{
+	"понедельник": time.Monday, "вторник": time.Tuesday, "сред": time.Wednesday, "четверг": time.Thursday,
+	"пятниц": time.Friday, "суббот": time.Saturday, "воскресень": time.Sunday,
+}
+
+// ParseWeekday parses an English or Russian weekday name, short or full.
+func ParseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(s)
+	if wd, ok := weekdays[s]; ok {
+		return wd, true
+	}
+	for stem, wd := range russianWeekdays {
+		if strings.HasPrefix(s, stem) {
+			return wd, true
+		}
+	}
+	if r := []rune(s); len(r) > 3 {
+		wd, ok := weekdays[string(r[:3])]
+		return wd, ok
+	}
+	return 0, false
+}
+
+// ParseClock parses "HH:MM".
+func ParseClock(s string) (int, int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid time %q", s)
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, 0, fmt.Errorf("invalid time %q", s)
+	}
+	return h, m, nil
+}
+
+// ParseRule parses a recurrence specification.
+func ParseRule(spec string) (*Rule, error) {
+	fields := strings.Fields(strings.ToLower(strings.TrimSpace(spec)))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty rule")
+	}
+	if fields[0] == "cron" {
+		c, err := parseCron(fields[1:])
+		if err != nil {
+			return nil, err
+		}
+		return &Rule{kind: kindCron, cron: c}, nil
+	}
+
+	r := &Rule{every: 1, hour: defaultHour, minute: defaultMinute}
+	// Optional trailing time of day
+	if n := len(fields); n > 1 && strings.Contains(fields[n-1], ":") {
+		h, m, err := ParseClock(fields[n-1])
+		if err != nil {
+			return nil, err
+		}
+		r.hour, r.minute = h, m
+		fields = fields[:n-1]
+		if n := len(fields); n > 1 && fields[n-1] == "at" {
+			fields = fields[:n-1]
+		}
+	}
+
+	switch fields[0] {
+	case "daily":
+		if len(fields) != 1 {
+			return nil, fmt.Errorf("daily takes no arguments")
+		}
+		r.kind = kindDays
+	case "weekly":
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("weekly needs a weekday")
+		}
+		wd, ok := ParseWeekday(fields[1])
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", fields[1])
+		}
+		r.kind, r.weekday = kindWeekly, wd
+	case "monthly":
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("monthly needs a day of month")
+		}
+		d, err := parseDayOfMonth(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		r.kind, r.day = kindMonths, d
+	case "every":
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("every needs a count and a unit")
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 || n > 366 {
+			return nil, fmt.Errorf("invalid interval %q", fields[1])
+		}
+		r.every = n
+		switch strings.TrimSuffix(fields[2], "s") {
+		case "day":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("unexpected %q", strings.Join(fields[3:], " "))
+			}
+			r.kind = kindDays
+		case "month":
+			if len(fields) != 5 || fields[3] != "on" {
+				return nil, fmt.Errorf("every N months needs \"on <day>\"")
+			}
+			d, err := parseDayOfMonth(fields[4])
+			if err != nil {
+				return nil, err
+			}
+			r.kind, r.day = kindMonths, d
+		default:
+			return nil, fmt.Errorf("unknown unit %q", fields[2])
+		}
+	default:
+		return nil, fmt.Errorf("unknown rule %q", fields[0])
+	}
+	return r, nil
+}
+
+func parseDayOfMonth(s string) (int, error) {
+	d, err := strconv.Atoi(s)
+	if err != nil || d < 1 || d > 31 {
+		return 0, fmt.Errorf("invalid day of month %q", s)
+	}
+	return d, nil
+}
+
+// String returns the canonical form of the rule, accepted by ParseRule.
+func (r *Rule) String() string {
+	at := fmt.Sprintf("%02d:%02d", r.hour, r.minute)
+	switch r.kind {
+	case kindCron:
+		return "cron " + r.cron.spec
+	case kindWeekly:
+		return "weekly " + strings.ToLower(r.weekday.String()[:3]) + " " + at
+	case kindMonths:
+		if r.every == 1 {
+			return fmt.Sprintf("monthly %d %s", r.day, at)
+		}
+		return fmt.Sprintf("every %d months on %d %s", r.every, r.day, at)
+	default:
+		if r.every == 1 {
+			return "daily " + at
+		}
+		return fmt.Sprintf("every %d days %s", r.every, at)
+	}
+}
+
+// Next returns the first occurrence strictly after the given time, in its location.
+func (r *Rule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	switch r.kind {
+	case kindCron:
+		return r.cron.next(after)
+	case kindWeekly:
+		c := time.Date(after.Year(), after.Month(), after.Day(), r.hour, r.minute, 0, 0, loc)
+		c = c.AddDate(0, 0, (int(r.weekday)-int(c.Weekday())+7)%7)
+		if !c.After(after) {
+			c = c.AddDate(0, 0, 7)
+		}
+		return c
+	case kindMonths:
+		c := monthDay(after.Year(), after.Month(), r.day, r.hour, r.minute, loc)
+		if !c.After(after) {
+			c = monthDay(after.Year(), after.Month()+time.Month(r.every), r.day, r.hour, r.minute, loc)
+		}
+		return c
+	default:
+		c := time.Date(after.Year(), after.Month(), after.Day(), r.hour, r.minute, 0, 0, loc)
+		if !c.After(after) {
+			c = c.AddDate(0, 0, r.every)
+		}
+		return c
+	}
+}
+
+// monthDay builds a date, clamping the day to the length of the month (e.g. 31 -> 28 in February).
+func monthDay(year int, month time.Month, day, hour, minute int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	last := first.AddDate(0, 1, -1).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, hour, minute, 0, 0, loc)
+}
+
+// cronSpec is a standard 5-field cron expression: minute hour day-of-month month day-of-week.
+type cronSpec struct {
+	spec                         string
+	minutes, hours, doms, months map[int]bool
+	dows                         map[int]bool
+	domRestricted, dowRestricted bool
+}
+
+func parseCron(fields []string) (*cronSpec, error) {
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron needs 5 fields, got %d", len(fields))
+	}
+	c := &cronSpec{spec: strings.Join(fields, " ")}
+	var err error
+	if c.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if c.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if c.doms, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if c.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if c.dows, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	if c.dows[7] {
+		c.dows[0] = true
+	}
+	c.domRestricted = fields[2] != "*"
+	c.dowRestricted = fields[4] != "*"
+	return c, nil
+}
+
+// parseCronField supports "*", numbers, lists "1,15", ranges "1-5" and steps "*/10" or "0-30/5".
+func parseCronField(s string, lo, hi int) (map[int]bool, error) {
+	set := map[int]bool{}
+	for _, part := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid cron step %q", part)
+			}
+			step, part = n, part[:i]
+		}
+		from, to := lo, hi
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid cron value %q", part)
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid cron value %q", part)
+				}
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return nil, fmt.Errorf("cron value %q out of range %d-%d", part, lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+func (c *cronSpec) dayMatches(t time.Time) bool {
+	dom, dow := c.doms[t.Day()], c.dows[int(t.Weekday())]
+	// As in classic cron, a restricted day-of-month and day-of-week match if either matches.
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// next scans forward minute by minute, skipping whole days and hours that cannot match.
+// Expressions that never match (e.g. "0 0 31 2 *") yield the zero time after five years.
+func (c *cronSpec) next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !c.months[int(t.Month())] || !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minutes[t.Minute()] {
+			return t
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRuleAndNext(t *testing.T) {
+	loc := time.UTC
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	cases := []struct {
+		spec      string
+		canonical string
+		after     string
+		next      string
+	}{
+		{"daily", "daily 09:00", "2025-03-05 08:00", "2025-03-05 09:00"},
+		{"daily 21:30", "daily 21:30", "2025-03-05 21:30", "2025-03-06 21:30"},
+		{"every 3 days at 10:00", "every 3 days 10:00", "2025-03-05 10:00", "2025-03-08 10:00"},
+		{"weekly mon 09:00", "weekly mon 09:00", "2025-03-05 12:00", "2025-03-10 09:00"},
+		{"weekly пт", "weekly fri 09:00", "2025-03-07 08:59", "2025-03-07 09:00"},
+		{"weekly Sunday", "weekly sun 09:00", "2025-03-09 09:00", "2025-03-16 09:00"},
+		{"weekly понедельник", "weekly mon 09:00", "2025-03-05 12:00", "2025-03-10 09:00"},
+		{"weekly Пятница 18:00", "weekly fri 18:00", "2025-03-05 12:00", "2025-03-07 18:00"},
+		{"weekly среду", "weekly wed 09:00", "2025-03-05 12:00", "2025-03-12 09:00"},
+		{"weekly воскресенье", "weekly sun 09:00", "2025-03-05 12:00", "2025-03-09 09:00"},
+		{"monthly 5", "monthly 5 09:00", "2025-03-05 09:00", "2025-04-05 09:00"},
+		{"monthly 31 12:00", "monthly 31 12:00", "2025-02-01 00:00", "2025-02-28 12:00"},
+		{"monthly 31 12:00", "monthly 31 12:00", "2025-02-28 12:00", "2025-03-31 12:00"},
+		{"every 2 months on 15", "every 2 months on 15 09:00", "2025-11-15 09:00", "2026-01-15 09:00"},
+		{"cron 30 9 * * 1-5", "cron 30 9 * * 1-5", "2025-03-07 10:00", "2025-03-10 09:30"},
+		{"cron */15 * * * *", "cron */15 * * * *", "2025-03-07 10:01", "2025-03-07 10:15"},
+		{"cron 0 0 1,15 * *", "cron 0 0 1,15 * *", "2025-03-02 00:00", "2025-03-15 00:00"},
+		{"cron 0 8 13 * 5", "cron 0 8 13 * 5", "2025-03-08 00:00", "2025-03-13 08:00"},
+	}
+	for _, tc := range cases {
+		r, err := ParseRule(tc.spec)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.spec, err)
+		}
+		if r.String() != tc.canonical {
+			t.Fatalf("%q: canonical %q, want %q", tc.spec, r.String(), tc.canonical)
+		}
+		if again, err := ParseRule(r.String()); err != nil || again.String() != tc.canonical {
+			t.Fatalf("%q: canonical form does not round-trip: %v", tc.spec, err)
+		}
+		if got := r.Next(at(tc.after)); !got.Equal(at(tc.next)) {
+			t.Fatalf("%q after %s: got %s, want %s", tc.spec, tc.after, got.Format("2006-01-02 15:04"), tc.next)
+		}
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	for _, spec := range []string{"", "hourly", "weekly", "weekly xyz", "weekly пон", "monthly 32", "every 0 days", "every 2 weeks", "every 2 months", "daily 25:00", "cron * * *", "cron 60 * * * *", "cron 5-1 * * * *"} {
+		if _, err := ParseRule(spec); err == nil {
+			t.Fatalf("%q: expected error", spec)
+		}
+	}
+}
+
+func TestCronNeverMatches(t *testing.T) {
+	r, err := ParseRule("cron 0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Next(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		t.Fatalf("expected zero time for an impossible date")
+	}
+}
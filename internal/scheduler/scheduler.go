@@ -0,0 +1,76 @@
+// Package scheduler runs periodic background jobs and evaluates recurrence rules.
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// JobFunc performs one pass of a job. now is the tick time.
+type JobFunc func(ctx context.Context, now time.Time) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      JobFunc
+}
+
+// Scheduler runs registered jobs on fixed intervals until its context is canceled.
+// Jobs must be idempotent: a pass may repeat after a restart.
+type Scheduler struct {
+	logger *zap.Logger
+	now    func() time.Time
+	jobs   []job
+	wg     sync.WaitGroup
+}
+
+// New constructs a Scheduler.
+func New(logger *zap.Logger) *Scheduler {
+	return &Scheduler{logger: logger, now: time.Now}
+}
+
+// Add registers a job. It must be called before Start.
+func (s *Scheduler) Add(name string, interval time.Duration, fn JobFunc) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: fn})
+}
+
+// Start runs every job immediately and then on its interval, each in its own goroutine.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+}
+
+// Wait blocks until all job loops have exited after the context is canceled.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		s.runOnce(ctx, j)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, j job) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("scheduled job panicked", zap.String("job", j.name), zap.Any("panic", r))
+		}
+	}()
+	if err := j.run(ctx, s.now()); err != nil {
+		s.logger.Warn("scheduled job failed", zap.String("job", j.name), zap.Error(err))
+	}
+}
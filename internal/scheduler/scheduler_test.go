@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestScheduler_RunsJobsUntilCanceled(t *testing.T) {
+	s := New(zap.NewNop())
+	var ok, failing, panicking atomic.Int32
+	s.Add("ok", 5*time.Millisecond, func(context.Context, time.Time) error { ok.Add(1); return nil })
+	s.Add("failing", 5*time.Millisecond, func(context.Context, time.Time) error { failing.Add(1); return errors.New("boom") })
+	s.Add("panicking", 5*time.Millisecond, func(context.Context, time.Time) error { panicking.Add(1); panic("boom") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	deadline := time.Now().Add(2 * time.Second)
+	for ok.Load() < 3 || failing.Load() < 3 || panicking.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("jobs did not run repeatedly: ok=%d failing=%d panicking=%d", ok.Load(), failing.Load(), panicking.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	s.Wait()
+	runs := ok.Load()
+	time.Sleep(20 * time.Millisecond)
+	if ok.Load() != runs {
+		t.Fatalf("job kept running after cancel")
+	}
+}
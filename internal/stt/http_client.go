@@ -0,0 +1,115 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// HTTPClient implements SpeechToText via an OpenAI-compatible /audio/transcriptions API.
+type HTTPClient struct {
+	baseURL string
+	apiKey  string
+	model   string
+	http    *http.Client
+}
+
+// NewHTTPClient creates a configured client.
+func NewHTTPClient(baseURL, apiKey, model string, timeout time.Duration) *HTTPClient {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	return &HTTPClient{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		model:   model,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Transcribe uploads the audio as multipart form data and returns the recognized text.
+func (c *HTTPClient) Transcribe(ctx context.Context, req TranscribeRequest) (string, error) {
+	if c.apiKey == "" || c.model == "" {
+		return "", fmt.Errorf("speech-to-text client is not configured")
+	}
+	if len(req.Audio) == 0 {
+		return "", fmt.Errorf("empty audio")
+	}
+	filename := req.Filename
+	if filename == "" {
+		filename = "voice.ogg"
+	}
+	mimeType := req.MimeType
+	if mimeType == "" {
+		mimeType = "audio/ogg"
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("model", c.model)
+	_ = w.WriteField("response_format", "json")
+	if req.Language != "" {
+		_ = w.WriteField("language", req.Language)
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, filename))
+	header.Set("Content-Type", mimeType)
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(req.Audio); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	httpReq.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("speech-to-text request failed (model=%s, base_url=%s): %w", c.model, c.baseURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("speech-to-text read body failed (status=%d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode >= 300 {
+		preview := string(respBody)
+		if len(preview) > 300 {
+			preview = preview[:300]
+		}
+		return "", fmt.Errorf("speech-to-text bad status %d, body=%q", resp.StatusCode, preview)
+	}
+
+	var parsed struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return "", fmt.Errorf("speech-to-text response decode failed: %w", err)
+	}
+	text := strings.TrimSpace(parsed.Text)
+	if text == "" {
+		return "", fmt.Errorf("empty transcript")
+	}
+	return text, nil
+}
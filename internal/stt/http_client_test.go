@@ -0,0 +1,72 @@
+package stt
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPClientTranscribe(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio/transcriptions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer k" {
+			t.Errorf("unexpected auth header: %q", r.Header.Get("Authorization"))
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		if r.FormValue("model") != "whisper-1" || r.FormValue("language") != "ru" {
+			t.Errorf("unexpected fields: %v", r.MultipartForm.Value)
+		}
+		f, fh, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("file missing: %v", err)
+		} else {
+			data, _ := io.ReadAll(f)
+			if string(data) != "OggS" || fh.Filename != "voice.ogg" {
+				t.Errorf("unexpected file %q %q", fh.Filename, data)
+			}
+		}
+		_, _ = w.Write([]byte(`{"text":" 450 шаурма "}`))
+	}))
+	defer ts.Close()
+
+	c := NewHTTPClient(ts.URL+"/", "k", "whisper-1", 0)
+	text, err := c.Transcribe(context.Background(), TranscribeRequest{Audio: []byte("OggS"), Language: "ru"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if text != "450 шаурма" {
+		t.Fatalf("unexpected text: %q", text)
+	}
+}
+
+func TestHTTPClientTranscribeErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer bad" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid key"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"text":""}`))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	if _, err := NewHTTPClient(ts.URL, "", "m", 0).Transcribe(ctx, TranscribeRequest{Audio: []byte("x")}); err == nil {
+		t.Fatalf("expected not configured error")
+	}
+	if _, err := NewHTTPClient(ts.URL, "k", "m", 0).Transcribe(ctx, TranscribeRequest{}); err == nil {
+		t.Fatalf("expected empty audio error")
+	}
+	if _, err := NewHTTPClient(ts.URL, "bad", "m", 0).Transcribe(ctx, TranscribeRequest{Audio: []byte("x")}); err == nil {
+		t.Fatalf("expected bad status error")
+	}
+	if _, err := NewHTTPClient(ts.URL, "k", "m", 0).Transcribe(ctx, TranscribeRequest{Audio: []byte("x")}); err == nil {
+		t.Fatalf("expected empty transcript error")
+	}
+}
@@ -0,0 +1,18 @@
+// Package stt contains speech-to-text clients used for voice message input.
+package stt
+
+import "context"
+
+// TranscribeRequest is an audio clip to recognize.
+type TranscribeRequest struct {
+	Audio    []byte
+	Filename string
+	MimeType string
+	// Language is an optional ISO-639-1 hint, e.g. "ru" or "en".
+	Language string
+}
+
+// SpeechToText converts recorded speech into text.
+type SpeechToText interface {
+	Transcribe(ctx context.Context, req TranscribeRequest) (string, error)
+}
@@ -1,9 +1,12 @@
 package testutil
 
 import (
+    "io"
     "net/http"
     "net/http/httptest"
+    "net/url"
     "strings"
+    "sync"
     "testing"
 
     tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -34,3 +37,81 @@ func NewTestBot(t testing.TB) *tgbotapi.BotAPI {
 }
 
 
+
+// TelegramCall is a single Bot API request captured by a recording test bot.
+type TelegramCall struct {
+    Method string
+    Params url.Values
+    Files  map[string][]byte // uploaded files by field name, e.g. "document"
+}
+
+// TelegramRecorder collects Bot API requests sent by a recording test bot.
+type TelegramRecorder struct {
+    mu    sync.Mutex
+    calls []TelegramCall
+}
+
+// Calls returns captured requests for a Bot API method (e.g. "sendMessage"); empty method returns all.
+func (r *TelegramRecorder) Calls(method string) []TelegramCall {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    var out []TelegramCall
+    for _, c := range r.calls {
+        if method == "" || c.Method == method {
+            out = append(out, c)
+        }
+    }
+    return out
+}
+
+// Texts returns the "text" parameter of every captured sendMessage/editMessageText request.
+func (r *TelegramRecorder) Texts() []string {
+    var out []string
+    for _, c := range r.Calls("") {
+        if c.Method == "sendMessage" || c.Method == "editMessageText" {
+            out = append(out, c.Params.Get("text"))
+        }
+    }
+    return out
+}
+
+// NewRecordingTestBot is like NewTestBot but also records every request made to the emulator.
+func NewRecordingTestBot(t testing.TB) (*tgbotapi.BotAPI, *TelegramRecorder) {
+    t.Helper()
+    rec := &TelegramRecorder{}
+    ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+        call := TelegramCall{Method: method}
+        if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+            _ = r.ParseMultipartForm(10 << 20)
+            if r.MultipartForm != nil {
+                call.Files = map[string][]byte{}
+                for field, headers := range r.MultipartForm.File {
+                    if f, err := headers[0].Open(); err == nil {
+                        call.Files[field], _ = io.ReadAll(f)
+                        _ = f.Close()
+                    }
+                }
+            }
+        } else {
+            _ = r.ParseForm()
+        }
+        call.Params = r.Form
+        rec.mu.Lock()
+        rec.calls = append(rec.calls, call)
+        rec.mu.Unlock()
+        switch method {
+        case "getMe":
+            _, _ = w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Test","username":"testbot"}}`))
+        case "sendMessage", "sendPhoto", "sendDocument":
+            _, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":100}}`))
+        default:
+            _, _ = w.Write([]byte(`{"ok":true,"result":true}`))
+        }
+    }))
+    t.Cleanup(ts.Close)
+    bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("TEST:TOKEN", ts.URL+"/bot%s/%s")
+    if err != nil { t.Fatalf("new bot: %v", err) }
+    return bot, rec
+}
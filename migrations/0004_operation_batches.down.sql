@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_operation_contexts_batch_id;
+ALTER TABLE operation_contexts DROP COLUMN batch_id;
@@ -0,0 +1,2 @@
+ALTER TABLE operation_contexts ADD COLUMN batch_id TEXT;
+CREATE INDEX IF NOT EXISTS idx_operation_contexts_batch_id ON operation_contexts(batch_id);
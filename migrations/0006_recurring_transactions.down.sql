@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS recurring_runs;
+DROP INDEX IF EXISTS idx_recurring_transactions_user;
+DROP INDEX IF EXISTS idx_recurring_transactions_due;
+DROP TABLE IF EXISTS recurring_transactions;
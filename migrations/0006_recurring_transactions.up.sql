@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS recurring_transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    telegram_id INTEGER NOT NULL,
+    chat_id INTEGER NOT NULL,
+    tenant_id TEXT NOT NULL,
+    schedule TEXT NOT NULL,
+    tx_type TEXT NOT NULL,
+    amount_minor INTEGER NOT NULL,
+    currency TEXT NOT NULL,
+    description TEXT NOT NULL,
+    category_id TEXT,
+    category_name TEXT,
+    confirm INTEGER NOT NULL DEFAULT 0,
+    paused INTEGER NOT NULL DEFAULT 0,
+    next_run_at INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_recurring_transactions_due ON recurring_transactions(paused, next_run_at);
+CREATE INDEX IF NOT EXISTS idx_recurring_transactions_user ON recurring_transactions(telegram_id);
+
+CREATE TABLE IF NOT EXISTS recurring_runs (
+    rule_id INTEGER NOT NULL,
+    due_at INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    transaction_id TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (rule_id, due_at)
+);
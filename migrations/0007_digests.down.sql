@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_digest_subscriptions_due;
+DROP TABLE IF EXISTS digest_subscriptions;
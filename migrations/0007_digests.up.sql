@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS digest_subscriptions (
+    telegram_id INTEGER NOT NULL,
+    chat_id INTEGER NOT NULL,
+    frequency TEXT NOT NULL,
+    schedule TEXT NOT NULL,
+    timezone TEXT NOT NULL,
+    next_run_at INTEGER NOT NULL,
+    last_sent_at INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (telegram_id, frequency)
+);
+CREATE INDEX IF NOT EXISTS idx_digest_subscriptions_due ON digest_subscriptions(next_run_at);
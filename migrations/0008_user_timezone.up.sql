@@ -0,0 +1 @@
+ALTER TABLE user_preferences ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
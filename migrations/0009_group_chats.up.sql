@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS group_chats (
+    chat_id INTEGER PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    bound_by INTEGER NOT NULL,
+    language TEXT NOT NULL DEFAULT '',
+    default_currency TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
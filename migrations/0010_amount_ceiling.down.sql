@@ -0,0 +1,2 @@
+ALTER TABLE user_preferences DROP COLUMN amount_ceiling_currency;
+ALTER TABLE user_preferences DROP COLUMN amount_ceiling_minor;
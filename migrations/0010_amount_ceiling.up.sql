@@ -0,0 +1,2 @@
+ALTER TABLE user_preferences ADD COLUMN amount_ceiling_minor INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE user_preferences ADD COLUMN amount_ceiling_currency TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transaction_drafts_created_at;
+ALTER TABLE transaction_drafts DROP COLUMN op_id;
@@ -0,0 +1,2 @@
+ALTER TABLE transaction_drafts ADD COLUMN op_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_transaction_drafts_created_at ON transaction_drafts(created_at);
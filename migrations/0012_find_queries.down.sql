@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_find_queries_created_at;
+DROP TABLE IF EXISTS find_queries;
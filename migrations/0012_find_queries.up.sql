@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS find_queries (
+    id TEXT PRIMARY KEY,
+    telegram_id INTEGER NOT NULL,
+    query TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_find_queries_created_at ON find_queries(created_at);
@@ -0,0 +1 @@
+ALTER TABLE transaction_drafts DROP COLUMN batch_id;
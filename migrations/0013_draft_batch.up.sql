@@ -0,0 +1 @@
+ALTER TABLE transaction_drafts ADD COLUMN batch_id TEXT NOT NULL DEFAULT '';